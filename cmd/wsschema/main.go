@@ -0,0 +1,38 @@
+// wsschema 依 internal/ws 的事件目錄產生 WebSocket 協定的 JSON Schema 與 TypeScript 定義
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/y3933y3933/joker/internal/ws"
+)
+
+func main() {
+	out := flag.String("out", "docs/ws", "output directory")
+	flag.Parse()
+
+	if err := run(*out); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(out string) error {
+	if err := os.MkdirAll(out, 0o755); err != nil {
+		return err
+	}
+
+	schema, err := ws.JSONSchema()
+	if err != nil {
+		return fmt.Errorf("generate json schema: %w", err)
+	}
+	schema = append(schema, '\n')
+	if err := os.WriteFile(filepath.Join(out, "protocol.schema.json"), schema, 0o644); err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(out, "protocol.d.ts"), ws.TypeScript(), 0o644)
+}
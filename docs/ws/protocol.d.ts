@@ -0,0 +1,302 @@
+// Code generated by cmd/wsschema. DO NOT EDIT.
+
+export const PROTOCOL_VERSION = 1;
+export const MIN_PROTOCOL_VERSION = 1;
+
+export interface AnswerSubmittedPayload {
+  answer: string;
+}
+
+export type AnswerTimePayload = Record<string, never>;
+
+export interface Avatar {
+  preset?: string;
+  color?: string;
+  emoji?: string;
+}
+
+export interface ChatMessagePayload {
+  playerID: number;
+  nickname: string;
+  text: string;
+  sentAt: string;
+}
+
+export interface ErrorPayload {
+  command: string;
+  message: string;
+}
+
+export interface Game {
+  id: number;
+  code: string;
+  status: string;
+  locked: boolean;
+  mode: string;
+  guessing: string;
+  scoring: unknown;
+  rematchCode?: string | null;
+  endedReason?: string | null;
+  access: string;
+}
+
+export interface GameEndedPayload {
+  gameCode: string;
+  reason?: string;
+}
+
+export interface GuessResultsPayload {
+  kind: string;
+  aboutPlayerID?: number | null;
+  truthful?: boolean | null;
+  correct: number[];
+  roundStatus: string;
+}
+
+export interface HostTransferredPayload {
+  id: number;
+  nickname: string;
+}
+
+export interface JokerRevealedPayload {
+  level: string;
+  content: string;
+}
+
+export interface KickPlayerCommand {
+  playerID: number;
+}
+
+export interface LeaderboardEntry {
+  playerID: number;
+  nickname: string;
+  avatar: Avatar;
+  score: number;
+  rank: number;
+}
+
+export interface LeaderboardPayload {
+  entries: LeaderboardEntry[];
+}
+
+export interface LockRoomCommand {
+  locked: boolean;
+}
+
+export interface MutePlayerCommand {
+  playerID: number;
+  muted: boolean;
+}
+
+export interface Player {
+  id: number;
+  nickname: string;
+  isHost: boolean;
+  gameID: number;
+  status: string;
+  role: string;
+  seat: number;
+  avatar: Avatar;
+  accountID?: number | null;
+}
+
+export interface PlayerJoinedPayload {
+  id: number;
+  nickname: string;
+  isHost: boolean;
+  avatar: Avatar;
+}
+
+export interface PlayerKickedPayload {
+  id: number;
+  nickname: string;
+}
+
+export interface PlayerLeftPayload {
+  id: number;
+  nickname: string;
+}
+
+export interface PlayerMutedPayload {
+  id: number;
+  nickname: string;
+  muted: boolean;
+}
+
+export interface PlayerOfflinePayload {
+  id: number;
+  nickname: string;
+}
+
+export type PlayerSafePayload = Record<string, never>;
+
+export interface PlayerUpdatedPayload {
+  id: number;
+  nickname: string;
+  avatar: Avatar;
+}
+
+export interface ReactionPayload {
+  playerID: number;
+  nickname: string;
+  emoji: string;
+}
+
+export interface RematchCreatedPayload {
+  gameCode: string;
+  players: RematchPlayerPayload[];
+}
+
+export interface RematchPlayerPayload {
+  previousID: number;
+  playerID: number;
+  nickname: string;
+  isHost: boolean;
+}
+
+export interface RematchSeatPayload {
+  gameCode: string;
+  playerID: number;
+  seatToken: string;
+}
+
+export interface ReorderSeatsCommand {
+  playerIDs: number[];
+}
+
+export interface RevealVoteResultPayload {
+  revealed: boolean;
+  revealVotes: number;
+  keepVotes: number;
+}
+
+export interface RoomAccessPayload {
+  access: string;
+}
+
+export interface RoomLockedPayload {
+  locked: boolean;
+}
+
+export interface Round {
+  id: number;
+  gameID: number;
+  questionID?: number | null;
+  answer?: string | null;
+  questionerID: number;
+  answererID: number;
+  isJoker: boolean;
+  status: string;
+  skipped: boolean;
+}
+
+export interface RoundQuestionPayload {
+  level: string;
+  content: string;
+}
+
+export interface RoundSkippedPayload {
+  reason: string;
+  roundID: number;
+  questionPlayerID: number;
+  answererID: number;
+}
+
+export interface RoundStartedPayload {
+  roundID: number;
+  questionPlayerID: number;
+  answererID: number;
+}
+
+export interface SeatsReorderedPayload {
+  playerIDs: number[];
+}
+
+export interface SendChatCommand {
+  text: string;
+}
+
+export interface SendReactionCommand {
+  emoji: string;
+}
+
+export interface SpectatorPayload {
+  id: number;
+  nickname: string;
+}
+
+export interface StateSnapshotPayload {
+  game: Game | null;
+  players: (Player | null)[];
+  spectators: (Player | null)[];
+  round: Round | null;
+  chat: ChatMessagePayload[];
+}
+
+export interface TransferHostCommand {
+  playerID: number;
+}
+
+export interface VoteCastPayload {
+  playerID: number;
+  votes: number;
+  needed: number;
+}
+
+export interface WelcomePayload {
+  protocolVersion: number;
+  playerID: number;
+  seq: number;
+  batch: boolean;
+  chat?: ChatMessagePayload[];
+}
+
+export type WSMessage =
+  | { type: "welcome"; seq?: number; data: WelcomePayload }
+  | { type: "state_snapshot"; seq?: number; data: StateSnapshotPayload }
+  | { type: "player_joined"; seq?: number; data: PlayerJoinedPayload }
+  | { type: "player_left"; seq?: number; data: PlayerLeftPayload }
+  | { type: "host_transferred"; seq?: number; data: HostTransferredPayload }
+  | { type: "player_disconnected"; seq?: number; data: PlayerOfflinePayload }
+  | { type: "player_reconnected"; seq?: number; data: PlayerOfflinePayload }
+  | { type: "spectator_joined"; seq?: number; data: SpectatorPayload }
+  | { type: "spectator_left"; seq?: number; data: SpectatorPayload }
+  | { type: "spectator_promoted"; seq?: number; data: PlayerJoinedPayload }
+  | { type: "game_started"; seq?: number; data: RoundStartedPayload }
+  | { type: "next_round_started"; seq?: number; data: RoundStartedPayload }
+  | { type: "round_skipped"; seq?: number; data: RoundSkippedPayload }
+  | { type: "round_question"; seq?: number; data: RoundQuestionPayload }
+  | { type: "answer_time"; seq?: number; data: AnswerTimePayload }
+  | { type: "answer_submitted"; seq?: number; data: AnswerSubmittedPayload }
+  | { type: "joker_revealed"; seq?: number; data: JokerRevealedPayload }
+  | { type: "player_safe"; seq?: number; data: PlayerSafePayload }
+  | { type: "game_ended"; seq?: number; data: GameEndedPayload }
+  | { type: "chat_message"; seq?: number; data: ChatMessagePayload }
+  | { type: "reaction"; seq?: number; data: ReactionPayload }
+  | { type: "player_muted"; seq?: number; data: PlayerMutedPayload }
+  | { type: "error"; seq?: number; data: ErrorPayload }
+  | { type: "player_kicked"; seq?: number; data: PlayerKickedPayload }
+  | { type: "seats_reordered"; seq?: number; data: SeatsReorderedPayload }
+  | { type: "room_locked"; seq?: number; data: RoomLockedPayload }
+  | { type: "room_access_changed"; seq?: number; data: RoomAccessPayload }
+  | { type: "vote_cast"; seq?: number; data: VoteCastPayload }
+  | { type: "guess_results"; seq?: number; data: GuessResultsPayload }
+  | { type: "reveal_vote_result"; seq?: number; data: RevealVoteResultPayload }
+  | { type: "leaderboard"; seq?: number; data: LeaderboardPayload }
+  | { type: "rematch_created"; seq?: number; data: RematchCreatedPayload }
+  | { type: "rematch_seat"; seq?: number; data: RematchSeatPayload }
+  | { type: "player_updated"; seq?: number; data: PlayerUpdatedPayload }
+  | { type: "batch"; seq?: number; data: unknown[] };
+
+export type WSMessageType = WSMessage["type"];
+
+export type ClientMessage =
+  | { type: "send_chat"; data: SendChatCommand }
+  | { type: "send_reaction"; data: SendReactionCommand }
+  | { type: "mute_player"; data: MutePlayerCommand }
+  | { type: "kick_player"; data: KickPlayerCommand }
+  | { type: "transfer_host"; data: TransferHostCommand }
+  | { type: "reorder_seats"; data: ReorderSeatsCommand }
+  | { type: "lock_room"; data: LockRoomCommand };
+
+export type ClientMessageType = ClientMessage["type"];
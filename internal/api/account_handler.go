@@ -0,0 +1,177 @@
+package api
+
+import (
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+	"github.com/y3933y3933/joker/internal/service"
+	"github.com/y3933y3933/joker/internal/utils/errx"
+	"github.com/y3933y3933/joker/internal/utils/httpx"
+	"github.com/y3933y3933/joker/internal/utils/param"
+)
+
+// AccountHandler 處理可選的玩家帳號，和後台的 AuthHandler 分開
+type AccountHandler struct {
+	accountService *service.AccountService
+	playerService  *service.PlayerService
+	roomAccess     *service.RoomAccess
+	logger         *slog.Logger
+}
+
+func NewAccountHandler(accountService *service.AccountService, playerService *service.PlayerService, roomAccess *service.RoomAccess, logger *slog.Logger) *AccountHandler {
+	return &AccountHandler{
+		accountService: accountService,
+		playerService:  playerService,
+		roomAccess:     roomAccess,
+		logger:         logger,
+	}
+}
+
+// accountID 取得 OptionalAccount 設定的帳號，未登入時回傳 nil
+func accountID(c *gin.Context) *int64 {
+	id, ok := c.Get("account_id")
+	if !ok {
+		return nil
+	}
+	v := id.(int64)
+	return &v
+}
+
+type AccountRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+func (h *AccountHandler) HandleRegister(c *gin.Context) {
+	var req AccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httpx.Error(c, err)
+		return
+	}
+
+	account, err := h.accountService.Register(c.Request.Context(), req.Username, req.Password)
+	if err != nil {
+		httpx.Error(c, err)
+		return
+	}
+
+	httpx.SuccessResponse(c, account)
+}
+
+func (h *AccountHandler) HandleLogin(c *gin.Context) {
+	var req AccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httpx.Error(c, err)
+		return
+	}
+
+	token, err := h.accountService.Login(c.Request.Context(), req.Username, req.Password)
+	if err != nil {
+		httpx.Error(c, err)
+		return
+	}
+
+	httpx.SuccessResponse(c, &LoginResponse{Token: token})
+}
+
+func (h *AccountHandler) HandleGetMe(c *gin.Context) {
+	account, err := h.accountService.GetAccount(c.Request.Context(), c.GetInt64("account_id"))
+	if err != nil {
+		httpx.Error(c, err)
+		return
+	}
+	httpx.SuccessResponse(c, account)
+}
+
+// HandleListGames 帳號玩過的遊戲，支援 page、page_size
+func (h *AccountHandler) HandleListGames(c *gin.Context) {
+	page := param.ReadIntQuery(c, "page", 1)
+	pageSize := param.ReadIntQuery(c, "page_size", 10)
+
+	games, err := h.accountService.ListGames(c.Request.Context(), c.GetInt64("account_id"), page, pageSize)
+	if err != nil {
+		httpx.Error(c, err)
+		return
+	}
+	httpx.SuccessResponse(c, games)
+}
+
+func (h *AccountHandler) HandleGetStats(c *gin.Context) {
+	stats, err := h.accountService.GetStats(c.Request.Context(), c.GetInt64("account_id"))
+	if err != nil {
+		httpx.Error(c, err)
+		return
+	}
+	httpx.SuccessResponse(c, stats)
+}
+
+func (h *AccountHandler) HandleListFavorites(c *gin.Context) {
+	favorites, err := h.accountService.ListFavorites(c.Request.Context(), c.GetInt64("account_id"))
+	if err != nil {
+		httpx.Error(c, err)
+		return
+	}
+	httpx.SuccessResponse(c, favorites)
+}
+
+type FavoriteRequest struct {
+	QuestionID int64 `json:"questionID" binding:"required"`
+}
+
+func (h *AccountHandler) HandleAddFavorite(c *gin.Context) {
+	var req FavoriteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httpx.Error(c, err)
+		return
+	}
+
+	err := h.accountService.AddFavorite(c.Request.Context(), c.GetInt64("account_id"), req.QuestionID)
+	if err != nil {
+		httpx.Error(c, err)
+		return
+	}
+	httpx.SuccessResponse(c, gin.H{"message": "favorite added"})
+}
+
+func (h *AccountHandler) HandleRemoveFavorite(c *gin.Context) {
+	questionID, err := param.ParseIntParam(c, "id")
+	if err != nil {
+		httpx.Error(c, err)
+		return
+	}
+
+	err = h.accountService.RemoveFavorite(c.Request.Context(), c.GetInt64("account_id"), questionID)
+	if err != nil {
+		httpx.Error(c, err)
+		return
+	}
+	httpx.SuccessResponse(c, gin.H{"message": "favorite removed"})
+}
+
+// ClaimPlayerRequest 以加入時拿到的座位憑證證明 X-Player-ID 是自己的玩家
+type ClaimPlayerRequest struct {
+	SeatToken string `json:"seatToken" binding:"required"`
+}
+
+// HandleClaimPlayer 把匿名加入時建立的玩家（X-Player-ID）歸屬到登入的帳號
+func (h *AccountHandler) HandleClaimPlayer(c *gin.Context) {
+	var req ClaimPlayerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httpx.Error(c, err)
+		return
+	}
+
+	playerID := c.GetInt64("player_id")
+	code, seatPlayerID, ok := h.roomAccess.VerifySeat(req.SeatToken)
+	if !ok || seatPlayerID != playerID {
+		httpx.Error(c, errx.ErrInvalidSeatToken)
+		return
+	}
+
+	player, err := h.playerService.ClaimPlayer(c.Request.Context(), code, playerID, c.GetInt64("account_id"))
+	if err != nil {
+		httpx.Error(c, err)
+		return
+	}
+	httpx.SuccessResponse(c, player)
+}
@@ -23,7 +23,7 @@ func NewAdminHandler(logger *slog.Logger, adminService *service.AdminService) *A
 func (h *AdminHandler) HandleDashboardData(c *gin.Context) {
 	data, err := h.adminService.GetDashboardData(c.Request.Context())
 	if err != nil {
-		httpx.ServerErrorResponse(c, h.logger, err)
+		httpx.Error(c, err)
 		return
 	}
 
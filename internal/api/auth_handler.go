@@ -1,12 +1,10 @@
 package api
 
 import (
-	"errors"
 	"log/slog"
 
 	"github.com/gin-gonic/gin"
 	"github.com/y3933y3933/joker/internal/service"
-	"github.com/y3933y3933/joker/internal/utils/errx"
 	"github.com/y3933y3933/joker/internal/utils/httpx"
 )
 
@@ -22,21 +20,21 @@ func NewAuthHandler(authService *service.AuthService, logger *slog.Logger) *Auth
 	}
 }
 
-type createUserRequest struct {
+type CreateUserRequest struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
 }
 
 func (h *AuthHandler) HandleRegisterUser(c *gin.Context) {
-	var req createUserRequest
+	var req CreateUserRequest
 	if err := c.ShouldBindBodyWithJSON(&req); err != nil {
-		httpx.BadRequestResponse(c, err)
+		httpx.Error(c, err)
 		return
 	}
 
 	user, err := h.authService.CreateUser(c.Request.Context(), req.Username, req.Password)
 	if err != nil {
-		httpx.ServerErrorResponse(c, h.logger, err)
+		httpx.Error(c, err)
 		return
 	}
 
@@ -53,20 +51,15 @@ type LoginResponse struct {
 }
 
 func (h *AuthHandler) HandleLogin(c *gin.Context) {
-	var req createUserRequest
+	var req LoginRequest
 	if err := c.ShouldBindBodyWithJSON(&req); err != nil {
-		httpx.BadRequestResponse(c, err)
+		httpx.Error(c, err)
 		return
 	}
 
 	token, err := h.authService.Login(c.Request.Context(), req.Username, req.Password)
 	if err != nil {
-		switch {
-		case errors.Is(err, errx.ErrInvalidCredentials):
-			httpx.UnAuthorized(c, err)
-		default:
-			httpx.ServerErrorResponse(c, h.logger, err)
-		}
+		httpx.Error(c, err)
 		return
 	}
 
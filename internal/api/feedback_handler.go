@@ -22,15 +22,15 @@ func NewFeedbackHandler(logger *slog.Logger, feedbackService *service.FeedbackSe
 	}
 }
 
-type createFeedbackRequest struct {
+type CreateFeedbackRequest struct {
 	Type    string `json:"type" binding:"required,oneof=feature issue other" `
 	Content string `json:"content" binding:"required"`
 }
 
 func (h *FeedbackHandler) HandleCreateFeedback(c *gin.Context) {
-	var req createFeedbackRequest
+	var req CreateFeedbackRequest
 	if err := c.ShouldBindBodyWithJSON(&req); err != nil {
-		httpx.BadRequestResponse(c, err)
+		httpx.Error(c, err)
 		return
 	}
 
@@ -40,7 +40,7 @@ func (h *FeedbackHandler) HandleCreateFeedback(c *gin.Context) {
 	})
 
 	if err != nil {
-		httpx.ServerErrorResponse(c, h.logger, err)
+		httpx.Error(c, err)
 		return
 	}
 
@@ -51,13 +51,13 @@ func (h *FeedbackHandler) HandlerListFeedback(c *gin.Context) {
 	params := h.parseQueryParams(c)
 
 	if err := h.feedbackService.ValidateFeedbackParams(params); err != nil {
-		httpx.BadRequestResponse(c, err)
+		httpx.Error(c, err)
 		return
 	}
 
 	result, err := h.feedbackService.ListFeedback(c.Request.Context(), params)
 	if err != nil {
-		httpx.ServerErrorResponse(c, h.logger, err)
+		httpx.Error(c, err)
 		return
 	}
 
@@ -82,39 +82,40 @@ func (h *FeedbackHandler) parseQueryParams(c *gin.Context) service.FeedbackQuery
 func (h *FeedbackHandler) HandleGetFeedbackByID(c *gin.Context) {
 	id, err := param.ParseIntParam(c, "id")
 	if err != nil {
-		httpx.BadRequestResponse(c, err)
+		httpx.Error(c, err)
 		return
 	}
 
 	result, err := h.feedbackService.GetFeedbackByID(c.Request.Context(), id)
 	if err != nil {
-		httpx.ServerErrorResponse(c, h.logger, err)
+		httpx.Error(c, err)
 		return
 	}
 	httpx.SuccessResponse(c, result)
 
 }
 
+type UpdateReviewStatusRequest struct {
+	ReviewStatus string `json:"reviewStatus" binding:"required"`
+}
+
 func (h *FeedbackHandler) HandleUpdateFeedbackReviewStatus(c *gin.Context) {
-	var req struct {
-		ReviewStatus string `json:"reviewStatus" binding:"required"`
-	}
+	var req UpdateReviewStatusRequest
 
 	id, err := param.ParseIntParam(c, "id")
 	if err != nil {
-		httpx.BadRequestResponse(c, err)
+		httpx.Error(c, err)
 		return
 	}
 
 	if err := c.ShouldBindBodyWithJSON(&req); err != nil {
-		h.logger.Error("Failed to bind request body", slog.Any("error", err))
-		httpx.BadRequestResponse(c, err)
+		httpx.Error(c, err)
 		return
 	}
 
 	err = h.feedbackService.UpdateFeedbackReviewStatus(c.Request.Context(), id, req.ReviewStatus)
 	if err != nil {
-		httpx.ServerErrorResponse(c, h.logger, err)
+		httpx.Error(c, err)
 		return
 	}
 
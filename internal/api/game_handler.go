@@ -2,13 +2,13 @@ package api
 
 import (
 	"errors"
+	"io"
 	"log/slog"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/y3933y3933/joker/internal/service"
 	"github.com/y3933y3933/joker/internal/store"
-	"github.com/y3933y3933/joker/internal/utils/errx"
 	"github.com/y3933y3933/joker/internal/utils/httpx"
 	"github.com/y3933y3933/joker/internal/utils/param"
 	"github.com/y3933y3933/joker/internal/ws"
@@ -17,23 +17,44 @@ import (
 type GameHandler struct {
 	gameService     *service.GameService
 	questionService *service.QuestionService
+	roomAccess      *service.RoomAccess
 	hub             *ws.Hub
 	logger          *slog.Logger
 }
 
-func NewGameHandler(gameService *service.GameService, questionService *service.QuestionService, hub *ws.Hub, logger *slog.Logger) *GameHandler {
+func NewGameHandler(gameService *service.GameService, questionService *service.QuestionService, roomAccess *service.RoomAccess, hub *ws.Hub, logger *slog.Logger) *GameHandler {
 	return &GameHandler{
 		gameService:     gameService,
 		questionService: questionService,
+		roomAccess:      roomAccess,
 		hub:             hub,
 		logger:          logger,
 	}
 }
 
+type CreateGameRequest struct {
+	Mode     string                `json:"mode"`
+	Guessing string                `json:"guessing"`
+	Scoring  *service.ScoringRules `json:"scoring"` // 只需要填要調整的項目
+}
+
 func (h *GameHandler) HandleCreateGame(c *gin.Context) {
-	game, err := h.gameService.CreateGame(c.Request.Context())
+	// body 可省略，未指定時使用 classic 玩法且不猜測
+	// 先填入預設計分，body 只會覆蓋有帶的項目
+	rules := service.DefaultScoringRules
+	req := CreateGameRequest{Scoring: &rules}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		httpx.Error(c, err)
+		return
+	}
+
+	game, err := h.gameService.CreateGame(c.Request.Context(), service.GameOptions{
+		Mode:     req.Mode,
+		Guessing: req.Guessing,
+		Scoring:  req.Scoring,
+	})
 	if err != nil {
-		httpx.ServerErrorResponse(c, h.logger, err)
+		httpx.Error(c, err)
 		return
 	}
 	httpx.SuccessResponse(c, game)
@@ -48,7 +69,7 @@ func (h *GameHandler) HandleGetQuestions(c *gin.Context) {
 
 	questions, err := h.questionService.ListRandomQuestions(c.Request.Context(), limit)
 	if err != nil {
-		httpx.ServerErrorResponse(c, h.logger, err)
+		httpx.Error(c, err)
 		return
 	}
 
@@ -58,41 +79,89 @@ func (h *GameHandler) HandleGetQuestions(c *gin.Context) {
 func (h *GameHandler) HandleEndGame(c *gin.Context) {
 	gameAny, exists := c.Get("game")
 	if !exists {
-		httpx.ServerErrorResponse(c, h.logger, errors.New("missing game in context"))
+		httpx.Error(c, errors.New("missing game in context"))
 		return
 	}
 	game := gameAny.(*store.Game)
 
-	err := h.gameService.EndGame(c.Request.Context(), game.Code)
+	err := h.gameService.EndGame(c.Request.Context(), game.Code, store.EndReasonFinished)
 	if err != nil {
-		if errors.Is(err, errx.ErrInvalidGameStatus) {
-			httpx.BadRequestResponse(c, err)
-			return
-		}
-		httpx.ServerErrorResponse(c, h.logger, err)
+		httpx.Error(c, err)
 		return
 	}
 
 	// 推播 game_ended 給所有人（若有 hub）
 	if room := h.hub.GetRoom(game.Code); room != nil {
-		msg, _ := ws.NewWSMessage(ws.MsgTypeGameEnded, gin.H{"gameCode": game.Code})
-		room.Broadcast(msg)
+		msg := ws.MustWSMessage(ws.MsgTypeGameEnded, ws.GameEndedPayload{GameCode: game.Code, Reason: store.EndReasonFinished})
+		room.Broadcast(c.Request.Context(), msg)
 	}
 
 	httpx.SuccessResponse(c, gin.H{"message": "game ended"})
 }
 
+// RematchResponse 再來一局的結果，seatToken 是呼叫者在新遊戲的座位憑證
+type RematchResponse struct {
+	*service.RematchResult
+	SeatToken string `json:"seatToken,omitempty"`
+}
+
+// HandleRematch 遊戲結束後用同一群人再開一局
+func (h *GameHandler) HandleRematch(c *gin.Context) {
+	gameAny, exists := c.Get("game")
+	if !exists {
+		httpx.Error(c, errors.New("missing game in context"))
+		return
+	}
+	game := gameAny.(*store.Game)
+
+	playerIDAny, ok := c.Get("player_id")
+	if !ok {
+		httpx.Error(c, errors.New("missing player id"))
+		return
+	}
+	playerID := playerIDAny.(int64)
+
+	result, err := h.gameService.Rematch(c.Request.Context(), game, playerID)
+	if err != nil {
+		httpx.Error(c, err)
+		return
+	}
+
+	// 新遊戲沿用原本的加入限制，每位玩家各自拿到新的座位憑證
+	res := &RematchResponse{RematchResult: result}
+	seats := make(map[int64]string, len(result.Players))
+	for _, p := range result.Players {
+		seat, err := h.roomAccess.IssueSeat(result.Game.Code, p.Player.ID)
+		if err != nil {
+			httpx.Error(c, err)
+			return
+		}
+		seats[p.PreviousID] = seat
+	}
+	res.SeatToken = seats[playerID]
+
+	// 只有第一次開的時候推播，所有連線中的 client 自動移到新遊戲；座位憑證只私訊給本人
+	if room := h.hub.GetRoom(game.Code); room != nil && result.Created {
+		for _, p := range result.Players {
+			room.SendTo(p.PreviousID, ws.RematchSeatMessage(result.Game.Code, p.Player.ID, seats[p.PreviousID]))
+		}
+		room.Broadcast(c.Request.Context(), ws.RematchMessage(result))
+	}
+
+	httpx.SuccessResponse(c, res)
+}
+
 func (h *GameHandler) GetGameSummary(c *gin.Context) {
 	gameAny, exists := c.Get("game")
 	if !exists {
-		httpx.ServerErrorResponse(c, h.logger, errors.New("missing game in context"))
+		httpx.Error(c, errors.New("missing game in context"))
 		return
 	}
 	game := gameAny.(*store.Game)
 
-	summary, err := h.gameService.GetGameSummaryByCode(c.Request.Context(), game.ID)
+	summary, err := h.gameService.GetGameSummaryByCode(c.Request.Context(), game)
 	if err != nil {
-		httpx.ServerErrorResponse(c, h.logger, errors.New("failed to get game summary"))
+		httpx.Error(c, err)
 		return
 	}
 	httpx.SuccessResponse(c, summary)
@@ -102,13 +171,13 @@ func (h *GameHandler) HandleListGame(c *gin.Context) {
 	params := h.parseQueryParams(c)
 
 	if err := h.gameService.ValidateGameParams(params); err != nil {
-		httpx.BadRequestResponse(c, err)
+		httpx.Error(c, err)
 		return
 	}
 
 	result, err := h.gameService.ListGame(c.Request.Context(), params)
 	if err != nil {
-		httpx.ServerErrorResponse(c, h.logger, err)
+		httpx.Error(c, err)
 		return
 	}
 
@@ -129,23 +198,25 @@ func (h *GameHandler) parseQueryParams(c *gin.Context) service.GameQueryParams {
 	return params
 }
 
+type AdminEndGameRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
 func (h *GameHandler) HandleAdminEndGame(c *gin.Context) {
-	var req struct {
-		Code string `json:"code" binding:"required"`
-	}
+	var req AdminEndGameRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		httpx.BadRequestResponse(c, err)
+		httpx.Error(c, err)
 		return
 	}
-	err := h.gameService.EndGame(c.Request.Context(), req.Code)
+	err := h.gameService.EndGame(c.Request.Context(), req.Code, store.EndReasonAdmin)
 	if err != nil {
-		if errors.Is(err, errx.ErrInvalidGameStatus) {
-			httpx.BadRequestResponse(c, err)
-			return
-		}
-		httpx.ServerErrorResponse(c, h.logger, err)
+		httpx.Error(c, err)
 		return
 	}
+
+	// 和 janitor 結束遊戲時一樣，推播 game_ended 後關閉房間的連線
+	h.hub.CloseRoom(c.Request.Context(), req.Code, store.EndReasonAdmin)
+
 	httpx.SuccessResponse(c, gin.H{"message": "game ended"})
 }
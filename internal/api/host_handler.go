@@ -0,0 +1,234 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/y3933y3933/joker/internal/service"
+	"github.com/y3933y3933/joker/internal/store"
+	"github.com/y3933y3933/joker/internal/utils/errx"
+	"github.com/y3933y3933/joker/internal/utils/httpx"
+	"github.com/y3933y3933/joker/internal/ws"
+)
+
+type HostHandler struct {
+	hostService *service.HostService
+	hub         *ws.Hub
+	logger      *slog.Logger
+}
+
+func NewHostHandler(hostService *service.HostService, hub *ws.Hub, logger *slog.Logger) *HostHandler {
+	return &HostHandler{
+		hostService: hostService,
+		hub:         hub,
+		logger:      logger,
+	}
+}
+
+type HostTargetRequest struct {
+	PlayerID int64 `json:"playerID" binding:"required"`
+}
+
+type ReorderSeatsRequest struct {
+	PlayerIDs []int64 `json:"playerIDs" binding:"required"`
+}
+
+type LockRoomRequest struct {
+	Locked *bool `json:"locked" binding:"required"`
+}
+
+type RoomAccessRequest struct {
+	Access   string `json:"access" binding:"required"`
+	Passcode string `json:"passcode"` // access 為 passcode 時必填
+}
+
+type CreateInviteRequest struct {
+	TTLMinutes int `json:"ttlMinutes"` // 0 表示使用預設的 24 小時，最長 7 天
+}
+
+// hostContext 取出 middleware 放進 context 的遊戲與發出請求的玩家
+func (h *HostHandler) hostContext(c *gin.Context) (*store.Game, int64, bool) {
+	gameAny, ok := c.Get("game")
+	if !ok {
+		httpx.Error(c, errors.New("missing game in context"))
+		return nil, 0, false
+	}
+
+	playerIDAny, ok := c.Get("player_id")
+	if !ok {
+		httpx.Error(c, errors.New("missing player id"))
+		return nil, 0, false
+	}
+
+	return gameAny.(*store.Game), playerIDAny.(int64), true
+}
+
+func (h *HostHandler) HandleKickPlayer(c *gin.Context) {
+	var req HostTargetRequest
+	if err := c.ShouldBindBodyWithJSON(&req); err != nil {
+		httpx.Error(c, err)
+		return
+	}
+
+	game, hostID, ok := h.hostContext(c)
+	if !ok {
+		return
+	}
+
+	res, err := h.hostService.KickPlayer(c.Request.Context(), game, hostID, req.PlayerID)
+	if err != nil {
+		httpx.Error(c, err)
+		return
+	}
+
+	room := h.hub.GetRoom(game.Code)
+	if room != nil {
+		room.Broadcast(c.Request.Context(), ws.KickMessages(res, game.Code)...)
+		room.Kick(res.Player.ID)
+	}
+
+	httpx.SuccessResponse(c, res)
+}
+
+func (h *HostHandler) HandleTransferHost(c *gin.Context) {
+	var req HostTargetRequest
+	if err := c.ShouldBindBodyWithJSON(&req); err != nil {
+		httpx.Error(c, err)
+		return
+	}
+
+	game, hostID, ok := h.hostContext(c)
+	if !ok {
+		return
+	}
+
+	newHost, err := h.hostService.TransferHost(c.Request.Context(), game, hostID, req.PlayerID)
+	if err != nil {
+		httpx.Error(c, err)
+		return
+	}
+
+	room := h.hub.GetRoom(game.Code)
+	if room != nil {
+		msg := ws.MustWSMessage(ws.MsgHostTransferred, ws.HostTransferredPayload{
+			ID:       newHost.ID,
+			Nickname: newHost.Nickname,
+		})
+		room.Broadcast(c.Request.Context(), msg)
+	}
+
+	httpx.SuccessResponse(c, newHost)
+}
+
+func (h *HostHandler) HandleReorderSeats(c *gin.Context) {
+	var req ReorderSeatsRequest
+	if err := c.ShouldBindBodyWithJSON(&req); err != nil {
+		httpx.Error(c, err)
+		return
+	}
+
+	game, hostID, ok := h.hostContext(c)
+	if !ok {
+		return
+	}
+
+	err := h.hostService.ReorderSeats(c.Request.Context(), game, hostID, req.PlayerIDs)
+	if err != nil {
+		httpx.Error(c, err)
+		return
+	}
+
+	room := h.hub.GetRoom(game.Code)
+	if room != nil {
+		msg := ws.MustWSMessage(ws.MsgSeatsReordered, ws.SeatsReorderedPayload{PlayerIDs: req.PlayerIDs})
+		room.Broadcast(c.Request.Context(), msg)
+	}
+
+	httpx.SuccessResponse(c, gin.H{"playerIDs": req.PlayerIDs})
+}
+
+func (h *HostHandler) HandleLockRoom(c *gin.Context) {
+	var req LockRoomRequest
+	if err := c.ShouldBindBodyWithJSON(&req); err != nil {
+		httpx.Error(c, err)
+		return
+	}
+
+	game, hostID, ok := h.hostContext(c)
+	if !ok {
+		return
+	}
+
+	err := h.hostService.SetLocked(c.Request.Context(), game, hostID, *req.Locked)
+	if err != nil {
+		httpx.Error(c, err)
+		return
+	}
+
+	room := h.hub.GetRoom(game.Code)
+	if room != nil {
+		msg := ws.MustWSMessage(ws.MsgRoomLocked, ws.RoomLockedPayload{Locked: *req.Locked})
+		room.Broadcast(c.Request.Context(), msg)
+	}
+
+	httpx.SuccessResponse(c, gin.H{"locked": *req.Locked})
+}
+
+// HandleSetRoomAccess 設定房間為公開、需要密碼或只限邀請
+func (h *HostHandler) HandleSetRoomAccess(c *gin.Context) {
+	var req RoomAccessRequest
+	if err := c.ShouldBindBodyWithJSON(&req); err != nil {
+		httpx.Error(c, err)
+		return
+	}
+
+	game, hostID, ok := h.hostContext(c)
+	if !ok {
+		return
+	}
+
+	err := h.hostService.SetAccess(c.Request.Context(), game, hostID, req.Access, req.Passcode)
+	if err != nil {
+		httpx.Error(c, err)
+		return
+	}
+
+	room := h.hub.GetRoom(game.Code)
+	if room != nil {
+		msg := ws.MustWSMessage(ws.MsgRoomAccessChanged, ws.RoomAccessPayload{Access: req.Access})
+		room.Broadcast(c.Request.Context(), msg)
+	}
+
+	httpx.SuccessResponse(c, gin.H{"access": req.Access})
+}
+
+// HandleCreateInvite 產生邀請連結的 token，前端以 ?invite=<token> 組成連結
+func (h *HostHandler) HandleCreateInvite(c *gin.Context) {
+	var req CreateInviteRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindBodyWithJSON(&req); err != nil {
+			httpx.Error(c, err)
+			return
+		}
+	}
+	if req.TTLMinutes < 0 {
+		httpx.Error(c, fmt.Errorf("%w: ttlMinutes must not be negative", errx.ErrInvalidInput))
+		return
+	}
+
+	game, hostID, ok := h.hostContext(c)
+	if !ok {
+		return
+	}
+
+	invite, err := h.hostService.CreateInvite(c.Request.Context(), game, hostID, time.Duration(req.TTLMinutes)*time.Minute)
+	if err != nil {
+		httpx.Error(c, err)
+		return
+	}
+
+	httpx.SuccessResponse(c, invite)
+}
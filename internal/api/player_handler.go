@@ -7,54 +7,81 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/y3933y3933/joker/internal/service"
 	"github.com/y3933y3933/joker/internal/store"
-	"github.com/y3933y3933/joker/internal/utils/errx"
 	"github.com/y3933y3933/joker/internal/utils/httpx"
+	"github.com/y3933y3933/joker/internal/utils/param"
 	"github.com/y3933y3933/joker/internal/ws"
 )
 
 type PlayerHandler struct {
 	playerService *service.PlayerService
+	roomAccess    *service.RoomAccess
 	hub           *ws.Hub
 	logger        *slog.Logger
 }
 
-func NewPlayerHandler(playerService *service.PlayerService, hub *ws.Hub, logger *slog.Logger) *PlayerHandler {
+func NewPlayerHandler(playerService *service.PlayerService, roomAccess *service.RoomAccess, hub *ws.Hub, logger *slog.Logger) *PlayerHandler {
 	return &PlayerHandler{
 		playerService: playerService,
+		roomAccess:    roomAccess,
 		hub:           hub,
 		logger:        logger,
 	}
 }
 
 type JoinGameRequest struct {
-	Nickname string `json:"nickname" binding:"required"`
+	Nickname string       `json:"nickname" binding:"required"`
+	Avatar   store.Avatar `json:"avatar"` // 可省略
+	// 房間需要密碼或邀請時帶其中一個
+	Passcode string `json:"passcode"`
+	Invite   string `json:"invite"`
+}
+
+// JoinResponse 加入後的玩家，seatToken 在重新連線 WebSocket 時使用
+type JoinResponse struct {
+	*store.Player
+	SeatToken string `json:"seatToken"`
+}
+
+// checkRoomAccess 檢查密碼或邀請連結，不通過時已回應錯誤
+func (h *PlayerHandler) checkRoomAccess(c *gin.Context, game *store.Game, req JoinGameRequest) bool {
+	err := h.roomAccess.Check(game, 0, service.AccessCredentials{Passcode: req.Passcode, Invite: req.Invite})
+	if err != nil {
+		httpx.Error(c, err)
+		return false
+	}
+	return true
+}
+
+func (h *PlayerHandler) joinResponse(c *gin.Context, game *store.Game, player *store.Player) {
+	seat, err := h.roomAccess.IssueSeat(game.Code, player.ID)
+	if err != nil {
+		httpx.Error(c, err)
+		return
+	}
+	httpx.SuccessResponse(c, &JoinResponse{Player: player, SeatToken: seat})
 }
 
 func (h *PlayerHandler) HandleJoinGame(c *gin.Context) {
 	var req JoinGameRequest
 	if err := c.ShouldBindBodyWithJSON(&req); err != nil {
-		httpx.BadRequestResponse(c, err)
+		httpx.Error(c, err)
 		return
 	}
 
 	gameAny, ok := c.Get("game")
 	if !ok {
-		httpx.ServerErrorResponse(c, h.logger, errors.New("missing game in context"))
+		httpx.Error(c, errors.New("missing game in context"))
 		return
 	}
 
 	game := gameAny.(*store.Game)
+	if !h.checkRoomAccess(c, game, req) {
+		return
+	}
 
-	player, err := h.playerService.JoinGame(c.Request.Context(), game.ID, req.Nickname)
+	player, err := h.playerService.JoinGame(c.Request.Context(), game, req.Nickname, req.Avatar, accountID(c))
 	if err != nil {
-		switch {
-		case errors.Is(err, errx.ErrGameNotFound):
-			httpx.BadRequestResponse(c, errors.New("game not found"))
-		case errors.Is(err, errx.ErrDuplicateNickname):
-			httpx.BadRequestResponse(c, errors.New("this nickname is already in use"))
-		default:
-			httpx.ServerErrorResponse(c, h.logger, err)
-		}
+		httpx.Error(c, err)
 
 		return
 	}
@@ -66,29 +93,107 @@ func (h *PlayerHandler) HandleJoinGame(c *gin.Context) {
 			ID:       player.ID,
 			Nickname: player.Nickname,
 			IsHost:   player.IsHost,
+			Avatar:   player.Avatar,
 		})
 		if err != nil {
-			httpx.ServerErrorResponse(c, h.logger, err)
+			httpx.Error(c, err)
 			return
 		}
-		room.Broadcast(msg)
+		room.Broadcast(c.Request.Context(), msg)
 	}
 
-	httpx.SuccessResponse(c, player)
+	h.joinResponse(c, game, player)
+}
+
+func (h *PlayerHandler) HandleSpectateGame(c *gin.Context) {
+	var req JoinGameRequest
+	if err := c.ShouldBindBodyWithJSON(&req); err != nil {
+		httpx.Error(c, err)
+		return
+	}
+
+	gameAny, ok := c.Get("game")
+	if !ok {
+		httpx.Error(c, errors.New("missing game in context"))
+		return
+	}
+	game := gameAny.(*store.Game)
+	if !h.checkRoomAccess(c, game, req) {
+		return
+	}
+
+	spectator, err := h.playerService.SpectateGame(c.Request.Context(), game, req.Nickname, req.Avatar, accountID(c))
+	if err != nil {
+		httpx.Error(c, err)
+		return
+	}
+
+	// 推播 spectator_joined 給房間內所有人
+	room := h.hub.GetRoom(game.Code)
+	if room != nil {
+		msg := ws.MustWSMessage(ws.MsgSpectatorJoined, ws.SpectatorPayload{
+			ID:       spectator.ID,
+			Nickname: spectator.Nickname,
+		})
+		room.Broadcast(c.Request.Context(), msg)
+	}
+
+	h.joinResponse(c, game, spectator)
+}
+
+func (h *PlayerHandler) HandlePromoteSpectator(c *gin.Context) {
+	gameAny, ok := c.Get("game")
+	if !ok {
+		httpx.Error(c, errors.New("missing game in context"))
+		return
+	}
+	game := gameAny.(*store.Game)
+
+	spectatorID, err := param.ParseIntParam(c, "id")
+	if err != nil {
+		httpx.Error(c, err)
+		return
+	}
 
+	playerIDAny, ok := c.Get("player_id")
+	if !ok {
+		httpx.Error(c, errors.New("missing player id"))
+		return
+	}
+	playerID := playerIDAny.(int64)
+
+	player, err := h.playerService.PromoteSpectator(c.Request.Context(), game, playerID, spectatorID)
+	if err != nil {
+		httpx.Error(c, err)
+		return
+	}
+
+	room := h.hub.GetRoom(game.Code)
+	if room != nil {
+		room.SetSpectator(player.ID, false)
+
+		msg := ws.MustWSMessage(ws.MsgSpectatorPromoted, ws.PlayerJoinedPayload{
+			ID:       player.ID,
+			Nickname: player.Nickname,
+			IsHost:   player.IsHost,
+		})
+		room.Broadcast(c.Request.Context(), msg)
+	}
+
+	httpx.SuccessResponse(c, player)
 }
 
 func (h *PlayerHandler) HandleListPlayers(c *gin.Context) {
 	gameAny, ok := c.Get("game")
 	if !ok {
-		httpx.ServerErrorResponse(c, h.logger, errors.New("missing game in context"))
+		httpx.Error(c, errors.New("missing game in context"))
 		return
 	}
 	game := gameAny.(*store.Game)
 
 	players, err := h.playerService.ListPlayersInGame(c.Request.Context(), game.ID)
 	if err != nil {
-		httpx.ServerErrorResponse(c, h.logger, err)
+		httpx.Error(c, err)
 		return
 	}
 
@@ -98,38 +203,87 @@ func (h *PlayerHandler) HandleListPlayers(c *gin.Context) {
 func (h *PlayerHandler) HandleLeaveGame(c *gin.Context) {
 	gameAny, ok := c.Get("game")
 	if !ok {
-		httpx.ServerErrorResponse(c, h.logger, errors.New("missing game in context"))
+		httpx.Error(c, errors.New("missing game in context"))
 		return
 	}
 	game := gameAny.(*store.Game)
 
 	playerIDAny, ok := c.Get("player_id")
 	if !ok {
-		httpx.ServerErrorResponse(c, h.logger, errors.New("missing player id"))
+		httpx.Error(c, errors.New("missing player id"))
 		return
 	}
 	playerID := playerIDAny.(int64)
 
 	left, newHost, err := h.playerService.LeaveGame(c.Request.Context(), playerID)
 	if err != nil {
-		httpx.ServerErrorResponse(c, h.logger, err)
+		httpx.Error(c, err)
 		return
 	}
 
 	room := h.hub.GetRoom(game.Code)
 	if room != nil {
-		msg1, _ := ws.NewWSMessage(ws.MsgPlayerLeft, ws.PlayerLeftPayload{
+		msg1 := ws.MustWSMessage(ws.MsgPlayerLeft, ws.PlayerLeftPayload{
 			ID:       left.ID,
 			Nickname: left.Nickname,
 		})
-		room.Broadcast(msg1)
+		burst := []ws.WSMessage{msg1}
 
 		if newHost != nil {
-			msg2, _ := ws.NewWSMessage(ws.MsgHostTransferred, ws.HostTransferredPayload{
+			msg2 := ws.MustWSMessage(ws.MsgHostTransferred, ws.HostTransferredPayload{
 				ID:       newHost.ID,
 				Nickname: newHost.Nickname,
 			})
-			room.Broadcast(msg2)
+			burst = append(burst, msg2)
 		}
+		room.Broadcast(c.Request.Context(), burst...)
+	}
+
+	httpx.SuccessResponse(c, nil)
+}
+
+// HandleUpdateProfile 玩家在遊戲開始前修改自己的暱稱或頭像
+func (h *PlayerHandler) HandleUpdateProfile(c *gin.Context) {
+	var req service.ProfileUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httpx.Error(c, err)
+		return
 	}
+
+	gameAny, ok := c.Get("game")
+	if !ok {
+		httpx.Error(c, errors.New("missing game in context"))
+		return
+	}
+	game := gameAny.(*store.Game)
+
+	playerIDAny, ok := c.Get("player_id")
+	if !ok {
+		httpx.Error(c, errors.New("missing player id"))
+		return
+	}
+	playerID := playerIDAny.(int64)
+
+	player, err := h.playerService.UpdateProfile(c.Request.Context(), game, playerID, req)
+	if err != nil {
+		httpx.Error(c, err)
+		return
+	}
+
+	if room := h.hub.GetRoom(game.Code); room != nil {
+		room.SetNickname(player.ID, player.Nickname)
+		msg := ws.MustWSMessage(ws.MsgTypePlayerUpdated, ws.PlayerUpdatedPayload{
+			ID:       player.ID,
+			Nickname: player.Nickname,
+			Avatar:   player.Avatar,
+		})
+		room.Broadcast(c.Request.Context(), msg)
+	}
+
+	httpx.SuccessResponse(c, player)
+}
+
+// HandleListAvatars 列出可選的預設頭像
+func (h *PlayerHandler) HandleListAvatars(c *gin.Context) {
+	httpx.SuccessResponse(c, gin.H{"presets": service.AvatarPresets})
 }
@@ -30,12 +30,12 @@ func (h *QuestionHandler) HandleGetPaginatedQuestions(c *gin.Context) {
 	params := h.parseQueryParams(c)
 
 	if err := h.questionService.ValidateQuestionParams(params); err != nil {
-		httpx.BadRequestResponse(c, err)
+		httpx.Error(c, err)
 		return
 	}
 	result, err := h.questionService.ListQuestions(c.Request.Context(), params)
 	if err != nil {
-		httpx.ServerErrorResponse(c, h.logger, err)
+		httpx.Error(c, err)
 		return
 	}
 
@@ -62,21 +62,21 @@ func (h *QuestionHandler) parseQueryParams(c *gin.Context) service.QuestionQuery
 	return params
 }
 
-type createQuestionRequest struct {
+type CreateQuestionRequest struct {
 	Level   string `json:"level" binding:"required,oneof=normal spicy"`
 	Content string `json:"content" binding:"required"`
 }
 
 func (h *QuestionHandler) HandleCreateQuestion(c *gin.Context) {
-	var req createQuestionRequest
+	var req CreateQuestionRequest
 	if err := c.ShouldBindBodyWithJSON(&req); err != nil {
-		httpx.BadRequestResponse(c, err)
+		httpx.Error(c, err)
 		return
 	}
 
 	q, err := h.questionService.CreateQuestion(c.Request.Context(), req.Content, req.Level)
 	if err != nil {
-		httpx.ServerErrorResponse(c, h.logger, err)
+		httpx.Error(c, err)
 		return
 	}
 
@@ -86,20 +86,20 @@ func (h *QuestionHandler) HandleCreateQuestion(c *gin.Context) {
 func (h *QuestionHandler) HandleDeleteQuestion(c *gin.Context) {
 	id, err := param.ParseIntParam(c, "id")
 	if err != nil {
-		httpx.BadRequestResponse(c, err)
+		httpx.Error(c, err)
 		return
 	}
 
 	err = h.questionService.DeleteQuestion(c.Request.Context(), id)
 	if err != nil {
-		httpx.ServerErrorResponse(c, h.logger, err)
+		httpx.Error(c, err)
 		return
 	}
 
 	httpx.SuccessResponse(c, nil)
 }
 
-type updateQuestionRequest struct {
+type UpdateQuestionRequest struct {
 	Level   *string `json:"level" binding:"oneof=normal spicy" `
 	Content *string `json:"content"`
 }
@@ -107,19 +107,19 @@ type updateQuestionRequest struct {
 func (h *QuestionHandler) HandleUpdateQuestion(c *gin.Context) {
 	id, err := param.ParseIntParam(c, "id")
 	if err != nil {
-		httpx.BadRequestResponse(c, err)
+		httpx.Error(c, err)
 		return
 	}
 
-	var req updateQuestionRequest
+	var req UpdateQuestionRequest
 	if err := c.ShouldBindBodyWithJSON(&req); err != nil {
-		httpx.BadRequestResponse(c, err)
+		httpx.Error(c, err)
 		return
 	}
 
 	q, err := h.questionService.UpdateQuestion(c.Request.Context(), id, req.Content, req.Level)
 	if err != nil {
-		httpx.ServerErrorResponse(c, h.logger, err)
+		httpx.Error(c, err)
 		return
 	}
 
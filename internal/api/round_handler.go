@@ -1,14 +1,15 @@
 package api
 
 import (
+	"context"
 	"errors"
 	"log/slog"
 
 	"github.com/gin-gonic/gin"
 	"github.com/y3933y3933/joker/internal/service"
 	"github.com/y3933y3933/joker/internal/store"
-	"github.com/y3933y3933/joker/internal/utils/errx"
 	"github.com/y3933y3933/joker/internal/utils/httpx"
+	"github.com/y3933y3933/joker/internal/utils/logx"
 	"github.com/y3933y3933/joker/internal/utils/param"
 	"github.com/y3933y3933/joker/internal/ws"
 )
@@ -30,23 +31,14 @@ func NewRoundHandler(roundService *service.RoundService, logger *slog.Logger, hu
 func (h *RoundHandler) HandleStartGame(c *gin.Context) {
 	gameAny, ok := c.Get("game")
 	if !ok {
-		httpx.ServerErrorResponse(c, h.logger, errors.New("missing game in context"))
+		httpx.Error(c, errors.New("missing game in context"))
 		return
 	}
 	game := gameAny.(*store.Game)
 
 	round, err := h.roundService.StartGame(c.Request.Context(), game)
 	if err != nil {
-		switch {
-		case errors.Is(err, errx.ErrInvalidGameStatus):
-			httpx.BadRequestResponse(c, errors.New("game already started or ended"))
-
-		case errors.Is(err, errx.ErrInvalidGameStatus):
-			httpx.BadRequestResponse(c, errors.New("game already started or ended"))
-
-		default:
-			httpx.ServerErrorResponse(c, h.logger, err)
-		}
+		httpx.Error(c, err)
 		return
 
 	}
@@ -54,12 +46,12 @@ func (h *RoundHandler) HandleStartGame(c *gin.Context) {
 	// ✅ 推播給所有人
 	room := h.hub.GetRoom(game.Code)
 	if room != nil {
-		msg, _ := ws.NewWSMessage(ws.MsgTypeGameStarted, ws.RoundStartedPayload{
+		msg := ws.MustWSMessage(ws.MsgTypeGameStarted, ws.RoundStartedPayload{
 			RoundID:          round.ID,
 			QuestionPlayerID: round.QuestionPlayerID,
 			AnswererID:       round.AnswerPlayerID,
 		})
-		room.Broadcast(msg)
+		room.Broadcast(c.Request.Context(), msg)
 	}
 
 	httpx.SuccessResponse(c, round)
@@ -72,46 +64,39 @@ type SubmitQuestionRequest struct {
 func (h *RoundHandler) HandleSubmitQuestion(c *gin.Context) {
 	var req SubmitQuestionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		httpx.BadRequestResponse(c, err)
+		httpx.Error(c, err)
 		return
 	}
 
 	roundID, err := param.ParseIntParam(c, "id")
 	if err != nil {
-		httpx.BadRequestResponse(c, errors.New("invalid round id"))
+		httpx.Error(c, err)
 		return
 	}
 
 	playerIDAny, ok := c.Get("player_id")
 	if !ok {
-		httpx.ServerErrorResponse(c, h.logger, errors.New("missing player id"))
+		httpx.Error(c, errors.New("missing player id"))
 		return
 	}
 	playerID := playerIDAny.(int64)
 
 	err = h.roundService.SubmitQuestion(c.Request.Context(), roundID, req.QuestionID, playerID)
 	if err != nil {
-		switch {
-		case errors.Is(err, errx.ErrForbidden):
-			httpx.ForbiddenResponse(c, err)
-		case errors.Is(err, errx.ErrInvalidStatus):
-			httpx.BadRequestResponse(c, err)
-		default:
-			httpx.ServerErrorResponse(c, h.logger, err)
-		}
+		httpx.Error(c, err)
 		return
 	}
 
 	// 拿 round + question 資料（包含回答者 ID 與題目內容）
 	round, err := h.roundService.GetRoundWithQuestion(c.Request.Context(), roundID)
 	if err != nil {
-		httpx.ServerErrorResponse(c, h.logger, err)
+		httpx.Error(c, err)
 		return
 	}
 
 	gameAny, ok := c.Get("game")
 	if !ok {
-		httpx.ServerErrorResponse(c, h.logger, errors.New("missing game in context"))
+		httpx.Error(c, errors.New("missing game in context"))
 		return
 	}
 	game := gameAny.(*store.Game)
@@ -120,14 +105,14 @@ func (h *RoundHandler) HandleSubmitQuestion(c *gin.Context) {
 	room := h.hub.GetRoom(game.Code)
 	if room != nil {
 		// 1️⃣ 推播給所有人：進入回答時間
-		msg1, _ := ws.NewWSMessage(ws.MsgTypeAnswerTime, nil)
-		room.Broadcast(msg1)
+		msg1 := ws.MustWSMessage(ws.MsgTypeAnswerTime, ws.AnswerTimePayload{})
+		room.Broadcast(c.Request.Context(), msg1)
 
 		// 2️⃣ 私訊給回答者：這是題目內容
 
-		msg2, _ := ws.NewWSMessage(ws.MsgTypeRoundQuestion, map[string]string{
-			"level":   round.Level,
-			"content": round.Content,
+		msg2 := ws.MustWSMessage(ws.MsgTypeRoundQuestion, ws.RoundQuestionPayload{
+			Level:   round.Level,
+			Content: round.Content,
 		})
 
 		room.SendTo(round.AnswerPlayerID, msg2)
@@ -137,62 +122,57 @@ func (h *RoundHandler) HandleSubmitQuestion(c *gin.Context) {
 }
 
 type SubmitAnswerRequest struct {
-	Answer string `json:"answer" binding:"required"`
+	Answer        string `json:"answer" binding:"required"`
+	AboutPlayerID *int64 `json:"aboutPlayerID"` // 開啟猜測時：回答說的是哪位玩家
+	Truthful      *bool  `json:"truthful"`      // 開啟猜測時：回答是否屬實
 }
 
 func (h *RoundHandler) HandleSubmitAnswer(c *gin.Context) {
 	var req SubmitAnswerRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		httpx.BadRequestResponse(c, err)
+		httpx.Error(c, err)
 		return
 	}
 
 	// 取得 roundID
 	roundID, err := param.ParseIntParam(c, "id")
 	if err != nil {
-		httpx.BadRequestResponse(c, errors.New("invalid round id"))
+		httpx.Error(c, err)
 		return
 	}
 
 	// 從 context 取得 playerID
 	playerIDAny, ok := c.Get("player_id")
 	if !ok {
-		httpx.ServerErrorResponse(c, h.logger, errors.New("missing player id"))
+		httpx.Error(c, errors.New("missing player id"))
 		return
 	}
 	playerID := playerIDAny.(int64)
 
-	// 呼叫 Service
-	err = h.roundService.SubmitAnswer(c.Request.Context(), roundID, req.Answer, playerID)
-	if err != nil {
-		switch {
-		case errors.Is(err, errx.ErrForbidden):
-			httpx.ForbiddenResponse(c, err)
-		case errors.Is(err, errx.ErrInvalidStatus):
-			httpx.BadRequestResponse(c, err)
-		case errors.Is(err, errx.ErrRoundNotFound):
-			httpx.NotFoundResponse(c, err)
-		default:
-			httpx.ServerErrorResponse(c, h.logger, err)
-		}
-		return
-	}
-
-	// 取 game code 推播
 	gameAny, ok := c.Get("game")
 	if !ok {
-		httpx.ServerErrorResponse(c, h.logger, errors.New("missing game context"))
+		httpx.Error(c, errors.New("missing game context"))
 		return
 	}
 	game := gameAny.(*store.Game)
 
+	// 呼叫 Service
+	err = h.roundService.SubmitAnswer(c.Request.Context(), game, roundID, req.Answer, playerID, store.AnswerSecret{
+		AboutPlayerID: req.AboutPlayerID,
+		Truthful:      req.Truthful,
+	})
+	if err != nil {
+		httpx.Error(c, err)
+		return
+	}
+
 	// 推播 answer_submitted 給所有人
 	room := h.hub.GetRoom(game.Code)
 	if room != nil {
-		msg, _ := ws.NewWSMessage(ws.MsgTypeAnswerSubmitted, ws.AnswerSubmittedPayload{
+		msg := ws.MustWSMessage(ws.MsgTypeAnswerSubmitted, ws.AnswerSubmittedPayload{
 			Answer: req.Answer,
 		})
-		room.Broadcast(msg)
+		room.Broadcast(c.Request.Context(), msg)
 	}
 
 	httpx.SuccessResponse(c, gin.H{"message": "answer submitted"})
@@ -205,33 +185,26 @@ type DrawCardRequest struct {
 func (h *RoundHandler) HandleDrawCard(c *gin.Context) {
 	var req DrawCardRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		httpx.BadRequestResponse(c, err)
+		httpx.Error(c, err)
 		return
 	}
 
 	roundID, err := param.ParseIntParam(c, "id")
 	if err != nil {
-		httpx.BadRequestResponse(c, errors.New("invalid round id"))
+		httpx.Error(c, err)
 		return
 	}
 
 	playerIDAny, ok := c.Get("player_id")
 	if !ok {
-		httpx.ServerErrorResponse(c, h.logger, errors.New("missing player id"))
+		httpx.Error(c, errors.New("missing player id"))
 		return
 	}
 	playerID := playerIDAny.(int64)
 
 	round, err := h.roundService.DrawCard(c.Request.Context(), roundID, playerID, *req.Index)
 	if err != nil {
-		switch {
-		case errors.Is(err, errx.ErrForbidden):
-			httpx.ForbiddenResponse(c, err)
-		case errors.Is(err, errx.ErrInvalidStatus):
-			httpx.BadRequestResponse(c, err)
-		default:
-			httpx.ServerErrorResponse(c, h.logger, err)
-		}
+		httpx.Error(c, err)
 		return
 	}
 
@@ -241,16 +214,16 @@ func (h *RoundHandler) HandleDrawCard(c *gin.Context) {
 
 	room := h.hub.GetRoom(game.Code)
 	if room != nil {
+		var msg ws.WSMessage
 		if round.IsJoker {
-			msg, _ := ws.NewWSMessage(ws.MsgTypeJokerRevealed, ws.JokerRevealedPayload{
+			msg = ws.MustWSMessage(ws.MsgTypeJokerRevealed, ws.JokerRevealedPayload{
 				Level:   round.Level,
 				Content: round.Content,
 			})
-			room.Broadcast(msg)
 		} else {
-			msg, _ := ws.NewWSMessage(ws.MsgTypePlayerSafe, nil)
-			room.Broadcast(msg)
+			msg = ws.MustWSMessage(ws.MsgTypePlayerSafe, ws.PlayerSafePayload{})
 		}
+		room.Broadcast(c.Request.Context(), append([]ws.WSMessage{msg}, h.leaderboardMessages(c.Request.Context(), game)...)...)
 	}
 
 	httpx.SuccessResponse(c, gin.H{
@@ -258,30 +231,86 @@ func (h *RoundHandler) HandleDrawCard(c *gin.Context) {
 	})
 }
 
+// HandleVote 在投票階段送出猜測或是否公開題目的選擇
+func (h *RoundHandler) HandleVote(c *gin.Context) {
+	var req service.Ballot
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httpx.Error(c, err)
+		return
+	}
+
+	roundID, err := param.ParseIntParam(c, "id")
+	if err != nil {
+		httpx.Error(c, err)
+		return
+	}
+
+	playerIDAny, ok := c.Get("player_id")
+	if !ok {
+		httpx.Error(c, errors.New("missing player id"))
+		return
+	}
+	playerID := playerIDAny.(int64)
+
+	gameAny, ok := c.Get("game")
+	if !ok {
+		httpx.Error(c, errors.New("missing game in context"))
+		return
+	}
+	game := gameAny.(*store.Game)
+
+	result, err := h.roundService.SubmitVote(c.Request.Context(), game, roundID, playerID, req)
+	if err != nil {
+		httpx.Error(c, err)
+		return
+	}
+
+	room := h.hub.GetRoom(game.Code)
+	if room != nil {
+		msgs := ws.VoteMessages(playerID, result)
+		if result.Resolved {
+			msgs = append(msgs, h.leaderboardMessages(c.Request.Context(), game)...)
+		}
+		room.Broadcast(c.Request.Context(), msgs...)
+	}
+
+	httpx.SuccessResponse(c, result)
+}
+
 func (h *RoundHandler) HandleCreateNextRound(c *gin.Context) {
 	gameAny, ok := c.Get("game")
 	if !ok {
-		httpx.ServerErrorResponse(c, h.logger, errors.New("missing game in context"))
+		httpx.Error(c, errors.New("missing game in context"))
 		return
 	}
 	game := gameAny.(*store.Game)
 
 	round, err := h.roundService.CreateNextRound(c.Request.Context(), game)
 	if err != nil {
-		httpx.ServerErrorResponse(c, h.logger, err)
+		httpx.Error(c, err)
 		return
 	}
 
 	// 推播 round_started 給所有人
 	room := h.hub.GetRoom(game.Code)
 	if room != nil {
-		msg, _ := ws.NewWSMessage(ws.MsgNextRoundStarted, ws.RoundStartedPayload{
+		msg := ws.MustWSMessage(ws.MsgNextRoundStarted, ws.RoundStartedPayload{
 			RoundID:          round.ID,
 			AnswererID:       round.AnswerPlayerID,
 			QuestionPlayerID: round.QuestionPlayerID,
 		})
-		room.Broadcast(msg)
+		room.Broadcast(c.Request.Context(), msg)
 	}
 
 	httpx.SuccessResponse(c, round)
 }
+
+// leaderboardMessages 回合結算後附上最新排行榜，查詢失敗只記錄不影響回合
+func (h *RoundHandler) leaderboardMessages(ctx context.Context, game *store.Game) []ws.WSMessage {
+	entries, err := h.roundService.Leaderboard(ctx, game)
+	if err != nil {
+		logx.FromContext(ctx).ErrorContext(ctx, "Leaderboard failed", "error", err)
+		return nil
+	}
+	return []ws.WSMessage{ws.LeaderboardMessage(entries)}
+}
@@ -1,12 +1,10 @@
 package api
 
 import (
-	"errors"
 	"log/slog"
 
 	"github.com/gin-gonic/gin"
 	"github.com/y3933y3933/joker/internal/service"
-	"github.com/y3933y3933/joker/internal/utils/errx"
 	"github.com/y3933y3933/joker/internal/utils/httpx"
 )
 
@@ -27,12 +25,7 @@ func (h *UserHandler) HandlerGetUserInfo(c *gin.Context) {
 
 	user, err := h.userService.GetUserInfo(c.Request.Context(), userID)
 	if err != nil {
-		switch {
-		case errors.Is(err, errx.ErrUserNotFound):
-			httpx.BadRequestResponse(c, err)
-		default:
-			httpx.ServerErrorResponse(c, h.logger, err)
-		}
+		httpx.Error(c, err)
 		return
 	}
 
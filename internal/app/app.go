@@ -1,26 +1,37 @@
 package app
 
 import (
+	"context"
 	"flag"
 	"log/slog"
-	"net/http"
 	"os"
+	"strings"
+	"sync/atomic"
+	"time"
 
-	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/y3933y3933/joker/internal/api"
 	"github.com/y3933y3933/joker/internal/db/sqlc"
+	"github.com/y3933y3933/joker/internal/metrics"
 	"github.com/y3933y3933/joker/internal/middleware"
 	"github.com/y3933y3933/joker/internal/service"
 	"github.com/y3933y3933/joker/internal/store"
+	"github.com/y3933y3933/joker/internal/tracing"
+	"github.com/y3933y3933/joker/internal/utils/logx"
 	"github.com/y3933y3933/joker/internal/ws"
 )
 
-type config struct {
-	Port       int
-	Env        string
-	DB_URL     string
-	JWT_SECRET string
+type Config struct {
+	Port          int
+	Env           string
+	LogLevel      string
+	DB_URL        string
+	AutoMigrate   bool
+	JWT_SECRET    string
+	ChatBlocklist string
+	Janitor       service.JanitorConfig
+	Tracing       tracing.Config
+	Shutdown      ShutdownConfig
 }
 
 type db struct {
@@ -29,11 +40,14 @@ type db struct {
 }
 
 type Application struct {
-	Config            config
+	Config            Config
 	Logger            *slog.Logger
 	DB                *db
+	Metrics           *metrics.Metrics
+	Hub               *ws.Hub
 	GameHandler       *api.GameHandler
 	PlayerHandler     *api.PlayerHandler
+	HostHandler       *api.HostHandler
 	RoundHandler      *api.RoundHandler
 	FeedbackHandler   *api.FeedbackHandler
 	AuthHandler       *api.AuthHandler
@@ -42,65 +56,130 @@ type Application struct {
 	UserHandler       *api.UserHandler
 	AdminHandler      *api.AdminHandler
 	QuestionHandler   *api.QuestionHandler
+	AccountHandler    *api.AccountHandler
+	Janitor           *service.Janitor
+
+	// 收到 SIGTERM 後設為 true，readiness 改回 503
+	shuttingDown atomic.Bool
 }
 
 func NewApplication() (*Application, error) {
-	var cfg config
+	var cfg Config
 	flag.IntVar(&cfg.Port, "port", 8080, "API server port")
 	flag.StringVar(&cfg.Env, "env", "dev", "Environment (dev|prod)")
+	flag.StringVar(&cfg.LogLevel, "log-level", "info", "Minimum log level (debug|info|warn|error)")
 	flag.StringVar(&cfg.DB_URL, "db", "", "database url")
+	flag.BoolVar(&cfg.AutoMigrate, "auto-migrate", false, "Apply pending migrations at startup, other instances wait on an advisory lock")
 	flag.StringVar(&cfg.JWT_SECRET, "jwt-secret", "", "JWT Secret")
+	flag.StringVar(&cfg.ChatBlocklist, "chat-blocklist", "", "Comma-separated words masked in chat messages")
+	flag.DurationVar(&cfg.Janitor.Interval, "janitor-interval", service.DefaultJanitorConfig.Interval, "How often to look for abandoned games")
+	flag.DurationVar(&cfg.Janitor.WaitingIdle, "idle-waiting", service.DefaultJanitorConfig.WaitingIdle, "End waiting games idle for longer than this")
+	flag.DurationVar(&cfg.Janitor.PlayingIdle, "idle-playing", service.DefaultJanitorConfig.PlayingIdle, "End playing games idle for longer than this")
+	flag.DurationVar(&cfg.Janitor.EmptyGrace, "empty-game-grace", service.DefaultJanitorConfig.EmptyGrace, "Delete never-started games with no players after this long")
+	flag.StringVar(&cfg.Tracing.Exporter, "otel-exporter", tracing.ExporterNone, "Trace exporter (none|stdout|otlp)")
+	flag.StringVar(&cfg.Tracing.Endpoint, "otel-endpoint", "", "OTLP/HTTP endpoint URL, defaults to the OTEL_EXPORTER_OTLP_* environment variables")
+	flag.Float64Var(&cfg.Tracing.SampleRatio, "otel-sample-ratio", 1, "Fraction of new traces to sample (0-1)")
+	flag.DurationVar(&cfg.Shutdown.Drain, "shutdown-drain", 5*time.Second, "How long to report not ready before closing the listener on shutdown")
+	flag.DurationVar(&cfg.Shutdown.Timeout, "shutdown-timeout", 15*time.Second, "How long to wait for in-flight requests and WebSocket rooms on shutdown")
 	flag.Parse()
 
-	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	level, err := logx.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		return nil, err
+	}
+	// prod 輸出 JSON，沒有請求 context 的地方也用同一個 logger
+	logger := logx.New(os.Stdout, cfg.Env, level)
+	slog.SetDefault(logger)
 
 	pgDB, queries, err := store.Open(cfg.DB_URL)
 	if err != nil {
 		return nil, err
 	}
+	logger.Info("database connection pool established")
 
-	// store
-	gameStore := store.NewPostgresGameStore(queries)
-	playerStore := store.NewPostgresPlayerStore(queries)
-	roundStore := store.NewPostgresRoundStore(queries)
-	questionStore := store.NewPostgresQuestionStore(queries)
-	feedbackStore := store.NewPostgresFeedStore(queries)
-	userStore := store.NewPostgresUserStore(queries)
+	if err := prepareSchema(context.Background(), pgDB, cfg.AutoMigrate, logger); err != nil {
+		pgDB.Close()
+		return nil, err
+	}
+
+	app := New(cfg, logger, Stores{
+		Games:     store.NewPostgresGameStore(queries),
+		Players:   store.NewPostgresPlayerStore(queries),
+		Rounds:    store.NewPostgresRoundStore(queries),
+		Votes:     store.NewPostgresVoteStore(queries),
+		Questions: store.NewPostgresQuestionStore(queries),
+		Feedback:  store.NewPostgresFeedStore(queries),
+		Users:     store.NewPostgresUserStore(queries),
+		Accounts:  store.NewPostgresAccountStore(queries),
+		Tx:        store.NewPostgresTxRunner(pgDB, queries),
+	})
+	app.DB = &db{
+		ConnPool: pgDB,
+		Queries:  queries,
+	}
+	app.Metrics.WatchPool(pgDB)
+	return app, nil
+}
+
+// Stores 是應用程式使用的所有 store，正式環境為 PostgreSQL，測試可以換成 store.MemoryDB
+type Stores struct {
+	Games     store.GameStore
+	Players   store.PlayerStore
+	Rounds    store.RoundStore
+	Votes     store.VoteStore
+	Questions store.QuestionStore
+	Feedback  store.FeedbackStore
+	Users     store.UserStore
+	Accounts  store.AccountStore
+	Tx        store.TxRunner
+}
+
+// New 以指定的 store 組出所有 service 與 handler，不連線資料庫，DB 由呼叫端設定
+func New(cfg Config, logger *slog.Logger, stores Stores) *Application {
+	appMetrics := metrics.NewMetrics()
 
 	// service
-	gameService := service.NewGameService(gameStore, playerStore)
-	playerService := service.NewPlayerService(playerStore, gameStore)
-	roundService := service.NewRoundService(roundStore, playerStore, gameStore)
-	questionService := service.NewQuestionService(questionStore)
-	feedbackService := service.NewFeedbackService(feedbackStore)
-	authService := service.NewAuthService(userStore, []byte(cfg.JWT_SECRET))
-	userService := service.NewUserService(userStore)
-	adminService := service.NewAdminService(playerStore, feedbackStore, gameStore)
+	gameService := service.NewGameService(stores.Games, stores.Players, stores.Rounds, stores.Votes, stores.Tx)
+	playerService := service.NewPlayerService(stores.Players, stores.Games, stores.Tx)
+	roundService := service.NewRoundService(stores.Rounds, stores.Players, stores.Games, stores.Votes, stores.Tx, appMetrics)
+	questionService := service.NewQuestionService(stores.Questions)
+	feedbackService := service.NewFeedbackService(stores.Feedback)
+	authService := service.NewAuthService(stores.Users, []byte(cfg.JWT_SECRET), appMetrics)
+	userService := service.NewUserService(stores.Users)
+	adminService := service.NewAdminService(stores.Players, stores.Feedback, stores.Games)
+	roomAccess := service.NewRoomAccess([]byte(cfg.JWT_SECRET), service.SystemClock)
+	hostService := service.NewHostService(stores.Players, stores.Games, roundService, roomAccess, stores.Tx)
+	accountService := service.NewAccountService(stores.Accounts, []byte(cfg.JWT_SECRET), appMetrics)
 
 	// ws
-	hub := ws.NewHub()
+	hub := ws.NewHub(logger)
+	appMetrics.WatchHub(hub)
 
 	// handler
-	gameHandler := api.NewGameHandler(gameService, questionService, hub, logger)
-	playerHandler := api.NewPlayerHandler(playerService, hub, logger)
+	gameHandler := api.NewGameHandler(gameService, questionService, roomAccess, hub, logger)
+	playerHandler := api.NewPlayerHandler(playerService, roomAccess, hub, logger)
 	roundHandler := api.NewRoundHandler(roundService, logger, hub)
-	wsHandler := ws.NewHandler(hub, logger, playerService, gameService, roundService)
+	hostHandler := api.NewHostHandler(hostService, hub, logger)
+	wsHandler := ws.NewHandler(hub, playerService, gameService, roundService, hostService, roomAccess)
+	wsHandler.ChatFilter = ws.NewBlocklistFilter(strings.Split(cfg.ChatBlocklist, ","))
 	feedbackHandler := api.NewFeedbackHandler(logger, feedbackService)
 	authHandler := api.NewAuthHandler(authService, logger)
-	middlewareHandler := middleware.NewMiddleware(gameService, authService)
+	middlewareHandler := middleware.NewMiddleware(gameService, authService, accountService, roomAccess)
 	userHandler := api.NewUserHandler(userService, logger)
 	adminHandler := api.NewAdminHandler(logger, adminService)
 	questionHandler := api.NewQuestionHandler(logger, questionService)
+	accountHandler := api.NewAccountHandler(accountService, playerService, roomAccess, logger)
+
+	janitor := service.NewJanitor(stores.Games, hub, service.SystemClock, cfg.Janitor, logger)
 
-	app := &Application{
-		Config: cfg,
-		Logger: logger,
-		DB: &db{
-			ConnPool: pgDB,
-			Queries:  queries,
-		},
+	return &Application{
+		Config:            cfg,
+		Logger:            logger,
+		Metrics:           appMetrics,
+		Hub:               hub,
 		GameHandler:       gameHandler,
 		PlayerHandler:     playerHandler,
+		HostHandler:       hostHandler,
 		RoundHandler:      roundHandler,
 		AuthHandler:       authHandler,
 		WSHandler:         wsHandler,
@@ -109,13 +188,7 @@ func NewApplication() (*Application, error) {
 		AdminHandler:      adminHandler,
 		UserHandler:       userHandler,
 		QuestionHandler:   questionHandler,
+		AccountHandler:    accountHandler,
+		Janitor:           janitor,
 	}
-	return app, nil
-}
-
-func (app *Application) HealthCheck(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"status": "available",
-		"env":    app.Config.Env,
-	})
 }
@@ -0,0 +1,147 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/y3933y3933/joker/internal/store"
+	"github.com/y3933y3933/joker/migrations"
+)
+
+// 整體與各元件的狀態
+const (
+	StatusReady        = "ready"
+	StatusNotReady     = "not_ready"
+	StatusShuttingDown = "shutting_down"
+
+	CheckOK      = "ok"
+	CheckFail    = "fail"
+	CheckSkipped = "skipped" // 沒有設定這個元件，例如測試沒有連線資料庫
+)
+
+// 所有元件檢查共用的時間上限，資料庫卡住時也要在 Caddy 的 health check 逾時前回應
+const readinessTimeout = 2 * time.Second
+
+// Readiness 是 /api/readyz 的回應
+type Readiness struct {
+	Status     string                    `json:"status"` // ready、not_ready 或 shutting_down
+	Env        string                    `json:"env"`
+	Components map[string]ComponentCheck `json:"components,omitempty"`
+}
+
+// ComponentCheck 是單一元件的檢查結果
+type ComponentCheck struct {
+	Status    string           `json:"status"` // ok、fail 或 skipped
+	Error     string           `json:"error,omitempty"`
+	LatencyMS int64            `json:"latencyMs"`
+	Details   map[string]int64 `json:"details,omitempty"`
+}
+
+// HealthCheck 是 liveness，只要程序還能處理請求就回 200，不檢查依賴，避免資料庫短暫斷線就被重啟
+func (app *Application) HealthCheck(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status": "available",
+		"env":    app.Config.Env,
+	})
+}
+
+// ReadinessCheck 檢查資料庫、schema 版本與 WebSocket hub，全部正常才回 200，否則回 503 讓 Caddy 暫停轉發。
+// 開始關機後直接回 503，不再檢查
+func (app *Application) ReadinessCheck(c *gin.Context) {
+	if app.shuttingDown.Load() {
+		c.JSON(http.StatusServiceUnavailable, Readiness{Status: StatusShuttingDown, Env: app.Config.Env})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	report := Readiness{
+		Status: StatusReady,
+		Env:    app.Config.Env,
+		Components: map[string]ComponentCheck{
+			"database":   app.checkDatabase(ctx),
+			"migrations": app.checkMigrations(ctx),
+			"hub":        app.checkHub(),
+		},
+	}
+	status := http.StatusOK
+	for _, check := range report.Components {
+		if check.Status == CheckFail {
+			report.Status = StatusNotReady
+			status = http.StatusServiceUnavailable
+		}
+	}
+	c.JSON(status, report)
+}
+
+// MarkShuttingDown 讓 readiness 改回 503，之後的 health check 會讓 Caddy 停止轉發新請求
+func (app *Application) MarkShuttingDown() {
+	app.shuttingDown.Store(true)
+}
+
+func (app *Application) checkDatabase(ctx context.Context) ComponentCheck {
+	if app.DB == nil {
+		return ComponentCheck{Status: CheckSkipped}
+	}
+	start := time.Now()
+	err := app.DB.ConnPool.Ping(ctx)
+	stat := app.DB.ConnPool.Stat()
+	check := ComponentCheck{
+		Status:    CheckOK,
+		LatencyMS: time.Since(start).Milliseconds(),
+		Details: map[string]int64{
+			"totalConns":    int64(stat.TotalConns()),
+			"idleConns":     int64(stat.IdleConns()),
+			"acquiredConns": int64(stat.AcquiredConns()),
+			"maxConns":      int64(stat.MaxConns()),
+		},
+	}
+	if err != nil {
+		check.Status = CheckFail
+		check.Error = err.Error()
+	}
+	return check
+}
+
+// checkMigrations 比對資料庫的 schema 版本與嵌入的 migration，不一致代表部署時漏跑或回退了 migration
+func (app *Application) checkMigrations(ctx context.Context) ComponentCheck {
+	if app.DB == nil {
+		return ComponentCheck{Status: CheckSkipped}
+	}
+	start := time.Now()
+	expected, err := migrations.LatestVersion()
+	if err != nil {
+		return ComponentCheck{Status: CheckFail, Error: err.Error()}
+	}
+	current, err := store.SchemaVersion(ctx, app.DB.ConnPool)
+	check := ComponentCheck{
+		Status:    CheckOK,
+		LatencyMS: time.Since(start).Milliseconds(),
+		Details:   map[string]int64{"current": current, "expected": expected},
+	}
+	switch {
+	case err != nil:
+		check.Status = CheckFail
+		check.Error = err.Error()
+	case current != expected:
+		check.Status = CheckFail
+		check.Error = "schema version does not match the migrations in this build"
+	}
+	return check
+}
+
+func (app *Application) checkHub() ComponentCheck {
+	start := time.Now()
+	stats := app.Hub.Stats()
+	return ComponentCheck{
+		Status:    CheckOK,
+		LatencyMS: time.Since(start).Milliseconds(),
+		Details: map[string]int64{
+			"rooms":       int64(stats.Rooms),
+			"connections": int64(stats.Connections),
+		},
+	}
+}
@@ -0,0 +1,102 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/y3933y3933/joker/internal/store"
+)
+
+// RunMigrate 執行 migrate 子指令：api migrate -db=... up|down|status
+func RunMigrate(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	dbURL := fs.String("db", "", "database url")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: api migrate -db=<url> up|down|status")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("migrate: expected one of up, down or status")
+	}
+
+	pool, _, err := store.Open(*dbURL)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+	migrator, err := store.NewMigrator(pool)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	ctx := context.Background()
+	switch fs.Arg(0) {
+	case "up":
+		results, err := migrator.Up(ctx)
+		for _, res := range results {
+			fmt.Fprintln(out, res)
+		}
+		if err != nil {
+			return err
+		}
+		if len(results) == 0 {
+			fmt.Fprintln(out, "no migrations to apply")
+		}
+	case "down":
+		res, err := migrator.Down(ctx)
+		if res != nil {
+			fmt.Fprintln(out, res)
+		}
+		return err
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tSTATE\tAPPLIED AT\tFILE")
+		for _, s := range statuses {
+			appliedAt := "-"
+			if !s.AppliedAt.IsZero() {
+				appliedAt = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", s.Source.Version, s.State, appliedAt, s.Source.Path)
+		}
+		return w.Flush()
+	default:
+		fs.Usage()
+		return fmt.Errorf("migrate: unknown command %q", fs.Arg(0))
+	}
+	return nil
+}
+
+// prepareSchema 在啟動時依設定執行 migration，並確認 schema 版本與這個執行檔相符，不相符就拒絕啟動
+func prepareSchema(ctx context.Context, pool *pgxpool.Pool, autoMigrate bool, logger *slog.Logger) error {
+	if autoMigrate {
+		migrator, err := store.NewMigrator(pool)
+		if err != nil {
+			return err
+		}
+		defer migrator.Close()
+		results, err := migrator.Up(ctx)
+		for _, res := range results {
+			logger.Info("migration applied", "version", res.Source.Version, "file", res.Source.Path, "duration", res.Duration)
+		}
+		if err != nil {
+			return fmt.Errorf("auto-migrate: %w", err)
+		}
+	}
+	return store.CheckSchemaVersion(ctx, pool)
+}
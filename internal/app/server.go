@@ -0,0 +1,99 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+type ShutdownConfig struct {
+	Drain   time.Duration // 收到 SIGTERM 後先回報 not ready 的時間，讓 Caddy 的 health check 有機會把流量移走
+	Timeout time.Duration // 等待進行中的請求與 WebSocket 房間結束的上限
+}
+
+// Serve 在 Config.Port 上提供 handler，直到收到 SIGINT 或 SIGTERM。
+// 開始監聽後通知 systemd (Type=notify) 服務已就緒；關機時依序：readiness 回 503、等待 Drain、
+// 停止接受新連線並等待進行中的請求、以 going away 關閉所有 WebSocket 房間、停止 janitor
+func (app *Application) Serve(handler http.Handler) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", app.Config.Port))
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		ErrorLog:          slog.NewLogLogger(app.Logger.Handler(), slog.LevelWarn),
+	}
+
+	// 背景清理閒置的遊戲
+	janitorCtx, stopJanitor := context.WithCancel(context.Background())
+	defer stopJanitor()
+	go app.Janitor.Run(janitorCtx)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.Serve(ln)
+	}()
+	app.Logger.Info("server started", "addr", ln.Addr().String(), "env", app.Config.Env)
+	if err := sdNotify("READY=1"); err != nil {
+		app.Logger.Warn("systemd notify failed", "error", err)
+	}
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+	// 第二次 Ctrl-C 恢復預設行為，直接結束
+	stop()
+
+	app.Logger.Info("shutting down", "drain", app.Config.Shutdown.Drain)
+	app.MarkShuttingDown()
+	if err := sdNotify("STOPPING=1"); err != nil {
+		app.Logger.Warn("systemd notify failed", "error", err)
+	}
+	time.Sleep(app.Config.Shutdown.Drain)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), app.Config.Shutdown.Timeout)
+	defer cancel()
+	// Shutdown 不會等 hijack 後的 WebSocket，房間另外關閉
+	var errs []error
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		errs = append(errs, fmt.Errorf("http shutdown: %w", err))
+	}
+	if err := app.Hub.Shutdown(shutdownCtx, "server restarting"); err != nil {
+		errs = append(errs, fmt.Errorf("websocket shutdown: %w", err))
+	}
+	stopJanitor()
+
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		errs = append(errs, err)
+	}
+	app.Logger.Info("server stopped")
+	return errors.Join(errs...)
+}
+
+// sdNotify 把狀態送到 systemd 的 NOTIFY_SOCKET，不是由 systemd 啟動時什麼都不做
+func sdNotify(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte(state))
+	return err
+}
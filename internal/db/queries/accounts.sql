@@ -0,0 +1,60 @@
+-- name: CreateAccount :one
+INSERT INTO accounts (username, password_hash)
+VALUES ($1, $2)
+RETURNING id, created_at;
+
+-- name: GetAccountByUsername :one
+SELECT id, username, password_hash, created_at
+FROM accounts
+WHERE username = $1;
+
+-- name: GetAccountByID :one
+SELECT id, username, created_at
+FROM accounts
+WHERE id = $1;
+
+-- name: ListAccountGames :many
+SELECT
+  COUNT(*) OVER() AS total_count,
+  g.code,
+  g.status,
+  g.mode,
+  g.ended_reason,
+  g.created_at,
+  p.id AS player_id,
+  p.nickname,
+  (SELECT COUNT(*) FROM rounds r WHERE r.answer_player_id = p.id AND r.answer IS NOT NULL) AS answered,
+  (SELECT COUNT(*) FROM rounds r WHERE r.answer_player_id = p.id AND r.is_joker = TRUE) AS joker_cards_drawn
+FROM players p
+JOIN games g ON g.id = p.game_id
+WHERE p.account_id = $1
+ORDER BY g.created_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: GetAccountStats :one
+SELECT
+  COUNT(DISTINCT p.game_id) AS games_played,
+  COUNT(r.id) FILTER (WHERE r.answer IS NOT NULL) AS answered,
+  COUNT(r.id) FILTER (WHERE r.is_joker = TRUE) AS joker_cards_drawn,
+  COUNT(r.id) FILTER (WHERE r.status = 'revealed') AS revealed,
+  (SELECT COUNT(*) FROM rounds q JOIN players qp ON qp.id = q.question_player_id
+   WHERE qp.account_id = $1 AND q.question_id IS NOT NULL) AS asked
+FROM players p
+LEFT JOIN rounds r ON r.answer_player_id = p.id
+WHERE p.account_id = $1;
+
+-- name: AddFavoriteQuestion :exec
+INSERT INTO account_favorite_questions (account_id, question_id)
+VALUES ($1, $2)
+ON CONFLICT (account_id, question_id) DO NOTHING;
+
+-- name: RemoveFavoriteQuestion :exec
+DELETE FROM account_favorite_questions
+WHERE account_id = $1 AND question_id = $2;
+
+-- name: ListFavoriteQuestions :many
+SELECT q.id, q.level, q.content, q.created_at AS question_created_at, f.created_at
+FROM account_favorite_questions f
+JOIN questions q ON q.id = f.question_id
+WHERE f.account_id = $1
+ORDER BY f.created_at DESC;
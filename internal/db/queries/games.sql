@@ -1,10 +1,10 @@
 -- name: CreateGame :one
-INSERT INTO games (code, status)
-VALUES($1, $2)
-RETURNING id, code, status, created_at;
+INSERT INTO games (code, status, mode, guessing, scoring)
+VALUES($1, $2, $3, $4, $5)
+RETURNING id, code, status, mode, guessing, scoring, created_at;
 
 -- name: GetGameByCode :one
-SELECT id, code , status, created_at, updated_at 
+SELECT id, code , status, locked, mode, guessing, scoring, rematch_code, ended_reason, access, passcode_hash, created_at, updated_at 
 FROM games
 WHERE code = $1;
 
@@ -17,9 +17,16 @@ WHERE id = $1;
 -- name: EndGame :exec
 UPDATE games
 SET status = 'ended',
+    ended_reason = $2,
     updated_at = NOW()
-WHERE code = $1;
+WHERE code = $1 AND status != 'ended';
+
 
+-- name: LockGame :one
+SELECT id
+FROM games
+WHERE id = $1
+FOR UPDATE;
 
 -- name: GetGameStatusByID :one
 SELECT status
@@ -55,9 +62,46 @@ SELECT
   COUNT(p.id) AS player_count,
   g.created_at
 FROM games g
-LEFT JOIN players p ON p.game_id = g.id
+LEFT JOIN players p ON p.game_id = g.id AND p.role = 'player'
 WHERE (UPPER(g.code) = UPPER($1) OR $1 = '')
     AND (g.status = $2 OR $2 = ''  )
 GROUP BY g.id
 ORDER BY g.created_at DESC
-LIMIT $3 OFFSET $4;
\ No newline at end of file
+LIMIT $3 OFFSET $4;
+
+-- name: UpdateGameLocked :exec
+UPDATE games
+SET locked = $2,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: UpdateGameAccess :exec
+UPDATE games
+SET access = $2,
+    passcode_hash = $3,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: SetRematchCode :execrows
+UPDATE games
+SET rematch_code = $2,
+    updated_at = NOW()
+WHERE id = $1 AND rematch_code IS NULL;
+
+-- name: ListUnfinishedGameActivity :many
+-- 所有未結束的遊戲與最後一次有動靜的時間（加入、回合變動、投票）
+SELECT
+  g.id,
+  g.code,
+  g.status,
+  g.created_at,
+  (SELECT COUNT(*) FROM players p WHERE p.game_id = g.id AND p.role = 'player') AS player_count,
+  GREATEST(
+    g.updated_at,
+    (SELECT MAX(p.joined_at) FROM players p WHERE p.game_id = g.id),
+    (SELECT MAX(r.updated_at) FROM rounds r WHERE r.game_id = g.id),
+    (SELECT MAX(v.created_at) FROM votes v JOIN rounds r ON r.id = v.round_id WHERE r.game_id = g.id)
+  )::timestamptz AS last_activity_at
+FROM games g
+WHERE g.status != 'ended'
+ORDER BY g.id;
@@ -1,23 +1,26 @@
 -- name: CreatePlayer :one 
-INSERT INTO players(game_id, nickname, is_host, status)
-VALUES($1, $2, $3, 'online')
-RETURNING id, game_id,nickname, is_host, status;
+INSERT INTO players(game_id, nickname, is_host, status, role, seat, nickname_key, avatar_preset, avatar_color, avatar_emoji, account_id)
+VALUES($1, $2, $3, 'online', $4,
+  (SELECT COALESCE(MAX(seat), 0) + 1 FROM players WHERE game_id = $1),
+  $5, $6, $7, $8, $9)
+RETURNING id, game_id,nickname, is_host, status, role, seat, nickname_key, avatar_preset, avatar_color, avatar_emoji, account_id;
 
 -- name: CountPlayersInGame :one
 SELECT COUNT(*)
 FROM players
-WHERE game_id = $1;
+WHERE game_id = $1 AND role = 'player';
 
 -- name: FindPlayersByGameID :many
-SELECT id, nickname, is_host, game_id, status
+SELECT id, nickname, is_host, game_id, status, role, seat, nickname_key, avatar_preset, avatar_color, avatar_emoji, account_id
 FROM players
 WHERE game_id = $1
-ORDER BY id;
+ORDER BY seat, id;
 
 -- name: FindOnlinePlayersByGameID :many
-SELECT id, nickname, game_id, is_host, status
+SELECT id, nickname, game_id, is_host, status, role, seat, nickname_key, avatar_preset, avatar_color, avatar_emoji, account_id
 FROM players
-WHERE game_id = $1 AND status = 'online';
+WHERE game_id = $1 AND status = 'online' AND role = 'player'
+ORDER BY seat, id;
 
 
 -- name: DeletePlayerByID :exec
@@ -26,7 +29,7 @@ DELETE FROM players WHERE id = $1;
 
 
 -- name: FindPlayerByID :one
-SELECT id, nickname, is_host, game_id, status
+SELECT id, nickname, is_host, game_id, status, role, seat, nickname_key, avatar_preset, avatar_color, avatar_emoji, account_id
 FROM players
 WHERE id = $1;
 
@@ -36,24 +39,27 @@ SET is_host = $2
 WHERE id = $1;
 
 -- name: FindPlayerByNickname :one
-SELECT id, nickname, is_host, game_id, status
+SELECT id, nickname, is_host, game_id, status, role, seat, nickname_key, avatar_preset, avatar_color, avatar_emoji, account_id
 FROM players
-WHERE game_id = $1 AND nickname = $2;
+WHERE game_id = $1 AND nickname_key = $2;
 
 -- name: GetGamePlayerStats :many
 SELECT
   p.id,
   p.nickname,
+  p.avatar_preset,
+  p.avatar_color,
+  p.avatar_emoji,
   COUNT(CASE WHEN r.is_joker = TRUE THEN 1 END) AS joker_cards_drawn
 FROM players p
 LEFT JOIN rounds r ON r.answer_player_id = p.id AND r.game_id = $1
-WHERE p.game_id = $1
-GROUP BY p.id, p.nickname
+WHERE p.game_id = $1 AND p.role = 'player'
+GROUP BY p.id, p.nickname, p.avatar_preset, p.avatar_color, p.avatar_emoji
 ORDER BY p.id;
 
 -- name: GetPlayerCountByGameCode :one
 SELECT COUNT(*) FROM players
-WHERE game_id = (SELECT id FROM games WHERE code = $1);
+WHERE game_id = (SELECT id FROM games WHERE code = $1) AND role = 'player';
 
 
 -- name: UpdatePlayerStatus :exec
@@ -64,4 +70,46 @@ WHERE id = $1;
 -- name: GetLivePlayerCount :one
 SELECT COUNT(*) AS live_player_count
 FROM players
-WHERE status = 'online';
\ No newline at end of file
+WHERE status = 'online' AND role = 'player';
+
+-- name: UpdatePlayerRole :exec
+UPDATE players
+SET role = $2
+WHERE id = $1;
+
+-- name: UpdatePlayerSeat :exec
+UPDATE players
+SET seat = $2
+WHERE id = $1;
+
+-- name: CreateGameBan :exec
+INSERT INTO game_bans (game_id, nickname)
+VALUES ($1, $2)
+ON CONFLICT (game_id, nickname) DO NOTHING;
+
+-- name: CopyGameBans :exec
+INSERT INTO game_bans (game_id, nickname)
+SELECT sqlc.arg(to_game_id)::BIGINT, b.nickname FROM game_bans b
+WHERE b.game_id = sqlc.arg(from_game_id)
+ON CONFLICT (game_id, nickname) DO NOTHING;
+
+-- name: IsNicknameBanned :one
+SELECT EXISTS (
+  SELECT 1 FROM game_bans
+  WHERE game_id = $1 AND nickname = $2
+);
+
+-- name: UpdatePlayerProfile :exec
+UPDATE players
+SET nickname = $2,
+    nickname_key = $3,
+    avatar_preset = $4,
+    avatar_color = $5,
+    avatar_emoji = $6
+WHERE id = $1;
+
+-- name: ClaimPlayer :execrows
+-- 尚未歸屬或已經屬於同一個帳號時才更新
+UPDATE players
+SET account_id = $2
+WHERE id = $1 AND (account_id IS NULL OR account_id = $2);
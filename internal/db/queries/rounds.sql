@@ -13,15 +13,16 @@ RETURNING id, game_id, question_id, answer, question_player_id,
 -- name: SetRoundQuestion :exec
 UPDATE rounds
 SET question_id = $1,
-    status = 'waiting_for_answer'
+    status = 'waiting_for_answer',
+    updated_at = NOW()
 WHERE id = $2;
 
 -- name: GetRoundByID :one
-SELECT id, game_id, question_id, answer, question_player_id, answer_player_id, is_joker, status, deck  
+SELECT id, game_id, question_id, answer, question_player_id, answer_player_id, is_joker, status, deck, about_player_id, truthful, skipped
 FROM rounds WHERE id = $1;
 
 -- name: GetRoundWithQuestion :one
-SELECT r.id, r.game_id, r.question_id, r.answer, r.question_player_id, r.answer_player_id, r.status, r.deck,r.is_joker,q.level, q.content AS question_content
+SELECT r.id, r.game_id, r.question_id, r.answer, r.question_player_id, r.answer_player_id, r.status, r.deck,r.is_joker, r.about_player_id, r.truthful, r.skipped, q.level, q.content AS question_content
 FROM rounds r
 JOIN questions q ON q.id = r.question_id
 WHERE r.id = $1;
@@ -29,17 +30,21 @@ WHERE r.id = $1;
 -- name: UpdateAnswer :exec
 UPDATE rounds
 SET answer = $2,
-    status = $3
+    status = $3,
+    about_player_id = $4,
+    truthful = $5,
+    updated_at = NOW()
 WHERE id = $1;
 
 -- name: UpdateDrawResult :exec
 UPDATE rounds
 SET is_joker = $2,
-    status = $3
+    status = $3,
+    updated_at = NOW()
 WHERE id = $1;
 
 -- name: FindLastRoundByGameID :one
-SELECT id, game_id, question_id, answer, question_player_id, answer_player_id, is_joker,status,deck
+SELECT id, game_id, question_id, answer, question_player_id, answer_player_id, is_joker,status,deck, about_player_id, truthful, skipped
 FROM rounds
 WHERE game_id = $1
 ORDER BY created_at DESC
@@ -52,8 +57,33 @@ SELECT
 FROM rounds
 WHERE game_id = $1;
 
+-- name: SkipRound :exec
+-- 跳過的回合結束在 done，另外標記 skipped，計分時略過
+UPDATE rounds
+SET status = 'done',
+    skipped = TRUE,
+    updated_at = NOW()
+WHERE id = $1;
+
 -- name: UpdateRoundStatus :exec
 UPDATE rounds
-SET status = $2
+SET status = $2,
+    updated_at = NOW()
 WHERE id = $1;
 
+
+-- name: ListRoundsByGameID :many
+SELECT r.id, r.game_id, r.question_id, r.answer, r.question_player_id, r.answer_player_id, r.is_joker, r.status, r.deck, r.about_player_id, r.truthful, r.skipped,
+  COALESCE(q.level, '')::text AS level, COALESCE(q.content, '')::text AS question_content
+FROM rounds r
+LEFT JOIN questions q ON q.id = r.question_id
+WHERE r.game_id = $1
+ORDER BY r.created_at, r.id;
+
+-- name: ResolveVotes :execrows
+-- 只有仍在等待投票時才結算，同時送出的最後一票只有一個會更新成功
+UPDATE rounds
+SET status = sqlc.arg(status),
+    is_joker = COALESCE(sqlc.narg(is_joker)::boolean, is_joker),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id) AND status = 'waiting_for_votes';
@@ -0,0 +1,17 @@
+-- name: CreateVote :one
+INSERT INTO votes (round_id, player_id, kind, choice)
+VALUES ($1, $2, $3, $4)
+RETURNING id, round_id, player_id, kind, choice, created_at;
+
+-- name: ListVotesByRoundID :many
+SELECT id, round_id, player_id, kind, choice, created_at
+FROM votes
+WHERE round_id = $1
+ORDER BY id;
+
+-- name: ListVotesByGameID :many
+SELECT v.id, v.round_id, v.player_id, v.kind, v.choice, v.created_at
+FROM votes v
+JOIN rounds r ON r.id = v.round_id
+WHERE r.game_id = $1
+ORDER BY v.id;
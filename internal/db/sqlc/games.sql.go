@@ -1,6 +1,6 @@
 // Code generated by sqlc. DO NOT EDIT.
 // versions:
-//   sqlc v1.28.0
+//   sqlc v1.29.0
 // source: games.sql
 
 package sqlc
@@ -12,30 +12,45 @@ import (
 )
 
 const createGame = `-- name: CreateGame :one
-INSERT INTO games (code, status)
-VALUES($1, $2)
-RETURNING id, code, status, created_at
+INSERT INTO games (code, status, mode, guessing, scoring)
+VALUES($1, $2, $3, $4, $5)
+RETURNING id, code, status, mode, guessing, scoring, created_at
 `
 
 type CreateGameParams struct {
-	Code   string
-	Status string
+	Code     string
+	Status   string
+	Mode     string
+	Guessing string
+	Scoring  []byte
 }
 
 type CreateGameRow struct {
 	ID        int64
 	Code      string
 	Status    string
+	Mode      string
+	Guessing  string
+	Scoring   []byte
 	CreatedAt pgtype.Timestamptz
 }
 
 func (q *Queries) CreateGame(ctx context.Context, arg CreateGameParams) (CreateGameRow, error) {
-	row := q.db.QueryRow(ctx, createGame, arg.Code, arg.Status)
+	row := q.db.QueryRow(ctx, createGame,
+		arg.Code,
+		arg.Status,
+		arg.Mode,
+		arg.Guessing,
+		arg.Scoring,
+	)
 	var i CreateGameRow
 	err := row.Scan(
 		&i.ID,
 		&i.Code,
 		&i.Status,
+		&i.Mode,
+		&i.Guessing,
+		&i.Scoring,
 		&i.CreatedAt,
 	)
 	return i, err
@@ -53,12 +68,18 @@ func (q *Queries) DeleteByCode(ctx context.Context, code string) error {
 const endGame = `-- name: EndGame :exec
 UPDATE games
 SET status = 'ended',
+    ended_reason = $2,
     updated_at = NOW()
-WHERE code = $1
+WHERE code = $1 AND status != 'ended'
 `
 
-func (q *Queries) EndGame(ctx context.Context, code string) error {
-	_, err := q.db.Exec(ctx, endGame, code)
+type EndGameParams struct {
+	Code        string
+	EndedReason pgtype.Text
+}
+
+func (q *Queries) EndGame(ctx context.Context, arg EndGameParams) error {
+	_, err := q.db.Exec(ctx, endGame, arg.Code, arg.EndedReason)
 	return err
 }
 
@@ -76,18 +97,42 @@ func (q *Queries) GetActiveRoomsCount(ctx context.Context) (int64, error) {
 }
 
 const getGameByCode = `-- name: GetGameByCode :one
-SELECT id, code , status, created_at, updated_at 
+SELECT id, code , status, locked, mode, guessing, scoring, rematch_code, ended_reason, access, passcode_hash, created_at, updated_at 
 FROM games
 WHERE code = $1
 `
 
-func (q *Queries) GetGameByCode(ctx context.Context, code string) (Game, error) {
+type GetGameByCodeRow struct {
+	ID           int64
+	Code         string
+	Status       string
+	Locked       bool
+	Mode         string
+	Guessing     string
+	Scoring      []byte
+	RematchCode  pgtype.Text
+	EndedReason  pgtype.Text
+	Access       string
+	PasscodeHash []byte
+	CreatedAt    pgtype.Timestamptz
+	UpdatedAt    pgtype.Timestamptz
+}
+
+func (q *Queries) GetGameByCode(ctx context.Context, code string) (GetGameByCodeRow, error) {
 	row := q.db.QueryRow(ctx, getGameByCode, code)
-	var i Game
+	var i GetGameByCodeRow
 	err := row.Scan(
 		&i.ID,
 		&i.Code,
 		&i.Status,
+		&i.Locked,
+		&i.Mode,
+		&i.Guessing,
+		&i.Scoring,
+		&i.RematchCode,
+		&i.EndedReason,
+		&i.Access,
+		&i.PasscodeHash,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
@@ -129,7 +174,7 @@ SELECT
   COUNT(p.id) AS player_count,
   g.created_at
 FROM games g
-LEFT JOIN players p ON p.game_id = g.id
+LEFT JOIN players p ON p.game_id = g.id AND p.role = 'player'
 WHERE (UPPER(g.code) = UPPER($1) OR $1 = '')
     AND (g.status = $2 OR $2 = ''  )
 GROUP BY g.id
@@ -185,6 +230,130 @@ func (q *Queries) ListGames(ctx context.Context, arg ListGamesParams) ([]ListGam
 	return items, nil
 }
 
+const listUnfinishedGameActivity = `-- name: ListUnfinishedGameActivity :many
+SELECT
+  g.id,
+  g.code,
+  g.status,
+  g.created_at,
+  (SELECT COUNT(*) FROM players p WHERE p.game_id = g.id AND p.role = 'player') AS player_count,
+  GREATEST(
+    g.updated_at,
+    (SELECT MAX(p.joined_at) FROM players p WHERE p.game_id = g.id),
+    (SELECT MAX(r.updated_at) FROM rounds r WHERE r.game_id = g.id),
+    (SELECT MAX(v.created_at) FROM votes v JOIN rounds r ON r.id = v.round_id WHERE r.game_id = g.id)
+  )::timestamptz AS last_activity_at
+FROM games g
+WHERE g.status != 'ended'
+ORDER BY g.id
+`
+
+type ListUnfinishedGameActivityRow struct {
+	ID             int64
+	Code           string
+	Status         string
+	CreatedAt      pgtype.Timestamptz
+	PlayerCount    int64
+	LastActivityAt pgtype.Timestamptz
+}
+
+// 所有未結束的遊戲與最後一次有動靜的時間（加入、回合變動、投票）
+func (q *Queries) ListUnfinishedGameActivity(ctx context.Context) ([]ListUnfinishedGameActivityRow, error) {
+	rows, err := q.db.Query(ctx, listUnfinishedGameActivity)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUnfinishedGameActivityRow
+	for rows.Next() {
+		var i ListUnfinishedGameActivityRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Code,
+			&i.Status,
+			&i.CreatedAt,
+			&i.PlayerCount,
+			&i.LastActivityAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockGame = `-- name: LockGame :one
+SELECT id
+FROM games
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) LockGame(ctx context.Context, id int64) (int64, error) {
+	row := q.db.QueryRow(ctx, lockGame, id)
+	err := row.Scan(&id)
+	return id, err
+}
+
+const setRematchCode = `-- name: SetRematchCode :execrows
+UPDATE games
+SET rematch_code = $2,
+    updated_at = NOW()
+WHERE id = $1 AND rematch_code IS NULL
+`
+
+type SetRematchCodeParams struct {
+	ID          int64
+	RematchCode pgtype.Text
+}
+
+func (q *Queries) SetRematchCode(ctx context.Context, arg SetRematchCodeParams) (int64, error) {
+	result, err := q.db.Exec(ctx, setRematchCode, arg.ID, arg.RematchCode)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateGameAccess = `-- name: UpdateGameAccess :exec
+UPDATE games
+SET access = $2,
+    passcode_hash = $3,
+    updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdateGameAccessParams struct {
+	ID           int64
+	Access       string
+	PasscodeHash []byte
+}
+
+func (q *Queries) UpdateGameAccess(ctx context.Context, arg UpdateGameAccessParams) error {
+	_, err := q.db.Exec(ctx, updateGameAccess, arg.ID, arg.Access, arg.PasscodeHash)
+	return err
+}
+
+const updateGameLocked = `-- name: UpdateGameLocked :exec
+UPDATE games
+SET locked = $2,
+    updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdateGameLockedParams struct {
+	ID     int64
+	Locked bool
+}
+
+func (q *Queries) UpdateGameLocked(ctx context.Context, arg UpdateGameLockedParams) error {
+	_, err := q.db.Exec(ctx, updateGameLocked, arg.ID, arg.Locked)
+	return err
+}
+
 const updateGameStatus = `-- name: UpdateGameStatus :exec
 UPDATE games
 SET status = $2,
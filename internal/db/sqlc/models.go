@@ -1,6 +1,6 @@
 // Code generated by sqlc. DO NOT EDIT.
 // versions:
-//   sqlc v1.28.0
+//   sqlc v1.29.0
 
 package sqlc
 
@@ -8,6 +8,19 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type Account struct {
+	ID           int64
+	Username     string
+	PasswordHash []byte
+	CreatedAt    pgtype.Timestamptz
+}
+
+type AccountFavoriteQuestion struct {
+	AccountID  int64
+	QuestionID int64
+	CreatedAt  pgtype.Timestamptz
+}
+
 type Feedback struct {
 	ID           int64
 	Type         string
@@ -17,20 +30,42 @@ type Feedback struct {
 }
 
 type Game struct {
+	ID           int64
+	Code         string
+	Status       string
+	CreatedAt    pgtype.Timestamptz
+	UpdatedAt    pgtype.Timestamptz
+	Locked       bool
+	Mode         string
+	Guessing     string
+	Scoring      []byte
+	RematchCode  pgtype.Text
+	EndedReason  pgtype.Text
+	Access       string
+	PasscodeHash []byte
+}
+
+type GameBan struct {
 	ID        int64
-	Code      string
-	Status    string
+	GameID    int64
+	Nickname  string
 	CreatedAt pgtype.Timestamptz
-	UpdatedAt pgtype.Timestamptz
 }
 
 type Player struct {
-	ID       int64
-	GameID   int64
-	Nickname string
-	IsHost   pgtype.Bool
-	JoinedAt pgtype.Timestamptz
-	Status   string
+	ID           int64
+	GameID       int64
+	Nickname     string
+	IsHost       pgtype.Bool
+	JoinedAt     pgtype.Timestamptz
+	Status       string
+	Role         string
+	Seat         int32
+	NicknameKey  string
+	AvatarPreset string
+	AvatarColor  string
+	AvatarEmoji  string
+	AccountID    pgtype.Int8
 }
 
 type Question struct {
@@ -52,6 +87,10 @@ type Round struct {
 	Status           string
 	CreatedAt        pgtype.Timestamptz
 	Deck             []string
+	AboutPlayerID    pgtype.Int8
+	Truthful         pgtype.Bool
+	UpdatedAt        pgtype.Timestamptz
+	Skipped          bool
 }
 
 type User struct {
@@ -60,3 +99,12 @@ type User struct {
 	PasswordHash []byte
 	CreatedAt    pgtype.Timestamptz
 }
+
+type Vote struct {
+	ID        int64
+	RoundID   int64
+	PlayerID  int64
+	Kind      string
+	Choice    string
+	CreatedAt pgtype.Timestamptz
+}
@@ -1,6 +1,6 @@
 // Code generated by sqlc. DO NOT EDIT.
 // versions:
-//   sqlc v1.28.0
+//   sqlc v1.29.0
 // source: players.sql
 
 package sqlc
@@ -11,10 +11,47 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const claimPlayer = `-- name: ClaimPlayer :execrows
+UPDATE players
+SET account_id = $2
+WHERE id = $1 AND (account_id IS NULL OR account_id = $2)
+`
+
+type ClaimPlayerParams struct {
+	ID        int64
+	AccountID pgtype.Int8
+}
+
+// 尚未歸屬或已經屬於同一個帳號時才更新
+func (q *Queries) ClaimPlayer(ctx context.Context, arg ClaimPlayerParams) (int64, error) {
+	result, err := q.db.Exec(ctx, claimPlayer, arg.ID, arg.AccountID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const copyGameBans = `-- name: CopyGameBans :exec
+INSERT INTO game_bans (game_id, nickname)
+SELECT $1::BIGINT, b.nickname FROM game_bans b
+WHERE b.game_id = $2
+ON CONFLICT (game_id, nickname) DO NOTHING
+`
+
+type CopyGameBansParams struct {
+	ToGameID   int64
+	FromGameID int64
+}
+
+func (q *Queries) CopyGameBans(ctx context.Context, arg CopyGameBansParams) error {
+	_, err := q.db.Exec(ctx, copyGameBans, arg.ToGameID, arg.FromGameID)
+	return err
+}
+
 const countPlayersInGame = `-- name: CountPlayersInGame :one
 SELECT COUNT(*)
 FROM players
-WHERE game_id = $1
+WHERE game_id = $1 AND role = 'player'
 `
 
 func (q *Queries) CountPlayersInGame(ctx context.Context, gameID int64) (int64, error) {
@@ -24,28 +61,69 @@ func (q *Queries) CountPlayersInGame(ctx context.Context, gameID int64) (int64,
 	return count, err
 }
 
-const createPlayer = `-- name: CreatePlayer :one
-INSERT INTO players(game_id, nickname, is_host, status)
-VALUES($1, $2, $3, 'online')
-RETURNING id, game_id,nickname, is_host, status
+const createGameBan = `-- name: CreateGameBan :exec
+INSERT INTO game_bans (game_id, nickname)
+VALUES ($1, $2)
+ON CONFLICT (game_id, nickname) DO NOTHING
 `
 
-type CreatePlayerParams struct {
+type CreateGameBanParams struct {
 	GameID   int64
 	Nickname string
-	IsHost   pgtype.Bool
+}
+
+func (q *Queries) CreateGameBan(ctx context.Context, arg CreateGameBanParams) error {
+	_, err := q.db.Exec(ctx, createGameBan, arg.GameID, arg.Nickname)
+	return err
+}
+
+const createPlayer = `-- name: CreatePlayer :one
+INSERT INTO players(game_id, nickname, is_host, status, role, seat, nickname_key, avatar_preset, avatar_color, avatar_emoji, account_id)
+VALUES($1, $2, $3, 'online', $4,
+  (SELECT COALESCE(MAX(seat), 0) + 1 FROM players WHERE game_id = $1),
+  $5, $6, $7, $8, $9)
+RETURNING id, game_id,nickname, is_host, status, role, seat, nickname_key, avatar_preset, avatar_color, avatar_emoji, account_id
+`
+
+type CreatePlayerParams struct {
+	GameID       int64
+	Nickname     string
+	IsHost       pgtype.Bool
+	Role         string
+	NicknameKey  string
+	AvatarPreset string
+	AvatarColor  string
+	AvatarEmoji  string
+	AccountID    pgtype.Int8
 }
 
 type CreatePlayerRow struct {
-	ID       int64
-	GameID   int64
-	Nickname string
-	IsHost   pgtype.Bool
-	Status   string
+	ID           int64
+	GameID       int64
+	Nickname     string
+	IsHost       pgtype.Bool
+	Status       string
+	Role         string
+	Seat         int32
+	NicknameKey  string
+	AvatarPreset string
+	AvatarColor  string
+	AvatarEmoji  string
+	AccountID    pgtype.Int8
 }
 
 func (q *Queries) CreatePlayer(ctx context.Context, arg CreatePlayerParams) (CreatePlayerRow, error) {
-	row := q.db.QueryRow(ctx, createPlayer, arg.GameID, arg.Nickname, arg.IsHost)
+	row := q.db.QueryRow(ctx, createPlayer,
+		arg.GameID,
+		arg.Nickname,
+		arg.IsHost,
+		arg.Role,
+		arg.NicknameKey,
+		arg.AvatarPreset,
+		arg.AvatarColor,
+		arg.AvatarEmoji,
+		arg.AccountID,
+	)
 	var i CreatePlayerRow
 	err := row.Scan(
 		&i.ID,
@@ -53,6 +131,13 @@ func (q *Queries) CreatePlayer(ctx context.Context, arg CreatePlayerParams) (Cre
 		&i.Nickname,
 		&i.IsHost,
 		&i.Status,
+		&i.Role,
+		&i.Seat,
+		&i.NicknameKey,
+		&i.AvatarPreset,
+		&i.AvatarColor,
+		&i.AvatarEmoji,
+		&i.AccountID,
 	)
 	return i, err
 }
@@ -67,17 +152,25 @@ func (q *Queries) DeletePlayerByID(ctx context.Context, id int64) error {
 }
 
 const findOnlinePlayersByGameID = `-- name: FindOnlinePlayersByGameID :many
-SELECT id, nickname, game_id, is_host, status
+SELECT id, nickname, game_id, is_host, status, role, seat, nickname_key, avatar_preset, avatar_color, avatar_emoji, account_id
 FROM players
-WHERE game_id = $1 AND status = 'online'
+WHERE game_id = $1 AND status = 'online' AND role = 'player'
+ORDER BY seat, id
 `
 
 type FindOnlinePlayersByGameIDRow struct {
-	ID       int64
-	Nickname string
-	GameID   int64
-	IsHost   pgtype.Bool
-	Status   string
+	ID           int64
+	Nickname     string
+	GameID       int64
+	IsHost       pgtype.Bool
+	Status       string
+	Role         string
+	Seat         int32
+	NicknameKey  string
+	AvatarPreset string
+	AvatarColor  string
+	AvatarEmoji  string
+	AccountID    pgtype.Int8
 }
 
 func (q *Queries) FindOnlinePlayersByGameID(ctx context.Context, gameID int64) ([]FindOnlinePlayersByGameIDRow, error) {
@@ -95,6 +188,13 @@ func (q *Queries) FindOnlinePlayersByGameID(ctx context.Context, gameID int64) (
 			&i.GameID,
 			&i.IsHost,
 			&i.Status,
+			&i.Role,
+			&i.Seat,
+			&i.NicknameKey,
+			&i.AvatarPreset,
+			&i.AvatarColor,
+			&i.AvatarEmoji,
+			&i.AccountID,
 		); err != nil {
 			return nil, err
 		}
@@ -107,17 +207,24 @@ func (q *Queries) FindOnlinePlayersByGameID(ctx context.Context, gameID int64) (
 }
 
 const findPlayerByID = `-- name: FindPlayerByID :one
-SELECT id, nickname, is_host, game_id, status
+SELECT id, nickname, is_host, game_id, status, role, seat, nickname_key, avatar_preset, avatar_color, avatar_emoji, account_id
 FROM players
 WHERE id = $1
 `
 
 type FindPlayerByIDRow struct {
-	ID       int64
-	Nickname string
-	IsHost   pgtype.Bool
-	GameID   int64
-	Status   string
+	ID           int64
+	Nickname     string
+	IsHost       pgtype.Bool
+	GameID       int64
+	Status       string
+	Role         string
+	Seat         int32
+	NicknameKey  string
+	AvatarPreset string
+	AvatarColor  string
+	AvatarEmoji  string
+	AccountID    pgtype.Int8
 }
 
 func (q *Queries) FindPlayerByID(ctx context.Context, id int64) (FindPlayerByIDRow, error) {
@@ -129,31 +236,45 @@ func (q *Queries) FindPlayerByID(ctx context.Context, id int64) (FindPlayerByIDR
 		&i.IsHost,
 		&i.GameID,
 		&i.Status,
+		&i.Role,
+		&i.Seat,
+		&i.NicknameKey,
+		&i.AvatarPreset,
+		&i.AvatarColor,
+		&i.AvatarEmoji,
+		&i.AccountID,
 	)
 	return i, err
 }
 
 const findPlayerByNickname = `-- name: FindPlayerByNickname :one
-SELECT id, nickname, is_host, game_id, status
+SELECT id, nickname, is_host, game_id, status, role, seat, nickname_key, avatar_preset, avatar_color, avatar_emoji, account_id
 FROM players
-WHERE game_id = $1 AND nickname = $2
+WHERE game_id = $1 AND nickname_key = $2
 `
 
 type FindPlayerByNicknameParams struct {
-	GameID   int64
-	Nickname string
+	GameID      int64
+	NicknameKey string
 }
 
 type FindPlayerByNicknameRow struct {
-	ID       int64
-	Nickname string
-	IsHost   pgtype.Bool
-	GameID   int64
-	Status   string
+	ID           int64
+	Nickname     string
+	IsHost       pgtype.Bool
+	GameID       int64
+	Status       string
+	Role         string
+	Seat         int32
+	NicknameKey  string
+	AvatarPreset string
+	AvatarColor  string
+	AvatarEmoji  string
+	AccountID    pgtype.Int8
 }
 
 func (q *Queries) FindPlayerByNickname(ctx context.Context, arg FindPlayerByNicknameParams) (FindPlayerByNicknameRow, error) {
-	row := q.db.QueryRow(ctx, findPlayerByNickname, arg.GameID, arg.Nickname)
+	row := q.db.QueryRow(ctx, findPlayerByNickname, arg.GameID, arg.NicknameKey)
 	var i FindPlayerByNicknameRow
 	err := row.Scan(
 		&i.ID,
@@ -161,23 +282,37 @@ func (q *Queries) FindPlayerByNickname(ctx context.Context, arg FindPlayerByNick
 		&i.IsHost,
 		&i.GameID,
 		&i.Status,
+		&i.Role,
+		&i.Seat,
+		&i.NicknameKey,
+		&i.AvatarPreset,
+		&i.AvatarColor,
+		&i.AvatarEmoji,
+		&i.AccountID,
 	)
 	return i, err
 }
 
 const findPlayersByGameID = `-- name: FindPlayersByGameID :many
-SELECT id, nickname, is_host, game_id, status
+SELECT id, nickname, is_host, game_id, status, role, seat, nickname_key, avatar_preset, avatar_color, avatar_emoji, account_id
 FROM players
 WHERE game_id = $1
-ORDER BY id
+ORDER BY seat, id
 `
 
 type FindPlayersByGameIDRow struct {
-	ID       int64
-	Nickname string
-	IsHost   pgtype.Bool
-	GameID   int64
-	Status   string
+	ID           int64
+	Nickname     string
+	IsHost       pgtype.Bool
+	GameID       int64
+	Status       string
+	Role         string
+	Seat         int32
+	NicknameKey  string
+	AvatarPreset string
+	AvatarColor  string
+	AvatarEmoji  string
+	AccountID    pgtype.Int8
 }
 
 func (q *Queries) FindPlayersByGameID(ctx context.Context, gameID int64) ([]FindPlayersByGameIDRow, error) {
@@ -195,6 +330,13 @@ func (q *Queries) FindPlayersByGameID(ctx context.Context, gameID int64) ([]Find
 			&i.IsHost,
 			&i.GameID,
 			&i.Status,
+			&i.Role,
+			&i.Seat,
+			&i.NicknameKey,
+			&i.AvatarPreset,
+			&i.AvatarColor,
+			&i.AvatarEmoji,
+			&i.AccountID,
 		); err != nil {
 			return nil, err
 		}
@@ -210,17 +352,23 @@ const getGamePlayerStats = `-- name: GetGamePlayerStats :many
 SELECT
   p.id,
   p.nickname,
+  p.avatar_preset,
+  p.avatar_color,
+  p.avatar_emoji,
   COUNT(CASE WHEN r.is_joker = TRUE THEN 1 END) AS joker_cards_drawn
 FROM players p
 LEFT JOIN rounds r ON r.answer_player_id = p.id AND r.game_id = $1
-WHERE p.game_id = $1
-GROUP BY p.id, p.nickname
+WHERE p.game_id = $1 AND p.role = 'player'
+GROUP BY p.id, p.nickname, p.avatar_preset, p.avatar_color, p.avatar_emoji
 ORDER BY p.id
 `
 
 type GetGamePlayerStatsRow struct {
 	ID              int64
 	Nickname        string
+	AvatarPreset    string
+	AvatarColor     string
+	AvatarEmoji     string
 	JokerCardsDrawn int64
 }
 
@@ -233,7 +381,14 @@ func (q *Queries) GetGamePlayerStats(ctx context.Context, gameID int64) ([]GetGa
 	var items []GetGamePlayerStatsRow
 	for rows.Next() {
 		var i GetGamePlayerStatsRow
-		if err := rows.Scan(&i.ID, &i.Nickname, &i.JokerCardsDrawn); err != nil {
+		if err := rows.Scan(
+			&i.ID,
+			&i.Nickname,
+			&i.AvatarPreset,
+			&i.AvatarColor,
+			&i.AvatarEmoji,
+			&i.JokerCardsDrawn,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -247,7 +402,7 @@ func (q *Queries) GetGamePlayerStats(ctx context.Context, gameID int64) ([]GetGa
 const getLivePlayerCount = `-- name: GetLivePlayerCount :one
 SELECT COUNT(*) AS live_player_count
 FROM players
-WHERE status = 'online'
+WHERE status = 'online' AND role = 'player'
 `
 
 func (q *Queries) GetLivePlayerCount(ctx context.Context) (int64, error) {
@@ -259,7 +414,7 @@ func (q *Queries) GetLivePlayerCount(ctx context.Context) (int64, error) {
 
 const getPlayerCountByGameCode = `-- name: GetPlayerCountByGameCode :one
 SELECT COUNT(*) FROM players
-WHERE game_id = (SELECT id FROM games WHERE code = $1)
+WHERE game_id = (SELECT id FROM games WHERE code = $1) AND role = 'player'
 `
 
 func (q *Queries) GetPlayerCountByGameCode(ctx context.Context, code string) (int64, error) {
@@ -269,6 +424,25 @@ func (q *Queries) GetPlayerCountByGameCode(ctx context.Context, code string) (in
 	return count, err
 }
 
+const isNicknameBanned = `-- name: IsNicknameBanned :one
+SELECT EXISTS (
+  SELECT 1 FROM game_bans
+  WHERE game_id = $1 AND nickname = $2
+)
+`
+
+type IsNicknameBannedParams struct {
+	GameID   int64
+	Nickname string
+}
+
+func (q *Queries) IsNicknameBanned(ctx context.Context, arg IsNicknameBannedParams) (bool, error) {
+	row := q.db.QueryRow(ctx, isNicknameBanned, arg.GameID, arg.Nickname)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
 const updateHost = `-- name: UpdateHost :exec
 UPDATE players
 SET is_host = $2
@@ -285,6 +459,69 @@ func (q *Queries) UpdateHost(ctx context.Context, arg UpdateHostParams) error {
 	return err
 }
 
+const updatePlayerProfile = `-- name: UpdatePlayerProfile :exec
+UPDATE players
+SET nickname = $2,
+    nickname_key = $3,
+    avatar_preset = $4,
+    avatar_color = $5,
+    avatar_emoji = $6
+WHERE id = $1
+`
+
+type UpdatePlayerProfileParams struct {
+	ID           int64
+	Nickname     string
+	NicknameKey  string
+	AvatarPreset string
+	AvatarColor  string
+	AvatarEmoji  string
+}
+
+func (q *Queries) UpdatePlayerProfile(ctx context.Context, arg UpdatePlayerProfileParams) error {
+	_, err := q.db.Exec(ctx, updatePlayerProfile,
+		arg.ID,
+		arg.Nickname,
+		arg.NicknameKey,
+		arg.AvatarPreset,
+		arg.AvatarColor,
+		arg.AvatarEmoji,
+	)
+	return err
+}
+
+const updatePlayerRole = `-- name: UpdatePlayerRole :exec
+UPDATE players
+SET role = $2
+WHERE id = $1
+`
+
+type UpdatePlayerRoleParams struct {
+	ID   int64
+	Role string
+}
+
+func (q *Queries) UpdatePlayerRole(ctx context.Context, arg UpdatePlayerRoleParams) error {
+	_, err := q.db.Exec(ctx, updatePlayerRole, arg.ID, arg.Role)
+	return err
+}
+
+const updatePlayerSeat = `-- name: UpdatePlayerSeat :exec
+UPDATE players
+SET seat = $2
+WHERE id = $1
+`
+
+type UpdatePlayerSeatParams struct {
+	ID   int64
+	Seat int32
+}
+
+func (q *Queries) UpdatePlayerSeat(ctx context.Context, arg UpdatePlayerSeatParams) error {
+	_, err := q.db.Exec(ctx, updatePlayerSeat, arg.ID, arg.Seat)
+	return err
+}
+
 const updatePlayerStatus = `-- name: UpdatePlayerStatus :exec
 UPDATE players
 SET status = $2
@@ -1,6 +1,6 @@
 // Code generated by sqlc. DO NOT EDIT.
 // versions:
-//   sqlc v1.28.0
+//   sqlc v1.29.0
 // source: rounds.sql
 
 package sqlc
@@ -34,7 +34,20 @@ type CreateRoundParams struct {
 	Deck             []string
 }
 
-func (q *Queries) CreateRound(ctx context.Context, arg CreateRoundParams) (Round, error) {
+type CreateRoundRow struct {
+	ID               int64
+	GameID           int64
+	QuestionID       pgtype.Int8
+	Answer           pgtype.Text
+	QuestionPlayerID int64
+	AnswerPlayerID   int64
+	IsJoker          pgtype.Bool
+	Status           string
+	CreatedAt        pgtype.Timestamptz
+	Deck             []string
+}
+
+func (q *Queries) CreateRound(ctx context.Context, arg CreateRoundParams) (CreateRoundRow, error) {
 	row := q.db.QueryRow(ctx, createRound,
 		arg.GameID,
 		arg.QuestionID,
@@ -45,7 +58,7 @@ func (q *Queries) CreateRound(ctx context.Context, arg CreateRoundParams) (Round
 		arg.Status,
 		arg.Deck,
 	)
-	var i Round
+	var i CreateRoundRow
 	err := row.Scan(
 		&i.ID,
 		&i.GameID,
@@ -62,7 +75,7 @@ func (q *Queries) CreateRound(ctx context.Context, arg CreateRoundParams) (Round
 }
 
 const findLastRoundByGameID = `-- name: FindLastRoundByGameID :one
-SELECT id, game_id, question_id, answer, question_player_id, answer_player_id, is_joker,status,deck
+SELECT id, game_id, question_id, answer, question_player_id, answer_player_id, is_joker,status,deck, about_player_id, truthful, skipped
 FROM rounds
 WHERE game_id = $1
 ORDER BY created_at DESC
@@ -79,6 +92,9 @@ type FindLastRoundByGameIDRow struct {
 	IsJoker          pgtype.Bool
 	Status           string
 	Deck             []string
+	AboutPlayerID    pgtype.Int8
+	Truthful         pgtype.Bool
+	Skipped          bool
 }
 
 func (q *Queries) FindLastRoundByGameID(ctx context.Context, gameID int64) (FindLastRoundByGameIDRow, error) {
@@ -94,6 +110,9 @@ func (q *Queries) FindLastRoundByGameID(ctx context.Context, gameID int64) (Find
 		&i.IsJoker,
 		&i.Status,
 		&i.Deck,
+		&i.AboutPlayerID,
+		&i.Truthful,
+		&i.Skipped,
 	)
 	return i, err
 }
@@ -119,7 +138,7 @@ func (q *Queries) GetGameSummaryStats(ctx context.Context, gameID int64) (GetGam
 }
 
 const getRoundByID = `-- name: GetRoundByID :one
-SELECT id, game_id, question_id, answer, question_player_id, answer_player_id, is_joker, status, deck  
+SELECT id, game_id, question_id, answer, question_player_id, answer_player_id, is_joker, status, deck, about_player_id, truthful, skipped
 FROM rounds WHERE id = $1
 `
 
@@ -133,6 +152,9 @@ type GetRoundByIDRow struct {
 	IsJoker          pgtype.Bool
 	Status           string
 	Deck             []string
+	AboutPlayerID    pgtype.Int8
+	Truthful         pgtype.Bool
+	Skipped          bool
 }
 
 func (q *Queries) GetRoundByID(ctx context.Context, id int64) (GetRoundByIDRow, error) {
@@ -148,12 +170,15 @@ func (q *Queries) GetRoundByID(ctx context.Context, id int64) (GetRoundByIDRow,
 		&i.IsJoker,
 		&i.Status,
 		&i.Deck,
+		&i.AboutPlayerID,
+		&i.Truthful,
+		&i.Skipped,
 	)
 	return i, err
 }
 
 const getRoundWithQuestion = `-- name: GetRoundWithQuestion :one
-SELECT r.id, r.game_id, r.question_id, r.answer, r.question_player_id, r.answer_player_id, r.status, r.deck,r.is_joker,q.level, q.content AS question_content
+SELECT r.id, r.game_id, r.question_id, r.answer, r.question_player_id, r.answer_player_id, r.status, r.deck,r.is_joker, r.about_player_id, r.truthful, r.skipped, q.level, q.content AS question_content
 FROM rounds r
 JOIN questions q ON q.id = r.question_id
 WHERE r.id = $1
@@ -169,6 +194,9 @@ type GetRoundWithQuestionRow struct {
 	Status           string
 	Deck             []string
 	IsJoker          pgtype.Bool
+	AboutPlayerID    pgtype.Int8
+	Truthful         pgtype.Bool
+	Skipped          bool
 	Level            string
 	QuestionContent  string
 }
@@ -186,16 +214,104 @@ func (q *Queries) GetRoundWithQuestion(ctx context.Context, id int64) (GetRoundW
 		&i.Status,
 		&i.Deck,
 		&i.IsJoker,
+		&i.AboutPlayerID,
+		&i.Truthful,
+		&i.Skipped,
 		&i.Level,
 		&i.QuestionContent,
 	)
 	return i, err
 }
 
+const listRoundsByGameID = `-- name: ListRoundsByGameID :many
+SELECT r.id, r.game_id, r.question_id, r.answer, r.question_player_id, r.answer_player_id, r.is_joker, r.status, r.deck, r.about_player_id, r.truthful, r.skipped,
+  COALESCE(q.level, '')::text AS level, COALESCE(q.content, '')::text AS question_content
+FROM rounds r
+LEFT JOIN questions q ON q.id = r.question_id
+WHERE r.game_id = $1
+ORDER BY r.created_at, r.id
+`
+
+type ListRoundsByGameIDRow struct {
+	ID               int64
+	GameID           int64
+	QuestionID       pgtype.Int8
+	Answer           pgtype.Text
+	QuestionPlayerID int64
+	AnswerPlayerID   int64
+	IsJoker          pgtype.Bool
+	Status           string
+	Deck             []string
+	AboutPlayerID    pgtype.Int8
+	Truthful         pgtype.Bool
+	Skipped          bool
+	Level            string
+	QuestionContent  string
+}
+
+func (q *Queries) ListRoundsByGameID(ctx context.Context, gameID int64) ([]ListRoundsByGameIDRow, error) {
+	rows, err := q.db.Query(ctx, listRoundsByGameID, gameID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListRoundsByGameIDRow
+	for rows.Next() {
+		var i ListRoundsByGameIDRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.GameID,
+			&i.QuestionID,
+			&i.Answer,
+			&i.QuestionPlayerID,
+			&i.AnswerPlayerID,
+			&i.IsJoker,
+			&i.Status,
+			&i.Deck,
+			&i.AboutPlayerID,
+			&i.Truthful,
+			&i.Skipped,
+			&i.Level,
+			&i.QuestionContent,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const resolveVotes = `-- name: ResolveVotes :execrows
+UPDATE rounds
+SET status = $1,
+    is_joker = COALESCE($2::boolean, is_joker),
+    updated_at = NOW()
+WHERE id = $3 AND status = 'waiting_for_votes'
+`
+
+type ResolveVotesParams struct {
+	Status  string
+	IsJoker pgtype.Bool
+	ID      int64
+}
+
+// 只有仍在等待投票時才結算，同時送出的最後一票只有一個會更新成功
+func (q *Queries) ResolveVotes(ctx context.Context, arg ResolveVotesParams) (int64, error) {
+	result, err := q.db.Exec(ctx, resolveVotes, arg.Status, arg.IsJoker, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const setRoundQuestion = `-- name: SetRoundQuestion :exec
 UPDATE rounds
 SET question_id = $1,
-    status = 'waiting_for_answer'
+    status = 'waiting_for_answer',
+    updated_at = NOW()
 WHERE id = $2
 `
 
@@ -209,28 +325,54 @@ func (q *Queries) SetRoundQuestion(ctx context.Context, arg SetRoundQuestionPara
 	return err
 }
 
+const skipRound = `-- name: SkipRound :exec
+UPDATE rounds
+SET status = 'done',
+    skipped = TRUE,
+    updated_at = NOW()
+WHERE id = $1
+`
+
+// 跳過的回合結束在 done，另外標記 skipped，計分時略過
+func (q *Queries) SkipRound(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, skipRound, id)
+	return err
+}
+
 const updateAnswer = `-- name: UpdateAnswer :exec
 UPDATE rounds
 SET answer = $2,
-    status = $3
+    status = $3,
+    about_player_id = $4,
+    truthful = $5,
+    updated_at = NOW()
 WHERE id = $1
 `
 
 type UpdateAnswerParams struct {
-	ID     int64
-	Answer pgtype.Text
-	Status string
+	ID            int64
+	Answer        pgtype.Text
+	Status        string
+	AboutPlayerID pgtype.Int8
+	Truthful      pgtype.Bool
 }
 
 func (q *Queries) UpdateAnswer(ctx context.Context, arg UpdateAnswerParams) error {
-	_, err := q.db.Exec(ctx, updateAnswer, arg.ID, arg.Answer, arg.Status)
+	_, err := q.db.Exec(ctx, updateAnswer,
+		arg.ID,
+		arg.Answer,
+		arg.Status,
+		arg.AboutPlayerID,
+		arg.Truthful,
+	)
 	return err
 }
 
 const updateDrawResult = `-- name: UpdateDrawResult :exec
 UPDATE rounds
 SET is_joker = $2,
-    status = $3
+    status = $3,
+    updated_at = NOW()
 WHERE id = $1
 `
 
@@ -247,7 +389,8 @@ func (q *Queries) UpdateDrawResult(ctx context.Context, arg UpdateDrawResultPara
 
 const updateRoundStatus = `-- name: UpdateRoundStatus :exec
 UPDATE rounds
-SET status = $2
+SET status = $2,
+    updated_at = NOW()
 WHERE id = $1
 `
 
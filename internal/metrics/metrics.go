@@ -0,0 +1,166 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/y3933y3933/joker/internal/service"
+	"github.com/y3933y3933/joker/internal/ws"
+)
+
+const namespace = "joker"
+
+// Metrics 收集 HTTP、WebSocket、遊戲事件與資料庫連線池的指標。
+// 計數都在記憶體內完成，連線與連線池的數字在 scrape 時才讀取，不會查詢資料庫
+type Metrics struct {
+	registry     *prometheus.Registry
+	httpDuration *prometheus.HistogramVec
+	rounds       *prometheus.CounterVec
+	loginFailed  *prometheus.CounterVec
+}
+
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		rounds: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rounds_total",
+			Help:      "Rounds by outcome: started, skipped or revealed.",
+		}, []string{"event"}),
+		loginFailed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "login_failures_total",
+			Help:      "Failed logins by kind: admin or account.",
+		}, []string{"kind"}),
+	}
+
+	// 先建立 label，尚未發生的事件也會以 0 出現
+	for _, event := range []string{"started", "skipped", "revealed"} {
+		m.rounds.WithLabelValues(event)
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpDuration,
+		m.rounds,
+		m.loginFailed,
+	)
+	return m
+}
+
+// Handler 回傳 /metrics 使用的 handler
+func (m *Metrics) Handler() gin.HandlerFunc {
+	h := promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+	return gin.WrapH(h)
+}
+
+// Middleware 以路由樣板（例如 /api/games/:code）記錄延遲與狀態，避免每個遊戲代碼各自成為一組 label
+func (m *Metrics) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		m.httpDuration.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
+
+// WatchHub 在 scrape 時讀取 hub 的房間、連線與待送訊息數
+func (m *Metrics) WatchHub(hub *ws.Hub) {
+	m.registry.MustRegister(&hubCollector{hub: hub})
+}
+
+// WatchPool 在 scrape 時讀取資料庫連線池的統計
+func (m *Metrics) WatchPool(pool *pgxpool.Pool) {
+	m.registry.MustRegister(&poolCollector{pool: pool})
+}
+
+func (m *Metrics) RoundStarted()  { m.rounds.WithLabelValues("started").Inc() }
+func (m *Metrics) RoundSkipped()  { m.rounds.WithLabelValues("skipped").Inc() }
+func (m *Metrics) RoundRevealed() { m.rounds.WithLabelValues("revealed").Inc() }
+
+func (m *Metrics) LoginFailed(kind string) {
+	m.loginFailed.WithLabelValues(kind).Inc()
+}
+
+var (
+	hubRoomsDesc       = prometheus.NewDesc(namespace+"_ws_rooms", "Rooms currently open.", nil, nil)
+	hubConnectionsDesc = prometheus.NewDesc(namespace+"_ws_connections", "WebSocket connections currently open.", nil, nil)
+	hubQueuedDesc      = prometheus.NewDesc(namespace+"_ws_queued_frames", "Frames waiting in connection send queues.", nil, nil)
+	hubMaxQueueDesc    = prometheus.NewDesc(namespace+"_ws_max_queued_frames", "Largest send queue of a single connection.", nil, nil)
+)
+
+type hubCollector struct {
+	hub *ws.Hub
+}
+
+func (c *hubCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- hubRoomsDesc
+	ch <- hubConnectionsDesc
+	ch <- hubQueuedDesc
+	ch <- hubMaxQueueDesc
+}
+
+func (c *hubCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.hub.Stats()
+	ch <- prometheus.MustNewConstMetric(hubRoomsDesc, prometheus.GaugeValue, float64(stats.Rooms))
+	ch <- prometheus.MustNewConstMetric(hubConnectionsDesc, prometheus.GaugeValue, float64(stats.Connections))
+	ch <- prometheus.MustNewConstMetric(hubQueuedDesc, prometheus.GaugeValue, float64(stats.QueuedFrames))
+	ch <- prometheus.MustNewConstMetric(hubMaxQueueDesc, prometheus.GaugeValue, float64(stats.MaxQueue))
+}
+
+var (
+	poolAcquiredDesc        = prometheus.NewDesc(namespace+"_db_pool_acquired_conns", "Connections currently in use.", nil, nil)
+	poolIdleDesc            = prometheus.NewDesc(namespace+"_db_pool_idle_conns", "Idle connections.", nil, nil)
+	poolTotalDesc           = prometheus.NewDesc(namespace+"_db_pool_total_conns", "Total connections in the pool.", nil, nil)
+	poolMaxDesc             = prometheus.NewDesc(namespace+"_db_pool_max_conns", "Maximum size of the pool.", nil, nil)
+	poolAcquireDesc         = prometheus.NewDesc(namespace+"_db_pool_acquires_total", "Successful connection acquires.", nil, nil)
+	poolEmptyAcquireDesc    = prometheus.NewDesc(namespace+"_db_pool_empty_acquires_total", "Acquires that had to wait for a connection.", nil, nil)
+	poolCanceledAcquireDesc = prometheus.NewDesc(namespace+"_db_pool_canceled_acquires_total", "Acquires canceled by their context.", nil, nil)
+	poolAcquireWaitDesc     = prometheus.NewDesc(namespace+"_db_pool_acquire_wait_seconds_total", "Total time spent acquiring connections.", nil, nil)
+)
+
+type poolCollector struct {
+	pool *pgxpool.Pool
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- poolAcquiredDesc
+	ch <- poolIdleDesc
+	ch <- poolTotalDesc
+	ch <- poolMaxDesc
+	ch <- poolAcquireDesc
+	ch <- poolEmptyAcquireDesc
+	ch <- poolCanceledAcquireDesc
+	ch <- poolAcquireWaitDesc
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(poolAcquiredDesc, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(poolIdleDesc, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(poolTotalDesc, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(poolMaxDesc, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(poolAcquireDesc, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolEmptyAcquireDesc, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolCanceledAcquireDesc, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolAcquireWaitDesc, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+}
+
+var _ service.Metrics = (*Metrics)(nil)
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/y3933y3933/joker/internal/utils/httpx"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// 沿用 client 或 proxy 帶來的 ID，格式不對就重新產生，避免把任意內容寫進 log
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID 為每個請求設定 request_id，並放在回應的 X-Request-ID header
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !requestIDPattern.MatchString(id) {
+			id = newRequestID()
+		}
+
+		c.Set("request_id", id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ErrorHandler 在 handler 結束後，把 httpx.Error 記錄的最後一個錯誤轉成統一格式的錯誤回應
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		httpx.WriteError(c, c.Errors.Last().Err)
+	}
+}
+
+// Recovery 將 panic 當成 500 錯誤回應
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, recovered any) {
+		httpx.Error(c, fmt.Errorf("panic: %v", recovered))
+	})
+}
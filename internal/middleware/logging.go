@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/y3933y3933/joker/internal/utils/httpx"
+	"github.com/y3933y3933/joker/internal/utils/logx"
+)
+
+// RequestLogger 把帶有 request_id、game_code、player_id 的 logger 放進請求的 context，
+// 請求結束時記錄一行存取紀錄。必須放在 RequestID 與 Tracing 之後，以 *Context 方法記錄時會帶上 trace_id
+func RequestLogger(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		args := []any{"request_id", httpx.RequestID(c)}
+		if code := c.Param("code"); code != "" {
+			args = append(args, "game_code", code)
+		}
+		// REST 以 header 帶玩家 ID，WebSocket 以 query 帶
+		playerID := c.GetHeader("X-Player-ID")
+		if playerID == "" {
+			playerID = c.Query("player_id")
+		}
+		if id, err := strconv.ParseInt(playerID, 10, 64); err == nil {
+			args = append(args, "player_id", id)
+		}
+
+		reqLogger := logger.With(args...)
+		c.Request = c.Request.WithContext(logx.WithLogger(c.Request.Context(), reqLogger))
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		reqLogger.InfoContext(c.Request.Context(), "request",
+			"method", c.Request.Method,
+			"route", route,
+			"path", c.Request.URL.Path,
+			"status", c.Writer.Status(),
+			"duration", time.Since(start),
+		)
+	}
+}
@@ -1,7 +1,6 @@
 package middleware
 
 import (
-	"errors"
 	"net/http"
 	"regexp"
 	"strconv"
@@ -10,20 +9,25 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/y3933y3933/joker/internal/service"
+	"github.com/y3933y3933/joker/internal/store"
 	"github.com/y3933y3933/joker/internal/utils/errx"
 	"github.com/y3933y3933/joker/internal/utils/httpx"
 )
 
 type Middleware struct {
-	gameService *service.GameService
-	authService *service.AuthService
+	gameService    *service.GameService
+	authService    *service.AuthService
+	accountService *service.AccountService
+	roomAccess     *service.RoomAccess
 }
 
 func NewMiddleware(gameService *service.GameService,
-	authService *service.AuthService) *Middleware {
+	authService *service.AuthService, accountService *service.AccountService, roomAccess *service.RoomAccess) *Middleware {
 	return &Middleware{
-		gameService: gameService,
-		authService: authService,
+		gameService:    gameService,
+		authService:    authService,
+		accountService: accountService,
+		roomAccess:     roomAccess,
 	}
 }
 
@@ -35,14 +39,14 @@ func (m *Middleware) ValidateGameExists() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		code := c.Param("code")
 		if code == "" || !codePattern.MatchString(code) {
-			httpx.BadRequestResponse(c, errors.New("invalid game code"))
+			httpx.Error(c, errx.ErrInvalidGameCode)
 			return
 		}
 
 		game, err := m.gameService.GetGameByCode(c.Request.Context(), code)
 
 		if err != nil {
-			httpx.NotFoundResponse(c, errors.New("game not found"))
+			httpx.Error(c, err)
 			return
 		}
 
@@ -54,13 +58,9 @@ func (m *Middleware) ValidateGameExists() gin.HandlerFunc {
 func (m *Middleware) WithPlayerID() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		playerIDStr := c.GetHeader("X-Player-ID")
-		if playerIDStr == "" {
-			httpx.BadRequestResponse(c, errors.New("missing X-Player-ID header"))
-			return
-		}
 		playerID, err := strconv.ParseInt(playerIDStr, 10, 64)
 		if err != nil {
-			httpx.BadRequestResponse(c, errors.New("invalid player id"))
+			httpx.Error(c, errx.ErrInvalidPlayerID)
 			return
 		}
 		c.Set("player_id", playerID)
@@ -68,6 +68,20 @@ func (m *Middleware) WithPlayerID() gin.HandlerFunc {
 	}
 }
 
+// RequireSeat 必須在 ValidateGameExists 與 WithPlayerID 之後使用。
+// 玩家 ID 是流水號且會出現在廣播中，host 操作另外要求加入時拿到的座位憑證（X-Seat-Token）
+func (m *Middleware) RequireSeat() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		game := c.MustGet("game").(*store.Game)
+		code, playerID, ok := m.roomAccess.VerifySeat(c.GetHeader("X-Seat-Token"))
+		if !ok || code != game.Code || playerID != c.GetInt64("player_id") {
+			httpx.Error(c, errx.ErrInvalidSeatToken)
+			return
+		}
+		c.Next()
+	}
+}
+
 func (m *Middleware) Authenticate() gin.HandlerFunc {
 	return func(c *gin.Context) {
 
@@ -75,23 +89,23 @@ func (m *Middleware) Authenticate() gin.HandlerFunc {
 
 		tokenString := extractTokenFromHeaders(c.Request.Header)
 		if tokenString == "" {
-			httpx.UnAuthorized(c, errx.ErrInvalidAuthorizationHeader)
+			httpx.Error(c, errx.ErrInvalidAuthorizationHeader)
 			return
 		}
 
 		claims, err := m.authService.ParseToken(tokenString)
 		if err != nil {
-			httpx.UnAuthorized(c, err)
+			httpx.Error(c, err)
 			return
 		}
 
 		if claims.ExpiresAt == nil {
-			httpx.UnAuthorized(c, errx.ErrInvalidToken)
+			httpx.Error(c, errx.ErrInvalidToken)
 			return
 		}
 
 		if time.Now().After(claims.ExpiresAt.Time) {
-			httpx.UnAuthorized(c, errx.ErrTokenExpired)
+			httpx.Error(c, errx.ErrTokenExpired)
 			return
 		}
 
@@ -118,7 +132,46 @@ func (m *Middleware) RequireUser() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		_, exists := c.Get("user_id")
 		if !exists {
-			httpx.UnAuthorized(c, errx.ErrLoginRequired)
+			httpx.Error(c, errx.ErrLoginRequired)
+			return
+		}
+		c.Next()
+	}
+}
+
+// OptionalAccount 有帶玩家帳號的 token 時設定 account_id，沒帶時照常以匿名身分繼續
+func (m *Middleware) OptionalAccount() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Vary", "Authorization")
+
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+
+		tokenString := extractTokenFromHeaders(c.Request.Header)
+		if tokenString == "" {
+			httpx.Error(c, errx.ErrInvalidAuthorizationHeader)
+			return
+		}
+
+		claims, err := m.accountService.ParseToken(tokenString)
+		if err != nil {
+			httpx.Error(c, err)
+			return
+		}
+
+		c.Set("account_id", claims.AccountID)
+		c.Next()
+	}
+}
+
+// RequireAccount 必須在 OptionalAccount 之後使用
+func (m *Middleware) RequireAccount() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_, exists := c.Get("account_id")
+		if !exists {
+			httpx.Error(c, errx.ErrLoginRequired)
 			return
 		}
 		c.Next()
@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// Tracing 為每個請求建立 span，名稱是路由樣板，並接續上游 traceparent header 帶來的 trace。
+// /metrics 與健康檢查會被頻繁呼叫，不建立 span
+func Tracing() gin.HandlerFunc {
+	return otelgin.Middleware("joker-api", otelgin.WithFilter(func(r *http.Request) bool {
+		return r.URL.Path != "/metrics" && r.URL.Path != "/api/healthz" && r.URL.Path != "/api/readyz"
+	}))
+}
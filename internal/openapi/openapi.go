@@ -0,0 +1,241 @@
+// Package openapi 由 REST 端點的目錄與 Go 型別產生 OpenAPI 3.1 文件
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/y3933y3933/joker/internal/utils/errx"
+	"github.com/y3933y3933/joker/internal/utils/httpx"
+	"github.com/y3933y3933/joker/internal/utils/jsonschema"
+)
+
+// 驗證方式，對應 components.securitySchemes
+const (
+	SecurityPlayer = "playerId"   // X-Player-ID header
+	SecurityBearer = "bearerAuth" // 玩家帳號或後台的 JWT
+	SecuritySeat   = "seatToken"  // X-Seat-Token header
+)
+
+// Operation 描述一個 REST 端點
+type Operation struct {
+	Method       string
+	Path         string // gin 的路徑，例如 /api/games/:code/join
+	ID           string
+	Summary      string
+	Description  string
+	Tag          string
+	Security     []string // 需要同時提供的驗證方式
+	OptionalAuth bool     // Security 可以不帶，例如登入玩家帳號後加入遊戲
+	Query        []Param
+
+	Request         reflect.Type // nil 表示沒有 body
+	RequestOptional bool         // body 可以省略
+	Response        reflect.Type // 回應 data 欄位的型別，nil 表示 data 為 null
+	NoEnvelope      bool         // 回應不包在 {"data": ...} 裡，Response 是整個回應；沒有 Response 時以 Description 說明
+}
+
+// Param 是 query string 參數
+type Param struct {
+	Name        string
+	Type        string // integer、string 或 boolean
+	Description string
+}
+
+// Info 文件的標題與版本
+type Info struct {
+	Title   string
+	Version string
+}
+
+var pathParam = regexp.MustCompile(`:(\w+)`)
+
+// Document 產生 OpenAPI 3.1 文件
+func Document(info Info, ops []Operation) ([]byte, error) {
+	b := jsonschema.New("#/components/schemas/")
+
+	// 先產生所有回應，request 與回應共用的型別才會以 <Name>Input 命名 request 版本
+	responses := make([]map[string]any, len(ops))
+	for i, op := range ops {
+		responses[i] = responseFor(b, op)
+	}
+
+	paths := map[string]map[string]any{}
+	for i, op := range ops {
+		path := pathParam.ReplaceAllString(op.Path, "{$1}")
+		if paths[path] == nil {
+			paths[path] = map[string]any{}
+		}
+		method := strings.ToLower(op.Method)
+		if _, ok := paths[path][method]; ok {
+			return nil, fmt.Errorf("openapi: duplicate operation %s %s", op.Method, op.Path)
+		}
+		paths[path][method] = operationFor(b, op, responses[i])
+	}
+
+	errorSchema := b.Schema(reflect.TypeFor[httpx.ErrorBody]())
+	addErrorCodes(b.Defs)
+
+	doc := map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":   info.Title,
+			"version": info.Version,
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": b.Defs,
+			"responses": map[string]any{
+				"Error": map[string]any{
+					"description": "Error. code is stable and safe to branch on; message is for humans.",
+					"content": jsonContent(map[string]any{
+						"type":                 "object",
+						"properties":           map[string]any{"error": errorSchema},
+						"required":             []string{"error"},
+						"additionalProperties": false,
+					}),
+				},
+			},
+			"securitySchemes": map[string]any{
+				SecurityPlayer: map[string]any{
+					"type":        "apiKey",
+					"in":          "header",
+					"name":        "X-Player-ID",
+					"description": "ID of the player returned when joining the game.",
+				},
+				SecuritySeat: map[string]any{
+					"type":        "apiKey",
+					"in":          "header",
+					"name":        "X-Seat-Token",
+					"description": "seatToken returned when joining the game, proving that X-Player-ID is yours.",
+				},
+				SecurityBearer: map[string]any{
+					"type":         "http",
+					"scheme":       "bearer",
+					"bearerFormat": "JWT",
+				},
+			},
+		},
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+func operationFor(b *jsonschema.Builder, op Operation, response map[string]any) map[string]any {
+	out := map[string]any{
+		"operationId": op.ID,
+		"summary":     op.Summary,
+		"tags":        []string{op.Tag},
+		"responses": map[string]any{
+			"200":     response,
+			"default": map[string]any{"$ref": "#/components/responses/Error"},
+		},
+	}
+	if op.Description != "" {
+		out["description"] = op.Description
+	}
+
+	security := []any{}
+	if len(op.Security) > 0 {
+		requirement := map[string]any{}
+		for _, name := range op.Security {
+			requirement[name] = []string{}
+		}
+		security = append(security, requirement)
+	}
+	if op.OptionalAuth {
+		security = append(security, map[string]any{})
+	}
+	out["security"] = security
+
+	var params []any
+	for _, m := range pathParam.FindAllStringSubmatch(op.Path, -1) {
+		params = append(params, map[string]any{
+			"name":     m[1],
+			"in":       "path",
+			"required": true,
+			"schema":   pathParamSchema(m[1]),
+		})
+	}
+	for _, p := range op.Query {
+		param := map[string]any{
+			"name":   p.Name,
+			"in":     "query",
+			"schema": map[string]any{"type": p.Type},
+		}
+		if p.Description != "" {
+			param["description"] = p.Description
+		}
+		params = append(params, param)
+	}
+	if len(params) > 0 {
+		out["parameters"] = params
+	}
+
+	if op.Request != nil {
+		out["requestBody"] = map[string]any{
+			"required": !op.RequestOptional,
+			"content":  jsonContent(b.RequestSchema(op.Request)),
+		}
+	}
+	return out
+}
+
+func responseFor(b *jsonschema.Builder, op Operation) map[string]any {
+	if op.NoEnvelope && op.Response == nil {
+		return map[string]any{"description": "See the operation description."}
+	}
+	if op.NoEnvelope {
+		return map[string]any{"description": "OK", "content": jsonContent(b.Schema(op.Response))}
+	}
+
+	data := map[string]any{"type": "null"}
+	if op.Response != nil {
+		data = b.Schema(op.Response)
+	}
+	return map[string]any{
+		"description": "OK",
+		"content": jsonContent(map[string]any{
+			"type":                 "object",
+			"properties":           map[string]any{"data": data},
+			"required":             []string{"data"},
+			"additionalProperties": false,
+		}),
+	}
+}
+
+// pathParamSchema 遊戲代碼是 6 碼英數字，其他路徑參數都是數字 ID
+func pathParamSchema(name string) map[string]any {
+	if name == "code" {
+		return map[string]any{"type": "string", "pattern": "^[A-Za-z0-9]{6}$"}
+	}
+	return map[string]any{"type": "integer"}
+}
+
+// addErrorCodes 列出 errx 定義的所有錯誤碼，client 可以依此產生列舉
+func addErrorCodes(defs map[string]any) {
+	body, ok := defs["ErrorBody"].(map[string]any)
+	if !ok {
+		return
+	}
+	codes := make([]string, 0, len(errx.All()))
+	for _, e := range errx.All() {
+		codes = append(codes, e.Code)
+	}
+	properties := body["properties"].(map[string]any)
+	properties["code"] = map[string]any{"type": "string", "enum": codes}
+}
+
+func jsonContent(schema map[string]any) map[string]any {
+	return map[string]any{
+		"application/json": map[string]any{"schema": schema},
+	}
+}
+
+// RouteKey 與 gin.RouteInfo 比對用的 "METHOD path"
+func (op Operation) RouteKey() string {
+	return op.Method + " " + op.Path
+}
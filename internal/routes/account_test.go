@@ -0,0 +1,51 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/y3933y3933/joker/internal/api"
+	"github.com/y3933y3933/joker/internal/store"
+	"github.com/y3933y3933/joker/internal/utils/errx"
+)
+
+// signUp 註冊並登入帳號，回傳 bearer token
+func (s *simServer) signUp(username string) string {
+	s.t.Helper()
+	account := map[string]any{"username": username, "password": "correct-horse"}
+	s.call(http.MethodPost, "/api/accounts", 0, account)
+	return decode[api.LoginResponse](s.t, s.call(http.MethodPost, "/api/accounts/login", 0, account)).Token
+}
+
+// 只知道 X-Player-ID 不能認領別人的玩家，必須帶加入時拿到的座位憑證
+func TestClaimPlayerRequiresSeatToken(t *testing.T) {
+	s := newSimServer(t)
+	code := s.createGame(nil)
+	alice := s.join(code, "alice")
+	bob := s.join(code, "bob")
+	other := s.join(s.createGame(nil), "carol")
+	mallory := s.signUp("mallory")
+	aliceAccount := s.signUp("alice")
+
+	claim := func(token string, p *simPlayer, seat string) error {
+		_, _, err := s.requestAs(token, http.MethodPost, "/api/me/players/claim", p.ID, map[string]any{"seatToken": seat})
+		return err
+	}
+
+	_, _, err := s.requestAs(mallory, http.MethodPost, "/api/me/players/claim", alice.ID, map[string]any{})
+	expectAPIError(t, err, errx.ErrValidationFailed)
+	expectAPIError(t, claim(mallory, alice, bob.SeatToken), errx.ErrInvalidSeatToken)
+	expectAPIError(t, claim(mallory, alice, other.SeatToken), errx.ErrInvalidSeatToken)
+	expectAPIError(t, claim(mallory, alice, "not-a-token"), errx.ErrInvalidSeatToken)
+
+	_, data, err := s.requestAs(aliceAccount, http.MethodPost, "/api/me/players/claim", alice.ID, map[string]any{"seatToken": alice.SeatToken})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if player := decode[store.Player](t, data); player.ID != alice.ID || player.AccountID == nil {
+		t.Errorf("claimed player = %+v, want alice linked to the account", player)
+	}
+
+	// 座位憑證外流也不能轉給其他帳號
+	expectAPIError(t, claim(mallory, alice, alice.SeatToken), errx.ErrPlayerClaimed)
+}
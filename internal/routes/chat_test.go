@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"testing"
+
+	"github.com/y3933y3933/joker/internal/ws"
+)
+
+// 聊天訊息經過 app 設定的字詞過濾後廣播，之後才加入的玩家在 welcome 裡拿到聊天紀錄
+func TestChatIsFilteredAndSentToLateJoiners(t *testing.T) {
+	s := newSimServer(t)
+	code := s.createGame(nil)
+	players := joinAndConnect(s, code, "alice", "bob")
+	alice := players[0]
+
+	alice.command(ws.CmdSendChat, ws.SendChatCommand{Text: "darn, I'm the joker"})
+	chat := decode[ws.ChatMessagePayload](t, expectAll(players, ws.MsgTypeChatMessage)[0].Data)
+	if chat.Text != "****, I'm the joker" || chat.PlayerID != alice.ID {
+		t.Fatalf("chat = %+v, want alice's message with the blocked word masked", chat)
+	}
+
+	carol := s.join(code, "carol")
+	carol.mustConnect()
+	if len(carol.welcome.Chat) != 1 || carol.welcome.Chat[0] != chat {
+		t.Errorf("welcome chat = %+v, want [%+v]", carol.welcome.Chat, chat)
+	}
+}
@@ -0,0 +1,78 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/y3933y3933/joker/internal/middleware"
+	"github.com/y3933y3933/joker/internal/utils/errx"
+	"github.com/y3933y3933/joker/internal/utils/httpx"
+)
+
+func TestErrorResponses(t *testing.T) {
+	s := newSimServer(t)
+	code := s.createGame(nil)
+	alice := s.join(code, "alice")
+
+	t.Run("ValidationDetails", func(t *testing.T) {
+		_, _, err := s.request(http.MethodPost, roundPath(code, 1, "question"), alice.ID, map[string]any{})
+		apiErr := expectAPIError(t, err, errx.ErrValidationFailed)
+		want := []httpx.FieldError{{Field: "questionID", Rule: "required", Message: "is required"}}
+		if len(apiErr.Details) != 1 || apiErr.Details[0] != want[0] {
+			t.Errorf("details = %+v, want %+v", apiErr.Details, want)
+		}
+	})
+
+	t.Run("WrongType", func(t *testing.T) {
+		_, _, err := s.request(http.MethodPost, roundPath(code, 1, "question"), alice.ID, map[string]any{"questionID": "one"})
+		apiErr := expectAPIError(t, err, errx.ErrInvalidBody)
+		if len(apiErr.Details) != 1 || apiErr.Details[0].Field != "questionID" || apiErr.Details[0].Message != "must be a number" {
+			t.Errorf("details = %+v, want questionID must be a number", apiErr.Details)
+		}
+	})
+
+	t.Run("Sentinels", func(t *testing.T) {
+		_, _, err := s.request(http.MethodGet, "/api/games/ZZZZZZ/players", 0, nil)
+		expectAPIError(t, err, errx.ErrGameNotFound)
+
+		_, _, err = s.request(http.MethodGet, "/api/games/bad/players", 0, nil)
+		expectAPIError(t, err, errx.ErrInvalidGameCode)
+
+		_, _, err = s.request(http.MethodPost, gamePath(code, "/players/leave"), 0, nil)
+		expectAPIError(t, err, errx.ErrInvalidPlayerID)
+
+		_, _, err = s.request(http.MethodPost, roundPath(code, 0, "x"), alice.ID, nil)
+		expectAPIError(t, err, errx.ErrRouteNotFound)
+
+		_, _, err = s.request(http.MethodPost, gamePath(code, "/rounds/abc/answer"), alice.ID, map[string]any{"answer": "a"})
+		apiErr := expectAPIError(t, err, errx.ErrInvalidParam)
+		if apiErr.Message != "invalid param: id" {
+			t.Errorf("message = %q, want the param name", apiErr.Message)
+		}
+	})
+
+	t.Run("RequestID", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPost, s.server.URL+gamePath(code, "/join"), strings.NewReader("{"))
+		req.Header.Set(middleware.RequestIDHeader, "trace-123")
+		res, err := s.server.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+
+		var body struct {
+			Error httpx.ErrorBody `json:"error"`
+		}
+		if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != http.StatusBadRequest || body.Error.Code != errx.ErrInvalidBody.Code {
+			t.Errorf("got %d %s, want %d %s", res.StatusCode, body.Error.Code, http.StatusBadRequest, errx.ErrInvalidBody.Code)
+		}
+		if body.Error.RequestID != "trace-123" || res.Header.Get(middleware.RequestIDHeader) != "trace-123" {
+			t.Errorf("request id = %q (header %q), want trace-123", body.Error.RequestID, res.Header.Get(middleware.RequestIDHeader))
+		}
+	})
+}
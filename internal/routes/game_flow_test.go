@@ -0,0 +1,267 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/y3933y3933/joker/internal/api"
+	"github.com/y3933y3933/joker/internal/service"
+	"github.com/y3933y3933/joker/internal/store"
+	"github.com/y3933y3933/joker/internal/utils/errx"
+	"github.com/y3933y3933/joker/internal/ws"
+)
+
+// joinAndConnect 讓玩家依序加入並連線，每位新玩家加入時已連線的人都會收到 player_joined
+func joinAndConnect(s *simServer, code string, nicknames ...string) []*simPlayer {
+	var players []*simPlayer
+	for _, nickname := range nicknames {
+		p := s.join(code, nickname)
+		expectAll(players, ws.MsgTypePlayerJoined)
+		p.mustConnect()
+		players = append(players, p)
+	}
+	return players
+}
+
+func gamePath(code, path string) string {
+	return "/api/games/" + code + path
+}
+
+func roundPath(code string, roundID int64, action string) string {
+	return gamePath(code, fmt.Sprintf("/rounds/%d/%s", roundID, action))
+}
+
+// expectAPIError 確認請求失敗，且狀態與錯誤碼符合 errx 的定義
+func expectAPIError(t *testing.T, err error, want *errx.Error) *simAPIError {
+	t.Helper()
+	var apiErr *simAPIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("error = %v, want %s", err, want.Code)
+	}
+	if apiErr.status != want.Status || apiErr.Code != want.Code {
+		t.Errorf("%s %s: got %d %s, want %d %s", apiErr.method, apiErr.path, apiErr.status, apiErr.Code, want.Status, want.Code)
+	}
+	return apiErr
+}
+
+func TestSimulatedGame(t *testing.T) {
+	s := newSimServer(t)
+	code := s.createGame(nil)
+
+	players := joinAndConnect(s, code, "alice", "bob", "carol")
+	alice, bob, carol := players[0], players[1], players[2]
+
+	// 第一回合：第一個座位出題，第二個座位回答
+	s.call(http.MethodPost, gamePath(code, "/start"), 0, nil)
+	started := decode[ws.RoundStartedPayload](t, expectAll(players, ws.MsgTypeGameStarted)[0].Data)
+	if started.QuestionPlayerID != alice.ID || started.AnswererID != bob.ID {
+		t.Fatalf("first round = %+v, want alice asking bob", started)
+	}
+
+	// 出題：所有人進入回答時間，只有回答者收到題目
+	questions := decode[[]store.Question](t, s.call(http.MethodGet, gamePath(code, "/questions"), 0, nil))
+	s.call(http.MethodPost, roundPath(code, started.RoundID, "question"), alice.ID, map[string]any{"questionID": questions[0].ID})
+	expectAll(players, ws.MsgTypeAnswerTime)
+	question := decode[ws.RoundQuestionPayload](t, bob.expectDirect(ws.MsgTypeRoundQuestion)[0].Data)
+	if question.Content != questions[0].Content {
+		t.Errorf("answerer got question %q, want %q", question.Content, questions[0].Content)
+	}
+
+	// 不是回答者不能回答
+	_, _, err := s.request(http.MethodPost, roundPath(code, started.RoundID, "answer"), carol.ID, map[string]any{"answer": "me"})
+	expectAPIError(t, err, errx.ErrForbidden)
+
+	s.call(http.MethodPost, roundPath(code, started.RoundID, "answer"), bob.ID, map[string]any{"answer": "carol"})
+	answered := decode[ws.AnswerSubmittedPayload](t, expectAll(players, ws.MsgTypeAnswerSubmitted)[0].Data)
+	if answered.Answer != "carol" {
+		t.Errorf("answer = %q, want carol", answered.Answer)
+	}
+
+	// 抽牌：抽到鬼牌公開題目，否則安全，接著是排行榜
+	drawn := decode[struct {
+		Joker bool `json:"joker"`
+	}](t, s.call(http.MethodPost, roundPath(code, started.RoundID, "draw"), bob.ID, map[string]any{"index": 0}))
+	result := ws.MsgTypePlayerSafe
+	if drawn.Joker {
+		result = ws.MsgTypeJokerRevealed
+	}
+	expectAll(players, result, ws.MsgTypeLeaderboard)
+
+	// 下一回合：上一位回答者出題，下一個座位回答
+	s.call(http.MethodPost, gamePath(code, "/rounds/next"), alice.ID, nil)
+	next := decode[ws.RoundStartedPayload](t, expectAll(players, ws.MsgNextRoundStarted)[0].Data)
+	if next.QuestionPlayerID != bob.ID || next.AnswererID != carol.ID {
+		t.Fatalf("second round = %+v, want bob asking carol", next)
+	}
+
+	// 出題者斷線：回合被跳過，仍在線的回答者重新回答
+	bob.disconnect()
+	events := expectAll([]*simPlayer{alice, carol}, ws.MsgTypePlayerOffline, ws.MsgTypeRoundSkipped)
+	skipped := decode[ws.RoundSkippedPayload](t, events[1].Data)
+	if skipped.QuestionPlayerID != alice.ID || skipped.AnswererID != carol.ID {
+		t.Errorf("skipped round = %+v, want alice asking carol", skipped)
+	}
+
+	// 重新連線後回到遊戲，所有人（包含自己）收到 player_reconnected
+	bob.mustConnect()
+	reconnected := decode[ws.PlayerOfflinePayload](t, expectAll(players, ws.MsgTypePlayerOnline)[0].Data)
+	if reconnected.ID != bob.ID {
+		t.Errorf("reconnected player = %d, want bob (%d)", reconnected.ID, bob.ID)
+	}
+
+	s.call(http.MethodPost, gamePath(code, "/end"), 0, nil)
+	ended := decode[ws.GameEndedPayload](t, expectAll(players, ws.MsgTypeGameEnded)[0].Data)
+	if ended.Reason != store.EndReasonFinished {
+		t.Errorf("end reason = %q, want %q", ended.Reason, store.EndReasonFinished)
+	}
+
+	for _, p := range players {
+		p.expectQuiet()
+	}
+}
+
+func TestSimulatedGameEndsWhenPlayersDisconnect(t *testing.T) {
+	s := newSimServer(t)
+	code := s.createGame(nil)
+
+	players := joinAndConnect(s, code, "alice", "bob", "carol")
+	alice, bob, carol := players[0], players[1], players[2]
+
+	s.call(http.MethodPost, gamePath(code, "/start"), 0, nil)
+	expectAll(players, ws.MsgTypeGameStarted)
+
+	// 和目前回合無關的玩家斷線，只通知其他人
+	carol.disconnect()
+	expectAll([]*simPlayer{alice, bob}, ws.MsgTypePlayerOffline)
+
+	// Host 同時是出題者：轉移 Host 後無法再開回合，遊戲因人數不足結束
+	alice.disconnect()
+	events := bob.expect(ws.MsgTypePlayerOffline, ws.MsgHostTransferred, ws.MsgTypeGameEnded)
+	if host := decode[ws.HostTransferredPayload](t, events[1].Data); host.ID != bob.ID {
+		t.Errorf("new host = %d, want bob (%d)", host.ID, bob.ID)
+	}
+	if ended := decode[ws.GameEndedPayload](t, events[2].Data); ended.Reason != store.EndReasonNotEnoughPlayers {
+		t.Errorf("end reason = %q, want %q", ended.Reason, store.EndReasonNotEnoughPlayers)
+	}
+	bob.expectQuiet()
+}
+
+func TestSimulatedLobbyLeave(t *testing.T) {
+	s := newSimServer(t)
+	code := s.createGame(nil)
+
+	players := joinAndConnect(s, code, "alice", "bob", "carol")
+	alice, bob, carol := players[0], players[1], players[2]
+
+	// 開始前 Host 斷線等同離開，Host 轉給下一個座位
+	alice.disconnect()
+	events := expectAll([]*simPlayer{bob, carol}, ws.MsgPlayerLeft, ws.MsgHostTransferred)
+	if host := decode[ws.HostTransferredPayload](t, events[1].Data); host.ID != bob.ID {
+		t.Errorf("new host = %d, want bob (%d)", host.ID, bob.ID)
+	}
+
+	// 只剩兩人無法開始
+	_, _, err := s.request(http.MethodPost, gamePath(code, "/start"), 0, nil)
+	expectAPIError(t, err, errx.ErrNotEnoughPlayers)
+	bob.expectQuiet()
+	carol.expectQuiet()
+}
+
+func TestSimulatedRematch(t *testing.T) {
+	s := newSimServer(t)
+	code := s.createGame(nil)
+
+	players := joinAndConnect(s, code, "alice", "bob", "carol")
+	alice, bob := players[0], players[1]
+
+	s.call(http.MethodPost, gamePath(code, "/start"), 0, nil)
+	expectAll(players, ws.MsgTypeGameStarted)
+	s.call(http.MethodPost, gamePath(code, "/end"), 0, nil)
+	expectAll(players, ws.MsgTypeGameEnded)
+
+	// 結束後仍然可以看總結，但不能再加入或連線
+	summary := decode[store.GameSummary](t, s.call(http.MethodGet, gamePath(code, "/summary"), 0, nil))
+	if len(summary.Players) != 3 {
+		t.Errorf("summary players = %d, want 3", len(summary.Players))
+	}
+	_, _, err := s.request(http.MethodPost, gamePath(code, "/join"), 0, map[string]any{"nickname": "dave"})
+	expectAPIError(t, err, errx.ErrGameEnded)
+	late := &simPlayer{s: s, t: t, code: code, ID: alice.ID, Nickname: alice.Nickname, SeatToken: alice.SeatToken}
+	if err := late.connect(false, nil); err == nil {
+		late.disconnect()
+		t.Error("connecting to an ended game should fail")
+	}
+
+	// 再來一局：所有人收到新遊戲代碼與自己的新 ID
+	result := decode[api.RematchResponse](t, s.call(http.MethodPost, gamePath(code, "/rematch"), bob.ID, nil))
+	if !result.Created || result.Game.Code == code || len(result.Players) != 3 || result.SeatToken == "" {
+		t.Fatalf("rematch = %+v, want a new game with 3 players and bob's seat token", result)
+	}
+	created := decode[ws.RematchCreatedPayload](t, expectAll(players, ws.MsgTypeRematchCreated)[0].Data)
+	if created.GameCode != result.Game.Code {
+		t.Errorf("rematch_created code = %q, want %q", created.GameCode, result.Game.Code)
+	}
+	// 每個人私下收到自己在新遊戲的座位憑證
+	seats := make(map[int64]string)
+	for _, p := range players {
+		seat := decode[ws.RematchSeatPayload](t, p.expectDirect(ws.MsgTypeRematchSeat)[0].Data)
+		if seat.GameCode != result.Game.Code || seat.SeatToken == "" {
+			t.Errorf("%s rematch_seat = %+v, want a seat in %s", p.Nickname, seat, result.Game.Code)
+		}
+		seats[seat.PlayerID] = seat.SeatToken
+	}
+
+	// 第二次呼叫回傳同一場
+	again := decode[service.RematchResult](t, s.call(http.MethodPost, gamePath(code, "/rematch"), alice.ID, nil))
+	if again.Created || again.Game.Code != result.Game.Code || len(again.Players) != 3 {
+		t.Errorf("second rematch = %+v, want the existing game %s", again, result.Game.Code)
+	}
+
+	// 新遊戲可以連線並開始
+	var next []*simPlayer
+	for _, p := range result.Players {
+		np := &simPlayer{s: s, t: t, code: result.Game.Code, ID: p.Player.ID, Nickname: p.Player.Nickname, SeatToken: seats[p.Player.ID]}
+		np.mustConnect()
+		next = append(next, np)
+	}
+	s.call(http.MethodPost, gamePath(result.Game.Code, "/start"), 0, nil)
+	expectAll(next, ws.MsgTypeGameStarted)
+}
+
+// 管理員從後台結束遊戲時，房間裡的人收到 game_ended，連線隨後被關閉
+func TestAdminEndGameClosesRoom(t *testing.T) {
+	s := newSimServer(t)
+	code := s.createGame(nil)
+	players := joinAndConnect(s, code, "alice", "bob")
+
+	admin := &store.User{Username: "admin"}
+	if err := admin.Password.Set("correct-horse"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.db.Users().Create(context.Background(), admin); err != nil {
+		t.Fatal(err)
+	}
+	login := decode[api.LoginResponse](t, s.call(http.MethodPost, "/api/admin/login", 0, map[string]any{"username": "admin", "password": "correct-horse"}))
+	if _, _, err := s.requestAs(login.Token, http.MethodPost, "/api/admin/games/end", 0, map[string]any{"code": code}); err != nil {
+		t.Fatal(err)
+	}
+
+	ended := decode[ws.GameEndedPayload](t, expectAll(players, ws.MsgTypeGameEnded)[0].Data)
+	if ended.Reason != store.EndReasonAdmin {
+		t.Errorf("end reason = %q, want %q", ended.Reason, store.EndReasonAdmin)
+	}
+	for _, p := range players {
+		if e, ok := p.inbox.next(false, simEventTimeout); ok {
+			t.Fatalf("%s: got %s after game_ended, want the connection closed", p.Nickname, e.Type)
+		}
+		p.inbox.mu.Lock()
+		closed := p.inbox.closed
+		p.inbox.mu.Unlock()
+		if !closed {
+			t.Errorf("%s: connection still open after the admin ended the game", p.Nickname)
+		}
+	}
+}
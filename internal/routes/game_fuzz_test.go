@@ -0,0 +1,270 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/y3933y3933/joker/internal/store"
+	"github.com/y3933y3933/joker/internal/utils/errx"
+	"github.com/y3933y3933/joker/internal/ws"
+)
+
+var (
+	simSeed  = flag.Uint64("sim.seed", 0, "seed for the randomized game simulation, 0 picks one from the clock")
+	simGames = flag.Int("sim.games", 3, "number of randomized games to simulate")
+	simSteps = flag.Int("sim.steps", 40, "steps per randomized game")
+)
+
+// simStepQuietPeriod 每一步之間只短暫等待，沒讀到的事件會在下一步或最後結束時讀到
+const simStepQuietPeriod = 20 * time.Millisecond
+
+// simFuzzer 隨機讓玩家同時操作、斷線與重連，檢查伺服器不會回 5xx，
+// 每條連線收到的廣播序號連續（重連時以 since 補發），且同一個序號在所有人看來是同一個事件
+type simFuzzer struct {
+	t       *testing.T
+	s       *simServer
+	rng     *rand.Rand
+	code    string
+	gameID  int64
+	players []*simPlayer
+	online  map[*simPlayer]bool
+
+	mu   sync.Mutex
+	seen map[uint64]string // 序號 → 事件內容
+}
+
+func TestSimulatedGameFuzz(t *testing.T) {
+	seed := *simSeed
+	if seed == 0 {
+		seed = uint64(time.Now().UnixNano())
+	}
+	t.Logf("seed %d (rerun with -sim.seed=%d)", seed, seed)
+	rng := rand.New(rand.NewPCG(seed, seed))
+
+	for i := range *simGames {
+		t.Run(fmt.Sprintf("game%d", i+1), func(t *testing.T) {
+			f := newSimFuzzer(t, rng)
+			for range *simSteps {
+				f.step()
+			}
+			f.finish()
+		})
+	}
+}
+
+func newSimFuzzer(t *testing.T, rng *rand.Rand) *simFuzzer {
+	s := newSimServer(t)
+	f := &simFuzzer{
+		t:      t,
+		s:      s,
+		rng:    rng,
+		code:   s.createGame(nil),
+		online: make(map[*simPlayer]bool),
+		seen:   make(map[uint64]string),
+	}
+
+	game, err := s.db.Games().GetGameByCode(context.Background(), f.code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.gameID = game.ID
+
+	for i := range 3 + rng.IntN(3) {
+		p := s.join(f.code, fmt.Sprintf("player%d", i+1))
+		f.drainAll(simStepQuietPeriod)
+		// 一部分玩家開啟 batch，同時發生的事件會合併成一個 frame
+		query := url.Values{}
+		if rng.IntN(2) == 0 {
+			query.Set("batch", "1")
+		}
+		if err := p.connect(false, query); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(p.disconnect)
+		f.players = append(f.players, p)
+		f.online[p] = true
+	}
+
+	f.rest(http.MethodPost, gamePath(f.code, "/start"), 0, nil)
+	return f
+}
+
+// step 同時送出一到三個隨機操作，其中最多一個是斷線或重連
+func (f *simFuzzer) step() {
+	var wg sync.WaitGroup
+	for range 1 + f.rng.IntN(3) {
+		action := f.randomAction()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			action()
+		}()
+	}
+	if f.rng.IntN(4) == 0 {
+		f.toggleConnection()
+	}
+	wg.Wait()
+	f.drainAll(simStepQuietPeriod)
+}
+
+// randomAction 挑一個隨機玩家做一個隨機的回合操作，不一定輪到他，也不一定在對的階段
+func (f *simFuzzer) randomAction() func() {
+	p := f.players[f.rng.IntN(len(f.players))]
+	round, _ := f.s.db.Rounds().FindLastRoundByGameID(context.Background(), f.gameID)
+
+	var roundID int64
+	var deck int
+	if round != nil {
+		roundID = round.ID
+		deck = len(round.Deck)
+	}
+	question := f.s.questions[f.rng.IntN(len(f.s.questions))].ID
+	index := 0
+	if deck > 0 {
+		index = f.rng.IntN(deck)
+	}
+
+	switch f.rng.IntN(4) {
+	case 0:
+		return func() {
+			f.rest(http.MethodPost, roundPath(f.code, roundID, "question"), p.ID, map[string]any{"questionID": question})
+		}
+	case 1:
+		return func() {
+			f.rest(http.MethodPost, roundPath(f.code, roundID, "answer"), p.ID, map[string]any{"answer": "fuzz"})
+		}
+	case 2:
+		return func() {
+			f.rest(http.MethodPost, roundPath(f.code, roundID, "draw"), p.ID, map[string]any{"index": index})
+		}
+	default:
+		return func() {
+			f.rest(http.MethodPost, gamePath(f.code, "/rounds/next"), p.ID, nil)
+		}
+	}
+}
+
+// rest 送出請求，除了 5xx 以外的回應都可以接受
+func (f *simFuzzer) rest(method, path string, playerID int64, body any) {
+	status, _, err := f.s.request(method, path, playerID, body)
+	if status == 0 || status >= http.StatusInternalServerError {
+		f.t.Errorf("%s %s as %d: status %d: %v", method, path, playerID, status, err)
+	}
+}
+
+// toggleConnection 讓一位隨機玩家斷線，或讓斷線的玩家帶著 since 重新連線
+func (f *simFuzzer) toggleConnection() {
+	p := f.players[f.rng.IntN(len(f.players))]
+	if !f.online[p] {
+		f.reconnect(p)
+		return
+	}
+
+	f.drain(p, simQuietPeriod)
+	p.disconnect()
+	f.online[p] = false
+	f.waitDisconnected(p)
+}
+
+// reconnect 讓斷線的玩家帶著 since 重新連線。遊戲已經因人數不足結束時伺服器會拒絕連線，玩家維持離線
+func (f *simFuzzer) reconnect(p *simPlayer) {
+	err := p.connect(true, nil)
+	if err == nil {
+		f.online[p] = true
+		return
+	}
+	status, statusErr := f.s.db.Games().GetGameStatusByID(context.Background(), f.gameID)
+	if statusErr != nil || status != store.GameStatusEnded {
+		f.t.Error(err)
+	}
+}
+
+// waitDisconnected 等伺服器處理完斷線，避免同一位玩家的新舊連線交錯
+func (f *simFuzzer) waitDisconnected(p *simPlayer) {
+	ctx := context.Background()
+	deadline := time.Now().Add(simEventTimeout)
+	for time.Now().Before(deadline) {
+		status, err := f.s.db.Games().GetGameStatusByID(ctx, f.gameID)
+		if err != nil || status == store.GameStatusEnded {
+			return
+		}
+		player, err := f.s.db.Players().FindByID(ctx, p.ID)
+		if errors.Is(err, errx.ErrPlayerNotFound) || (err == nil && player.Status != store.PlayerStatusOnline) {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	f.t.Errorf("%s: server did not handle the disconnect", p.Nickname)
+}
+
+// drain 讀完一位在線玩家目前收到的廣播，檢查序號並記錄內容
+func (f *simFuzzer) drain(p *simPlayer, quiet time.Duration) {
+	for {
+		e, ok, err := p.nextBroadcast(quiet)
+		if err != nil {
+			f.t.Error(err)
+		}
+		if !ok {
+			return
+		}
+		if e.Type == ws.MsgTypeStateSnapshot {
+			continue
+		}
+
+		content := e.Type + " " + string(e.Data)
+		f.mu.Lock()
+		if prev, ok := f.seen[e.Seq]; ok && prev != content {
+			f.t.Errorf("%s: seq %d is %s, another client saw %s", p.Nickname, e.Seq, content, prev)
+		}
+		f.seen[e.Seq] = content
+		f.mu.Unlock()
+	}
+}
+
+// drainAll 同時讀所有在線玩家，等待安靜期的時間不會隨人數累加
+func (f *simFuzzer) drainAll(quiet time.Duration) {
+	var wg sync.WaitGroup
+	for _, p := range f.players {
+		if f.online[p] {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				f.drain(p, quiet)
+			}()
+		}
+	}
+	wg.Wait()
+}
+
+// finish 讓所有人重新連線後結束遊戲，最後每個在線的人都要收到同樣的結尾
+func (f *simFuzzer) finish() {
+	for _, p := range f.players {
+		if !f.online[p] {
+			f.reconnect(p)
+		}
+	}
+	f.drainAll(simQuietPeriod)
+
+	f.rest(http.MethodPost, gamePath(f.code, "/end"), 0, nil)
+	f.drainAll(simQuietPeriod)
+
+	var first *simPlayer
+	for _, p := range f.players {
+		if !f.online[p] {
+			continue
+		}
+		if first == nil {
+			first = p
+		} else if p.lastSeq != first.lastSeq {
+			f.t.Errorf("%s ended at seq %d, %s at %d", p.Nickname, p.lastSeq, first.Nickname, first.lastSeq)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/y3933y3933/joker/internal/app"
+)
+
+func TestReadiness(t *testing.T) {
+	s := newSimServer(t)
+	code := s.createGame(nil)
+	joinAndConnect(s, code, "alice")
+
+	readyz := func(wantStatus int) app.Readiness {
+		t.Helper()
+		res, err := s.server.Client().Get(s.server.URL + "/api/readyz")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		if res.StatusCode != wantStatus {
+			t.Fatalf("readyz status %d, want %d", res.StatusCode, wantStatus)
+		}
+		var report app.Readiness
+		if err := json.NewDecoder(res.Body).Decode(&report); err != nil {
+			t.Fatal(err)
+		}
+		return report
+	}
+
+	// 記憶體 store 沒有資料庫，只檢查 hub
+	report := readyz(http.StatusOK)
+	if report.Status != app.StatusReady {
+		t.Errorf("status %q, want %q", report.Status, app.StatusReady)
+	}
+	if got := report.Components["database"].Status; got != app.CheckSkipped {
+		t.Errorf("database check %q, want %q", got, app.CheckSkipped)
+	}
+	hub := report.Components["hub"]
+	if hub.Status != app.CheckOK || hub.Details["rooms"] != 1 || hub.Details["connections"] != 1 {
+		t.Errorf("hub check %+v, want 1 room with 1 connection", hub)
+	}
+
+	// 開始關機後 readiness 回 503，liveness 仍然正常
+	s.app.MarkShuttingDown()
+	if report := readyz(http.StatusServiceUnavailable); report.Status != app.StatusShuttingDown {
+		t.Errorf("status %q, want %q", report.Status, app.StatusShuttingDown)
+	}
+	res, err := s.server.Client().Get(s.server.URL + "/api/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("healthz status %d during shutdown", res.StatusCode)
+	}
+}
@@ -0,0 +1,80 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/y3933y3933/joker/internal/utils/errx"
+	"github.com/y3933y3933/joker/internal/ws"
+)
+
+// hostRequest 以 p 的 X-Player-ID 與 seat 送出 host 操作，seat 為空時不帶座位憑證
+func (p *simPlayer) hostRequest(seat, method, path string, body any) error {
+	header := http.Header{}
+	if seat != "" {
+		header.Set("X-Seat-Token", seat)
+	}
+	_, _, err := p.s.requestWith(header, method, gamePath(p.code, path), p.ID, body)
+	return err
+}
+
+// command 透過 WebSocket 送出指令
+func (p *simPlayer) command(msgType string, payload any) {
+	p.t.Helper()
+	data, err := json.Marshal(payload)
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	if err := p.conn.WriteJSON(ws.WSMessage{Type: msgType, Data: data}); err != nil {
+		p.t.Fatal(err)
+	}
+}
+
+// 玩家 ID 是流水號且會出現在廣播中，只帶 host 的 X-Player-ID 不能操作房間
+func TestHostRoutesRequireSeatToken(t *testing.T) {
+	s := newSimServer(t)
+	code := s.createGame(nil)
+	players := joinAndConnect(s, code, "alice", "bob", "carol")
+	alice, bob, carol := players[0], players[1], players[2]
+	other := s.join(s.createGame(nil), "dave")
+
+	// 冒充 alice：沒有憑證、拿自己的憑證、拿別場遊戲的憑證都不行
+	impostor := &simPlayer{s: s, t: t, code: code, ID: alice.ID}
+	lock := map[string]any{"locked": true}
+	expectAPIError(t, impostor.hostRequest("", http.MethodPost, "/host/lock", lock), errx.ErrInvalidSeatToken)
+	expectAPIError(t, impostor.hostRequest(bob.SeatToken, http.MethodPost, "/host/lock", lock), errx.ErrInvalidSeatToken)
+	expectAPIError(t, impostor.hostRequest(other.SeatToken, http.MethodPost, "/host/lock", lock), errx.ErrInvalidSeatToken)
+	expectAPIError(t, impostor.hostRequest("not-a-token", http.MethodPost, "/host/kick", map[string]any{"playerID": carol.ID}), errx.ErrInvalidSeatToken)
+	// 帶自己的憑證仍然不是 host
+	expectAPIError(t, bob.hostRequest(bob.SeatToken, http.MethodPost, "/host/lock", lock), errx.ErrForbidden)
+	for _, p := range players {
+		p.expectQuiet()
+	}
+
+	if err := alice.hostRequest(alice.SeatToken, http.MethodPost, "/host/lock", lock); err != nil {
+		t.Fatal(err)
+	}
+	expectAll(players, ws.MsgRoomLocked)
+}
+
+// WebSocket 上的 host 指令同樣需要以座位憑證連線
+func TestHostCommandsRequireSeatedConnection(t *testing.T) {
+	s := newSimServer(t)
+	code := s.createGame(nil)
+	players := joinAndConnect(s, code, "alice", "bob", "carol")
+	alice, carol := players[0], players[2]
+
+	// 公開房間不帶 seat 也能以 alice 的 ID 連線，但不能送出 host 指令
+	impostor := &simPlayer{s: s, t: t, code: code, ID: alice.ID, Nickname: "impostor"}
+	impostor.mustConnect()
+	t.Cleanup(impostor.disconnect)
+	impostor.command(ws.CmdKickPlayer, ws.KickPlayerCommand{PlayerID: carol.ID})
+	impostor.expectDirect(ws.MsgTypeError)
+	for _, p := range players {
+		p.expectQuiet()
+	}
+
+	alice.command(ws.CmdLockRoom, ws.LockRoomCommand{Locked: true})
+	expectAll(players, ws.MsgRoomLocked)
+}
@@ -0,0 +1,47 @@
+package routes
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/y3933y3933/joker/internal/ws"
+)
+
+func TestMetrics(t *testing.T) {
+	s := newSimServer(t)
+	code := s.createGame(nil)
+	players := joinAndConnect(s, code, "alice", "bob", "carol")
+
+	s.call(http.MethodPost, gamePath(code, "/start"), 0, nil)
+	expectAll(players, ws.MsgTypeGameStarted)
+	s.request(http.MethodPost, "/api/accounts/login", 0, map[string]any{"username": "nobody", "password": "wrong-password"})
+
+	res, err := s.server.Client().Get(s.server.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 路由以樣板記錄，遊戲代碼不會出現在 label 裡
+	for _, want := range []string{
+		`joker_http_request_duration_seconds_count{method="POST",route="/api/games/:code/start",status="200"} 1`,
+		`joker_rounds_total{event="started"} 1`,
+		`joker_rounds_total{event="skipped"} 0`,
+		`joker_login_failures_total{kind="account"} 1`,
+		`joker_ws_rooms 1`,
+		`joker_ws_connections 3`,
+	} {
+		if !strings.Contains(string(body), want+"\n") {
+			t.Errorf("metrics missing %s", want)
+		}
+	}
+	if strings.Contains(string(body), code) {
+		t.Errorf("metrics contain the game code %s", code)
+	}
+}
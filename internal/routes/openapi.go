@@ -0,0 +1,159 @@
+package routes
+
+import (
+	"net/http"
+	"reflect"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/y3933y3933/joker/internal/api"
+	"github.com/y3933y3933/joker/internal/app"
+	"github.com/y3933y3933/joker/internal/openapi"
+	"github.com/y3933y3933/joker/internal/service"
+	"github.com/y3933y3933/joker/internal/store"
+	"github.com/y3933y3933/joker/internal/utils/httpx"
+)
+
+// OpenAPI 文件的端點目錄，SetupRoutes 新增路由時也要加在這裡，
+// TestOpenAPICoversRoutes 會檢查兩邊是否一致
+
+// 用 gin.H 回應的 data 內容
+type (
+	messageData = struct {
+		Message string `json:"message"`
+	}
+	drawData = struct {
+		Joker bool `json:"joker"`
+	}
+	seatsData = struct {
+		PlayerIDs []int64 `json:"playerIDs"`
+	}
+	lockData = struct {
+		Locked bool `json:"locked"`
+	}
+	accessData = struct {
+		Access string `json:"access"`
+	}
+	avatarsData = struct {
+		Presets []string `json:"presets"`
+	}
+	healthData = struct {
+		Status string `json:"status"`
+		Env    string `json:"env"`
+	}
+)
+
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeFor[T]()
+}
+
+var pageParams = []openapi.Param{
+	{Name: "page", Type: "integer", Description: "Page number, starting at 1. Defaults to 1."},
+	{Name: "page_size", Type: "integer", Description: "Items per page, 1-100. Defaults to 10."},
+}
+
+var (
+	player  = []string{openapi.SecurityPlayer}
+	bearer  = []string{openapi.SecurityBearer}
+	account = []string{openapi.SecurityBearer, openapi.SecurityPlayer}
+	seated  = []string{openapi.SecurityPlayer, openapi.SecuritySeat}
+)
+
+func Operations() []openapi.Operation {
+	return []openapi.Operation{
+		{Method: http.MethodGet, Path: "/api/healthz", ID: "healthCheck", Tag: "system", Summary: "Liveness: report that the process is up", Description: "Does not check dependencies, so a database outage does not get the process restarted.", Response: typeOf[healthData](), NoEnvelope: true},
+		{Method: http.MethodGet, Path: "/api/readyz", ID: "readinessCheck", Tag: "system", Summary: "Readiness: check the database, schema version and WebSocket hub", Description: "Returns 503 with the same body when any component fails or the server is shutting down.", Response: typeOf[app.Readiness](), NoEnvelope: true},
+		{Method: http.MethodGet, Path: "/api/openapi.json", ID: "getOpenAPI", Tag: "system", Summary: "This OpenAPI document", NoEnvelope: true},
+		{Method: http.MethodGet, Path: "/metrics", ID: "getMetrics", Tag: "system", Summary: "Prometheus metrics", Description: "Prometheus text exposition format. Not proxied by Caddy, scrape it on the API port.", NoEnvelope: true},
+		{Method: http.MethodGet, Path: "/api/avatars", ID: "listAvatars", Tag: "players", Summary: "List the preset avatars", Response: typeOf[avatarsData]()},
+
+		// games
+		{Method: http.MethodPost, Path: "/api/games/", ID: "createGame", Tag: "games", Summary: "Create a game", Description: "The body may be omitted for a classic game without guessing. Scoring only needs the values to change.", Request: typeOf[api.CreateGameRequest](), RequestOptional: true, Response: typeOf[store.Game]()},
+		{Method: http.MethodPost, Path: "/api/games/:code/join", ID: "joinGame", Tag: "players", Summary: "Join a game as a player", Description: "Send passcode or invite when the room requires one. Signed-in accounts send their bearer token to link the player. Keep seatToken to reconnect the WebSocket.", Security: bearer, OptionalAuth: true, Request: typeOf[api.JoinGameRequest](), Response: typeOf[api.JoinResponse]()},
+		{Method: http.MethodPost, Path: "/api/games/:code/spectate", ID: "spectateGame", Tag: "players", Summary: "Join a game as a spectator", Security: bearer, OptionalAuth: true, Request: typeOf[api.JoinGameRequest](), Response: typeOf[api.JoinResponse]()},
+		{Method: http.MethodPost, Path: "/api/games/:code/spectators/:id/promote", ID: "promoteSpectator", Tag: "host", Summary: "Host: move a spectator into the game", Security: seated, Response: typeOf[store.Player]()},
+		{Method: http.MethodPatch, Path: "/api/games/:code/players/me", ID: "updateProfile", Tag: "players", Summary: "Change your nickname or avatar before the game starts", Security: player, Request: typeOf[service.ProfileUpdate](), Response: typeOf[store.Player]()},
+		{Method: http.MethodGet, Path: "/api/games/:code/players", ID: "listPlayers", Tag: "players", Summary: "List the players and spectators in a game", Response: typeOf[service.PlayerList]()},
+		{Method: http.MethodPost, Path: "/api/games/:code/start", ID: "startGame", Tag: "rounds", Summary: "Start the game with its first round", Response: typeOf[store.Round]()},
+		{Method: http.MethodGet, Path: "/api/games/:code/questions", ID: "listRandomQuestions", Tag: "rounds", Summary: "Draw random questions to pick from", Query: []openapi.Param{{Name: "limit", Type: "integer", Description: "Number of questions. Defaults to 3."}}, Response: typeOf[[]store.Question]()},
+		{Method: http.MethodPost, Path: "/api/games/:code/end", ID: "endGame", Tag: "games", Summary: "End the game", Response: typeOf[messageData]()},
+		{Method: http.MethodGet, Path: "/api/games/:code/summary", ID: "getGameSummary", Tag: "games", Summary: "Summary of a game", Response: typeOf[store.GameSummary]()},
+		{Method: http.MethodPost, Path: "/api/games/:code/rematch", ID: "rematch", Tag: "games", Summary: "Start a new game with the same settings and players", Security: player, Response: typeOf[api.RematchResponse]()},
+		{Method: http.MethodPost, Path: "/api/games/:code/players/leave", ID: "leaveGame", Tag: "players", Summary: "Leave a game that has not started", Security: player},
+
+		// host
+		{Method: http.MethodPost, Path: "/api/games/:code/host/kick", ID: "kickPlayer", Tag: "host", Summary: "Host: remove a player and ban the nickname", Security: seated, Request: typeOf[api.HostTargetRequest](), Response: typeOf[service.KickResult]()},
+		{Method: http.MethodPost, Path: "/api/games/:code/host/transfer", ID: "transferHost", Tag: "host", Summary: "Host: hand the host role to another player", Security: seated, Request: typeOf[api.HostTargetRequest](), Response: typeOf[store.Player]()},
+		{Method: http.MethodPut, Path: "/api/games/:code/host/seats", ID: "reorderSeats", Tag: "host", Summary: "Host: set the seating order", Security: seated, Request: typeOf[api.ReorderSeatsRequest](), Response: typeOf[seatsData]()},
+		{Method: http.MethodPost, Path: "/api/games/:code/host/lock", ID: "lockRoom", Tag: "host", Summary: "Host: lock or unlock the room to new joins", Security: seated, Request: typeOf[api.LockRoomRequest](), Response: typeOf[lockData]()},
+		{Method: http.MethodPut, Path: "/api/games/:code/host/access", ID: "setRoomAccess", Tag: "host", Summary: "Host: make the room public, passcode-protected or invite-only", Security: seated, Request: typeOf[api.RoomAccessRequest](), Response: typeOf[accessData]()},
+		{Method: http.MethodPost, Path: "/api/games/:code/host/invites", ID: "createInvite", Tag: "host", Summary: "Host: create an expiring invite token", Security: seated, Request: typeOf[api.CreateInviteRequest](), RequestOptional: true, Response: typeOf[service.Invite]()},
+
+		// rounds
+		{Method: http.MethodPost, Path: "/api/games/:code/rounds/:id/question", ID: "submitQuestion", Tag: "rounds", Summary: "Questioner: pick the question", Security: player, Request: typeOf[api.SubmitQuestionRequest](), Response: typeOf[messageData]()},
+		{Method: http.MethodPost, Path: "/api/games/:code/rounds/:id/answer", ID: "submitAnswer", Tag: "rounds", Summary: "Answerer: answer out loud", Description: "With guessing enabled, aboutPlayerID and truthful are required.", Security: player, Request: typeOf[api.SubmitAnswerRequest](), Response: typeOf[messageData]()},
+		{Method: http.MethodPost, Path: "/api/games/:code/rounds/:id/draw", ID: "drawCard", Tag: "rounds", Summary: "Answerer: draw a card", Security: player, Request: typeOf[api.DrawCardRequest](), Response: typeOf[drawData]()},
+		{Method: http.MethodPost, Path: "/api/games/:code/rounds/:id/vote", ID: "vote", Tag: "rounds", Summary: "Vote on the answer or on revealing the question", Security: player, Request: typeOf[service.Ballot](), Response: typeOf[service.VoteResult]()},
+		{Method: http.MethodPost, Path: "/api/games/:code/rounds/next", ID: "createNextRound", Tag: "rounds", Summary: "Start the next round", Security: player, Response: typeOf[store.Round]()},
+
+		// accounts
+		{Method: http.MethodPost, Path: "/api/accounts", ID: "registerAccount", Tag: "accounts", Summary: "Create a player account", Request: typeOf[api.AccountRequest](), Response: typeOf[store.Account]()},
+		{Method: http.MethodPost, Path: "/api/accounts/login", ID: "loginAccount", Tag: "accounts", Summary: "Sign in to a player account", Request: typeOf[api.AccountRequest](), Response: typeOf[api.LoginResponse]()},
+		{Method: http.MethodGet, Path: "/api/me", ID: "getMe", Tag: "accounts", Summary: "The signed-in account", Security: bearer, Response: typeOf[store.Account]()},
+		{Method: http.MethodGet, Path: "/api/me/games", ID: "listMyGames", Tag: "accounts", Summary: "Games the account played", Security: bearer, Query: pageParams, Response: typeOf[store.PaginatedAccountGame]()},
+		{Method: http.MethodGet, Path: "/api/me/stats", ID: "getMyStats", Tag: "accounts", Summary: "Totals across the account's games", Security: bearer, Response: typeOf[store.AccountStats]()},
+		{Method: http.MethodGet, Path: "/api/me/favorites", ID: "listFavorites", Tag: "accounts", Summary: "Favorite questions", Security: bearer, Response: typeOf[[]store.FavoriteQuestion]()},
+		{Method: http.MethodPost, Path: "/api/me/favorites", ID: "addFavorite", Tag: "accounts", Summary: "Add a favorite question", Security: bearer, Request: typeOf[api.FavoriteRequest](), Response: typeOf[messageData]()},
+		{Method: http.MethodDelete, Path: "/api/me/favorites/:id", ID: "removeFavorite", Tag: "accounts", Summary: "Remove a favorite question", Security: bearer, Response: typeOf[messageData]()},
+		{Method: http.MethodPost, Path: "/api/me/players/claim", ID: "claimPlayer", Tag: "accounts", Summary: "Link an anonymously joined player to the account", Description: "Send the seatToken returned when the player joined as proof that X-Player-ID is yours.", Security: account, Request: typeOf[api.ClaimPlayerRequest](), Response: typeOf[store.Player]()},
+
+		{Method: http.MethodPost, Path: "/api/feedback", ID: "createFeedback", Tag: "feedback", Summary: "Send feedback", Request: typeOf[api.CreateFeedbackRequest]()},
+		{Method: http.MethodGet, Path: "/ws/games/:code", ID: "connectWebSocket", Tag: "websocket", Summary: "Open the game's WebSocket", Description: "Upgrades to a WebSocket. Messages are described by docs/ws/protocol.schema.json. Protected rooms need seat, passcode or invite.", NoEnvelope: true, Query: []openapi.Param{
+			{Name: "player_id", Type: "integer", Description: "Player ID returned when joining."},
+			{Name: "seat", Type: "string", Description: "seatToken returned when joining. Host commands are only accepted on connections opened with it."},
+			{Name: "passcode", Type: "string"},
+			{Name: "invite", Type: "string"},
+			{Name: "since", Type: "integer", Description: "Last seq received, to replay missed events on reconnect."},
+			{Name: "protocol", Type: "integer", Description: "Protocol version. Defaults to the latest."},
+			{Name: "batch", Type: "string", Description: "1 to receive events sent together in one batch frame."},
+		}},
+
+		// admin
+		{Method: http.MethodPost, Path: "/api/admin/login", ID: "adminLogin", Tag: "admin", Summary: "Sign in to the admin console", Request: typeOf[api.LoginRequest](), Response: typeOf[api.LoginResponse]()},
+		{Method: http.MethodGet, Path: "/api/admin/users", ID: "adminGetUser", Tag: "admin", Summary: "The signed-in admin user", Security: bearer, Response: typeOf[store.User]()},
+		{Method: http.MethodGet, Path: "/api/admin/dashboard", ID: "adminDashboard", Tag: "admin", Summary: "Dashboard totals", Security: bearer, Response: typeOf[service.DashboardData]()},
+		{Method: http.MethodGet, Path: "/api/admin/questions", ID: "adminListQuestions", Tag: "admin", Summary: "Search questions", Security: bearer, Query: append([]openapi.Param{
+			{Name: "keyword", Type: "string"},
+			{Name: "level", Type: "string", Description: "normal or spicy"},
+			{Name: "sort_by", Type: "string", Description: "created_at_desc (default) or created_at_asc"},
+		}, pageParams...), Response: typeOf[store.PaginatedQuestion]()},
+		{Method: http.MethodPost, Path: "/api/admin/questions", ID: "adminCreateQuestion", Tag: "admin", Summary: "Create a question", Security: bearer, Request: typeOf[api.CreateQuestionRequest](), Response: typeOf[store.Question]()},
+		{Method: http.MethodPatch, Path: "/api/admin/questions/:id", ID: "adminUpdateQuestion", Tag: "admin", Summary: "Update a question", Security: bearer, Request: typeOf[api.UpdateQuestionRequest](), Response: typeOf[store.Question]()},
+		{Method: http.MethodDelete, Path: "/api/admin/questions/:id", ID: "adminDeleteQuestion", Tag: "admin", Summary: "Delete a question", Security: bearer},
+		{Method: http.MethodGet, Path: "/api/admin/feedback", ID: "adminListFeedback", Tag: "admin", Summary: "List feedback", Security: bearer, Query: append([]openapi.Param{
+			{Name: "type", Type: "string", Description: "feature, issue or other"},
+			{Name: "reviewStatus", Type: "string"},
+		}, pageParams...), Response: typeOf[store.PaginatedFeedback]()},
+		{Method: http.MethodGet, Path: "/api/admin/feedback/:id", ID: "adminGetFeedback", Tag: "admin", Summary: "Get one feedback", Security: bearer, Response: typeOf[store.Feedback]()},
+		{Method: http.MethodPatch, Path: "/api/admin/feedback/:id/review-status", ID: "adminReviewFeedback", Tag: "admin", Summary: "Set the review status of feedback", Security: bearer, Request: typeOf[api.UpdateReviewStatusRequest]()},
+		{Method: http.MethodGet, Path: "/api/admin/games", ID: "adminListGames", Tag: "admin", Summary: "List games", Security: bearer, Query: append([]openapi.Param{
+			{Name: "code", Type: "string"},
+			{Name: "status", Type: "string", Description: "waiting, playing or ended"},
+		}, pageParams...), Response: typeOf[store.PaginatedGame]()},
+		{Method: http.MethodPost, Path: "/api/admin/games/end", ID: "adminEndGame", Tag: "admin", Summary: "End a game by code", Security: bearer, Request: typeOf[api.AdminEndGameRequest](), Response: typeOf[messageData]()},
+	}
+}
+
+// openAPIDocument 只在第一次請求時產生
+var openAPIDocument = sync.OnceValues(func() ([]byte, error) {
+	return openapi.Document(openapi.Info{Title: "Joker API", Version: "1"}, Operations())
+})
+
+func serveOpenAPI(c *gin.Context) {
+	doc, err := openAPIDocument()
+	if err != nil {
+		httpx.Error(c, err)
+		return
+	}
+	c.Data(http.StatusOK, "application/json", doc)
+}
@@ -0,0 +1,97 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestOpenAPICoversRoutes 每條路由都要在 Operations() 中，文件也不能留著已移除的路由
+func TestOpenAPICoversRoutes(t *testing.T) {
+	s := newSimServer(t)
+
+	documented := make(map[string]bool)
+	ids := make(map[string]bool)
+	for _, op := range Operations() {
+		if documented[op.RouteKey()] {
+			t.Errorf("%s is documented twice", op.RouteKey())
+		}
+		documented[op.RouteKey()] = true
+
+		if op.ID == "" || ids[op.ID] {
+			t.Errorf("%s: operation ID %q is empty or reused", op.RouteKey(), op.ID)
+		}
+		ids[op.ID] = true
+		if op.Summary == "" {
+			t.Errorf("%s: missing summary", op.RouteKey())
+		}
+	}
+
+	for _, route := range s.router.Routes() {
+		key := route.Method + " " + route.Path
+		if !documented[key] {
+			t.Errorf("%s has no OpenAPI documentation, add it to Operations()", key)
+		}
+		delete(documented, key)
+	}
+	for key := range documented {
+		t.Errorf("%s is documented but not routed", key)
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	s := newSimServer(t)
+
+	res, err := s.server.Client().Get(s.server.URL + "/api/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("status %d", res.StatusCode)
+	}
+
+	var doc map[string]any
+	if err := json.NewDecoder(res.Body).Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc["openapi"] != "3.1.0" {
+		t.Errorf("openapi = %v, want 3.1.0", doc["openapi"])
+	}
+
+	// 所有 $ref 都要指到存在的 component
+	components := doc["components"].(map[string]any)
+	var walk func(path string, v any)
+	walk = func(path string, v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			if ref, ok := v["$ref"].(string); ok {
+				kind, name, _ := strings.Cut(strings.TrimPrefix(ref, "#/components/"), "/")
+				group, _ := components[kind].(map[string]any)
+				if group[name] == nil {
+					t.Errorf("%s: unresolved $ref %s", path, ref)
+				}
+			}
+			for k, child := range v {
+				walk(path+"/"+k, child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(path, child)
+			}
+		}
+	}
+	walk("", doc)
+
+	// request 依 binding tag 決定必填欄位
+	schemas := components["schemas"].(map[string]any)
+	draw := schemas["DrawCardRequest"].(map[string]any)
+	if required := draw["required"].([]any); len(required) != 1 || required[0] != "index" {
+		t.Errorf("DrawCardRequest required = %v, want [index]", required)
+	}
+	feedback := schemas["CreateFeedbackRequest"].(map[string]any)["properties"].(map[string]any)
+	if enum := feedback["type"].(map[string]any)["enum"]; len(enum.([]any)) != 3 {
+		t.Errorf("CreateFeedbackRequest.type enum = %v, want feature, issue and other", enum)
+	}
+}
@@ -3,12 +3,30 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/y3933y3933/joker/internal/app"
+	"github.com/y3933y3933/joker/internal/middleware"
+	"github.com/y3933y3933/joker/internal/utils/errx"
+	"github.com/y3933y3933/joker/internal/utils/httpx"
 )
 
 func SetupRoutes(app *app.Application) *gin.Engine {
-	router := gin.Default()
-
+	router := gin.New()
+	// 錯誤統一由 ErrorHandler 轉成 {"error": {"code", "message", "details", "requestId"}}
+	httpx.UseJSONFieldNames()
+	router.Use(app.Metrics.Middleware(), middleware.Tracing(), middleware.RequestID(), middleware.RequestLogger(app.Logger), middleware.ErrorHandler(), middleware.Recovery())
+	router.NoRoute(func(c *gin.Context) {
+		httpx.Error(c, errx.ErrRouteNotFound)
+	})
+
+	// liveness 與 readiness，Caddy 以 readyz 決定是否轉發
 	router.GET("/api/healthz", app.HealthCheck)
+	router.GET("/api/readyz", app.ReadinessCheck)
+	// Prometheus 指標，不在 /api 底下，Caddy 不會對外轉發
+	router.GET("/metrics", app.Metrics.Handler())
+	// 由 Operations() 與 Go 型別產生的 OpenAPI 文件
+	router.GET("/api/openapi.json", serveOpenAPI)
+
+	// 可選的預設頭像
+	router.GET("/api/avatars", app.PlayerHandler.HandleListAvatars)
 
 	// games
 	games := router.Group("/api/games")
@@ -18,7 +36,13 @@ func SetupRoutes(app *app.Application) *gin.Engine {
 	codes := games.Group("/:code", app.MiddlewareHandler.ValidateGameExists())
 	{
 		// 加入遊戲
-		codes.POST("/join", app.PlayerHandler.HandleJoinGame)
+		codes.POST("/join", app.MiddlewareHandler.OptionalAccount(), app.PlayerHandler.HandleJoinGame)
+		// 以觀戰者身分加入
+		codes.POST("/spectate", app.MiddlewareHandler.OptionalAccount(), app.PlayerHandler.HandleSpectateGame)
+		// Host 將觀戰者轉為玩家
+		codes.POST("/spectators/:id/promote", app.MiddlewareHandler.WithPlayerID(), app.MiddlewareHandler.RequireSeat(), app.PlayerHandler.HandlePromoteSpectator)
+		// 修改自己的暱稱與頭像（遊戲開始前）
+		codes.PATCH("/players/me", app.MiddlewareHandler.WithPlayerID(), app.PlayerHandler.HandleUpdateProfile)
 		// 查看所有玩家
 		codes.GET("/players", app.PlayerHandler.HandleListPlayers)
 		// 開始遊戲
@@ -32,9 +56,29 @@ func SetupRoutes(app *app.Application) *gin.Engine {
 
 		codes.GET("/summary", app.GameHandler.GetGameSummary)
 
+		// 再來一局：同樣的設定與玩家開新遊戲
+		codes.POST("/rematch", app.MiddlewareHandler.WithPlayerID(), app.GameHandler.HandleRematch)
+
 		// 離開遊戲（含 Host 轉移）
 		codes.POST("/players/leave", app.MiddlewareHandler.WithPlayerID(), app.PlayerHandler.HandleLeaveGame)
 
+		// host 操作需要座位憑證，只知道 X-Player-ID 不夠
+		host := codes.Group("/host", app.MiddlewareHandler.WithPlayerID(), app.MiddlewareHandler.RequireSeat())
+		{
+			// 踢出玩家並禁止同暱稱再加入
+			host.POST("/kick", app.HostHandler.HandleKickPlayer)
+			// 指定新的 Host
+			host.POST("/transfer", app.HostHandler.HandleTransferHost)
+			// 調整座位（輪替）順序
+			host.PUT("/seats", app.HostHandler.HandleReorderSeats)
+			// 鎖定／解鎖房間
+			host.POST("/lock", app.HostHandler.HandleLockRoom)
+			// 設定密碼或只限邀請
+			host.PUT("/access", app.HostHandler.HandleSetRoomAccess)
+			// 產生有期限的邀請連結
+			host.POST("/invites", app.HostHandler.HandleCreateInvite)
+		}
+
 		rounds := codes.Group("/rounds", app.MiddlewareHandler.WithPlayerID())
 		{
 			// 更新題目
@@ -46,6 +90,9 @@ func SetupRoutes(app *app.Application) *gin.Engine {
 			// 抽牌
 			rounds.POST("/:id/draw", app.RoundHandler.HandleDrawCard)
 
+			// 投票：猜測回答，或決定是否公開題目（vote_reveal 玩法）
+			rounds.POST("/:id/vote", app.RoundHandler.HandleVote)
+
 			// 下一回合
 			rounds.POST("/next", app.RoundHandler.HandleCreateNextRound)
 
@@ -53,6 +100,24 @@ func SetupRoutes(app *app.Application) *gin.Engine {
 
 	}
 
+	// accounts：可選的玩家帳號
+	router.POST("/api/accounts", app.AccountHandler.HandleRegister)
+	router.POST("/api/accounts/login", app.AccountHandler.HandleLogin)
+
+	me := router.Group("/api/me", app.MiddlewareHandler.OptionalAccount(), app.MiddlewareHandler.RequireAccount())
+	{
+		me.GET("", app.AccountHandler.HandleGetMe)
+		// 玩過的遊戲與累計數據
+		me.GET("/games", app.AccountHandler.HandleListGames)
+		me.GET("/stats", app.AccountHandler.HandleGetStats)
+		// 收藏的題目
+		me.GET("/favorites", app.AccountHandler.HandleListFavorites)
+		me.POST("/favorites", app.AccountHandler.HandleAddFavorite)
+		me.DELETE("/favorites/:id", app.AccountHandler.HandleRemoveFavorite)
+		// 將匿名加入的玩家（X-Player-ID）歸屬到帳號
+		me.POST("/players/claim", app.MiddlewareHandler.WithPlayerID(), app.AccountHandler.HandleClaimPlayer)
+	}
+
 	router.POST("/api/feedback", app.FeedbackHandler.HandleCreateFeedback)
 	// ws
 	router.GET("/ws/games/:code", app.WSHandler.ServeWS)
@@ -0,0 +1,447 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/y3933y3933/joker/internal/api"
+	"github.com/y3933y3933/joker/internal/app"
+	"github.com/y3933y3933/joker/internal/service"
+	"github.com/y3933y3933/joker/internal/store"
+	"github.com/y3933y3933/joker/internal/utils/httpx"
+	"github.com/y3933y3933/joker/internal/ws"
+)
+
+// 模擬器等待事件的時間上限，以及判斷「沒有更多事件」時等待的時間
+const (
+	simEventTimeout = 2 * time.Second
+	simQuietPeriod  = 100 * time.Millisecond
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+// simServer 以記憶體版本的 store 啟動完整的 router，供模擬玩家透過 REST 與 WebSocket 操作
+type simServer struct {
+	t         *testing.T
+	db        *store.MemoryDB
+	app       *app.Application
+	router    *gin.Engine
+	server    *httptest.Server
+	questions []*store.Question
+}
+
+func newSimServer(t *testing.T) *simServer {
+	t.Helper()
+
+	db := store.NewMemoryDB()
+	s := &simServer{t: t, db: db}
+	for i := range 5 {
+		q, err := db.Questions().Create(context.Background(), fmt.Sprintf("question %d", i+1), store.QuestionLevelNormal)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.questions = append(s.questions, q)
+	}
+
+	stores := db.Stores()
+	application := app.New(app.Config{
+		Env:           "test",
+		JWT_SECRET:    "simulator-secret",
+		ChatBlocklist: "darn",
+		Janitor:       service.DefaultJanitorConfig,
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)), app.Stores{
+		Games:     stores.Games,
+		Players:   stores.Players,
+		Rounds:    stores.Rounds,
+		Votes:     stores.Votes,
+		Questions: db.Questions(),
+		Feedback:  db.Feedback(),
+		Users:     db.Users(),
+		Accounts:  db.Accounts(),
+		Tx:        stores.Tx,
+	})
+
+	s.app = application
+	s.router = SetupRoutes(application)
+	s.server = httptest.NewServer(s.router)
+	t.Cleanup(s.server.Close)
+	return s
+}
+
+// request 送出 REST 請求，回傳狀態碼與回應中的 data；playerID 為 0 時不帶 X-Player-ID
+func (s *simServer) request(method, path string, playerID int64, body any) (int, json.RawMessage, error) {
+	return s.requestAs("", method, path, playerID, body)
+}
+
+// requestAs 和 request 相同，token 不為空時以該帳號登入
+func (s *simServer) requestAs(token, method, path string, playerID int64, body any) (int, json.RawMessage, error) {
+	header := http.Header{}
+	if token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+	return s.requestWith(header, method, path, playerID, body)
+}
+
+// requestWith 和 request 相同，另外帶上 header
+func (s *simServer) requestWith(header http.Header, method, path string, playerID int64, body any) (int, json.RawMessage, error) {
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return 0, nil, err
+		}
+		reader = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, s.server.URL+path, reader)
+	if err != nil {
+		return 0, nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if playerID != 0 {
+		req.Header.Set("X-Player-ID", strconv.FormatInt(playerID, 10))
+	}
+
+	res, err := s.server.Client().Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer res.Body.Close()
+
+	var envelope struct {
+		Data  json.RawMessage  `json:"data"`
+		Error *httpx.ErrorBody `json:"error"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&envelope); err != nil {
+		return res.StatusCode, nil, fmt.Errorf("%s %s: decode response: %w", method, path, err)
+	}
+	if envelope.Error != nil {
+		return res.StatusCode, nil, &simAPIError{method: method, path: path, status: res.StatusCode, ErrorBody: *envelope.Error}
+	}
+	return res.StatusCode, envelope.Data, nil
+}
+
+// simAPIError 伺服器回應的錯誤內容
+type simAPIError struct {
+	method, path string
+	status       int
+	httpx.ErrorBody
+}
+
+func (e *simAPIError) Error() string {
+	return fmt.Sprintf("%s %s: %d %s: %s", e.method, e.path, e.status, e.Code, e.Message)
+}
+
+// call 送出請求並要求回應 200
+func (s *simServer) call(method, path string, playerID int64, body any) json.RawMessage {
+	s.t.Helper()
+	status, data, err := s.request(method, path, playerID, body)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	if status != http.StatusOK {
+		s.t.Fatalf("%s %s: status %d", method, path, status)
+	}
+	return data
+}
+
+func (s *simServer) createGame(options map[string]any) string {
+	s.t.Helper()
+	game := decode[store.Game](s.t, s.call(http.MethodPost, "/api/games/", 0, options))
+	return game.Code
+}
+
+// join 透過 REST 加入遊戲，尚未連上 WebSocket
+func (s *simServer) join(code, nickname string) *simPlayer {
+	s.t.Helper()
+	joined := decode[api.JoinResponse](s.t, s.call(http.MethodPost, "/api/games/"+code+"/join", 0, map[string]any{
+		"nickname": nickname,
+	}))
+	return &simPlayer{s: s, t: s.t, code: code, ID: joined.ID, Nickname: nickname, SeatToken: joined.SeatToken}
+}
+
+func decode[T any](t *testing.T, data []byte) T {
+	t.Helper()
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatalf("decode %T: %v (%s)", v, err, data)
+	}
+	return v
+}
+
+// simEvent 是 client 收到的一個事件，batch 會被拆成個別事件
+type simEvent struct {
+	Type string          `json:"type"`
+	Seq  uint64          `json:"seq"`
+	Data json.RawMessage `json:"data"`
+}
+
+// simInbox 收集一條連線收到的事件：有序號的房間廣播與沒有序號的私訊分開排隊，
+// 兩者之間的先後伺服器不保證
+type simInbox struct {
+	mu        sync.Mutex
+	broadcast []simEvent
+	direct    []simEvent
+	closed    bool
+	notify    chan struct{}
+}
+
+func newSimInbox() *simInbox {
+	return &simInbox{notify: make(chan struct{}, 1)}
+}
+
+func (in *simInbox) push(e simEvent) {
+	in.mu.Lock()
+	// state_snapshot 的序號是重新同步的起點，和廣播放在同一個佇列
+	if e.Seq > 0 || e.Type == ws.MsgTypeStateSnapshot {
+		in.broadcast = append(in.broadcast, e)
+	} else {
+		in.direct = append(in.direct, e)
+	}
+	in.mu.Unlock()
+	in.wake()
+}
+
+func (in *simInbox) close() {
+	in.mu.Lock()
+	in.closed = true
+	in.mu.Unlock()
+	in.wake()
+}
+
+func (in *simInbox) wake() {
+	select {
+	case in.notify <- struct{}{}:
+	default:
+	}
+}
+
+// next 取出下一個廣播或私訊，逾時或連線已關閉且沒有剩餘事件時回傳 false
+func (in *simInbox) next(direct bool, timeout time.Duration) (simEvent, bool) {
+	deadline := time.After(timeout)
+	for {
+		in.mu.Lock()
+		queue := &in.broadcast
+		if direct {
+			queue = &in.direct
+		}
+		if len(*queue) > 0 {
+			e := (*queue)[0]
+			*queue = (*queue)[1:]
+			in.mu.Unlock()
+			return e, true
+		}
+		closed := in.closed
+		in.mu.Unlock()
+		if closed {
+			return simEvent{}, false
+		}
+
+		select {
+		case <-in.notify:
+		case <-deadline:
+			return simEvent{}, false
+		}
+	}
+}
+
+// simPlayer 是一位模擬玩家，透過 REST 操作遊戲並以真實的 WebSocket 連線接收事件
+type simPlayer struct {
+	s         *simServer
+	t         *testing.T
+	code      string
+	ID        int64
+	Nickname  string
+	SeatToken string
+
+	conn    *websocket.Conn
+	inbox   *simInbox
+	lastSeq uint64            // 最後一個處理過的廣播序號，重連時作為 since
+	welcome ws.WelcomePayload // 最近一次首次連線收到的 welcome
+}
+
+// connect 連上 WebSocket 並等待 welcome，resume 時帶上 since 補發錯過的事件
+func (p *simPlayer) connect(resume bool, query url.Values) error {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("player_id", strconv.FormatInt(p.ID, 10))
+	query.Set("seat", p.SeatToken)
+	if resume {
+		query.Set("since", strconv.FormatUint(p.lastSeq, 10))
+	}
+
+	u := "ws" + strings.TrimPrefix(p.s.server.URL, "http") + "/ws/games/" + p.code + "?" + query.Encode()
+	conn, res, err := websocket.DefaultDialer.Dial(u, http.Header{"Origin": []string{"http://localhost:3000"}})
+	if err != nil {
+		if res != nil {
+			return fmt.Errorf("%s: dial: %w (status %d)", p.Nickname, err, res.StatusCode)
+		}
+		return fmt.Errorf("%s: dial: %w", p.Nickname, err)
+	}
+
+	inbox := newSimInbox()
+	go readSimEvents(conn, inbox)
+
+	welcome, ok := inbox.next(true, simEventTimeout)
+	if !ok || welcome.Type != ws.MsgTypeWelcome {
+		conn.Close()
+		return fmt.Errorf("%s: expected welcome, got %+v", p.Nickname, welcome)
+	}
+	if !resume {
+		var payload ws.WelcomePayload
+		if err := json.Unmarshal(welcome.Data, &payload); err != nil {
+			conn.Close()
+			return err
+		}
+		p.lastSeq = payload.Seq
+		p.welcome = payload
+	}
+
+	p.conn = conn
+	p.inbox = inbox
+	return nil
+}
+
+func (p *simPlayer) mustConnect() {
+	p.t.Helper()
+	if err := p.connect(false, nil); err != nil {
+		p.t.Fatal(err)
+	}
+	p.t.Cleanup(p.disconnect)
+}
+
+// disconnect 關閉 WebSocket，伺服器會在讀取失敗後處理斷線
+func (p *simPlayer) disconnect() {
+	if p.conn == nil {
+		return
+	}
+	_ = p.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+	_ = p.conn.Close()
+	p.conn = nil
+}
+
+func readSimEvents(conn *websocket.Conn, inbox *simInbox) {
+	defer inbox.close()
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var e simEvent
+		if err := json.Unmarshal(data, &e); err != nil {
+			return
+		}
+		if e.Type != ws.MsgTypeBatch {
+			inbox.push(e)
+			continue
+		}
+
+		var batch []simEvent
+		if err := json.Unmarshal(e.Data, &batch); err != nil {
+			return
+		}
+		for _, b := range batch {
+			inbox.push(b)
+		}
+	}
+}
+
+// nextBroadcast 取出下一個房間廣播並檢查序號連續
+func (p *simPlayer) nextBroadcast(timeout time.Duration) (simEvent, bool, error) {
+	e, ok := p.inbox.next(false, timeout)
+	if !ok {
+		return e, false, nil
+	}
+	if e.Type == ws.MsgTypeStateSnapshot {
+		p.lastSeq = e.Seq
+		return e, true, nil
+	}
+	if e.Seq != p.lastSeq+1 {
+		return e, true, fmt.Errorf("%s: %s has seq %d, want %d", p.Nickname, e.Type, e.Seq, p.lastSeq+1)
+	}
+	p.lastSeq = e.Seq
+	return e, true, nil
+}
+
+// expect 要求依序收到這些房間廣播
+func (p *simPlayer) expect(types ...string) []simEvent {
+	p.t.Helper()
+	events := make([]simEvent, len(types))
+	for i, want := range types {
+		e, ok, err := p.nextBroadcast(simEventTimeout)
+		if err != nil {
+			p.t.Fatal(err)
+		}
+		if !ok {
+			p.t.Fatalf("%s: timed out waiting for %s (event %d of %v)", p.Nickname, want, i+1, types)
+		}
+		if e.Type != want {
+			p.t.Fatalf("%s: got %s %s, want %s (event %d of %v)", p.Nickname, e.Type, e.Data, want, i+1, types)
+		}
+		events[i] = e
+	}
+	return events
+}
+
+// expectDirect 要求依序收到這些私訊
+func (p *simPlayer) expectDirect(types ...string) []simEvent {
+	p.t.Helper()
+	events := make([]simEvent, len(types))
+	for i, want := range types {
+		e, ok := p.inbox.next(true, simEventTimeout)
+		if !ok {
+			p.t.Fatalf("%s: timed out waiting for direct message %s", p.Nickname, want)
+		}
+		if e.Type != want {
+			p.t.Fatalf("%s: got direct message %s %s, want %s", p.Nickname, e.Type, e.Data, want)
+		}
+		events[i] = e
+	}
+	return events
+}
+
+// expectQuiet 要求一段時間內沒有收到任何事件
+func (p *simPlayer) expectQuiet() {
+	p.t.Helper()
+	time.Sleep(simQuietPeriod)
+	p.inbox.mu.Lock()
+	defer p.inbox.mu.Unlock()
+	if n := len(p.inbox.broadcast) + len(p.inbox.direct); n > 0 {
+		p.t.Fatalf("%s: %d unexpected events: %+v %+v", p.Nickname, n, p.inbox.broadcast, p.inbox.direct)
+	}
+}
+
+// expectAll 要求每位玩家都依序收到這些廣播，回傳第一位玩家收到的事件
+func expectAll(players []*simPlayer, types ...string) []simEvent {
+	var first []simEvent
+	for i, p := range players {
+		events := p.expect(types...)
+		if i == 0 {
+			first = events
+		}
+	}
+	return first
+}
@@ -0,0 +1,51 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/y3933y3933/joker/internal/ws"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	s := newSimServer(t)
+	code := s.createGame(nil)
+	players := joinAndConnect(s, code, "alice", "bob", "carol")
+	s.call(http.MethodPost, gamePath(code, "/start"), 0, nil)
+	expectAll(players, ws.MsgTypeGameStarted)
+
+	// 同一個請求的 service 與推播都在 HTTP span 底下，實際推播在 Room.Run 裡結束，稍等一下
+	request := findSpan(t, recorder, "/api/games/:code/start", nil)
+	findSpan(t, recorder, "RoundService.StartGame", request)
+	broadcast := findSpan(t, recorder, "Room.Broadcast", request)
+	findSpan(t, recorder, "Room.fanout", broadcast)
+}
+
+// findSpan 等待指定名稱、且 parent 為 parent 的 span 結束，parent 為 nil 時不檢查
+func findSpan(t *testing.T, recorder *tracetest.SpanRecorder, name string, parent sdktrace.ReadOnlySpan) sdktrace.ReadOnlySpan {
+	t.Helper()
+	deadline := time.Now().Add(simEventTimeout)
+	for time.Now().Before(deadline) {
+		for _, span := range recorder.Ended() {
+			if span.Name() != name {
+				continue
+			}
+			if parent == nil || span.Parent().SpanID() == parent.SpanContext().SpanID() {
+				return span
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("no span %s found", name)
+	return nil
+}
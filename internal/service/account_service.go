@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/y3933y3933/joker/internal/store"
+	"github.com/y3933y3933/joker/internal/utils/errx"
+)
+
+// 玩家帳號的 token 帶有這個 audience，後台的 Authenticate 不接受
+const accountAudience = "player"
+
+const (
+	accountTokenTTL        = 30 * 24 * time.Hour
+	minPasswordLength      = 8
+	maxAccountPageSize     = 50
+	defaultAccountPageSize = 10
+)
+
+var accountUsernamePattern = regexp.MustCompile(`^[A-Za-z0-9_]{3,32}$`)
+
+type AccountClaims struct {
+	AccountID int64  `json:"account_id"`
+	Username  string `json:"username"`
+	jwt.RegisteredClaims
+}
+
+// AccountService 處理可選的玩家帳號：登入後建立的玩家會歸屬到帳號，用來查看歷史紀錄
+type AccountService struct {
+	accountStore store.AccountStore
+	jwtSecret    []byte
+	metrics      Metrics
+}
+
+func NewAccountService(accountStore store.AccountStore, jwtSecret []byte, metrics Metrics) *AccountService {
+	return &AccountService{accountStore: accountStore, jwtSecret: jwtSecret, metrics: metrics}
+}
+
+func (s *AccountService) Register(ctx context.Context, username, password string) (*store.Account, error) {
+	ctx, span := tracer.Start(ctx, "AccountService.Register")
+	defer span.End()
+
+	if !accountUsernamePattern.MatchString(username) || len(password) < minPasswordLength {
+		return nil, errx.ErrInvalidAccountInput
+	}
+
+	account := &store.Account{Username: username}
+	err := account.Password.Set(password)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.accountStore.Create(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+func (s *AccountService) Login(ctx context.Context, username, password string) (string, error) {
+	ctx, span := tracer.Start(ctx, "AccountService.Login")
+	defer span.End()
+
+	account, err := s.accountStore.GetByUsername(ctx, username)
+	if err != nil {
+		if errors.Is(err, errx.ErrAccountNotFound) {
+			s.metrics.LoginFailed(LoginKindAccount)
+			return "", errx.ErrInvalidCredentials
+		}
+		return "", err
+	}
+
+	ok, err := account.Password.Matches(password)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		s.metrics.LoginFailed(LoginKindAccount)
+		return "", errx.ErrInvalidCredentials
+	}
+
+	now := time.Now()
+	claims := AccountClaims{
+		AccountID: account.ID,
+		Username:  account.Username,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{accountAudience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(accountTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.jwtSecret)
+}
+
+// ParseToken 驗證玩家帳號的 token，過期或不是玩家帳號的 token 都視為無效
+func (s *AccountService) ParseToken(tokenString string) (*AccountClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &AccountClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return s.jwtSecret, nil
+	}, jwt.WithAudience(accountAudience), jwt.WithExpirationRequired(), jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, errx.ErrTokenExpired
+		}
+		return nil, errx.ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(*AccountClaims)
+	if !ok || !token.Valid {
+		return nil, errx.ErrInvalidToken
+	}
+	return claims, nil
+}
+
+func (s *AccountService) GetAccount(ctx context.Context, accountID int64) (*store.Account, error) {
+	ctx, span := tracer.Start(ctx, "AccountService.GetAccount")
+	defer span.End()
+
+	return s.accountStore.GetByID(ctx, accountID)
+}
+
+// ListGames 帳號玩過的遊戲，新的在前
+func (s *AccountService) ListGames(ctx context.Context, accountID int64, page, pageSize int) (*store.PaginatedAccountGame, error) {
+	ctx, span := tracer.Start(ctx, "AccountService.ListGames")
+	defer span.End()
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > maxAccountPageSize {
+		pageSize = defaultAccountPageSize
+	}
+	return s.accountStore.ListGames(ctx, accountID, store.Filters{Page: page, PageSize: pageSize})
+}
+
+func (s *AccountService) GetStats(ctx context.Context, accountID int64) (*store.AccountStats, error) {
+	ctx, span := tracer.Start(ctx, "AccountService.GetStats")
+	defer span.End()
+
+	return s.accountStore.GetStats(ctx, accountID)
+}
+
+func (s *AccountService) ListFavorites(ctx context.Context, accountID int64) ([]store.FavoriteQuestion, error) {
+	ctx, span := tracer.Start(ctx, "AccountService.ListFavorites")
+	defer span.End()
+
+	return s.accountStore.ListFavorites(ctx, accountID)
+}
+
+func (s *AccountService) AddFavorite(ctx context.Context, accountID, questionID int64) error {
+	ctx, span := tracer.Start(ctx, "AccountService.AddFavorite")
+	defer span.End()
+
+	return s.accountStore.AddFavorite(ctx, accountID, questionID)
+}
+
+func (s *AccountService) RemoveFavorite(ctx context.Context, accountID, questionID int64) error {
+	ctx, span := tracer.Start(ctx, "AccountService.RemoveFavorite")
+	defer span.End()
+
+	return s.accountStore.RemoveFavorite(ctx, accountID, questionID)
+}
@@ -28,6 +28,9 @@ type DashboardData struct {
 }
 
 func (s *AdminService) GetDashboardData(ctx context.Context) (*DashboardData, error) {
+	ctx, span := tracer.Start(ctx, "AdminService.GetDashboardData")
+	defer span.End()
+
 	activeRoomsCount, err := s.gameStore.GetActiveRoomsCount(ctx)
 	if err != nil {
 		return nil, err
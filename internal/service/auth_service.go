@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -19,13 +20,17 @@ type CustomClaims struct {
 type AuthService struct {
 	userStore store.UserStore
 	jwtSecret []byte
+	metrics   Metrics
 }
 
-func NewAuthService(userStore store.UserStore, jwtSecret []byte) *AuthService {
-	return &AuthService{userStore: userStore, jwtSecret: jwtSecret}
+func NewAuthService(userStore store.UserStore, jwtSecret []byte, metrics Metrics) *AuthService {
+	return &AuthService{userStore: userStore, jwtSecret: jwtSecret, metrics: metrics}
 }
 
 func (s *AuthService) CreateUser(ctx context.Context, username, password string) (*store.User, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.CreateUser")
+	defer span.End()
+
 	user := &store.User{
 		Username: username,
 	}
@@ -43,8 +48,15 @@ func (s *AuthService) CreateUser(ctx context.Context, username, password string)
 }
 
 func (s *AuthService) Login(ctx context.Context, username, password string) (string, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.Login")
+	defer span.End()
+
 	user, err := s.userStore.GetUserByUsername(ctx, username)
 	if err != nil {
+		if errors.Is(err, errx.ErrUserNotFound) {
+			s.metrics.LoginFailed(LoginKindAdmin)
+			return "", errx.ErrInvalidCredentials
+		}
 		return "", err
 	}
 	passwordIsMatch, err := user.Password.Matches(password)
@@ -53,6 +65,7 @@ func (s *AuthService) Login(ctx context.Context, username, password string) (str
 	}
 
 	if !passwordIsMatch {
+		s.metrics.LoginFailed(LoginKindAdmin)
 		return "", errx.ErrInvalidCredentials
 	}
 
@@ -107,6 +120,11 @@ func (s *AuthService) ParseToken(tokenString string) (*CustomClaims, error) {
 		return nil, errx.ErrInvalidToken
 	}
 
+	// 玩家帳號的 token 用同一把金鑰簽發，不能拿來存取後台
+	if len(claims.Audience) > 0 {
+		return nil, errx.ErrInvalidToken
+	}
+
 	return claims, nil
 
 }
@@ -0,0 +1,40 @@
+package service
+
+import "strings"
+
+// confusables 取自 Unicode confusables.txt（UTS #39）中和拉丁字母、數字外觀相同的常見字元，
+// 對應到大寫的拉丁原型字元，只收單一字元對單一字元的對應。
+// 同一個字母的大小寫都列出並對應到同一個原型，之後再忽略大小寫，比對鍵才不受大小寫影響。
+// migrations/00018 以同一張表的 TRANSLATE 更新既有玩家的比對鍵，修改時需一起更新
+var confusables = map[rune]rune{
+	// 數字與符號
+	'0': 'O', '1': 'I', '|': 'I',
+
+	// 西里爾字母
+	'А': 'A', 'а': 'A', 'В': 'B', 'в': 'B', 'Е': 'E', 'е': 'E', 'К': 'K', 'к': 'K',
+	'М': 'M', 'м': 'M', 'Н': 'H', 'н': 'H', 'О': 'O', 'о': 'O', 'Р': 'P', 'р': 'P',
+	'С': 'C', 'с': 'C', 'Т': 'T', 'т': 'T', 'Х': 'X', 'х': 'X', 'У': 'Y', 'у': 'Y',
+	'Ү': 'Y', 'ү': 'Y', 'Ѕ': 'S', 'ѕ': 'S', 'І': 'I', 'і': 'I', 'Ӏ': 'I', 'ӏ': 'I',
+	'Ј': 'J', 'ј': 'J', 'Ԛ': 'Q', 'ԛ': 'Q', 'Ԝ': 'W', 'ԝ': 'W', 'Һ': 'H', 'һ': 'H',
+	'Ԁ': 'D', 'ԁ': 'D',
+
+	// 希臘字母
+	'Α': 'A', 'α': 'A', 'Β': 'B', 'β': 'B', 'Ε': 'E', 'ε': 'E', 'Ζ': 'Z', 'ζ': 'Z',
+	'Η': 'H', 'η': 'H', 'Ι': 'I', 'ι': 'I', 'Κ': 'K', 'κ': 'K', 'Μ': 'M', 'μ': 'M',
+	'Ν': 'N', 'ν': 'N', 'Ο': 'O', 'ο': 'O', 'Ρ': 'P', 'ρ': 'P', 'Τ': 'T', 'τ': 'T',
+	'Υ': 'Y', 'υ': 'Y', 'Χ': 'X', 'χ': 'X', 'Ϲ': 'C', 'ϲ': 'C', 'Ϳ': 'J', 'ϳ': 'J',
+
+	// 亞美尼亞字母與其他拉丁字母
+	'Օ': 'O', 'օ': 'O', 'Ս': 'U', 'ս': 'U',
+	'ı': 'I', 'Ɑ': 'A', 'ɑ': 'A', 'Ɡ': 'G', 'ɡ': 'G',
+}
+
+// skeleton 把外觀相同的字元換成同一個原型，"раураl"（西里爾字母）和 "PAYPAL" 會得到相同結果
+func skeleton(s string) string {
+	return strings.Map(func(r rune) rune {
+		if p, ok := confusables[r]; ok {
+			return p
+		}
+		return r
+	}, s)
+}
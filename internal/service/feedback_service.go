@@ -2,9 +2,10 @@ package service
 
 import (
 	"context"
-	"errors"
+	"fmt"
 
 	"github.com/y3933y3933/joker/internal/store"
+	"github.com/y3933y3933/joker/internal/utils/errx"
 )
 
 type FeedbackService struct {
@@ -18,6 +19,9 @@ func NewFeedbackService(feedbackStore store.FeedbackStore) *FeedbackService {
 }
 
 func (s *FeedbackService) CreateFeedback(ctx context.Context, feedback *store.Feedback) error {
+	ctx, span := tracer.Start(ctx, "FeedbackService.CreateFeedback")
+	defer span.End()
+
 	return s.feedbackStore.Create(ctx, feedback)
 }
 
@@ -29,6 +33,9 @@ type FeedbackQueryParams struct {
 }
 
 func (s *FeedbackService) ListFeedback(ctx context.Context, query FeedbackQueryParams) (*store.PaginatedFeedback, error) {
+	ctx, span := tracer.Start(ctx, "FeedbackService.ListFeedback")
+	defer span.End()
+
 	filters := store.Filters{
 		Page:     query.Page,
 		PageSize: query.PageSize,
@@ -44,26 +51,32 @@ func (s *FeedbackService) ListFeedback(ctx context.Context, query FeedbackQueryP
 }
 
 func (s *FeedbackService) GetFeedbackByID(ctx context.Context, id int64) (*store.Feedback, error) {
+	ctx, span := tracer.Start(ctx, "FeedbackService.GetFeedbackByID")
+	defer span.End()
+
 	return s.feedbackStore.GetByID(ctx, id)
 }
 
 func (s *FeedbackService) UpdateFeedbackReviewStatus(ctx context.Context, id int64, reviewStatus string) error {
+	ctx, span := tracer.Start(ctx, "FeedbackService.UpdateFeedbackReviewStatus")
+	defer span.End()
+
 	return s.feedbackStore.UpdateReviewStatus(ctx, id, reviewStatus)
 }
 
 func (s *FeedbackService) ValidateFeedbackParams(params FeedbackQueryParams) error {
 	if params.Type != "" {
 		if params.Type != "feature" && params.Type != "other" && params.Type != "issue" {
-			return errors.New("invalid level: must be 'feature' or 'issue' or 'other'")
+			return fmt.Errorf("%w: type must be 'feature', 'issue' or 'other'", errx.ErrInvalidInput)
 		}
 	}
 
 	if params.Page < 1 {
-		return errors.New("page must be greater than 0")
+		return fmt.Errorf("%w: page must be greater than 0", errx.ErrInvalidInput)
 	}
 
 	if params.PageSize < 1 || params.PageSize > 100 {
-		return errors.New("page_size must be between 1 and 100")
+		return fmt.Errorf("%w: page_size must be between 1 and 100", errx.ErrInvalidInput)
 	}
 
 	return nil
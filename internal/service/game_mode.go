@@ -0,0 +1,154 @@
+package service
+
+import (
+	"github.com/y3933y3933/joker/internal/store"
+	"github.com/y3933y3933/joker/internal/utils/errx"
+)
+
+// GameMode 定義一種玩法：每輪由誰出題、誰回答，回答之後如何結束回合，以及如何計分。
+// 出題與回答的流程所有玩法共用
+type GameMode interface {
+	Name() string
+	// NextPair 決定新回合的出題者與回答者，online 依座位排序且至少兩人，第一輪 prev 為 nil
+	NextPair(online []*store.Player, prev *PreviousRound) (questioner, answerer *store.Player)
+	// Deck 新回合的牌組，不抽牌的玩法回傳空牌組
+	Deck() []string
+	// AnsweredStatus 回答送出後回合進入的狀態
+	AnsweredStatus() string
+	// Score 依整場的回合、投票與計分規則計算每位玩家的得分，猜測階段的分數另外計算
+	Score(rounds []*store.Round, votes []*store.Vote, rules ScoringRules) map[int64]int
+}
+
+// PreviousRound 是決定下一輪時需要的上一輪資訊，出題者與回答者可能已離線
+type PreviousRound struct {
+	Round      *store.Round
+	Questioner *store.Player
+	Answerer   *store.Player
+}
+
+func (p *PreviousRound) answered() bool {
+	return p.Round.Answer != nil
+}
+
+var gameModes = map[string]GameMode{
+	store.GameModeClassic:    classicMode{},
+	store.GameModeHotSeat:    hotSeatMode{},
+	store.GameModeVoteReveal: voteRevealMode{},
+}
+
+// LookupGameMode 依名稱取得玩法，空字串視為 classic
+func LookupGameMode(name string) (GameMode, error) {
+	if name == "" {
+		name = store.GameModeClassic
+	}
+	mode, ok := gameModes[name]
+	if !ok {
+		return nil, errx.ErrInvalidGameMode
+	}
+	return mode, nil
+}
+
+func gameModeOf(game *store.Game) GameMode {
+	mode, err := LookupGameMode(game.Mode)
+	if err != nil {
+		return classicMode{}
+	}
+	return mode
+}
+
+// classicMode 原本的玩法：回答者依座位輪替，回答後從一張鬼牌的牌組抽牌
+type classicMode struct{}
+
+func (classicMode) Name() string { return store.GameModeClassic }
+
+func (classicMode) NextPair(online []*store.Player, prev *PreviousRound) (*store.Player, *store.Player) {
+	if prev == nil {
+		return nextPair(online, nil, false)
+	}
+	return nextPair(online, prev.Answerer, prev.answered())
+}
+
+func (classicMode) Deck() []string { return generateDeck(DECK_LENGTH) }
+
+func (classicMode) AnsweredStatus() string { return store.RoundStatusWaitingForDraw }
+
+// Score 依抽牌結果給回答者分數
+func (classicMode) Score(rounds []*store.Round, _ []*store.Vote, rules ScoringRules) map[int64]int {
+	scores := make(map[int64]int)
+	answerPoints(scores, rounds, rules)
+	return scores
+}
+
+// hotSeatMode 同一位回答者留在熱座上，其他人依座位輪流出題，
+// 所有人都問過之後熱座換到下一個座位
+type hotSeatMode struct{}
+
+func (hotSeatMode) Name() string { return store.GameModeHotSeat }
+
+func (hotSeatMode) NextPair(online []*store.Player, prev *PreviousRound) (*store.Player, *store.Player) {
+	if prev == nil || prev.Answerer == nil {
+		// 第一輪：第一位坐上熱座，從下一位開始出題
+		return online[1], online[0]
+	}
+
+	if i := indexOfPlayer(online, prev.Answerer.ID); i >= 0 && prev.Questioner != nil {
+		hotSeat := online[i]
+		next := online[seatAfter(online, prev.Questioner)]
+		if next.ID != hotSeat.ID {
+			return next, hotSeat
+		}
+	}
+
+	// 熱座上的人已經回答完一圈或離線，換下一個座位
+	answerer := online[seatAfter(online, prev.Answerer)]
+	questioner := online[seatAfter(online, answerer)]
+	return questioner, answerer
+}
+
+func (hotSeatMode) Deck() []string { return generateDeck(DECK_LENGTH) }
+
+func (hotSeatMode) AnsweredStatus() string { return store.RoundStatusWaitingForDraw }
+
+// Score 和 classic 相同
+func (hotSeatMode) Score(rounds []*store.Round, votes []*store.Vote, rules ScoringRules) map[int64]int {
+	return classicMode{}.Score(rounds, votes, rules)
+}
+
+// voteRevealMode 不抽牌，回答後由其他玩家投票決定是否公開題目
+type voteRevealMode struct{}
+
+func (voteRevealMode) Name() string { return store.GameModeVoteReveal }
+
+func (voteRevealMode) NextPair(online []*store.Player, prev *PreviousRound) (*store.Player, *store.Player) {
+	return classicMode{}.NextPair(online, prev)
+}
+
+func (voteRevealMode) Deck() []string { return []string{} }
+
+func (voteRevealMode) AnsweredStatus() string { return store.RoundStatusWaitingForVotes }
+
+// Score 回答者依投票結果得分，投票和最後結果一致的玩家另外得分
+func (voteRevealMode) Score(rounds []*store.Round, votes []*store.Vote, rules ScoringRules) map[int64]int {
+	scores := make(map[int64]int)
+	answerPoints(scores, rounds, rules)
+
+	outcome := make(map[int64]string)
+	for _, r := range rounds {
+		if !scored(r) {
+			continue
+		}
+		switch r.Status {
+		case store.RoundStatusRevealed:
+			outcome[r.ID] = store.VoteChoiceReveal
+		case store.RoundStatusDone:
+			outcome[r.ID] = store.VoteChoiceKeep
+		}
+	}
+
+	for _, v := range votes {
+		if v.Kind == store.VoteKindReveal && outcome[v.RoundID] == v.Choice {
+			scores[v.PlayerID] += rules.MajorityVote
+		}
+	}
+	return scores
+}
@@ -2,9 +2,10 @@ package service
 
 import (
 	"context"
-	"errors"
+	"encoding/json"
 	"fmt"
 	"slices"
+	"sort"
 
 	"github.com/y3933y3933/joker/internal/store"
 	"github.com/y3933y3933/joker/internal/utils/codegen"
@@ -14,15 +15,28 @@ import (
 type GameService struct {
 	gameStore   store.GameStore
 	playerStore store.PlayerStore
+	roundStore  store.RoundStore
+	voteStore   store.VoteStore
+	tx          store.TxRunner
 }
 
-func NewGameService(gameStore store.GameStore, playerStore store.PlayerStore) *GameService {
+func NewGameService(gameStore store.GameStore, playerStore store.PlayerStore, roundStore store.RoundStore, voteStore store.VoteStore, tx store.TxRunner) *GameService {
 	return &GameService{
 		gameStore:   gameStore,
 		playerStore: playerStore,
+		roundStore:  roundStore,
+		voteStore:   voteStore,
+		tx:          tx,
 	}
 }
 
+// inTx 以交易內的 store 執行 fn
+func (s *GameService) inTx(ctx context.Context, fn func(s *GameService) error) error {
+	return s.tx.WithTx(ctx, func(tx store.Stores) error {
+		return fn(NewGameService(tx.Games, tx.Players, tx.Rounds, tx.Votes, tx.Tx))
+	})
+}
+
 func (s *GameService) generateCode(ctx context.Context) (string, error) {
 	const maxTries = 10
 
@@ -40,15 +54,55 @@ func (s *GameService) generateCode(ctx context.Context) (string, error) {
 	return "", errx.ErrGenerateCode
 }
 
-func (s *GameService) CreateGame(ctx context.Context) (*store.Game, error) {
+// GameOptions 建立遊戲時可選的設定，空值使用預設
+type GameOptions struct {
+	Mode     string        // 預設 classic
+	Guessing string        // 預設 off
+	Scoring  *ScoringRules // 預設 DefaultScoringRules
+}
+
+// CreateGame 建立新遊戲
+func (s *GameService) CreateGame(ctx context.Context, opts GameOptions) (*store.Game, error) {
+	ctx, span := tracer.Start(ctx, "GameService.CreateGame")
+	defer span.End()
+
+	gameMode, err := LookupGameMode(opts.Mode)
+	if err != nil {
+		return nil, err
+	}
+
+	guessing := opts.Guessing
+	switch guessing {
+	case "":
+		guessing = store.GuessingOff
+	case store.GuessingOff, store.GuessingAbout, store.GuessingTruth:
+	default:
+		return nil, errx.ErrInvalidGuessing
+	}
+
+	rules := DefaultScoringRules
+	if opts.Scoring != nil {
+		rules = *opts.Scoring
+	}
+	if err := rules.validate(); err != nil {
+		return nil, err
+	}
+	scoring, err := json.Marshal(rules)
+	if err != nil {
+		return nil, err
+	}
+
 	code, err := s.generateCode(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	args := &store.Game{
-		Code:   code,
-		Status: store.GameStatusWaiting,
+		Code:     code,
+		Status:   store.GameStatusWaiting,
+		Mode:     gameMode.Name(),
+		Guessing: guessing,
+		Scoring:  scoring,
 	}
 	game, err := s.gameStore.Create(ctx, args)
 	if err != nil {
@@ -58,7 +112,11 @@ func (s *GameService) CreateGame(ctx context.Context) (*store.Game, error) {
 	return game, nil
 }
 
-func (s *GameService) EndGame(ctx context.Context, code string) error {
+// EndGame 結束遊戲並記錄原因（store.EndReason*）
+func (s *GameService) EndGame(ctx context.Context, code string, reason string) error {
+	ctx, span := tracer.Start(ctx, "GameService.EndGame")
+	defer span.End()
+
 	game, err := s.gameStore.GetGameByCode(ctx, code)
 	if err != nil {
 		return err
@@ -68,28 +126,68 @@ func (s *GameService) EndGame(ctx context.Context, code string) error {
 		return errx.ErrInvalidGameStatus
 	}
 
-	return s.gameStore.EndGame(ctx, code)
+	return s.gameStore.EndGame(ctx, code, reason)
 }
 
-func (s *GameService) GetGameSummaryByCode(ctx context.Context, gameID int64) (*store.GameSummary, error) {
-	stats, err := s.gameStore.GetGameSummary(ctx, gameID)
+func (s *GameService) GetGameSummaryByCode(ctx context.Context, game *store.Game) (*store.GameSummary, error) {
+	ctx, span := tracer.Start(ctx, "GameService.GetGameSummaryByCode")
+	defer span.End()
+
+	stats, err := s.gameStore.GetGameSummary(ctx, game.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	playerStats, err := s.gameStore.GetGamePlayerStats(ctx, game.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	playerStats, err := s.gameStore.GetGamePlayerStats(ctx, gameID)
+	rounds, err := s.roundStore.ListByGameID(ctx, game.ID)
+	if err != nil {
+		return nil, err
+	}
+	votes, err := s.voteStore.ListByGameID(ctx, game.ID)
 	if err != nil {
 		return nil, err
 	}
 
+	// 分數依玩法與這場遊戲的計分規則計算
+	scores, guesses := computeScores(game, unwrapRounds(rounds), votes)
+	_, spicyAsked := playerHistory(playerStats, rounds)
+
+	entries := make([]LeaderboardEntry, len(playerStats))
+	for i := range playerStats {
+		id := playerStats[i].ID
+		playerStats[i].CorrectGuesses = guesses[id]
+		playerStats[i].Score = scores[id]
+		entries[i] = LeaderboardEntry{PlayerID: id, Score: scores[id]}
+	}
+	rankByScore(entries)
+	rankOf := make(map[int64]int, len(entries))
+	for _, e := range entries {
+		rankOf[e.PlayerID] = e.Rank
+	}
+	for i := range playerStats {
+		playerStats[i].Rank = rankOf[playerStats[i].ID]
+	}
+	sort.SliceStable(playerStats, func(i, j int) bool {
+		return playerStats[i].Rank < playerStats[j].Rank
+	})
+
 	return &store.GameSummary{
+		Mode:        gameModeOf(game).Name(),
 		TotalRounds: stats.TotalRounds,
 		JokerCards:  stats.JokerCards,
 		Players:     playerStats,
+		Awards:      gameAwards(playerStats, spicyAsked),
 	}, nil
 }
 
 func (s *GameService) DeleteGameIfEmpty(ctx context.Context, gameCode string) error {
+	ctx, span := tracer.Start(ctx, "GameService.DeleteGameIfEmpty")
+	defer span.End()
+
 	count, err := s.playerStore.GetPlayerCountByGameCode(ctx, gameCode)
 	if err != nil {
 		return err
@@ -102,10 +200,29 @@ func (s *GameService) DeleteGameIfEmpty(ctx context.Context, gameCode string) er
 	return s.gameStore.DeleteByCode(ctx, gameCode)
 }
 
+// GetGameByCode 包含已結束的遊戲，結束後仍要能查看總結與開再來一局
 func (s *GameService) GetGameByCode(ctx context.Context, gameCode string) (*store.Game, error) {
+	ctx, span := tracer.Start(ctx, "GameService.GetGameByCode")
+	defer span.End()
+
 	return s.gameStore.GetGameByCode(ctx, gameCode)
 }
 
+// GetUnfinishedGameByCode 給加入遊戲與 WebSocket 連線使用，已結束的遊戲回傳 ErrGameEnded
+func (s *GameService) GetUnfinishedGameByCode(ctx context.Context, gameCode string) (*store.Game, error) {
+	ctx, span := tracer.Start(ctx, "GameService.GetUnfinishedGameByCode")
+	defer span.End()
+
+	game, err := s.gameStore.GetGameByCode(ctx, gameCode)
+	if err != nil {
+		return nil, err
+	}
+	if game.Status == store.GameStatusEnded {
+		return nil, errx.ErrGameEnded
+	}
+	return game, nil
+}
+
 type GameQueryParams struct {
 	Code     string `json:"code"`
 	Status   string `json:"status"`
@@ -114,6 +231,9 @@ type GameQueryParams struct {
 }
 
 func (s *GameService) ListGame(ctx context.Context, query GameQueryParams) (*store.PaginatedGame, error) {
+	ctx, span := tracer.Start(ctx, "GameService.ListGame")
+	defer span.End()
+
 	filters := store.Filters{
 		Page:     query.Page,
 		PageSize: query.PageSize,
@@ -131,17 +251,17 @@ func (s *GameService) ListGame(ctx context.Context, query GameQueryParams) (*sto
 func (s *GameService) ValidateGameParams(params GameQueryParams) error {
 	if params.Status != "" {
 		if !slices.Contains([]string{"waiting", "playing", "ended"}, params.Status) {
-			return errors.New("invalid status: must be 'waiting', 'playing', or 'ended'")
+			return fmt.Errorf("%w: status must be 'waiting', 'playing' or 'ended'", errx.ErrInvalidInput)
 		}
 
 	}
 
 	if params.Page < 1 {
-		return errors.New("page must be greater than 0")
+		return fmt.Errorf("%w: page must be greater than 0", errx.ErrInvalidInput)
 	}
 
 	if params.PageSize < 1 || params.PageSize > 100 {
-		return errors.New("page_size must be between 1 and 100")
+		return fmt.Errorf("%w: page_size must be between 1 and 100", errx.ErrInvalidInput)
 	}
 
 	return nil
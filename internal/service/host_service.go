@@ -0,0 +1,276 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/y3933y3933/joker/internal/store"
+	"github.com/y3933y3933/joker/internal/utils/errx"
+)
+
+// HostService 處理只有 host 能執行的房間管理操作
+type HostService struct {
+	playerStore  store.PlayerStore
+	gameStore    store.GameStore
+	roundService *RoundService
+	roomAccess   *RoomAccess
+	tx           store.TxRunner
+}
+
+func NewHostService(playerStore store.PlayerStore, gameStore store.GameStore, roundService *RoundService, roomAccess *RoomAccess, tx store.TxRunner) *HostService {
+	return &HostService{
+		playerStore:  playerStore,
+		gameStore:    gameStore,
+		roundService: roundService,
+		roomAccess:   roomAccess,
+		tx:           tx,
+	}
+}
+
+// inTx 以交易內的 store 執行 fn，roundService 也會使用同一個交易
+func (s *HostService) inTx(ctx context.Context, fn func(s *HostService) error) error {
+	return s.tx.WithTx(ctx, func(tx store.Stores) error {
+		return fn(NewHostService(tx.Players, tx.Games, s.roundService.withStores(tx), s.roomAccess, tx.Tx))
+	})
+}
+
+type KickResult struct {
+	Player       *store.Player `json:"player"`
+	SkippedRound *store.Round  `json:"skippedRound,omitempty"` // 被踢的人正在出題或回答時開的新回合
+	GameEnded    bool          `json:"gameEnded"`              // 人數不足而結束遊戲
+	// 踢掉的是最後一位還沒投票的人時，投票會直接結算
+	Votes       *VoteResult        `json:"votes,omitempty"`
+	Leaderboard []LeaderboardEntry `json:"leaderboard,omitempty"`
+}
+
+func (s *HostService) requireHost(ctx context.Context, game *store.Game, hostID int64) (*store.Player, error) {
+	host, err := s.playerStore.FindByID(ctx, hostID)
+	if err != nil {
+		if errors.Is(err, errx.ErrPlayerNotFound) {
+			return nil, errx.ErrForbidden
+		}
+		return nil, err
+	}
+	if host.GameID != game.ID || !host.IsHost {
+		return nil, errx.ErrForbidden
+	}
+	return host, nil
+}
+
+func (s *HostService) findPlayerInGame(ctx context.Context, game *store.Game, playerID int64) (*store.Player, error) {
+	player, err := s.playerStore.FindByID(ctx, playerID)
+	if err != nil {
+		return nil, err
+	}
+	if player.GameID != game.ID {
+		return nil, errx.ErrPlayerNotFound
+	}
+	return player, nil
+}
+
+// KickPlayer 把玩家踢出遊戲，並禁止同一個暱稱再加入
+func (s *HostService) KickPlayer(ctx context.Context, game *store.Game, hostID, playerID int64) (*KickResult, error) {
+	ctx, span := tracer.Start(ctx, "HostService.KickPlayer")
+	defer span.End()
+
+	if game.Status == store.GameStatusEnded {
+		return nil, errx.ErrInvalidGameStatus
+	}
+
+	host, err := s.requireHost(ctx, game, hostID)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := s.findPlayerInGame(ctx, game, playerID)
+	if err != nil {
+		return nil, err
+	}
+	if target.ID == host.ID {
+		return nil, errx.ErrForbidden
+	}
+
+	result := &KickResult{Player: target}
+	err = s.inTx(ctx, func(tx *HostService) error {
+		return tx.kick(ctx, game, target, result)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// kick 禁止暱稱後移除玩家，進行中的遊戲另外處理他負責的回合
+func (s *HostService) kick(ctx context.Context, game *store.Game, target *store.Player, result *KickResult) error {
+	err := s.playerStore.BanNickname(ctx, game.ID, target.NicknameKey)
+	if err != nil {
+		return err
+	}
+
+	// 尚未開始或觀戰者沒有回合紀錄，可以直接刪除；進行中的玩家保留紀錄供結算
+	if game.Status == store.GameStatusWaiting || target.Role == store.PlayerRoleSpectator {
+		return s.playerStore.DeleteByID(ctx, target.ID)
+	}
+
+	err = s.playerStore.UpdatePlayerStatus(ctx, target.ID, store.PlayerStatusKicked)
+	if err != nil {
+		return err
+	}
+	target.Status = store.PlayerStatusKicked
+
+	round, err := s.roundService.SkipRoundIfInvolved(ctx, game, target.ID)
+	if err != nil {
+		if errors.Is(err, errx.ErrNotEnoughPlayers) {
+			err = s.gameStore.EndGame(ctx, game.Code, store.EndReasonNotEnoughPlayers)
+			if err != nil {
+				return err
+			}
+			result.GameEnded = true
+			return nil
+		}
+		return err
+	}
+	result.SkippedRound = round
+
+	// 被踢的人不再計入投票人數，和斷線一樣重新檢查投票
+	votes, err := s.roundService.RecheckVotes(ctx, game)
+	if err != nil {
+		return err
+	}
+	if votes == nil || !votes.Resolved {
+		return nil
+	}
+	result.Votes = votes
+	result.Leaderboard, err = s.roundService.Leaderboard(ctx, game)
+	return err
+}
+
+// TransferHost 由目前的 host 指定新的 host
+func (s *HostService) TransferHost(ctx context.Context, game *store.Game, hostID, playerID int64) (*store.Player, error) {
+	ctx, span := tracer.Start(ctx, "HostService.TransferHost")
+	defer span.End()
+
+	host, err := s.requireHost(ctx, game, hostID)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := s.findPlayerInGame(ctx, game, playerID)
+	if err != nil {
+		return nil, err
+	}
+	if target.ID == host.ID || target.Role != store.PlayerRolePlayer || target.Status != store.PlayerStatusOnline {
+		return nil, errx.ErrForbidden
+	}
+
+	err = s.inTx(ctx, func(tx *HostService) error {
+		err := tx.playerStore.UpdateHost(ctx, host.ID, false)
+		if err != nil {
+			return err
+		}
+		return tx.playerStore.UpdateHost(ctx, target.ID, true)
+	})
+	if err != nil {
+		return nil, err
+	}
+	target.IsHost = true
+
+	return target, nil
+}
+
+// ReorderSeats 依 playerIDs 的順序重新排座位，決定之後出題與回答的輪替順序
+func (s *HostService) ReorderSeats(ctx context.Context, game *store.Game, hostID int64, playerIDs []int64) error {
+	ctx, span := tracer.Start(ctx, "HostService.ReorderSeats")
+	defer span.End()
+
+	if game.Status == store.GameStatusEnded {
+		return errx.ErrInvalidGameStatus
+	}
+
+	if _, err := s.requireHost(ctx, game, hostID); err != nil {
+		return err
+	}
+
+	players, err := s.playerStore.FindPlayersByGameID(ctx, game.ID)
+	if err != nil {
+		return err
+	}
+
+	// 沿用玩家原本的座位號碼重新分配，才不會和觀戰者的座位重複
+	seated := make(map[int64]bool)
+	var seats []int32
+	for _, p := range players {
+		if p.Role == store.PlayerRolePlayer {
+			seated[p.ID] = true
+			seats = append(seats, p.Seat)
+		}
+	}
+	if len(playerIDs) != len(seated) {
+		return errx.ErrInvalidSeatOrder
+	}
+	seen := make(map[int64]bool, len(playerIDs))
+	for _, id := range playerIDs {
+		if !seated[id] || seen[id] {
+			return errx.ErrInvalidSeatOrder
+		}
+		seen[id] = true
+	}
+
+	// 全部座位一起更新，避免只改到一半
+	return s.inTx(ctx, func(tx *HostService) error {
+		for i, id := range playerIDs {
+			err := tx.playerStore.UpdateSeat(ctx, id, seats[i])
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SetLocked 上鎖後不再接受新玩家或觀戰者加入
+func (s *HostService) SetLocked(ctx context.Context, game *store.Game, hostID int64, locked bool) error {
+	ctx, span := tracer.Start(ctx, "HostService.SetLocked")
+	defer span.End()
+
+	if _, err := s.requireHost(ctx, game, hostID); err != nil {
+		return err
+	}
+	return s.gameStore.UpdateLocked(ctx, game.ID, locked)
+}
+
+// SetAccess 設定房間的加入方式，已經在房間裡的玩家不受影響
+func (s *HostService) SetAccess(ctx context.Context, game *store.Game, hostID int64, access, passcode string) error {
+	ctx, span := tracer.Start(ctx, "HostService.SetAccess")
+	defer span.End()
+
+	if _, err := s.requireHost(ctx, game, hostID); err != nil {
+		return err
+	}
+	if err := validateAccess(access, passcode); err != nil {
+		return err
+	}
+
+	game.Access = access
+	if access == store.GameAccessPasscode {
+		if err := game.Passcode.Set(passcode); err != nil {
+			return err
+		}
+	}
+	return s.gameStore.UpdateAccess(ctx, game)
+}
+
+// CreateInvite 產生有期限的邀請連結，持有者不需要密碼即可加入
+func (s *HostService) CreateInvite(ctx context.Context, game *store.Game, hostID int64, ttl time.Duration) (*Invite, error) {
+	ctx, span := tracer.Start(ctx, "HostService.CreateInvite")
+	defer span.End()
+
+	if game.Status == store.GameStatusEnded {
+		return nil, errx.ErrInvalidGameStatus
+	}
+	if _, err := s.requireHost(ctx, game, hostID); err != nil {
+		return nil, err
+	}
+	return s.roomAccess.CreateInvite(game.Code, ttl)
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/y3933y3933/joker/internal/store"
+)
+
+// Clock 提供目前時間與計時，測試時可以換成假的時鐘
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// SystemClock 使用系統時間
+var SystemClock Clock = systemClock{}
+
+type JanitorConfig struct {
+	Interval    time.Duration // 多久檢查一次
+	WaitingIdle time.Duration // 尚未開始的遊戲閒置多久後結束
+	PlayingIdle time.Duration // 進行中的遊戲閒置多久後結束
+	EmptyGrace  time.Duration // 沒有任何玩家、尚未開始的遊戲閒置多久後刪除
+}
+
+var DefaultJanitorConfig = JanitorConfig{
+	Interval:    time.Minute,
+	WaitingIdle: 30 * time.Minute,
+	PlayingIdle: 2 * time.Hour,
+	EmptyGrace:  5 * time.Minute,
+}
+
+// RoomCloser 關閉遊戲的即時連線，由 ws.Hub 實作
+type RoomCloser interface {
+	CloseRoom(ctx context.Context, code string, reason string)
+}
+
+// Janitor 定期清理被放著不管的遊戲：閒置過久的遊戲以 idle 結束，
+// 沒有玩家又沒開始的遊戲直接刪除，並關閉它們的 WebSocket 房間
+type Janitor struct {
+	gameStore store.GameStore
+	rooms     RoomCloser
+	clock     Clock
+	config    JanitorConfig
+	logger    *slog.Logger
+}
+
+func NewJanitor(gameStore store.GameStore, rooms RoomCloser, clock Clock, config JanitorConfig, logger *slog.Logger) *Janitor {
+	return &Janitor{
+		gameStore: gameStore,
+		rooms:     rooms,
+		clock:     clock,
+		config:    config,
+		logger:    logger,
+	}
+}
+
+type SweepResult struct {
+	Ended   []string `json:"ended"`   // 因閒置而結束的遊戲代碼
+	Deleted []string `json:"deleted"` // 被刪除的空遊戲代碼
+}
+
+// Run 每隔 Interval 清理一次，直到 ctx 結束
+func (j *Janitor) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-j.clock.After(j.config.Interval):
+			res, err := j.Sweep(ctx)
+			if err != nil {
+				j.logger.Error("janitor sweep failed", "error", err)
+				continue
+			}
+			if len(res.Ended) > 0 || len(res.Deleted) > 0 {
+				j.logger.Info("janitor sweep", "ended", res.Ended, "deleted", res.Deleted)
+			}
+		}
+	}
+}
+
+// Sweep 檢查一次所有未結束的遊戲，單一遊戲處理失敗時記錄後繼續
+func (j *Janitor) Sweep(ctx context.Context) (*SweepResult, error) {
+	ctx, span := tracer.Start(ctx, "Janitor.Sweep")
+	defer span.End()
+
+	games, err := j.gameStore.ListUnfinishedActivity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := j.clock.Now()
+	res := &SweepResult{Ended: []string{}, Deleted: []string{}}
+	for _, g := range games {
+		idle := now.Sub(g.LastActivityAt)
+
+		switch {
+		case g.Status == store.GameStatusWaiting && g.PlayerCount == 0:
+			if idle < j.config.EmptyGrace {
+				continue
+			}
+			if err := j.gameStore.DeleteByCode(ctx, g.Code); err != nil {
+				j.logger.ErrorContext(ctx, "janitor delete game failed", "game_code", g.Code, "error", err)
+				continue
+			}
+			res.Deleted = append(res.Deleted, g.Code)
+
+		case g.Status == store.GameStatusWaiting && idle >= j.config.WaitingIdle,
+			g.Status == store.GameStatusPlaying && idle >= j.config.PlayingIdle:
+			if err := j.gameStore.EndGame(ctx, g.Code, store.EndReasonIdle); err != nil {
+				j.logger.ErrorContext(ctx, "janitor end game failed", "game_code", g.Code, "error", err)
+				continue
+			}
+			res.Ended = append(res.Ended, g.Code)
+
+		default:
+			continue
+		}
+
+		j.rooms.CloseRoom(ctx, g.Code, store.EndReasonIdle)
+	}
+	return res, nil
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/y3933y3933/joker/internal/store"
+	"github.com/y3933y3933/joker/internal/utils/errx"
+)
+
+// fakeRooms 記錄 janitor 關閉了哪些房間，格式為 "代碼:原因"
+type fakeRooms struct {
+	mu     sync.Mutex
+	closed []string
+	notify chan string
+}
+
+func newFakeRooms() *fakeRooms {
+	return &fakeRooms{notify: make(chan string, 16)}
+}
+
+func (r *fakeRooms) CloseRoom(_ context.Context, code string, reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = append(r.closed, code+":"+reason)
+	r.notify <- code
+}
+
+func (r *fakeRooms) Closed() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.closed)
+}
+
+type janitorFixture struct {
+	t       *testing.T
+	games   store.GameStore
+	players store.PlayerStore
+	clock   *fakeClock
+	rooms   *fakeRooms
+	janitor *Janitor
+}
+
+func newJanitorFixture(t *testing.T) *janitorFixture {
+	db := store.NewMemoryDB()
+	stores := db.Stores()
+	f := &janitorFixture{t: t, games: stores.Games, players: stores.Players, rooms: newFakeRooms()}
+	f.clock = newFakeClock()
+	f.janitor = NewJanitor(stores.Games, f.rooms, f.clock, DefaultJanitorConfig, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	return f
+}
+
+// game 建立遊戲，players 為 0 時是沒有人的空房間
+func (f *janitorFixture) game(code, status string, players int) {
+	f.t.Helper()
+	ctx := context.Background()
+	game, err := f.games.Create(ctx, &store.Game{Code: code, Status: status, Mode: store.GameModeClassic, Guessing: store.GuessingOff})
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	for i := range players {
+		key := code + string(rune('a'+i))
+		if _, err := f.players.Create(ctx, &store.Player{GameID: game.ID, Nickname: key, NicknameKey: key, Role: store.PlayerRolePlayer}); err != nil {
+			f.t.Fatal(err)
+		}
+	}
+}
+
+// start 讓假時鐘從最後一筆資料的時間開始，記憶體 store 以系統時間記錄活動
+func (f *janitorFixture) start() {
+	f.clock.mu.Lock()
+	f.clock.now = time.Now()
+	f.clock.mu.Unlock()
+}
+
+func (f *janitorFixture) sweep(wantEnded, wantDeleted []string) {
+	f.t.Helper()
+	res, err := f.janitor.Sweep(context.Background())
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	if !slices.Equal(res.Ended, wantEnded) || !slices.Equal(res.Deleted, wantDeleted) {
+		f.t.Fatalf("sweep = ended %v deleted %v, want ended %v deleted %v", res.Ended, res.Deleted, wantEnded, wantDeleted)
+	}
+}
+
+func (f *janitorFixture) wantEnded(code, reason string) {
+	f.t.Helper()
+	game, err := f.games.GetGameByCode(context.Background(), code)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	if game.Status != store.GameStatusEnded || game.EndedReason == nil || *game.EndedReason != reason {
+		f.t.Fatalf("%s = status %s reason %v, want ended with %s", code, game.Status, game.EndedReason, reason)
+	}
+}
+
+func TestJanitorSweep(t *testing.T) {
+	f := newJanitorFixture(t)
+	cfg := DefaultJanitorConfig
+	f.game("EMPTY0", store.GameStatusWaiting, 0)
+	f.game("LOBBY0", store.GameStatusWaiting, 2)
+	f.game("PLAY00", store.GameStatusPlaying, 3)
+	f.game("DONE00", store.GameStatusPlaying, 2)
+	if err := f.games.EndGame(context.Background(), "DONE00", store.EndReasonFinished); err != nil {
+		t.Fatal(err)
+	}
+	f.start()
+
+	f.clock.Advance(cfg.EmptyGrace - time.Second)
+	f.sweep([]string{}, []string{})
+
+	// 空房間過了 EmptyGrace 直接刪除，有玩家的房間還沒到 WaitingIdle
+	f.clock.Advance(time.Second)
+	f.sweep([]string{}, []string{"EMPTY0"})
+	if _, err := f.games.GetGameByCode(context.Background(), "EMPTY0"); !errors.Is(err, errx.ErrGameNotFound) {
+		t.Fatalf("empty game after sweep: err = %v, want %v", err, errx.ErrGameNotFound)
+	}
+
+	f.clock.Advance(cfg.WaitingIdle - cfg.EmptyGrace)
+	f.sweep([]string{"LOBBY0"}, []string{})
+	f.wantEnded("LOBBY0", store.EndReasonIdle)
+
+	f.clock.Advance(cfg.PlayingIdle - cfg.WaitingIdle - time.Second)
+	f.sweep([]string{}, []string{})
+
+	f.clock.Advance(time.Second)
+	f.sweep([]string{"PLAY00"}, []string{})
+	f.wantEnded("PLAY00", store.EndReasonIdle)
+	// 已經結束的遊戲不受影響
+	f.wantEnded("DONE00", store.EndReasonFinished)
+
+	want := []string{"EMPTY0:idle", "LOBBY0:idle", "PLAY00:idle"}
+	if closed := f.rooms.Closed(); !slices.Equal(closed, want) {
+		t.Fatalf("closed rooms = %v, want %v", closed, want)
+	}
+}
+
+func TestJanitorRunSweepsEveryInterval(t *testing.T) {
+	f := newJanitorFixture(t)
+	f.game("EMPTY0", store.GameStatusWaiting, 0)
+	f.start()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f.janitor.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	// Run 可能還沒開始等待，每次只前進一個 Interval 直到房間被關閉
+	deadline := time.After(2 * time.Second)
+	for {
+		f.clock.Advance(DefaultJanitorConfig.Interval)
+		select {
+		case code := <-f.rooms.notify:
+			if code != "EMPTY0" {
+				t.Fatalf("closed %s, want EMPTY0", code)
+			}
+			return
+		case <-time.After(5 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("janitor did not sweep")
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package service
+
+// Metrics 接收遊戲與登入事件，由 internal/metrics 以 Prometheus counter 實作
+type Metrics interface {
+	RoundStarted()
+	RoundSkipped()
+	RoundRevealed()
+	LoginFailed(kind string)
+}
+
+// 登入失敗的種類
+const (
+	LoginKindAdmin   = "admin"
+	LoginKindAccount = "account"
+)
+
+type nopMetrics struct{}
+
+func (nopMetrics) RoundStarted()      {}
+func (nopMetrics) RoundSkipped()      {}
+func (nopMetrics) RoundRevealed()     {}
+func (nopMetrics) LoginFailed(string) {}
+
+// NopMetrics 不記錄任何事件，給不需要 metrics 的呼叫端使用
+var NopMetrics Metrics = nopMetrics{}
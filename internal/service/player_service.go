@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 
 	"github.com/y3933y3933/joker/internal/store"
 	"github.com/y3933y3933/joker/internal/utils/errx"
@@ -10,51 +11,229 @@ import (
 type PlayerService struct {
 	playerStore store.PlayerStore
 	gameStore   store.GameStore
+	tx          store.TxRunner
 }
 
-func NewPlayerService(playerStore store.PlayerStore, gameStore store.GameStore) *PlayerService {
+func NewPlayerService(playerStore store.PlayerStore, gameStore store.GameStore, tx store.TxRunner) *PlayerService {
 	return &PlayerService{
 		playerStore: playerStore,
 		gameStore:   gameStore,
+		tx:          tx,
 	}
 }
 
-func (s *PlayerService) JoinGame(ctx context.Context, gameID int64, nickname string) (*store.Player, error) {
+// inTx 以交易內的 store 執行 fn
+func (s *PlayerService) inTx(ctx context.Context, fn func(s *PlayerService) error) error {
+	return s.tx.WithTx(ctx, func(tx store.Stores) error {
+		return fn(NewPlayerService(tx.Players, tx.Games, tx.Tx))
+	})
+}
+
+// checkCanJoin 檢查遊戲是否已結束、房間是否上鎖、暱稱是否被 host 踢出或已被使用
+func (s *PlayerService) checkCanJoin(ctx context.Context, game *store.Game, nicknameKey string) error {
+	if game.Status == store.GameStatusEnded {
+		return errx.ErrGameEnded
+	}
+	if game.Locked {
+		return errx.ErrGameLocked
+	}
+	return s.checkNickname(ctx, game, nicknameKey)
+}
+
+// checkNickname 檢查正規化後的暱稱是否被 host 踢出或已被使用
+func (s *PlayerService) checkNickname(ctx context.Context, game *store.Game, nicknameKey string) error {
+	banned, err := s.playerStore.IsNicknameBanned(ctx, game.ID, nicknameKey)
+	if err != nil {
+		return err
+	}
+	if banned {
+		return errx.ErrPlayerBanned
+	}
+
 	// 🔍 檢查暱稱是否已存在
-	existing, err := s.playerStore.FindByNickname(ctx, gameID, nickname)
+	existing, err := s.playerStore.FindByNickname(ctx, game.ID, nicknameKey)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	if existing != nil {
-		return nil, errx.ErrDuplicateNickname
+		return errx.ErrDuplicateNickname
 	}
+	return nil
+}
+
+// JoinGame accountID 為登入帳號時建立的玩家會直接歸屬到該帳號，未登入為 nil
+func (s *PlayerService) JoinGame(ctx context.Context, game *store.Game, nickname string, avatar store.Avatar, accountID *int64) (*store.Player, error) {
+	ctx, span := tracer.Start(ctx, "PlayerService.JoinGame")
+	defer span.End()
 
-	count, err := s.playerStore.CountPlayerInGame(ctx, gameID)
+	gameID := game.ID
+	nickname, key, err := NormalizeNickname(nickname)
 	if err != nil {
 		return nil, err
 	}
-
-	isHost := count == 0
-	args := &store.Player{
-		Nickname: nickname,
-		IsHost:   isHost,
-		GameID:   gameID,
+	if err := validateAvatar(avatar); err != nil {
+		return nil, err
 	}
-	player, err := s.playerStore.Create(ctx, args)
+	// 鎖住遊戲後才計算人數與分配座位，同時加入的玩家不會拿到相同座位或都成為 host
+	var player *store.Player
+	err = s.inTx(ctx, func(tx *PlayerService) error {
+		if err := tx.gameStore.LockForUpdate(ctx, gameID); err != nil {
+			return err
+		}
+		if err := tx.checkCanJoin(ctx, game, key); err != nil {
+			return err
+		}
 
+		count, err := tx.playerStore.CountPlayerInGame(ctx, gameID)
+		if err != nil {
+			return err
+		}
+
+		player, err = tx.playerStore.Create(ctx, &store.Player{
+			Nickname:    nickname,
+			NicknameKey: key,
+			IsHost:      count == 0,
+			GameID:      gameID,
+			Role:        store.PlayerRolePlayer,
+			Avatar:      avatar,
+			AccountID:   accountID,
+		})
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
 	return player, nil
+}
+
+// SpectateGame 以觀戰者身分加入，不會成為 host，也不會被排進輪替
+func (s *PlayerService) SpectateGame(ctx context.Context, game *store.Game, nickname string, avatar store.Avatar, accountID *int64) (*store.Player, error) {
+	ctx, span := tracer.Start(ctx, "PlayerService.SpectateGame")
+	defer span.End()
+
+	nickname, key, err := NormalizeNickname(nickname)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateAvatar(avatar); err != nil {
+		return nil, err
+	}
+	var spectator *store.Player
+	err = s.inTx(ctx, func(tx *PlayerService) error {
+		if err := tx.gameStore.LockForUpdate(ctx, game.ID); err != nil {
+			return err
+		}
+		if err := tx.checkCanJoin(ctx, game, key); err != nil {
+			return err
+		}
+
+		spectator, err = tx.playerStore.Create(ctx, &store.Player{
+			Nickname:    nickname,
+			NicknameKey: key,
+			IsHost:      false,
+			GameID:      game.ID,
+			Role:        store.PlayerRoleSpectator,
+			Avatar:      avatar,
+			AccountID:   accountID,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return spectator, nil
+}
 
+type PlayerList struct {
+	Players    []*store.Player `json:"players"`
+	Spectators []*store.Player `json:"spectators"`
 }
 
-func (s *PlayerService) ListPlayersInGame(ctx context.Context, gameID int64) ([]*store.Player, error) {
-	return s.playerStore.FindPlayersByGameID(ctx, gameID)
+func (s *PlayerService) ListPlayersInGame(ctx context.Context, gameID int64) (*PlayerList, error) {
+	ctx, span := tracer.Start(ctx, "PlayerService.ListPlayersInGame")
+	defer span.End()
+
+	all, err := s.playerStore.FindPlayersByGameID(ctx, gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &PlayerList{
+		Players:    make([]*store.Player, 0, len(all)),
+		Spectators: make([]*store.Player, 0),
+	}
+	for _, p := range all {
+		if p.Role == store.PlayerRoleSpectator {
+			list.Spectators = append(list.Spectators, p)
+		} else {
+			list.Players = append(list.Players, p)
+		}
+	}
+	return list, nil
+}
+
+// PromoteSpectator 讓 host 在等待階段把觀戰者轉為玩家
+func (s *PlayerService) PromoteSpectator(ctx context.Context, game *store.Game, hostID, spectatorID int64) (*store.Player, error) {
+	ctx, span := tracer.Start(ctx, "PlayerService.PromoteSpectator")
+	defer span.End()
+
+	if game.Status != store.GameStatusWaiting {
+		return nil, errx.ErrInvalidGameStatus
+	}
+
+	host, err := s.playerStore.FindByID(ctx, hostID)
+	if err != nil {
+		return nil, err
+	}
+	if host.GameID != game.ID || !host.IsHost {
+		return nil, errx.ErrForbidden
+	}
+
+	spectator, err := s.playerStore.FindByID(ctx, spectatorID)
+	if err != nil {
+		return nil, err
+	}
+	if spectator.GameID != game.ID {
+		return nil, errx.ErrPlayerNotFound
+	}
+	if spectator.Role != store.PlayerRoleSpectator {
+		return nil, errx.ErrNotSpectator
+	}
+
+	err = s.playerStore.UpdatePlayerRole(ctx, spectator.ID, store.PlayerRolePlayer)
+	if err != nil {
+		return nil, err
+	}
+	spectator.Role = store.PlayerRolePlayer
+
+	return spectator, nil
+}
+
+// RemoveSpectator 觀戰者斷線時直接移除，不影響遊戲進行
+func (s *PlayerService) RemoveSpectator(ctx context.Context, playerID int64) (*store.Player, error) {
+	ctx, span := tracer.Start(ctx, "PlayerService.RemoveSpectator")
+	defer span.End()
+
+	player, err := s.playerStore.FindByID(ctx, playerID)
+	if err != nil {
+		return nil, err
+	}
+	if player.Role != store.PlayerRoleSpectator {
+		return nil, errx.ErrNotSpectator
+	}
+
+	err = s.playerStore.DeleteByID(ctx, playerID)
+	if err != nil {
+		return nil, err
+	}
+	return player, nil
 }
 
 func (s *PlayerService) LeaveGame(ctx context.Context, playerID int64) (left *store.Player, newHost *store.Player, err error) {
+	ctx, span := tracer.Start(ctx, "PlayerService.LeaveGame")
+	defer span.End()
+
 	player, err := s.playerStore.FindByID(ctx, playerID)
 	if err != nil {
 		return nil, nil, err
@@ -69,24 +248,34 @@ func (s *PlayerService) LeaveGame(ctx context.Context, playerID int64) (left *st
 		return nil, nil, errx.ErrGameAlreadyStarted
 	}
 
-	err = s.playerStore.DeleteByID(ctx, playerID)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	if player.IsHost {
-		newHost, err := s.TransferHost(ctx, player)
+	// 刪除玩家與轉移 host 一起成功，避免房間留下沒有 host 的狀態
+	err = s.inTx(ctx, func(tx *PlayerService) error {
+		err := tx.playerStore.DeleteByID(ctx, playerID)
 		if err != nil {
-			return nil, nil, err
+			return err
 		}
-		return player, newHost, nil
 
+		if player.IsHost {
+			newHost, err = tx.TransferHost(ctx, player)
+			// 最後一位玩家離開時沒有人可以接手
+			if errors.Is(err, errx.ErrNotEnoughPlayers) {
+				return nil
+			}
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return player, nil, nil
+	return player, newHost, nil
 }
 
 func (s *PlayerService) TransferHost(ctx context.Context, player *store.Player) (*store.Player, error) {
+	ctx, span := tracer.Start(ctx, "PlayerService.TransferHost")
+	defer span.End()
+
 	players, err := s.playerStore.FindOnlinePlayersByGameID(ctx, player.GameID)
 	if err != nil {
 		return nil, err
@@ -103,9 +292,56 @@ func (s *PlayerService) TransferHost(ctx context.Context, player *store.Player)
 }
 
 func (s *PlayerService) MarkPlayerDisconnected(ctx context.Context, playerID int64) error {
+	ctx, span := tracer.Start(ctx, "PlayerService.MarkPlayerDisconnected")
+	defer span.End()
+
 	return s.playerStore.UpdatePlayerStatus(ctx, playerID, store.PlayerStatusOffline)
 }
 
+// MarkPlayerReconnected 讓斷線的玩家回到原本的座位繼續輪替
+func (s *PlayerService) MarkPlayerReconnected(ctx context.Context, playerID int64) error {
+	ctx, span := tracer.Start(ctx, "PlayerService.MarkPlayerReconnected")
+	defer span.End()
+
+	return s.playerStore.UpdatePlayerStatus(ctx, playerID, store.PlayerStatusOnline)
+}
+
 func (s *PlayerService) FindPlayerByID(ctx context.Context, playerID int64) (*store.Player, error) {
+	ctx, span := tracer.Start(ctx, "PlayerService.FindPlayerByID")
+	defer span.End()
+
 	return s.playerStore.FindByID(ctx, playerID)
 }
+
+// ClaimPlayer 把這場遊戲的玩家歸屬到登入的帳號，之後可以在帳號的歷史紀錄中看到。
+// code 是座位憑證上的遊戲代碼，玩家必須屬於這場遊戲
+func (s *PlayerService) ClaimPlayer(ctx context.Context, code string, playerID, accountID int64) (*store.Player, error) {
+	ctx, span := tracer.Start(ctx, "PlayerService.ClaimPlayer")
+	defer span.End()
+
+	game, err := s.gameStore.GetGameByCode(ctx, code)
+	if err != nil {
+		if errors.Is(err, errx.ErrGameNotFound) {
+			return nil, errx.ErrInvalidSeatToken
+		}
+		return nil, err
+	}
+	player, err := s.playerStore.FindByID(ctx, playerID)
+	if err != nil {
+		return nil, err
+	}
+	if player.GameID != game.ID {
+		return nil, errx.ErrInvalidSeatToken
+	}
+
+	claimed, err := s.playerStore.ClaimPlayer(ctx, playerID, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, errx.ErrPlayerClaimed
+	}
+
+	player.AccountID = &accountID
+	return player, nil
+}
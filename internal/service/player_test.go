@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/y3933y3933/joker/internal/store"
+)
+
+func newSeatingGame(t *testing.T) (store.Stores, *store.Game) {
+	t.Helper()
+	stores := store.NewMemoryDB().Stores()
+	game, err := stores.Games.Create(context.Background(), &store.Game{Code: "SEATS0", Status: store.GameStatusWaiting, Mode: store.GameModeClassic, Guessing: store.GuessingOff})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stores, game
+}
+
+// 同時加入的玩家與觀戰者各自拿到不同座位，且只有一人成為 host
+func TestConcurrentJoinsGetDistinctSeats(t *testing.T) {
+	for range 20 {
+		ctx := context.Background()
+		stores, game := newSeatingGame(t)
+		s := NewPlayerService(stores.Players, stores.Games, stores.Tx)
+
+		var wg sync.WaitGroup
+		for i := range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				join := s.JoinGame
+				if i%4 == 3 {
+					join = s.SpectateGame
+				}
+				if _, err := join(ctx, game, fmt.Sprintf("p%d", i), store.Avatar{}, nil); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+		if t.Failed() {
+			return
+		}
+
+		players, err := stores.Players.FindPlayersByGameID(ctx, game.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seats := make(map[int32]int64)
+		hosts := 0
+		for _, p := range players {
+			if other, ok := seats[p.Seat]; ok {
+				t.Fatalf("players %d and %d share seat %d", other, p.ID, p.Seat)
+			}
+			seats[p.Seat] = p.ID
+			if p.IsHost {
+				hosts++
+			}
+		}
+		if len(players) != 8 || hosts != 1 {
+			t.Fatalf("%d players with %d hosts, want 8 with 1", len(players), hosts)
+		}
+	}
+}
+
+// 調整座位只在玩家原本的座位之間交換，不會佔用觀戰者的座位
+func TestReorderSeatsKeepsSpectatorSeats(t *testing.T) {
+	ctx := context.Background()
+	stores, game := newSeatingGame(t)
+	players := NewPlayerService(stores.Players, stores.Games, stores.Tx)
+	rounds := NewRoundService(stores.Rounds, stores.Players, stores.Games, stores.Votes, stores.Tx, NopMetrics)
+	host := NewHostService(stores.Players, stores.Games, rounds, nil, stores.Tx)
+
+	alice, err := players.JoinGame(ctx, game, "alice", store.Avatar{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	watcher, err := players.SpectateGame(ctx, game, "watcher", store.Avatar{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := players.JoinGame(ctx, game, "bob", store.Avatar{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := host.ReorderSeats(ctx, game, alice.ID, []int64{bob.ID, alice.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[int64]int32{bob.ID: alice.Seat, watcher.ID: watcher.Seat, alice.ID: bob.Seat}
+	list, err := stores.Players.FindPlayersByGameID(ctx, game.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range list {
+		if p.Seat != want[p.ID] {
+			t.Errorf("player %d seat = %d, want %d", p.ID, p.Seat, want[p.ID])
+		}
+	}
+}
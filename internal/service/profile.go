@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/y3933y3933/joker/internal/store"
+	"github.com/y3933y3933/joker/internal/utils/errx"
+)
+
+const (
+	minNicknameLength = 1
+	maxNicknameLength = 20 // 以字元計算
+	maxEmojiRunes     = 8  // 膚色、ZWJ 組合的 emoji 由多個字元組成
+)
+
+// AvatarPresets 伺服器提供的預設頭像，前端依名稱顯示對應圖片
+var AvatarPresets = []string{
+	"joker", "fox", "cat", "owl", "panda", "frog",
+	"tiger", "octopus", "unicorn", "ghost", "robot", "alien",
+}
+
+var avatarColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+var nicknameFolder = cases.Fold()
+
+// NormalizeNickname 整理玩家輸入的暱稱，回傳顯示用的暱稱，以及用來判斷重複的比對鍵。
+// NFKC 讓全形、半形等外觀相同的字元視為同一個，比對鍵另外把其他文字中外觀相同的字元
+// 換成同一個原型（UTS #39 skeleton），並忽略大小寫
+func NormalizeNickname(raw string) (nickname, key string, err error) {
+	nickname = norm.NFKC.String(raw)
+	nickname = strings.Join(strings.Fields(nickname), " ")
+
+	n := utf8.RuneCountInString(nickname)
+	if n < minNicknameLength || n > maxNicknameLength {
+		return "", "", errx.ErrInvalidNickname
+	}
+	for _, r := range nickname {
+		if !unicode.IsPrint(r) {
+			return "", "", errx.ErrInvalidNickname
+		}
+	}
+
+	return nickname, nicknameFolder.String(skeleton(nickname)), nil
+}
+
+// isEmoji 粗略檢查是否只由 emoji 及其修飾字元組成
+func isEmoji(s string) bool {
+	n := utf8.RuneCountInString(s)
+	if n == 0 || n > maxEmojiRunes {
+		return false
+	}
+	keycap := strings.HasSuffix(s, "\u20e3")
+	for _, r := range s {
+		switch {
+		case unicode.Is(unicode.So, r), unicode.Is(unicode.Sk, r):
+		case r == '\u200d', r == '\ufe0f', r == '\u20e3': // ZWJ、emoji 樣式、keycap
+		case keycap && strings.ContainsRune("0123456789#*", r):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// validateAvatar 頭像只能是預設頭像，或同時指定顏色與 emoji，空值表示使用預設
+func validateAvatar(a store.Avatar) error {
+	switch {
+	case a == store.Avatar{}:
+		return nil
+	case a.Preset != "":
+		if a.Color != "" || a.Emoji != "" || !slices.Contains(AvatarPresets, a.Preset) {
+			return errx.ErrInvalidAvatar
+		}
+	default:
+		if !avatarColorPattern.MatchString(a.Color) || !isEmoji(a.Emoji) {
+			return errx.ErrInvalidAvatar
+		}
+	}
+	return nil
+}
+
+// ProfileUpdate 要修改的欄位，nil 表示不變
+type ProfileUpdate struct {
+	Nickname *string       `json:"nickname"`
+	Avatar   *store.Avatar `json:"avatar"`
+}
+
+// UpdateProfile 讓玩家在遊戲開始前修改自己的暱稱與頭像
+func (s *PlayerService) UpdateProfile(ctx context.Context, game *store.Game, playerID int64, update ProfileUpdate) (*store.Player, error) {
+	ctx, span := tracer.Start(ctx, "PlayerService.UpdateProfile")
+	defer span.End()
+
+	if game.Status != store.GameStatusWaiting {
+		return nil, errx.ErrInvalidGameStatus
+	}
+
+	player, err := s.playerStore.FindByID(ctx, playerID)
+	if err != nil {
+		return nil, err
+	}
+	if player.GameID != game.ID {
+		return nil, errx.ErrForbidden
+	}
+
+	if update.Nickname != nil {
+		nickname, key, err := NormalizeNickname(*update.Nickname)
+		if err != nil {
+			return nil, err
+		}
+		// 只改大小寫或空白時不需要重新檢查
+		if key != player.NicknameKey {
+			if err := s.checkNickname(ctx, game, key); err != nil {
+				return nil, err
+			}
+		}
+		player.Nickname = nickname
+		player.NicknameKey = key
+	}
+
+	if update.Avatar != nil {
+		if err := validateAvatar(*update.Avatar); err != nil {
+			return nil, err
+		}
+		player.Avatar = *update.Avatar
+	}
+
+	err = s.playerStore.UpdateProfile(ctx, player)
+	if err != nil {
+		return nil, err
+	}
+	return player, nil
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"errors"
+	"io/fs"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/y3933y3933/joker/internal/store"
+	"github.com/y3933y3933/joker/internal/utils/errx"
+	"github.com/y3933y3933/joker/migrations"
+)
+
+func TestNormalizeNickname(t *testing.T) {
+	tests := []struct {
+		raw      string
+		nickname string
+		key      string
+	}{
+		{"  Alice  ", "Alice", "alice"},
+		{"Mary   Jane", "Mary Jane", "mary jane"},
+		{"Ａｌｉｃｅ", "Alice", "alice"}, // 全形
+		{"ALICE", "ALICE", "alice"},
+		{"Straße", "Straße", "strasse"},
+		{"Аlice", "Аlice", "alice"},    // 西里爾字母 А
+		{"раураl", "раураl", "paypal"}, // 除了 l 都是西里爾字母
+		{"Ρaul", "Ρaul", "paul"},       // 希臘字母 Ρ
+		{"B0b", "B0b", "bob"},
+		{"b1ll", "b1ll", "bill"},
+		{"вова", "вова", "boba"},
+		{"小丑", "小丑", "小丑"},
+		{"😀", "😀", "😀"},
+	}
+	for _, tt := range tests {
+		nickname, key, err := NormalizeNickname(tt.raw)
+		if err != nil {
+			t.Errorf("NormalizeNickname(%q) error = %v", tt.raw, err)
+			continue
+		}
+		if nickname != tt.nickname || key != tt.key {
+			t.Errorf("NormalizeNickname(%q) = %q, %q, want %q, %q", tt.raw, nickname, key, tt.nickname, tt.key)
+		}
+	}
+}
+
+func TestNormalizeNicknameConfusablesCollide(t *testing.T) {
+	pairs := [][2]string{
+		{"alice", "аlicе"},
+		{"Oscar", "0scar"},
+		{"bill", "b1ll"},
+		{"paypal", "PAYPAL"},
+		{"xena", "ХЕΝΑ"},
+		{"Вова", "ВОВА"},
+	}
+	for _, p := range pairs {
+		_, a, _ := NormalizeNickname(p[0])
+		_, b, _ := NormalizeNickname(p[1])
+		if a != b {
+			t.Errorf("keys for %q and %q = %q, %q, want equal", p[0], p[1], a, b)
+		}
+	}
+
+	for _, p := range [][2]string{{"ana", "anna"}, {"Bill", "Blll"}} {
+		_, a, _ := NormalizeNickname(p[0])
+		_, b, _ := NormalizeNickname(p[1])
+		if a == b {
+			t.Errorf("keys for %q and %q should differ, both %q", p[0], p[1], a)
+		}
+	}
+}
+
+func TestNormalizeNicknameRejectsInvalid(t *testing.T) {
+	for _, raw := range []string{
+		"",
+		"   ",
+		strings.Repeat("a", maxNicknameLength+1),
+		strings.Repeat("丑", maxNicknameLength+1),
+		"bob\u0000",
+		"bob\u200b", // 零寬空白不可見
+	} {
+		if _, _, err := NormalizeNickname(raw); !errors.Is(err, errx.ErrInvalidNickname) {
+			t.Errorf("NormalizeNickname(%q) error = %v, want %v", raw, err, errx.ErrInvalidNickname)
+		}
+	}
+
+	if _, _, err := NormalizeNickname(strings.Repeat("丑", maxNicknameLength)); err != nil {
+		t.Errorf("%d characters should be allowed: %v", maxNicknameLength, err)
+	}
+}
+
+// 00018 用 TRANSLATE 更新既有的比對鍵，對照表必須和 confusables 相同
+func TestMigrationTranslatesConfusables(t *testing.T) {
+	sql, err := fs.ReadFile(migrations.FS, "00018_add_player_profiles.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	translate := regexp.MustCompile(`TRANSLATE\(NORMALIZE\([a-z_.]+, NFKC\),\s*'([^']*)',\s*'([^']*)'\)`)
+	matches := translate.FindAllStringSubmatch(string(sql), -1)
+	if len(matches) == 0 {
+		t.Fatal("no TRANSLATE found in 00018")
+	}
+
+	for _, m := range matches {
+		from, to := []rune(m[1]), []rune(m[2])
+		if len(from) != len(to) || len(from) != len(confusables) {
+			t.Fatalf("TRANSLATE maps %d runes to %d, want %d", len(from), len(to), len(confusables))
+		}
+		for i, r := range from {
+			if confusables[r] != to[i] {
+				t.Errorf("TRANSLATE maps %q to %q, confusables has %q", r, to[i], confusables[r])
+			}
+		}
+	}
+}
+
+func TestValidateAvatar(t *testing.T) {
+	valid := []store.Avatar{
+		{},
+		{Preset: "joker"},
+		{Color: "#ff8800", Emoji: "🃏"},
+		{Color: "#FF8800", Emoji: "👍🏽"},
+		{Color: "#000000", Emoji: "👩‍💻"},
+		{Color: "#123abc", Emoji: "❤️"},
+		{Color: "#123abc", Emoji: "7️⃣"},
+	}
+	for _, a := range valid {
+		if err := validateAvatar(a); err != nil {
+			t.Errorf("validateAvatar(%+v) = %v, want nil", a, err)
+		}
+	}
+
+	invalid := []store.Avatar{
+		{Preset: "dragon"},
+		{Preset: "joker", Color: "#ff8800"},
+		{Preset: "joker", Emoji: "🃏"},
+		{Color: "#ff8800"},
+		{Emoji: "🃏"},
+		{Color: "ff8800", Emoji: "🃏"},
+		{Color: "#ff88", Emoji: "🃏"},
+		{Color: "#gg8800", Emoji: "🃏"},
+		{Color: "#ff8800", Emoji: "a"},
+		{Color: "#ff8800", Emoji: "7"},
+		{Color: "#ff8800", Emoji: "🃏<script>"},
+		{Color: "#ff8800", Emoji: strings.Repeat("🃏", maxEmojiRunes+1)},
+	}
+	for _, a := range invalid {
+		if err := validateAvatar(a); !errors.Is(err, errx.ErrInvalidAvatar) {
+			t.Errorf("validateAvatar(%+v) = %v, want %v", a, err, errx.ErrInvalidAvatar)
+		}
+	}
+}
@@ -2,10 +2,10 @@ package service
 
 import (
 	"context"
-	"errors"
 	"fmt"
 
 	"github.com/y3933y3933/joker/internal/store"
+	"github.com/y3933y3933/joker/internal/utils/errx"
 )
 
 type QuestionQueryParams struct {
@@ -27,10 +27,15 @@ func NewQuestionService(questionStore store.QuestionStore) *QuestionService {
 }
 
 func (s *QuestionService) ListRandomQuestions(ctx context.Context, limit int) ([]*store.Question, error) {
+	ctx, span := tracer.Start(ctx, "QuestionService.ListRandomQuestions")
+	defer span.End()
+
 	return s.questionStore.ListRandomQuestions(ctx, int32(limit))
 }
 
 func (s *QuestionService) ListQuestions(ctx context.Context, query QuestionQueryParams) (*store.PaginatedQuestion, error) {
+	ctx, span := tracer.Start(ctx, "QuestionService.ListQuestions")
+	defer span.End()
 
 	filters := store.Filters{
 		Page:     query.Page,
@@ -50,7 +55,7 @@ func (s *QuestionService) ValidateQuestionParams(params QuestionQueryParams) err
 	// 驗證 level
 	if params.Level != "" {
 		if params.Level != "normal" && params.Level != "spicy" {
-			return errors.New("invalid level: must be 'normal' or 'spicy'")
+			return fmt.Errorf("%w: level must be 'normal' or 'spicy'", errx.ErrInvalidInput)
 		}
 	}
 
@@ -67,29 +72,38 @@ func (s *QuestionService) ValidateQuestionParams(params QuestionQueryParams) err
 	}
 
 	if !valid {
-		return fmt.Errorf("invalid sort_by: must be one of %v", validSortOptions)
+		return fmt.Errorf("%w: sort_by must be one of %v", errx.ErrInvalidInput, validSortOptions)
 	}
 
 	if params.Page < 1 {
-		return errors.New("page must be greater than 0")
+		return fmt.Errorf("%w: page must be greater than 0", errx.ErrInvalidInput)
 	}
 
 	if params.PageSize < 1 || params.PageSize > 100 {
-		return errors.New("page_size must be between 1 and 100")
+		return fmt.Errorf("%w: page_size must be between 1 and 100", errx.ErrInvalidInput)
 	}
 
 	return nil
 }
 
 func (s *QuestionService) DeleteQuestion(ctx context.Context, id int64) error {
+	ctx, span := tracer.Start(ctx, "QuestionService.DeleteQuestion")
+	defer span.End()
+
 	return s.questionStore.Delete(ctx, id)
 }
 
 func (s *QuestionService) CreateQuestion(ctx context.Context, content, level string) (*store.Question, error) {
+	ctx, span := tracer.Start(ctx, "QuestionService.CreateQuestion")
+	defer span.End()
+
 	return s.questionStore.Create(ctx, content, level)
 }
 
 func (s *QuestionService) UpdateQuestion(ctx context.Context, id int64, content, level *string) (*store.Question, error) {
+	ctx, span := tracer.Start(ctx, "QuestionService.UpdateQuestion")
+	defer span.End()
+
 	question, err := s.questionStore.Get(ctx, id)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/y3933y3933/joker/internal/store"
+	"github.com/y3933y3933/joker/internal/utils/errx"
+)
+
+// RematchPlayer 是舊遊戲的玩家在再來一局中的新身分
+type RematchPlayer struct {
+	PreviousID int64         `json:"previousID"`
+	Player     *store.Player `json:"player"`
+}
+
+type RematchResult struct {
+	Game    *store.Game     `json:"game"`
+	Players []RematchPlayer `json:"players"`
+	Created bool            `json:"created"` // false 表示已經有人開過，回傳既有的那一場
+}
+
+// Rematch 遊戲結束後用同樣的設定（含加入方式與鎖定）開新的一局，把在線的玩家依原本的座位順序帶過去，host 不變。
+// 每場遊戲只會開一次，之後的呼叫回傳同一場
+func (s *GameService) Rematch(ctx context.Context, game *store.Game, playerID int64) (*RematchResult, error) {
+	ctx, span := tracer.Start(ctx, "GameService.Rematch")
+	defer span.End()
+
+	if game.Status != store.GameStatusEnded {
+		return nil, errx.ErrInvalidGameStatus
+	}
+
+	caller, err := s.playerStore.FindByID(ctx, playerID)
+	if err != nil {
+		if errors.Is(err, errx.ErrPlayerNotFound) {
+			return nil, errx.ErrForbidden
+		}
+		return nil, err
+	}
+	// 被踢出的玩家仍保留 player 身分供結算，但不能開新的一局
+	if caller.GameID != game.ID || caller.Role != store.PlayerRolePlayer || caller.Status == store.PlayerStatusKicked {
+		return nil, errx.ErrForbidden
+	}
+
+	if game.RematchCode != nil {
+		return s.existingRematch(ctx, game, *game.RematchCode)
+	}
+
+	var result *RematchResult
+	err = s.inTx(ctx, func(tx *GameService) error {
+		var err error
+		result, err = tx.createRematch(ctx, game, caller)
+		return err
+	})
+	if errors.Is(err, errRematchTaken) {
+		// 其他玩家同時按了再來一局，這邊建立的遊戲已隨交易還原，改用對方開的那一場
+		latest, err := s.gameStore.GetGameByCode(ctx, game.Code)
+		if err != nil {
+			return nil, err
+		}
+		if latest.RematchCode == nil {
+			return nil, errx.ErrGameNotFound
+		}
+		return s.existingRematch(ctx, game, *latest.RematchCode)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// errRematchTaken 讓交易還原，表示其他人已經先開了再來一局
+var errRematchTaken = errors.New("rematch already created")
+
+// createRematch 建立新遊戲、記錄到舊遊戲並帶玩家過去，需在交易中執行
+func (s *GameService) createRematch(ctx context.Context, game *store.Game, caller *store.Player) (*RematchResult, error) {
+	rules := ScoringRulesOf(game)
+	next, err := s.CreateGame(ctx, GameOptions{
+		Mode:     game.Mode,
+		Guessing: game.Guessing,
+		Scoring:  &rules,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 加入限制和鎖定一起帶過去，不然受保護的房間再來一局就變成公開的；
+	// 帶過去的玩家以新遊戲的座位憑證連線
+	next.Access = game.Access
+	next.Passcode = game.Passcode
+	if err := s.gameStore.UpdateAccess(ctx, next); err != nil {
+		return nil, err
+	}
+	if game.Locked {
+		if err := s.gameStore.UpdateLocked(ctx, next.ID, true); err != nil {
+			return nil, err
+		}
+		next.Locked = true
+	}
+
+	// 禁止的暱稱也帶過去，被踢的人不能換到新遊戲重新加入
+	if err := s.playerStore.CopyBans(ctx, game.ID, next.ID); err != nil {
+		return nil, err
+	}
+
+	claimed, err := s.gameStore.SetRematchCode(ctx, game.ID, next.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, errRematchTaken
+	}
+
+	online, err := s.playerStore.FindOnlinePlayersByGameID(ctx, game.ID)
+	if err != nil {
+		return nil, err
+	}
+	// 發起的人一定一起過去
+	if !slices.ContainsFunc(online, func(p *store.Player) bool { return p.ID == caller.ID }) {
+		online = append(online, caller)
+	}
+	// 原本的 host 已經離線時由發起的人擔任
+	hasHost := slices.ContainsFunc(online, func(p *store.Player) bool { return p.IsHost })
+
+	result := &RematchResult{Game: next, Players: make([]RematchPlayer, 0, len(online)), Created: true}
+	for _, p := range online {
+		banned, err := s.playerStore.IsNicknameBanned(ctx, game.ID, p.NicknameKey)
+		if err != nil {
+			return nil, err
+		}
+		if banned {
+			continue
+		}
+		// 依序建立，新遊戲的座位順序和原本相同
+		player, err := s.playerStore.Create(ctx, &store.Player{
+			GameID:      next.ID,
+			Nickname:    p.Nickname,
+			NicknameKey: p.NicknameKey,
+			IsHost:      p.IsHost || (!hasHost && p.ID == caller.ID),
+			Role:        store.PlayerRolePlayer,
+			Avatar:      p.Avatar,
+			AccountID:   p.AccountID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		result.Players = append(result.Players, RematchPlayer{PreviousID: p.ID, Player: player})
+	}
+	return result, nil
+}
+
+// existingRematch 依暱稱對應回舊遊戲的玩家，之後才加入的玩家不列出
+func (s *GameService) existingRematch(ctx context.Context, game *store.Game, code string) (*RematchResult, error) {
+	next, err := s.gameStore.GetGameByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := s.playerStore.FindPlayersByGameID(ctx, game.ID)
+	if err != nil {
+		return nil, err
+	}
+	previousIDs := make(map[string]int64, len(previous))
+	for _, p := range previous {
+		previousIDs[p.NicknameKey] = p.ID
+	}
+
+	players, err := s.playerStore.FindPlayersByGameID(ctx, next.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RematchResult{Game: next, Players: []RematchPlayer{}}
+	for _, p := range players {
+		if id, ok := previousIDs[p.NicknameKey]; ok {
+			result.Players = append(result.Players, RematchPlayer{PreviousID: id, Player: p})
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/y3933y3933/joker/internal/store"
+	"github.com/y3933y3933/joker/internal/utils/errx"
+)
+
+// 多位玩家同時按再來一局只會開出一場，輸的一方建立的遊戲隨交易還原，不會留下空房間
+func TestConcurrentRematchCreatesOneGame(t *testing.T) {
+	for range 20 {
+		ctx := context.Background()
+		db := store.NewMemoryDB()
+		stores := db.Stores()
+		s := NewGameService(stores.Games, stores.Players, stores.Rounds, stores.Votes, stores.Tx)
+
+		game, err := stores.Games.Create(ctx, &store.Game{Code: "ENDED0", Status: store.GameStatusWaiting, Mode: store.GameModeClassic, Guessing: store.GuessingOff})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var players []*store.Player
+		for i := range 4 {
+			p, err := stores.Players.Create(ctx, &store.Player{GameID: game.ID, Nickname: fmt.Sprintf("p%d", i), NicknameKey: fmt.Sprintf("p%d", i), IsHost: i == 0, Status: store.PlayerStatusOnline, Role: store.PlayerRolePlayer})
+			if err != nil {
+				t.Fatal(err)
+			}
+			players = append(players, p)
+		}
+		if err := stores.Games.EndGame(ctx, game.Code, store.EndReasonFinished); err != nil {
+			t.Fatal(err)
+		}
+		game, err = stores.Games.GetGameByCode(ctx, game.Code)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		results := make([]*RematchResult, len(players))
+		var wg sync.WaitGroup
+		for i, p := range players {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				result, err := s.Rematch(ctx, game, p.ID)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				results[i] = result
+			}()
+		}
+		wg.Wait()
+		if t.Failed() {
+			return
+		}
+
+		created := 0
+		for _, r := range results {
+			if r.Created {
+				created++
+			}
+			if r.Game.Code != results[0].Game.Code || len(r.Players) != len(players) {
+				t.Fatalf("rematch = %s with %d players, want %s with %d", r.Game.Code, len(r.Players), results[0].Game.Code, len(players))
+			}
+		}
+		if created != 1 {
+			t.Fatalf("%d callers created a rematch, want 1", created)
+		}
+
+		unfinished, err := stores.Games.ListUnfinishedActivity(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(unfinished) != 1 || unfinished[0].Code != results[0].Game.Code {
+			t.Fatalf("unfinished games = %+v, want only %s", unfinished, results[0].Game.Code)
+		}
+	}
+}
+
+// 需要密碼的房間再來一局後仍然需要密碼，鎖定也一起保留
+func TestRematchKeepsRoomAccess(t *testing.T) {
+	ctx := context.Background()
+	db := store.NewMemoryDB()
+	stores := db.Stores()
+	s := NewGameService(stores.Games, stores.Players, stores.Rounds, stores.Votes, stores.Tx)
+
+	game, err := stores.Games.Create(ctx, &store.Game{Code: "SECRET", Status: store.GameStatusWaiting, Mode: store.GameModeClassic, Guessing: store.GuessingOff})
+	if err != nil {
+		t.Fatal(err)
+	}
+	game.Access = store.GameAccessPasscode
+	if err := game.Passcode.Set("open-sesame"); err != nil {
+		t.Fatal(err)
+	}
+	if err := stores.Games.UpdateAccess(ctx, game); err != nil {
+		t.Fatal(err)
+	}
+	if err := stores.Games.UpdateLocked(ctx, game.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	host, err := stores.Players.Create(ctx, &store.Player{GameID: game.ID, Nickname: "alice", NicknameKey: "alice", IsHost: true, Status: store.PlayerStatusOnline, Role: store.PlayerRolePlayer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stores.Games.EndGame(ctx, game.Code, store.EndReasonFinished); err != nil {
+		t.Fatal(err)
+	}
+	game, err = stores.Games.GetGameByCode(ctx, game.Code)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := s.Rematch(ctx, game, host.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, err := stores.Games.GetGameByCode(ctx, result.Game.Code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.Access != store.GameAccessPasscode || !next.Locked {
+		t.Fatalf("rematch access = %s locked %v, want %s and locked", next.Access, next.Locked, store.GameAccessPasscode)
+	}
+
+	access := NewRoomAccess([]byte("test-secret"), newFakeClock())
+	if err := access.Check(next, 0, AccessCredentials{}); !errors.Is(err, errx.ErrRoomAccessRequired) {
+		t.Errorf("no credentials: err = %v, want %v", err, errx.ErrRoomAccessRequired)
+	}
+	if err := access.Check(next, 0, AccessCredentials{Passcode: "wrong"}); !errors.Is(err, errx.ErrRoomAccessDenied) {
+		t.Errorf("wrong passcode: err = %v, want %v", err, errx.ErrRoomAccessDenied)
+	}
+	if err := access.Check(next, 0, AccessCredentials{Passcode: "open-sesame"}); err != nil {
+		t.Errorf("passcode: %v", err)
+	}
+}
+
+// 被踢出的玩家不能發起再來一局，也不會被帶到新遊戲，禁止名單一起保留
+func TestRematchLeavesKickedPlayersBehind(t *testing.T) {
+	ctx := context.Background()
+	db := store.NewMemoryDB()
+	stores := db.Stores()
+	s := NewGameService(stores.Games, stores.Players, stores.Rounds, stores.Votes, stores.Tx)
+
+	game, err := stores.Games.Create(ctx, &store.Game{Code: "KICKED", Status: store.GameStatusPlaying, Mode: store.GameModeClassic, Guessing: store.GuessingOff})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var players []*store.Player
+	for i, name := range []string{"alice", "bob", "mallory"} {
+		p, err := stores.Players.Create(ctx, &store.Player{GameID: game.ID, Nickname: name, NicknameKey: name, IsHost: i == 0, Status: store.PlayerStatusOnline, Role: store.PlayerRolePlayer})
+		if err != nil {
+			t.Fatal(err)
+		}
+		players = append(players, p)
+	}
+	mallory := players[2]
+	// 和 HostService.kick 對進行中的遊戲做的一樣
+	if err := stores.Players.BanNickname(ctx, game.ID, mallory.NicknameKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := stores.Players.UpdatePlayerStatus(ctx, mallory.ID, store.PlayerStatusKicked); err != nil {
+		t.Fatal(err)
+	}
+	if err := stores.Games.EndGame(ctx, game.Code, store.EndReasonFinished); err != nil {
+		t.Fatal(err)
+	}
+	game, err = stores.Games.GetGameByCode(ctx, game.Code)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Rematch(ctx, game, mallory.ID); !errors.Is(err, errx.ErrForbidden) {
+		t.Fatalf("rematch by a kicked player: err = %v, want %v", err, errx.ErrForbidden)
+	}
+
+	result, err := s.Rematch(ctx, game, players[1].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range result.Players {
+		if p.PreviousID == mallory.ID {
+			t.Fatalf("rematch players = %+v, want mallory left behind", result.Players)
+		}
+	}
+	if len(result.Players) != 2 {
+		t.Errorf("rematch has %d players, want 2", len(result.Players))
+	}
+	banned, err := stores.Players.IsNicknameBanned(ctx, result.Game.ID, mallory.NicknameKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !banned {
+		t.Error("mallory should stay banned in the rematch")
+	}
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/y3933y3933/joker/internal/store"
+	"github.com/y3933y3933/joker/internal/utils/errx"
+)
+
+// 邀請連結與座位憑證的 audience，和帳號、後台的 token 互不通用
+const (
+	inviteAudience = "invite"
+	seatAudience   = "seat"
+)
+
+const (
+	DefaultInviteTTL = 24 * time.Hour
+	MaxInviteTTL     = 7 * 24 * time.Hour
+	seatTokenTTL     = 24 * time.Hour
+
+	minPasscodeLength = 4
+	maxPasscodeLength = 32
+
+	maxAccessFailures   = 5 // 同一個遊戲代碼在 accessFailureWindow 內最多猜錯幾次密碼
+	accessFailureWindow = time.Minute
+	accessPruneSize     = 1024 // 紀錄超過這個數量時清掉過期的代碼
+)
+
+// AccessCredentials 加入受保護房間時帶的憑證，任一個有效即可
+type AccessCredentials struct {
+	Passcode string
+	Invite   string
+	Seat     string // 加入時發給玩家的座位憑證，重新連線時使用
+}
+
+func (c AccessCredentials) empty() bool {
+	return c.Passcode == "" && c.Invite == "" && c.Seat == ""
+}
+
+type Invite struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// RoomAccess 簽發、驗證邀請連結與座位憑證，並限制每個遊戲代碼猜錯密碼的次數
+type RoomAccess struct {
+	secret []byte
+	clock  Clock
+
+	mu       sync.Mutex
+	failures map[string][]time.Time
+}
+
+func NewRoomAccess(secret []byte, clock Clock) *RoomAccess {
+	return &RoomAccess{
+		secret:   secret,
+		clock:    clock,
+		failures: make(map[string][]time.Time),
+	}
+}
+
+// Check 檢查是否可以進入房間。playerID 為 0 表示新加入，不接受座位憑證。
+// 座位憑證與邀請是簽章過的 token，有效就直接放行、不受限流影響；
+// 只有猜錯密碼會計入失敗次數，避免有人故意猜錯把其他人擋在外面
+func (a *RoomAccess) Check(game *store.Game, playerID int64, creds AccessCredentials) error {
+	if game.Access == store.GameAccessPublic {
+		return nil
+	}
+	if creds.empty() {
+		return errx.ErrRoomAccessRequired
+	}
+
+	if playerID != 0 && creds.Seat != "" && a.verify(creds.Seat, seatAudience, game.Code, strconv.FormatInt(playerID, 10)) {
+		return nil
+	}
+	if creds.Invite != "" && a.verify(creds.Invite, inviteAudience, game.Code, "") {
+		return nil
+	}
+	if game.Access != store.GameAccessPasscode || creds.Passcode == "" {
+		return errx.ErrRoomAccessDenied
+	}
+
+	if a.blocked(game.Code) {
+		return errx.ErrTooManyAttempts
+	}
+	ok, err := game.Passcode.Matches(creds.Passcode)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		a.recordFailure(game.Code)
+		return errx.ErrRoomAccessDenied
+	}
+	return nil
+}
+
+// CreateInvite 簽發邀請連結用的 token，ttl 為 0 時使用預設值
+func (a *RoomAccess) CreateInvite(code string, ttl time.Duration) (*Invite, error) {
+	if ttl <= 0 {
+		ttl = DefaultInviteTTL
+	}
+	ttl = min(ttl, MaxInviteTTL)
+
+	expiresAt := a.clock.Now().Add(ttl)
+	token, err := a.sign(inviteAudience, code, "", expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return &Invite{Token: token, ExpiresAt: expiresAt}, nil
+}
+
+// IssueSeat 發給剛加入的玩家，房間之後改成需要密碼時仍可重新連線
+func (a *RoomAccess) IssueSeat(code string, playerID int64) (string, error) {
+	return a.sign(seatAudience, code, strconv.FormatInt(playerID, 10), a.clock.Now().Add(seatTokenTTL))
+}
+
+// 遊戲代碼放在 subject，座位憑證另外以 ID 記錄玩家
+func (a *RoomAccess) sign(audience, code, id string, expiresAt time.Time) (string, error) {
+	claims := jwt.RegisteredClaims{
+		Audience:  jwt.ClaimStrings{audience},
+		Subject:   code,
+		ID:        id,
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+		IssuedAt:  jwt.NewNumericDate(a.clock.Now()),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(a.secret)
+}
+
+// VerifySeat 驗證座位憑證，回傳簽發時的遊戲代碼與玩家 id
+func (a *RoomAccess) VerifySeat(tokenString string) (code string, playerID int64, ok bool) {
+	claims, ok := a.parse(tokenString, seatAudience)
+	if !ok {
+		return "", 0, false
+	}
+	playerID, err := strconv.ParseInt(claims.ID, 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return claims.Subject, playerID, true
+}
+
+func (a *RoomAccess) verify(tokenString, audience, code, id string) bool {
+	claims, ok := a.parse(tokenString, audience)
+	return ok && claims.Subject == code && claims.ID == id
+}
+
+func (a *RoomAccess) parse(tokenString, audience string) (*jwt.RegisteredClaims, bool) {
+	var claims jwt.RegisteredClaims
+	token, err := jwt.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (interface{}, error) {
+		return a.secret, nil
+	}, jwt.WithAudience(audience), jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(a.clock.Now), jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !token.Valid {
+		return nil, false
+	}
+	return &claims, true
+}
+
+func (a *RoomAccess) blocked(code string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.recentFailures(code)) >= maxAccessFailures
+}
+
+func (a *RoomAccess) recordFailure(code string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if len(a.failures) >= accessPruneSize {
+		for c := range a.failures {
+			a.recentFailures(c)
+		}
+	}
+	a.failures[code] = append(a.recentFailures(code), a.clock.Now())
+}
+
+// recentFailures 丟掉視窗外的紀錄，呼叫前需持有 mu
+func (a *RoomAccess) recentFailures(code string) []time.Time {
+	cutoff := a.clock.Now().Add(-accessFailureWindow)
+	hits := a.failures[code]
+	i := 0
+	for i < len(hits) && !hits[i].After(cutoff) {
+		i++
+	}
+	hits = hits[i:]
+	if len(hits) == 0 {
+		delete(a.failures, code)
+		return nil
+	}
+	a.failures[code] = hits
+	return hits
+}
+
+// validateAccess 檢查 host 設定的加入方式，只有 passcode 需要（也只能）帶密碼
+func validateAccess(access, passcode string) error {
+	switch access {
+	case store.GameAccessPublic, store.GameAccessInvite:
+		if passcode != "" {
+			return errx.ErrInvalidRoomAccess
+		}
+	case store.GameAccessPasscode:
+		n := len([]rune(passcode))
+		if n < minPasscodeLength || n > maxPasscodeLength {
+			return errx.ErrInvalidRoomAccess
+		}
+	default:
+		return errx.ErrInvalidRoomAccess
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/y3933y3933/joker/internal/store"
+	"github.com/y3933y3933/joker/internal/utils/errx"
+)
+
+func TestRoomAccessWrongPasscodesDoNotBlockTokens(t *testing.T) {
+	clock := newFakeClock()
+	access := NewRoomAccess([]byte("test-secret"), clock)
+
+	game := &store.Game{Code: "ABC123", Access: store.GameAccessPasscode}
+	if err := game.Passcode.Set("open-sesame"); err != nil {
+		t.Fatal(err)
+	}
+	seat, err := access.IssueSeat(game.Code, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	invite, err := access.CreateInvite(game.Code, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range maxAccessFailures {
+		err := access.Check(game, 0, AccessCredentials{Passcode: "wrong"})
+		if !errors.Is(err, errx.ErrRoomAccessDenied) {
+			t.Fatalf("wrong passcode: err = %v, want %v", err, errx.ErrRoomAccessDenied)
+		}
+	}
+
+	// 密碼被鎖住，連正確的密碼也要等視窗過去
+	if err := access.Check(game, 0, AccessCredentials{Passcode: "open-sesame"}); !errors.Is(err, errx.ErrTooManyAttempts) {
+		t.Errorf("passcode while blocked: err = %v, want %v", err, errx.ErrTooManyAttempts)
+	}
+
+	// 有效的座位憑證與邀請不受影響
+	if err := access.Check(game, 7, AccessCredentials{Seat: seat}); err != nil {
+		t.Errorf("seat while blocked: %v", err)
+	}
+	if err := access.Check(game, 0, AccessCredentials{Invite: invite.Token}); err != nil {
+		t.Errorf("invite while blocked: %v", err)
+	}
+
+	// 無效的 token 被拒絕，但不會計入或受限於密碼的失敗次數
+	if err := access.Check(game, 8, AccessCredentials{Seat: seat}); !errors.Is(err, errx.ErrRoomAccessDenied) {
+		t.Errorf("another player's seat: err = %v, want %v", err, errx.ErrRoomAccessDenied)
+	}
+	other, err := access.CreateInvite("XYZ789", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := access.Check(game, 0, AccessCredentials{Invite: other.Token}); !errors.Is(err, errx.ErrRoomAccessDenied) {
+		t.Errorf("invite for another game: err = %v, want %v", err, errx.ErrRoomAccessDenied)
+	}
+
+	clock.Advance(accessFailureWindow + time.Second)
+	if err := access.Check(game, 0, AccessCredentials{Passcode: "open-sesame"}); err != nil {
+		t.Errorf("passcode after the window: %v", err)
+	}
+}
+
+func TestRoomAccessInviteOnlyIgnoresPasscode(t *testing.T) {
+	access := NewRoomAccess([]byte("test-secret"), newFakeClock())
+	game := &store.Game{Code: "ABC123", Access: store.GameAccessInvite}
+
+	if err := access.Check(game, 0, AccessCredentials{}); !errors.Is(err, errx.ErrRoomAccessRequired) {
+		t.Errorf("no credentials: err = %v, want %v", err, errx.ErrRoomAccessRequired)
+	}
+	for range maxAccessFailures + 1 {
+		if err := access.Check(game, 0, AccessCredentials{Passcode: "guess"}); !errors.Is(err, errx.ErrRoomAccessDenied) {
+			t.Fatalf("passcode on an invite-only room: err = %v, want %v", err, errx.ErrRoomAccessDenied)
+		}
+	}
+}
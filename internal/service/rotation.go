@@ -0,0 +1,57 @@
+package service
+
+import "github.com/y3933y3933/joker/internal/store"
+
+// 輪替規則：
+//   - 回答者沿著座位順序輪流，每次找上一位回答者之後第一個在線的座位，
+//     離線的座位直接跳過，不會重新從第一位開始
+//   - 上一輪沒有真正回答（例如被跳過）且該玩家仍在線時，由他重新回答
+//   - 出題者是回答者前一個在線的座位，正常情況下就是上一輪的回答者
+//
+// 在線名單不變的情況下，每位玩家都會回答一次之後才會有人回答第二次。
+
+// seatBefore 以 (seat, id) 排序，座位相同時（例如觀戰者轉為玩家）用 id 決定先後
+func seatBefore(a, b *store.Player) bool {
+	if a.Seat != b.Seat {
+		return a.Seat < b.Seat
+	}
+	return a.ID < b.ID
+}
+
+// seatAfter 回傳 p 之後第一個在線座位的 index，p 本身可能已離線；沒有的話繞回第一位
+func seatAfter(online []*store.Player, p *store.Player) int {
+	for i, o := range online {
+		if seatBefore(p, o) {
+			return i
+		}
+	}
+	return 0
+}
+
+func indexOfPlayer(online []*store.Player, id int64) int {
+	for i, p := range online {
+		if p.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// nextPair 決定下一輪的出題者與回答者。online 需依座位排序且至少兩人，
+// lastAnswerer 為上一輪的回答者（可能已離線），第一輪傳 nil
+func nextPair(online []*store.Player, lastAnswerer *store.Player, answered bool) (questioner, answerer *store.Player) {
+	n := len(online)
+
+	aIndex := 1 // 第一輪：第一位出題、第二位回答
+	if lastAnswerer != nil {
+		aIndex = seatAfter(online, lastAnswerer)
+		if !answered {
+			if i := indexOfPlayer(online, lastAnswerer.ID); i >= 0 {
+				aIndex = i
+			}
+		}
+	}
+
+	qIndex := (aIndex - 1 + n) % n
+	return online[qIndex], online[aIndex]
+}
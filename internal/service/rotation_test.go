@@ -0,0 +1,273 @@
+package service
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"slices"
+	"sort"
+	"testing"
+	"testing/quick"
+
+	"github.com/y3933y3933/joker/internal/store"
+)
+
+// seating 是隨機產生的一桌玩家與之後每一輪開始前的在線狀態：
+// 玩家依 (seat, id) 排序，座位可能重複（觀戰者轉為玩家），id 和座位順序無關
+type seating struct {
+	players  []*store.Player
+	online   [][]bool // 每一輪建立時誰在線，至少兩人
+	answered []bool   // 每一輪是否真的有回答
+	start    *store.Player
+}
+
+func (seating) Generate(r *rand.Rand, _ int) reflect.Value {
+	n := 2 + r.Intn(7)
+	s := seating{players: make([]*store.Player, n)}
+	for i, id := range r.Perm(n) {
+		s.players[i] = &store.Player{ID: int64(id + 1), Seat: int32(r.Intn(n)), Role: store.PlayerRolePlayer}
+	}
+	sort.Slice(s.players, func(i, j int) bool { return seatBefore(s.players[i], s.players[j]) })
+
+	mask := make([]bool, n)
+	for i := range mask {
+		mask[i] = r.Intn(4) > 0
+	}
+	for range 1 + r.Intn(60) {
+		mask = slices.Clone(mask)
+		for i := range mask {
+			if r.Intn(5) == 0 {
+				mask[i] = !mask[i]
+			}
+		}
+		for countOnline(mask) < 2 {
+			mask[r.Intn(n)] = true
+		}
+		s.online = append(s.online, mask)
+		s.answered = append(s.answered, r.Intn(5) > 0)
+	}
+
+	// 上一輪的回答者可能已離線，也可能是第一輪
+	if i := r.Intn(n + 1); i < n {
+		s.start = s.players[i]
+	}
+	return reflect.ValueOf(s)
+}
+
+func countOnline(mask []bool) int {
+	n := 0
+	for _, on := range mask {
+		if on {
+			n++
+		}
+	}
+	return n
+}
+
+func (s seating) onlineAt(step int) []*store.Player {
+	var online []*store.Player
+	for i, p := range s.players {
+		if s.online[step][i] {
+			online = append(online, p)
+		}
+	}
+	return online
+}
+
+func (s seating) isOnline(step int, p *store.Player) bool {
+	return s.online[step][slices.Index(s.players, p)]
+}
+
+// after 沿著整桌的座位順序找 p 之後第一位在線的玩家，必要時繞回開頭
+func (s seating) after(step int, p *store.Player) *store.Player {
+	i := slices.Index(s.players, p)
+	for k := 1; k <= len(s.players); k++ {
+		next := s.players[(i+k)%len(s.players)]
+		if s.online[step][slices.Index(s.players, next)] {
+			return next
+		}
+	}
+	return nil
+}
+
+func (s seating) String() string {
+	out := ""
+	for _, p := range s.players {
+		out += fmt.Sprintf("(id %d seat %d) ", p.ID, p.Seat)
+	}
+	return out
+}
+
+func previous(q, a *store.Player, answered bool) *PreviousRound {
+	round := &store.Round{}
+	if answered {
+		answer := "answer"
+		round.Answer = &answer
+	}
+	return &PreviousRound{Round: round, Questioner: q, Answerer: a}
+}
+
+func checkProperty(t *testing.T, prop func(s seating) bool) {
+	t.Helper()
+	if err := quick.Check(prop, &quick.Config{MaxCount: 500}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+var rotatingModes = []GameMode{classicMode{}, voteRevealMode{}}
+
+// 在線名單不變時，每位在線玩家都回答一次之後才會有人回答第二次，出題者是上一輪的回答者
+func TestRotationEveryoneAnswersBeforeAnyoneTwice(t *testing.T) {
+	for _, mode := range rotatingModes {
+		t.Run(mode.Name(), func(t *testing.T) {
+			checkProperty(t, func(s seating) bool {
+				online := s.onlineAt(0)
+				var prev *PreviousRound
+				if s.start != nil {
+					prev = previous(nil, s.start, true)
+				}
+
+				seen := make(map[int64]bool)
+				for i := range len(online) {
+					q, a := mode.NextPair(online, prev)
+					if q == a || !slices.Contains(online, q) || !slices.Contains(online, a) || seen[a.ID] {
+						t.Logf("%v online %v: round %d paired %d → %d", s, s.online[0], i, q.ID, a.ID)
+						return false
+					}
+					if i > 0 && q != prev.Answerer {
+						t.Logf("%v: round %d questioner %d, want last answerer %d", s, i, q.ID, prev.Answerer.ID)
+						return false
+					}
+					seen[a.ID] = true
+					prev = previous(q, a, true)
+				}
+				return true
+			})
+		})
+	}
+}
+
+// 玩家上下線時，回答者沿著整桌的座位往後找第一位在線的玩家，不會回到第一個座位重來；
+// 上一輪沒有回答且回答者仍在線時由他重新回答。
+// 任何人回答第二次之前，這段期間一直在線的玩家都已經回答過
+func TestRotationSkipsOfflineSeatsWithoutReset(t *testing.T) {
+	for _, mode := range rotatingModes {
+		t.Run(mode.Name(), func(t *testing.T) {
+			checkProperty(t, func(s seating) bool {
+				var prev *PreviousRound
+				if s.start != nil {
+					prev = previous(nil, s.start, true)
+				}
+				lastAnswer := make(map[int64]int)  // 玩家最近一次回答的輪次
+				onlineSince := make(map[int64]int) // 玩家這次連續在線從哪一輪開始
+
+				for step := range s.online {
+					online := s.onlineAt(step)
+					for _, p := range s.players {
+						if !s.isOnline(step, p) {
+							delete(onlineSince, p.ID)
+						} else if _, ok := onlineSince[p.ID]; !ok {
+							onlineSince[p.ID] = step
+						}
+					}
+
+					q, a := mode.NextPair(online, prev)
+					if prev != nil {
+						want := s.after(step, prev.Answerer)
+						if !prev.answered() && s.isOnline(step, prev.Answerer) {
+							want = prev.Answerer
+						}
+						if a != want {
+							t.Logf("%v step %d online %v: answerer %d, want %d after %d", s, step, s.online[step], a.ID, want.ID, prev.Answerer.ID)
+							return false
+						}
+					}
+					if q != online[(slices.Index(online, a)-1+len(online))%len(online)] {
+						t.Logf("%v step %d: questioner %d is not the seat before answerer %d", s, step, q.ID, a.ID)
+						return false
+					}
+
+					if s.answered[step] {
+						if last, ok := lastAnswer[a.ID]; ok {
+							for _, p := range online {
+								since, stayed := onlineSince[p.ID]
+								pLast, ever := lastAnswer[p.ID]
+								if p != a && stayed && since <= last+1 && (!ever || pLast < last) {
+									t.Logf("%v step %d: %d answers again before %d who stayed online since step %d", s, step, a.ID, p.ID, since)
+									return false
+								}
+							}
+						}
+						lastAnswer[a.ID] = step
+					}
+					prev = previous(q, a, s.answered[step])
+				}
+				return true
+			})
+		})
+	}
+}
+
+// 在線名單不變時，熱座上的人被其他每位玩家各問一次後才換座位，
+// 一圈下來每一組出題者與回答者剛好出現一次
+func TestHotSeatEveryoneAsksEachSeatOnce(t *testing.T) {
+	checkProperty(t, func(s seating) bool {
+		online := s.onlineAt(0)
+		n := len(online)
+		var prev *PreviousRound
+		pairs := make(map[[2]int64]bool)
+		hotSeats := make(map[int64]bool)
+
+		for i := range n * (n - 1) {
+			q, a := hotSeatMode{}.NextPair(online, prev)
+			pair := [2]int64{q.ID, a.ID}
+			if q == a || pairs[pair] {
+				t.Logf("%v online %v: round %d repeats pair %d → %d", s, s.online[0], i, q.ID, a.ID)
+				return false
+			}
+			if i%(n-1) == 0 {
+				if hotSeats[a.ID] {
+					t.Logf("%v: round %d puts %d on the hot seat twice", s, i, a.ID)
+					return false
+				}
+				hotSeats[a.ID] = true
+			} else if a != prev.Answerer {
+				t.Logf("%v: round %d moved the hot seat from %d before everyone asked", s, i, prev.Answerer.ID)
+				return false
+			}
+			pairs[pair] = true
+			prev = previous(q, a, true)
+		}
+		return true
+	})
+}
+
+// 玩家上下線時，熱座只會留在原位或換到下一個在線的座位；留在原位時出題者也是沿著座位往後找，
+// 不會回到第一個座位重來
+func TestHotSeatSkipsOfflineSeatsWithoutReset(t *testing.T) {
+	checkProperty(t, func(s seating) bool {
+		var prev *PreviousRound
+		for step := range s.online {
+			online := s.onlineAt(step)
+			q, a := hotSeatMode{}.NextPair(online, prev)
+			if q == a || !slices.Contains(online, q) || !slices.Contains(online, a) {
+				t.Logf("%v step %d online %v: paired %d → %d", s, step, s.online[step], q.ID, a.ID)
+				return false
+			}
+			if prev != nil {
+				hot := prev.Answerer
+				stayed := a == hot && s.isOnline(step, hot)
+				if !stayed && a != s.after(step, hot) {
+					t.Logf("%v step %d online %v: hot seat moved from %d to %d, want %d", s, step, s.online[step], hot.ID, a.ID, s.after(step, hot).ID)
+					return false
+				}
+				if stayed && q != s.after(step, prev.Questioner) {
+					t.Logf("%v step %d online %v: questioner %d, want %d after %d", s, step, s.online[step], q.ID, s.after(step, prev.Questioner).ID, prev.Questioner.ID)
+					return false
+				}
+			}
+			prev = previous(q, a, s.answered[step])
+		}
+		return true
+	})
+}
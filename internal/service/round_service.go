@@ -3,7 +3,6 @@ package service
 import (
 	"context"
 	"errors"
-	"fmt"
 
 	"math/rand"
 
@@ -18,17 +17,38 @@ type RoundService struct {
 	roundStore  store.RoundStore
 	playerStore store.PlayerStore
 	gameStore   store.GameStore
+	voteStore   store.VoteStore
+	tx          store.TxRunner
+	metrics     Metrics
 }
 
-func NewRoundService(roundStore store.RoundStore, playerStore store.PlayerStore, gameStore store.GameStore) *RoundService {
+func NewRoundService(roundStore store.RoundStore, playerStore store.PlayerStore, gameStore store.GameStore, voteStore store.VoteStore, tx store.TxRunner, metrics Metrics) *RoundService {
 	return &RoundService{
 		roundStore:  roundStore,
 		playerStore: playerStore,
 		gameStore:   gameStore,
+		voteStore:   voteStore,
+		tx:          tx,
+		metrics:     metrics,
 	}
 }
 
+// withStores 回傳使用交易內 store 的 RoundService
+func (s *RoundService) withStores(tx store.Stores) *RoundService {
+	return NewRoundService(tx.Rounds, tx.Players, tx.Games, tx.Votes, tx.Tx, s.metrics)
+}
+
+// inTx 在交易中執行 fn，fn 內的寫入會一起 commit 或 rollback
+func (s *RoundService) inTx(ctx context.Context, fn func(s *RoundService) error) error {
+	return s.tx.WithTx(ctx, func(tx store.Stores) error {
+		return fn(s.withStores(tx))
+	})
+}
+
 func (s *RoundService) StartGame(ctx context.Context, game *store.Game) (*store.Round, error) {
+	ctx, span := tracer.Start(ctx, "RoundService.StartGame")
+	defer span.End()
+
 	if game.Status != store.GameStatusWaiting {
 		return nil, errx.ErrInvalidGameStatus
 	}
@@ -41,21 +61,24 @@ func (s *RoundService) StartGame(ctx context.Context, game *store.Game) (*store.
 		return nil, errx.ErrNotEnoughPlayers
 	}
 
-	round, err := s.generateRound(ctx, game.ID, players)
-	if err != nil {
-		return nil, err
-	}
-
-	created, err := s.roundStore.Create(ctx, round)
+	round, err := s.generateRound(ctx, game, players)
 	if err != nil {
 		return nil, err
 	}
 
-	// 更新遊戲狀態為 playing
-	err = s.gameStore.UpdateStatus(ctx, game.ID, store.GameStatusPlaying)
+	// 建立第一回合與更新遊戲狀態為 playing 需要一起成功
+	var created *store.Round
+	err = s.inTx(ctx, func(tx *RoundService) error {
+		created, err = tx.roundStore.Create(ctx, round)
+		if err != nil {
+			return err
+		}
+		return tx.gameStore.UpdateStatus(ctx, game.ID, store.GameStatusPlaying)
+	})
 	if err != nil {
 		return nil, err
 	}
+	s.metrics.RoundStarted()
 
 	return created, nil
 
@@ -87,6 +110,9 @@ func generateDeck(n int) []string {
 }
 
 func (s *RoundService) SubmitQuestion(ctx context.Context, roundID int64, questionID int64, playerID int64) error {
+	ctx, span := tracer.Start(ctx, "RoundService.SubmitQuestion")
+	defer span.End()
+
 	round, err := s.roundStore.GetRoundByID(ctx, roundID)
 	if err != nil {
 		return err
@@ -104,6 +130,9 @@ func (s *RoundService) SubmitQuestion(ctx context.Context, roundID int64, questi
 }
 
 func (s *RoundService) GetRoundWithQuestion(ctx context.Context, roundID int64) (*store.RoundWithQuestion, error) {
+	ctx, span := tracer.Start(ctx, "RoundService.GetRoundWithQuestion")
+	defer span.End()
+
 	round, err := s.roundStore.GetRoundWithQuestion(ctx, roundID)
 	if err != nil {
 		return nil, err
@@ -111,7 +140,10 @@ func (s *RoundService) GetRoundWithQuestion(ctx context.Context, roundID int64)
 	return round, nil
 }
 
-func (s *RoundService) SubmitAnswer(ctx context.Context, roundID int64, answer string, playerID int64) error {
+func (s *RoundService) SubmitAnswer(ctx context.Context, game *store.Game, roundID int64, answer string, playerID int64, secret store.AnswerSecret) error {
+	ctx, span := tracer.Start(ctx, "RoundService.SubmitAnswer")
+	defer span.End()
+
 	round, err := s.roundStore.GetRoundByID(ctx, roundID)
 	if err != nil {
 		return err
@@ -125,8 +157,20 @@ func (s *RoundService) SubmitAnswer(ctx context.Context, roundID int64, answer s
 		return errx.ErrInvalidStatus
 	}
 
+	// 有猜測階段時先讓其他人投票，否則由玩法決定下一步（抽牌或投票）
+	status := gameModeOf(game).AnsweredStatus()
+	if game.Guessing != store.GuessingOff && game.Guessing != "" {
+		err = s.validateAnswerSecret(ctx, game, secret)
+		if err != nil {
+			return err
+		}
+		status = store.RoundStatusWaitingForVotes
+	} else {
+		secret = store.AnswerSecret{}
+	}
+
 	// 更新回答與狀態
-	err = s.roundStore.UpdateAnswer(ctx, roundID, answer, store.RoundStatusWaitingForDraw)
+	err = s.roundStore.UpdateAnswer(ctx, roundID, answer, status, secret)
 	if err != nil {
 		return err
 	}
@@ -134,6 +178,9 @@ func (s *RoundService) SubmitAnswer(ctx context.Context, roundID int64, answer s
 }
 
 func (s *RoundService) DrawCard(ctx context.Context, roundID, playerID int64, index int) (*store.RoundWithQuestion, error) {
+	ctx, span := tracer.Start(ctx, "RoundService.DrawCard")
+	defer span.End()
+
 	round, err := s.roundStore.GetRoundByID(ctx, roundID)
 	if err != nil {
 		return nil, err
@@ -146,7 +193,7 @@ func (s *RoundService) DrawCard(ctx context.Context, roundID, playerID int64, in
 		return nil, errx.ErrForbidden
 	}
 	if index < 0 || index >= len(round.Deck) {
-		return nil, errors.New("invalid card index")
+		return nil, errx.ErrInvalidCardIndex
 	}
 
 	card := round.Deck[index]
@@ -163,23 +210,37 @@ func (s *RoundService) DrawCard(ctx context.Context, roundID, playerID int64, in
 	if err != nil {
 		return nil, err
 	}
+	if isJoker {
+		s.metrics.RoundRevealed()
+	}
 
 	return s.roundStore.GetRoundWithQuestion(ctx, roundID)
 }
 
 func (s *RoundService) CreateNextRound(ctx context.Context, game *store.Game) (*store.Round, error) {
-	players, err := s.playerStore.FindOnlinePlayersByGameID(ctx, game.ID)
+	ctx, span := tracer.Start(ctx, "RoundService.CreateNextRound")
+	defer span.End()
+
+	created, err := s.createNextRound(ctx, game)
 	if err != nil {
 		return nil, err
 	}
+	s.metrics.RoundStarted()
+	return created, nil
+}
 
-	fmt.Println(len(players))
+// createNextRound 建立新回合但不計數，交易內呼叫時由外層在 commit 後計數
+func (s *RoundService) createNextRound(ctx context.Context, game *store.Game) (*store.Round, error) {
+	players, err := s.playerStore.FindOnlinePlayersByGameID(ctx, game.ID)
+	if err != nil {
+		return nil, err
+	}
 
 	if len(players) < 2 {
 		return nil, errx.ErrNotEnoughPlayers
 	}
 
-	round, err := s.generateRound(ctx, game.ID, players)
+	round, err := s.generateRound(ctx, game, players)
 	if err != nil {
 		return nil, err
 	}
@@ -191,64 +252,105 @@ func (s *RoundService) CreateNextRound(ctx context.Context, game *store.Game) (*
 	return created, nil
 }
 
-func (s *RoundService) generateRound(ctx context.Context, gameID int64, players []*store.Player) (*store.Round, error) {
+func (s *RoundService) generateRound(ctx context.Context, game *store.Game, players []*store.Player) (*store.Round, error) {
+	mode := gameModeOf(game)
+
 	// 找出上一輪
-	lastRound, err := s.roundStore.FindLastRoundByGameID(ctx, gameID)
+	lastRound, err := s.roundStore.FindLastRoundByGameID(ctx, game.ID)
 	if err != nil && !errors.Is(err, errx.ErrRoundNotFound) {
 		return nil, err
 	}
 
-	var questioner, answerer *store.Player
-
-	if lastRound == nil {
-		// 第一輪：取前兩位
-		questioner, answerer = players[0], players[1]
-	} else {
-		questioner, answerer = getNextPair(players, lastRound.QuestionPlayerID)
+	var prev *PreviousRound
+	if lastRound != nil {
+		// 上一輪的出題者與回答者可能已離線，仍以他們的座位作為輪替的起點
+		prev = &PreviousRound{Round: lastRound}
+		prev.Questioner, err = s.findRoundPlayer(ctx, lastRound.QuestionPlayerID)
+		if err != nil {
+			return nil, err
+		}
+		prev.Answerer, err = s.findRoundPlayer(ctx, lastRound.AnswerPlayerID)
+		if err != nil {
+			return nil, err
+		}
 	}
 
+	questioner, answerer := mode.NextPair(players, prev)
+
 	round := &store.Round{
-		GameID:           gameID,
+		GameID:           game.ID,
 		QuestionPlayerID: questioner.ID,
 		AnswerPlayerID:   answerer.ID,
 		Status:           store.RoundStatusWaitingForQuestion,
-		Deck:             generateDeck(DECK_LENGTH),
+		Deck:             mode.Deck(),
 	}
 
 	return round, nil
 }
 
-func getNextPair(players []*store.Player, lastQuestionerID int64) (questioner, answerer *store.Player) {
-	n := len(players)
-	var qIndex int
-
-	// 找出上一輪 questioner 的位置
-	for i, p := range players {
-		if p.ID == lastQuestionerID {
-			qIndex = (i + 1) % n // 下一位成為新的 questioner
-			break
+// findRoundPlayer 找不到玩家時回傳 nil，不視為錯誤
+func (s *RoundService) findRoundPlayer(ctx context.Context, playerID int64) (*store.Player, error) {
+	player, err := s.playerStore.FindByID(ctx, playerID)
+	if err != nil {
+		if errors.Is(err, errx.ErrPlayerNotFound) {
+			return nil, nil
 		}
+		return nil, err
 	}
-
-	aIndex := (qIndex + 1) % n // 下一位成為回答者
-	return players[qIndex], players[aIndex]
+	return player, nil
 }
 
+// SkipRound 結束目前回合並開新回合，開不了新回合時目前回合也保持不變
 func (s *RoundService) SkipRound(ctx context.Context, game *store.Game, roundID int64) (*store.Round, error) {
-	err := s.roundStore.UpdateRoundStatus(ctx, roundID, store.RoundStatusDone)
+	ctx, span := tracer.Start(ctx, "RoundService.SkipRound")
+	defer span.End()
+
+	var newRound *store.Round
+	err := s.inTx(ctx, func(tx *RoundService) error {
+		err := tx.roundStore.SkipRound(ctx, roundID)
+		if err != nil {
+			return err
+		}
+
+		newRound, err = tx.createNextRound(ctx, game)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
+	s.metrics.RoundSkipped()
+	s.metrics.RoundStarted()
 
-	newRound, err := s.CreateNextRound(ctx, game)
+	return newRound, nil
+
+}
+
+// SkipRoundIfInvolved 在玩家離開遊戲時，若他正負責出題或回答，跳過目前回合並開新回合。
+// 玩家與目前回合無關時回傳 nil
+func (s *RoundService) SkipRoundIfInvolved(ctx context.Context, game *store.Game, playerID int64) (*store.Round, error) {
+	ctx, span := tracer.Start(ctx, "RoundService.SkipRoundIfInvolved")
+	defer span.End()
+
+	round, err := s.roundStore.FindLastRoundByGameID(ctx, game.ID)
 	if err != nil {
+		if errors.Is(err, errx.ErrRoundNotFound) {
+			return nil, nil
+		}
 		return nil, err
 	}
 
-	return newRound, nil
+	isQuestion := round.Status == store.RoundStatusWaitingForQuestion && round.QuestionPlayerID == playerID
+	isAnswer := (round.Status == store.RoundStatusWaitingForAnswer || round.Status == store.RoundStatusWaitingForDraw) && round.AnswerPlayerID == playerID
+	if !isQuestion && !isAnswer {
+		return nil, nil
+	}
 
+	return s.SkipRound(ctx, game, round.ID)
 }
 
 func (s *RoundService) FindLastRoundByGameID(ctx context.Context, gameID int64) (*store.Round, error) {
+	ctx, span := tracer.Start(ctx, "RoundService.FindLastRoundByGameID")
+	defer span.End()
+
 	return s.roundStore.FindLastRoundByGameID(ctx, gameID)
 }
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/y3933y3933/joker/internal/store"
+	"github.com/y3933y3933/joker/internal/utils/errx"
+)
+
+// ScoringRules 每種事件得到的分數，建立遊戲時可以調整，可為負數
+type ScoringRules struct {
+	Survived     int `json:"survived"`     // 回答後題目沒有被公開（抽到安全牌或投票不公開）
+	Revealed     int `json:"revealed"`     // 回答後題目被公開
+	CorrectGuess int `json:"correctGuess"` // 猜測階段猜對
+	MajorityVote int `json:"majorityVote"` // vote_reveal：投票和最後結果一致
+}
+
+var DefaultScoringRules = ScoringRules{
+	Survived:     1,
+	Revealed:     0,
+	CorrectGuess: 1,
+	MajorityVote: 1,
+}
+
+const maxRulePoints = 10
+
+func (r ScoringRules) validate() error {
+	for _, p := range []int{r.Survived, r.Revealed, r.CorrectGuess, r.MajorityVote} {
+		if p < -maxRulePoints || p > maxRulePoints {
+			return errx.ErrInvalidScoring
+		}
+	}
+	return nil
+}
+
+// ScoringRulesOf 解析遊戲的計分規則，沒有設定的項目使用預設值
+func ScoringRulesOf(game *store.Game) ScoringRules {
+	rules := DefaultScoringRules
+	if len(game.Scoring) > 0 {
+		_ = json.Unmarshal(game.Scoring, &rules)
+	}
+	return rules
+}
+
+// scored 回合是否有抽牌或投票的結果；跳過的回合即使已經回答也不計分
+func scored(r *store.Round) bool {
+	return r.Answer != nil && !r.Skipped
+}
+
+// answerPoints 依回答後題目是否被公開給回答者分數，所有玩法共用
+func answerPoints(scores map[int64]int, rounds []*store.Round, rules ScoringRules) {
+	for _, r := range rounds {
+		if !scored(r) {
+			continue
+		}
+		switch r.Status {
+		case store.RoundStatusDone:
+			scores[r.AnswerPlayerID] += rules.Survived
+		case store.RoundStatusRevealed:
+			scores[r.AnswerPlayerID] += rules.Revealed
+		}
+	}
+}
+
+// computeScores 依玩法與計分規則算出整場每位玩家的分數，以及猜對的次數
+func computeScores(game *store.Game, rounds []*store.Round, votes []*store.Vote) (scores, guesses map[int64]int) {
+	rules := ScoringRulesOf(game)
+	scores = gameModeOf(game).Score(rounds, votes, rules)
+
+	guesses = correctGuesses(rounds, votes)
+	for id, n := range guesses {
+		scores[id] += n * rules.CorrectGuess
+	}
+	return scores, guesses
+}
+
+type LeaderboardEntry struct {
+	PlayerID int64        `json:"playerID"`
+	Nickname string       `json:"nickname"`
+	Avatar   store.Avatar `json:"avatar"`
+	Score    int          `json:"score"`
+	Rank     int          `json:"rank"` // 同分同名次
+}
+
+// rankByScore 依分數由高到低排序並給名次，同分時依座位順序
+func rankByScore(entries []LeaderboardEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Score > entries[j].Score
+	})
+	for i := range entries {
+		if i > 0 && entries[i].Score == entries[i-1].Score {
+			entries[i].Rank = entries[i-1].Rank
+		} else {
+			entries[i].Rank = i + 1
+		}
+	}
+}
+
+func unwrapRounds(rounds []*store.RoundWithQuestion) []*store.Round {
+	out := make([]*store.Round, len(rounds))
+	for i, r := range rounds {
+		out[i] = &r.Round
+	}
+	return out
+}
+
+// Leaderboard 目前的排行榜，每回合結束後推播給房間
+func (s *RoundService) Leaderboard(ctx context.Context, game *store.Game) ([]LeaderboardEntry, error) {
+	ctx, span := tracer.Start(ctx, "RoundService.Leaderboard")
+	defer span.End()
+
+	players, err := s.playerStore.FindPlayersByGameID(ctx, game.ID)
+	if err != nil {
+		return nil, err
+	}
+	rounds, err := s.roundStore.ListByGameID(ctx, game.ID)
+	if err != nil {
+		return nil, err
+	}
+	votes, err := s.voteStore.ListByGameID(ctx, game.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	scores, _ := computeScores(game, unwrapRounds(rounds), votes)
+
+	entries := make([]LeaderboardEntry, 0, len(players))
+	for _, p := range players {
+		if p.Role != store.PlayerRolePlayer {
+			continue
+		}
+		entries = append(entries, LeaderboardEntry{
+			PlayerID: p.ID,
+			Nickname: p.Nickname,
+			Avatar:   p.Avatar,
+			Score:    scores[p.ID],
+		})
+	}
+	rankByScore(entries)
+	return entries, nil
+}
+
+const (
+	AwardSpiciestAnswerer = "spiciest_answerer" // 回答最多 spicy 題目
+	AwardSpiciestAsker    = "spiciest_asker"    // 出最多 spicy 題目
+	AwardMostRevealed     = "most_revealed"     // 題目最常被公開
+	AwardLongestStreak    = "longest_streak"    // 最長的連續未公開紀錄
+)
+
+// playerHistory 從回合紀錄整理出每位玩家的統計
+func playerHistory(players []store.GamePlayerSummary, rounds []*store.RoundWithQuestion) (map[int64]*store.GamePlayerSummary, map[int64]int) {
+	byID := make(map[int64]*store.GamePlayerSummary, len(players))
+	for i := range players {
+		byID[players[i].ID] = &players[i]
+	}
+
+	spicyAsked := make(map[int64]int)
+	streak := make(map[int64]int)
+	for _, r := range rounds {
+		if r.Level == store.QuestionLevelSpicy {
+			spicyAsked[r.QuestionPlayerID]++
+		}
+		if p, ok := byID[r.QuestionPlayerID]; ok && r.QuestionID != nil {
+			p.Asked++
+		}
+
+		p, ok := byID[r.AnswerPlayerID]
+		if !ok || r.Answer == nil {
+			continue
+		}
+		p.Answered++
+		if r.Level == store.QuestionLevelSpicy {
+			p.SpicyAnswered++
+		}
+		// 跳過的回合沒有結果，不影響連續未公開的紀錄
+		if r.Skipped {
+			continue
+		}
+		switch r.Status {
+		case store.RoundStatusDone:
+			streak[p.ID]++
+			p.LongestStreak = max(p.LongestStreak, streak[p.ID])
+		case store.RoundStatusRevealed:
+			streak[p.ID] = 0
+		}
+	}
+	return byID, spicyAsked
+}
+
+// topAward 找出數值最高的玩家，全部為 0 時不頒發
+func topAward(award string, players []store.GamePlayerSummary, count func(p *store.GamePlayerSummary) int) *store.GameAward {
+	var best *store.GameAward
+	for i := range players {
+		n := count(&players[i])
+		if n > 0 && (best == nil || n > best.Count) {
+			best = &store.GameAward{
+				Award:    award,
+				PlayerID: players[i].ID,
+				Nickname: players[i].Nickname,
+				Count:    n,
+			}
+		}
+	}
+	return best
+}
+
+func gameAwards(players []store.GamePlayerSummary, spicyAsked map[int64]int) []store.GameAward {
+	candidates := []*store.GameAward{
+		topAward(AwardSpiciestAnswerer, players, func(p *store.GamePlayerSummary) int { return p.SpicyAnswered }),
+		topAward(AwardSpiciestAsker, players, func(p *store.GamePlayerSummary) int { return spicyAsked[p.ID] }),
+		topAward(AwardMostRevealed, players, func(p *store.GamePlayerSummary) int { return int(p.JokerCardsDrawn) }),
+		topAward(AwardLongestStreak, players, func(p *store.GamePlayerSummary) int { return p.LongestStreak }),
+	}
+
+	awards := []store.GameAward{}
+	for _, a := range candidates {
+		if a != nil {
+			awards = append(awards, *a)
+		}
+	}
+	return awards
+}
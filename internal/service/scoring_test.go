@@ -0,0 +1,189 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/y3933y3933/joker/internal/store"
+)
+
+func answered(id, questioner, answerer int64, status string) *store.Round {
+	answer := "answer"
+	return &store.Round{ID: id, QuestionPlayerID: questioner, AnswerPlayerID: answerer, Answer: &answer, Status: status}
+}
+
+func skipped(r *store.Round) *store.Round {
+	r.Skipped = true
+	return r
+}
+
+func TestAnswerPointsIgnoresSkippedRounds(t *testing.T) {
+	rules := ScoringRules{Survived: 2, Revealed: -1}
+	rounds := []*store.Round{
+		answered(1, 2, 1, store.RoundStatusDone),
+		answered(2, 1, 2, store.RoundStatusRevealed),
+		// 回答後在抽牌前斷線，回合被跳過
+		skipped(answered(3, 2, 1, store.RoundStatusDone)),
+		{ID: 4, QuestionPlayerID: 1, AnswerPlayerID: 2, Status: store.RoundStatusDone},
+	}
+
+	scores := make(map[int64]int)
+	answerPoints(scores, rounds, rules)
+
+	if scores[1] != 2 || scores[2] != -1 {
+		t.Fatalf("scores = %v, want 1:2 2:-1", scores)
+	}
+}
+
+func TestVoteRevealScoreIgnoresSkippedRounds(t *testing.T) {
+	rules := ScoringRules{Survived: 1, MajorityVote: 1}
+	rounds := []*store.Round{
+		answered(1, 2, 1, store.RoundStatusRevealed),
+		skipped(answered(2, 3, 2, store.RoundStatusDone)),
+	}
+	votes := []*store.Vote{
+		{RoundID: 1, PlayerID: 2, Kind: store.VoteKindReveal, Choice: store.VoteChoiceReveal},
+		{RoundID: 1, PlayerID: 3, Kind: store.VoteKindReveal, Choice: store.VoteChoiceKeep},
+		{RoundID: 2, PlayerID: 1, Kind: store.VoteKindReveal, Choice: store.VoteChoiceKeep},
+	}
+
+	scores := voteRevealMode{}.Score(rounds, votes, rules)
+
+	want := map[int64]int{2: 1}
+	for _, id := range []int64{1, 2, 3} {
+		if scores[id] != want[id] {
+			t.Fatalf("scores = %v, want %v", scores, want)
+		}
+	}
+}
+
+func TestComputeScoresAddsCorrectGuesses(t *testing.T) {
+	rules, _ := json.Marshal(ScoringRules{Survived: 3, CorrectGuess: 2})
+	game := &store.Game{Mode: store.GameModeClassic, Guessing: store.GuessingTruth, Scoring: rules}
+
+	truthful := true
+	r1 := answered(1, 2, 1, store.RoundStatusDone)
+	r1.Truthful = &truthful
+	r2 := skipped(answered(2, 1, 2, store.RoundStatusDone))
+	r2.Truthful = &truthful
+	votes := []*store.Vote{
+		{RoundID: 1, PlayerID: 2, Kind: store.VoteKindTruth, Choice: "true"},
+		{RoundID: 1, PlayerID: 3, Kind: store.VoteKindTruth, Choice: "false"},
+		{RoundID: 2, PlayerID: 3, Kind: store.VoteKindTruth, Choice: "true"},
+	}
+
+	scores, guesses := computeScores(game, []*store.Round{r1, r2}, votes)
+
+	if guesses[2] != 1 || guesses[3] != 0 {
+		t.Fatalf("guesses = %v, want 2:1 3:0", guesses)
+	}
+	if scores[1] != 3 || scores[2] != 2 || scores[3] != 0 {
+		t.Fatalf("scores = %v, want 1:3 2:2 3:0", scores)
+	}
+}
+
+func TestScoringRulesOfFillsDefaults(t *testing.T) {
+	game := &store.Game{Scoring: json.RawMessage(`{"revealed":-2}`)}
+
+	rules := ScoringRulesOf(game)
+
+	want := DefaultScoringRules
+	want.Revealed = -2
+	if rules != want {
+		t.Fatalf("rules = %+v, want %+v", rules, want)
+	}
+}
+
+func TestRankByScoreSharesRankOnTies(t *testing.T) {
+	entries := []LeaderboardEntry{
+		{PlayerID: 1, Score: 1},
+		{PlayerID: 2, Score: 3},
+		{PlayerID: 3, Score: 1},
+		{PlayerID: 4, Score: 3},
+		{PlayerID: 5, Score: 0},
+	}
+
+	rankByScore(entries)
+
+	want := []struct {
+		id   int64
+		rank int
+	}{{2, 1}, {4, 1}, {1, 3}, {3, 3}, {5, 5}}
+	for i, w := range want {
+		if entries[i].PlayerID != w.id || entries[i].Rank != w.rank {
+			t.Fatalf("entries[%d] = player %d rank %d, want player %d rank %d", i, entries[i].PlayerID, entries[i].Rank, w.id, w.rank)
+		}
+	}
+}
+
+func withLevel(r *store.Round, level string) *store.RoundWithQuestion {
+	if r.Answer != nil {
+		id := r.ID
+		r.QuestionID = &id
+	}
+	return &store.RoundWithQuestion{Round: *r, Question: store.Question{Level: level}}
+}
+
+func TestPlayerHistoryStreakIgnoresSkippedRounds(t *testing.T) {
+	players := []store.GamePlayerSummary{{ID: 1, Nickname: "a"}, {ID: 2, Nickname: "b"}}
+	rounds := []*store.RoundWithQuestion{
+		withLevel(answered(1, 2, 1, store.RoundStatusDone), store.QuestionLevelSpicy),
+		withLevel(answered(2, 1, 2, store.RoundStatusRevealed), store.QuestionLevelNormal),
+		// 跳過的回合不延長也不中斷連續紀錄
+		withLevel(skipped(answered(3, 2, 1, store.RoundStatusDone)), store.QuestionLevelSpicy),
+		withLevel(answered(4, 1, 2, store.RoundStatusDone), store.QuestionLevelNormal),
+		withLevel(answered(5, 2, 1, store.RoundStatusDone), store.QuestionLevelNormal),
+		withLevel(answered(6, 1, 2, store.RoundStatusDone), store.QuestionLevelSpicy),
+	}
+
+	byID, spicyAsked := playerHistory(players, rounds)
+
+	a, b := byID[1], byID[2]
+	if a.LongestStreak != 2 || b.LongestStreak != 2 {
+		t.Fatalf("streaks = %d, %d, want 2, 2", a.LongestStreak, b.LongestStreak)
+	}
+	if a.Answered != 3 || a.SpicyAnswered != 2 || a.Asked != 3 {
+		t.Fatalf("a = %+v, want answered 3, spicy 2, asked 3", *a)
+	}
+	if b.Answered != 3 || b.SpicyAnswered != 1 || b.Asked != 3 {
+		t.Fatalf("b = %+v, want answered 3, spicy 1, asked 3", *b)
+	}
+	if spicyAsked[2] != 2 || spicyAsked[1] != 1 {
+		t.Fatalf("spicyAsked = %v, want 1:1 2:2", spicyAsked)
+	}
+}
+
+func TestGameAwards(t *testing.T) {
+	players := []store.GamePlayerSummary{
+		{ID: 1, Nickname: "a", SpicyAnswered: 2, LongestStreak: 3},
+		{ID: 2, Nickname: "b", SpicyAnswered: 2, JokerCardsDrawn: 1},
+		{ID: 3, Nickname: "c"},
+	}
+	spicyAsked := map[int64]int{3: 1}
+
+	awards := gameAwards(players, spicyAsked)
+
+	want := []store.GameAward{
+		// 同分時頒給座位在前的玩家
+		{Award: AwardSpiciestAnswerer, PlayerID: 1, Nickname: "a", Count: 2},
+		{Award: AwardSpiciestAsker, PlayerID: 3, Nickname: "c", Count: 1},
+		{Award: AwardMostRevealed, PlayerID: 2, Nickname: "b", Count: 1},
+		{Award: AwardLongestStreak, PlayerID: 1, Nickname: "a", Count: 3},
+	}
+	if len(awards) != len(want) {
+		t.Fatalf("awards = %+v, want %+v", awards, want)
+	}
+	for i := range want {
+		if awards[i] != want[i] {
+			t.Fatalf("awards[%d] = %+v, want %+v", i, awards[i], want[i])
+		}
+	}
+}
+
+func TestGameAwardsSkipsZeroCounts(t *testing.T) {
+	players := []store.GamePlayerSummary{{ID: 1, Nickname: "a"}, {ID: 2, Nickname: "b"}}
+
+	if awards := gameAwards(players, nil); len(awards) != 0 {
+		t.Fatalf("awards = %+v, want none", awards)
+	}
+}
@@ -0,0 +1,6 @@
+package service
+
+import "github.com/y3933y3933/joker/internal/tracing"
+
+// tracer 為每個 service 方法建立 span，名稱為 "型別.方法"
+var tracer = tracing.NewTracer("github.com/y3933y3933/joker/internal/service")
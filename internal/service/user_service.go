@@ -15,5 +15,8 @@ func NewUserService(userStore store.UserStore) *UserService {
 }
 
 func (s *UserService) GetUserInfo(ctx context.Context, userID int64) (*store.User, error) {
+	ctx, span := tracer.Start(ctx, "UserService.GetUserInfo")
+	defer span.End()
+
 	return s.userStore.GetUserByID(ctx, userID)
 }
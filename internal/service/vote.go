@@ -0,0 +1,325 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/y3933y3933/joker/internal/store"
+	"github.com/y3933y3933/joker/internal/utils/errx"
+)
+
+// 回答之後的投票階段（waiting_for_votes）：
+//   - 遊戲開啟 guessing 時，其他玩家猜回答說的是誰，或回答是否屬實
+//   - vote_reveal 玩法中，其他玩家投票決定是否公開題目
+//
+// 兩種投票可以同時存在，每位玩家一次送出所有需要的選擇。
+// 所有在線玩家（回答者除外）都投完後結算。
+
+// Ballot 是一位玩家在投票階段的選擇，依遊戲設定填入需要的欄位
+type Ballot struct {
+	Reveal        *bool  `json:"reveal"`        // vote_reveal 玩法：是否公開題目
+	AboutPlayerID *int64 `json:"aboutPlayerID"` // guessing = about：猜回答說的是哪位玩家
+	Truthful      *bool  `json:"truthful"`      // guessing = truth：猜回答是否屬實
+}
+
+type RevealOutcome struct {
+	Revealed    bool `json:"revealed"` // 多數投公開才公開，平手不公開
+	RevealVotes int  `json:"revealVotes"`
+	KeepVotes   int  `json:"keepVotes"`
+}
+
+type GuessOutcome struct {
+	Kind          string  `json:"kind"`
+	AboutPlayerID *int64  `json:"aboutPlayerID,omitempty"`
+	Truthful      *bool   `json:"truthful,omitempty"`
+	Correct       []int64 `json:"correct"` // 猜對的玩家
+}
+
+type VoteResult struct {
+	Votes    int                      `json:"votes"`  // 已投票人數
+	Needed   int                      `json:"needed"` // 需要投票的人數：在線且不是回答者
+	Resolved bool                     `json:"resolved"`
+	Status   string                   `json:"status"` // 回合目前的狀態
+	Reveal   *RevealOutcome           `json:"reveal,omitempty"`
+	Guess    *GuessOutcome            `json:"guess,omitempty"`
+	Round    *store.RoundWithQuestion `json:"-"` // 結算後才有
+}
+
+func guessingEnabled(game *store.Game) bool {
+	return game.Guessing != "" && game.Guessing != store.GuessingOff
+}
+
+// voteKinds 列出這個遊戲在投票階段需要的投票種類
+func voteKinds(game *store.Game) []string {
+	var kinds []string
+	if guessingEnabled(game) {
+		kinds = append(kinds, game.Guessing)
+	}
+	if gameModeOf(game).AnsweredStatus() == store.RoundStatusWaitingForVotes {
+		kinds = append(kinds, store.VoteKindReveal)
+	}
+	return kinds
+}
+
+// secretChoice 把回答者提供的正確答案轉成和投票相同的格式
+func secretChoice(kind string, secret store.AnswerSecret) (string, bool) {
+	switch kind {
+	case store.VoteKindAbout:
+		if secret.AboutPlayerID == nil {
+			return "", false
+		}
+		return strconv.FormatInt(*secret.AboutPlayerID, 10), true
+	case store.VoteKindTruth:
+		if secret.Truthful == nil {
+			return "", false
+		}
+		return strconv.FormatBool(*secret.Truthful), true
+	}
+	return "", false
+}
+
+// guessedRight 投票是否猜中該回合的正確答案
+func guessedRight(round *store.Round, vote *store.Vote) bool {
+	answer, ok := secretChoice(vote.Kind, round.AnswerSecret)
+	return ok && vote.Choice == answer
+}
+
+// correctGuesses 計算整場遊戲每位玩家猜對的次數
+func correctGuesses(rounds []*store.Round, votes []*store.Vote) map[int64]int {
+	byID := make(map[int64]*store.Round, len(rounds))
+	for _, r := range rounds {
+		// 跳過的回合沒有結算，猜測也不計
+		if !r.Skipped {
+			byID[r.ID] = r
+		}
+	}
+
+	counts := make(map[int64]int)
+	for _, v := range votes {
+		if v.Kind != store.VoteKindAbout && v.Kind != store.VoteKindTruth {
+			continue
+		}
+		if r, ok := byID[v.RoundID]; ok && guessedRight(r, v) {
+			counts[v.PlayerID]++
+		}
+	}
+	return counts
+}
+
+func (s *RoundService) validateAnswerSecret(ctx context.Context, game *store.Game, secret store.AnswerSecret) error {
+	switch game.Guessing {
+	case store.GuessingAbout:
+		if secret.AboutPlayerID == nil {
+			return errx.ErrInvalidSecret
+		}
+		about, err := s.playerStore.FindByID(ctx, *secret.AboutPlayerID)
+		if err != nil {
+			if errors.Is(err, errx.ErrPlayerNotFound) {
+				return errx.ErrInvalidSecret
+			}
+			return err
+		}
+		if about.GameID != game.ID || about.Role != store.PlayerRolePlayer {
+			return errx.ErrInvalidSecret
+		}
+	case store.GuessingTruth:
+		if secret.Truthful == nil {
+			return errx.ErrInvalidSecret
+		}
+	}
+	return nil
+}
+
+func ballotChoice(kind string, ballot Ballot) (string, bool) {
+	switch kind {
+	case store.VoteKindReveal:
+		if ballot.Reveal == nil {
+			return "", false
+		}
+		if *ballot.Reveal {
+			return store.VoteChoiceReveal, true
+		}
+		return store.VoteChoiceKeep, true
+	case store.VoteKindAbout:
+		if ballot.AboutPlayerID == nil {
+			return "", false
+		}
+		return strconv.FormatInt(*ballot.AboutPlayerID, 10), true
+	case store.VoteKindTruth:
+		if ballot.Truthful == nil {
+			return "", false
+		}
+		return strconv.FormatBool(*ballot.Truthful), true
+	}
+	return "", false
+}
+
+// SubmitVote 送出一位玩家在投票階段的選擇，最後一位投完時一併結算
+func (s *RoundService) SubmitVote(ctx context.Context, game *store.Game, roundID, playerID int64, ballot Ballot) (*VoteResult, error) {
+	ctx, span := tracer.Start(ctx, "RoundService.SubmitVote")
+	defer span.End()
+
+	round, err := s.roundStore.GetRoundByID(ctx, roundID)
+	if err != nil {
+		return nil, err
+	}
+	if round.GameID != game.ID {
+		return nil, errx.ErrRoundNotFound
+	}
+	if round.Status != store.RoundStatusWaitingForVotes {
+		return nil, errx.ErrInvalidStatus
+	}
+	if round.AnswerPlayerID == playerID {
+		return nil, errx.ErrForbidden
+	}
+
+	voter, err := s.playerStore.FindByID(ctx, playerID)
+	if err != nil {
+		return nil, err
+	}
+	if voter.GameID != game.ID || voter.Role != store.PlayerRolePlayer {
+		return nil, errx.ErrForbidden
+	}
+
+	kinds := voteKinds(game)
+	votes := make([]*store.Vote, 0, len(kinds))
+	for _, kind := range kinds {
+		choice, ok := ballotChoice(kind, ballot)
+		if !ok {
+			return nil, errx.ErrInvalidBallot
+		}
+		votes = append(votes, &store.Vote{
+			RoundID:  round.ID,
+			PlayerID: playerID,
+			Kind:     kind,
+			Choice:   choice,
+		})
+	}
+
+	// 一張選票的多個選擇一起寫入；結算放在交易外，才看得到同時投票的其他人已 commit 的選票
+	err = s.inTx(ctx, func(tx *RoundService) error {
+		for _, v := range votes {
+			if _, err := tx.voteStore.Create(ctx, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.tallyVotes(ctx, game, round)
+}
+
+// RecheckVotes 在玩家離線後重新檢查投票是否已經可以結算，
+// 沒有進行中的投票時回傳 nil
+func (s *RoundService) RecheckVotes(ctx context.Context, game *store.Game) (*VoteResult, error) {
+	ctx, span := tracer.Start(ctx, "RoundService.RecheckVotes")
+	defer span.End()
+
+	round, err := s.roundStore.FindLastRoundByGameID(ctx, game.ID)
+	if err != nil {
+		if errors.Is(err, errx.ErrRoundNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if round.Status != store.RoundStatusWaitingForVotes {
+		return nil, nil
+	}
+	return s.tallyVotes(ctx, game, round)
+}
+
+func (s *RoundService) tallyVotes(ctx context.Context, game *store.Game, round *store.Round) (*VoteResult, error) {
+	players, err := s.playerStore.FindOnlinePlayersByGameID(ctx, round.GameID)
+	if err != nil {
+		return nil, err
+	}
+	votes, err := s.voteStore.ListByRoundID(ctx, round.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	voted := make(map[int64]bool, len(votes))
+	for _, v := range votes {
+		voted[v.PlayerID] = true
+	}
+
+	result := &VoteResult{Votes: len(voted), Status: round.Status}
+	pending := 0
+	for _, p := range players {
+		if p.ID == round.AnswerPlayerID {
+			continue
+		}
+		result.Needed++
+		if !voted[p.ID] {
+			pending++
+		}
+	}
+	if pending > 0 {
+		return result, nil
+	}
+
+	if guessingEnabled(game) {
+		guess := &GuessOutcome{
+			Kind:          game.Guessing,
+			AboutPlayerID: round.AboutPlayerID,
+			Truthful:      round.Truthful,
+			Correct:       []int64{},
+		}
+		for _, v := range votes {
+			if v.Kind == game.Guessing && guessedRight(round, v) {
+				guess.Correct = append(guess.Correct, v.PlayerID)
+			}
+		}
+		result.Guess = guess
+	}
+
+	var outcome *bool // vote_reveal 的結果，其他情況不改 is_joker
+	mode := gameModeOf(game)
+	if mode.AnsweredStatus() == store.RoundStatusWaitingForVotes {
+		reveal := &RevealOutcome{}
+		for _, v := range votes {
+			if v.Kind != store.VoteKindReveal {
+				continue
+			}
+			if v.Choice == store.VoteChoiceReveal {
+				reveal.RevealVotes++
+			} else {
+				reveal.KeepVotes++
+			}
+		}
+		reveal.Revealed = reveal.RevealVotes > reveal.KeepVotes
+		result.Reveal = reveal
+
+		result.Status = store.RoundStatusDone
+		if reveal.Revealed {
+			result.Status = store.RoundStatusRevealed
+		}
+		outcome = &reveal.Revealed
+	} else {
+		// 猜完之後回到玩法原本的流程，例如回答者抽牌
+		result.Status = mode.AnsweredStatus()
+	}
+
+	// 最後兩票同時送出、或最後一票和斷線重算同時發生時，只有更新成功的那一個結算並推播
+	resolved, err := s.roundStore.ResolveVotes(ctx, round.ID, result.Status, outcome)
+	if err != nil {
+		return nil, err
+	}
+	if !resolved {
+		return &VoteResult{Votes: result.Votes, Needed: result.Needed, Status: result.Status}, nil
+	}
+	result.Resolved = true
+	if result.Reveal != nil && result.Reveal.Revealed {
+		s.metrics.RoundRevealed()
+	}
+
+	result.Round, err = s.roundStore.GetRoundWithQuestion(ctx, round.ID)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
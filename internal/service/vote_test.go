@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/y3933y3933/joker/internal/store"
+)
+
+// newVotingRound 建立一場 vote_reveal 且開啟 guessing 的遊戲，回合停在 waiting_for_votes，
+// 第一位玩家是回答者
+func newVotingRound(t *testing.T, players int) (*RoundService, *store.Game, *store.Round, []*store.Player) {
+	t.Helper()
+	ctx := context.Background()
+	db := store.NewMemoryDB()
+	stores := db.Stores()
+
+	game, err := stores.Games.Create(ctx, &store.Game{Code: "VOTE00", Status: store.GameStatusPlaying, Mode: store.GameModeVoteReveal, Guessing: store.GuessingTruth})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var seated []*store.Player
+	for i := range players {
+		p, err := stores.Players.Create(ctx, &store.Player{GameID: game.ID, Nickname: fmt.Sprintf("p%d", i), NicknameKey: fmt.Sprintf("p%d", i), Role: store.PlayerRolePlayer})
+		if err != nil {
+			t.Fatal(err)
+		}
+		seated = append(seated, p)
+	}
+	q, err := db.Questions().Create(ctx, "question", store.QuestionLevelNormal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	round, err := stores.Rounds.Create(ctx, &store.Round{
+		GameID:           game.ID,
+		QuestionID:       &q.ID,
+		QuestionPlayerID: seated[1].ID,
+		AnswerPlayerID:   seated[0].ID,
+		Status:           store.RoundStatusWaitingForVotes,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewRoundService(stores.Rounds, stores.Players, stores.Games, stores.Votes, stores.Tx, NopMetrics)
+	return s, game, round, seated
+}
+
+func TestConcurrentFinalVotesResolveOnce(t *testing.T) {
+	for range 50 {
+		s, game, round, players := newVotingRound(t, 4)
+		reveal, truthful := true, false
+		ballot := Ballot{Reveal: &reveal, Truthful: &truthful}
+
+		if _, err := s.SubmitVote(context.Background(), game, round.ID, players[1].ID, ballot); err != nil {
+			t.Fatal(err)
+		}
+
+		// 最後兩票和斷線重算同時發生
+		var mu sync.Mutex
+		resolved := 0
+		count := func(res *VoteResult, err error) {
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if res != nil && res.Resolved {
+				mu.Lock()
+				resolved++
+				mu.Unlock()
+			}
+		}
+		var wg sync.WaitGroup
+		for _, p := range players[2:] {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				count(s.SubmitVote(context.Background(), game, round.ID, p.ID, ballot))
+			}()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			count(s.RecheckVotes(context.Background(), game))
+		}()
+		wg.Wait()
+
+		if resolved != 1 {
+			t.Fatalf("votes resolved %d times, want exactly once", resolved)
+		}
+	}
+}
+
+func TestTallyVotesResolvesOnlyFromWaitingForVotes(t *testing.T) {
+	ctx := context.Background()
+	s, game, round, players := newVotingRound(t, 3)
+	for _, p := range players[1:] {
+		if _, err := s.voteStore.Create(ctx, &store.Vote{RoundID: round.ID, PlayerID: p.ID, Kind: store.VoteKindReveal, Choice: store.VoteChoiceKeep}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.voteStore.Create(ctx, &store.Vote{RoundID: round.ID, PlayerID: p.ID, Kind: store.VoteKindTruth, Choice: "true"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 兩個請求都讀到 waiting_for_votes 的回合，只有先更新的那個結算
+	first, err := s.tallyVotes(ctx, game, round)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !first.Resolved || first.Reveal == nil || first.Reveal.Revealed || first.Status != store.RoundStatusDone {
+		t.Fatalf("first tally = %+v, want resolved and kept", first)
+	}
+	second, err := s.tallyVotes(ctx, game, round)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Resolved || second.Reveal != nil || second.Guess != nil {
+		t.Errorf("second tally = %+v, want unresolved", second)
+	}
+}
+
+// 踢掉最後一位還沒投票的人，回合不能一直卡在 waiting_for_votes
+func TestKickLastPendingVoterResolvesVotes(t *testing.T) {
+	ctx := context.Background()
+	s, game, round, players := newVotingRound(t, 4)
+	host := players[1]
+	if err := s.playerStore.UpdateHost(ctx, host.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	hosts := NewHostService(s.playerStore, s.gameStore, s, nil, s.tx)
+
+	reveal, truthful := true, false
+	for _, p := range players[1:3] {
+		if _, err := s.SubmitVote(ctx, game, round.ID, p.ID, Ballot{Reveal: &reveal, Truthful: &truthful}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	res, err := hosts.KickPlayer(ctx, game, host.ID, players[3].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Votes == nil || !res.Votes.Resolved || res.Leaderboard == nil {
+		t.Fatalf("kick result = %+v, want resolved votes and a leaderboard", res)
+	}
+	got, err := s.roundStore.FindLastRoundByGameID(ctx, game.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != round.ID || got.Status != store.RoundStatusRevealed {
+		t.Fatalf("round %d status = %s, want %d %s", got.ID, got.Status, round.ID, store.RoundStatusRevealed)
+	}
+}
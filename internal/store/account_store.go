@@ -0,0 +1,214 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/y3933y3933/joker/internal/db/sqlc"
+	"github.com/y3933y3933/joker/internal/utils/errx"
+)
+
+// Account 是玩家自己建立的帳號，和後台管理員的 User 分開
+type Account struct {
+	ID        int64     `json:"id"`
+	Username  string    `json:"username"`
+	Password  password  `json:"-"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// AccountGame 帳號玩過的一場遊戲
+type AccountGame struct {
+	Code            string    `json:"code"`
+	Status          string    `json:"status"`
+	Mode            string    `json:"mode"`
+	EndedReason     *string   `json:"endedReason,omitempty"`
+	PlayedAt        time.Time `json:"playedAt"`
+	PlayerID        int64     `json:"playerID"`
+	Nickname        string    `json:"nickname"`
+	Answered        int64     `json:"answered"`
+	JokerCardsDrawn int64     `json:"jokerCardsDrawn"`
+}
+
+type PaginatedAccountGame struct {
+	Games []AccountGame `json:"games"`
+	Metadata
+}
+
+// AccountStats 帳號所有遊戲累計的數據
+type AccountStats struct {
+	GamesPlayed     int64 `json:"gamesPlayed"`
+	Answered        int64 `json:"answered"`
+	Asked           int64 `json:"asked"`
+	JokerCardsDrawn int64 `json:"jokerCardsDrawn"`
+	Revealed        int64 `json:"revealed"`
+}
+
+type FavoriteQuestion struct {
+	Question
+	FavoritedAt time.Time `json:"favoritedAt"`
+}
+
+type PostgresAccountStore struct {
+	queries *sqlc.Queries
+}
+
+func NewPostgresAccountStore(queries *sqlc.Queries) *PostgresAccountStore {
+	return &PostgresAccountStore{queries: queries}
+}
+
+type AccountStore interface {
+	Create(ctx context.Context, account *Account) error
+	GetByUsername(ctx context.Context, username string) (*Account, error)
+	GetByID(ctx context.Context, id int64) (*Account, error)
+	ListGames(ctx context.Context, accountID int64, filters Filters) (*PaginatedAccountGame, error)
+	GetStats(ctx context.Context, accountID int64) (*AccountStats, error)
+	AddFavorite(ctx context.Context, accountID, questionID int64) error
+	RemoveFavorite(ctx context.Context, accountID, questionID int64) error
+	ListFavorites(ctx context.Context, accountID int64) ([]FavoriteQuestion, error)
+}
+
+func (pg *PostgresAccountStore) Create(ctx context.Context, account *Account) error {
+	row, err := pg.queries.CreateAccount(ctx, sqlc.CreateAccountParams{
+		Username:     account.Username,
+		PasswordHash: account.Password.hash,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return errx.ErrDuplicateUsername
+		}
+		return err
+	}
+
+	account.ID = row.ID
+	account.CreatedAt = row.CreatedAt.Time
+	return nil
+}
+
+func (pg *PostgresAccountStore) GetByUsername(ctx context.Context, username string) (*Account, error) {
+	row, err := pg.queries.GetAccountByUsername(ctx, username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errx.ErrAccountNotFound
+		}
+		return nil, err
+	}
+
+	return &Account{
+		ID:        row.ID,
+		Username:  row.Username,
+		Password:  password{hash: row.PasswordHash},
+		CreatedAt: row.CreatedAt.Time,
+	}, nil
+}
+
+func (pg *PostgresAccountStore) GetByID(ctx context.Context, id int64) (*Account, error) {
+	row, err := pg.queries.GetAccountByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errx.ErrAccountNotFound
+		}
+		return nil, err
+	}
+
+	return &Account{
+		ID:        row.ID,
+		Username:  row.Username,
+		CreatedAt: row.CreatedAt.Time,
+	}, nil
+}
+
+func (pg *PostgresAccountStore) ListGames(ctx context.Context, accountID int64, filters Filters) (*PaginatedAccountGame, error) {
+	rows, err := pg.queries.ListAccountGames(ctx, sqlc.ListAccountGamesParams{
+		AccountID: toPgInt8(&accountID),
+		Limit:     int32(filters.limit()),
+		Offset:    int32(filters.offset()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var totalCount int64
+	games := make([]AccountGame, len(rows))
+	for i, row := range rows {
+		totalCount = row.TotalCount
+		games[i] = AccountGame{
+			Code:            row.Code,
+			Status:          row.Status,
+			Mode:            row.Mode,
+			EndedReason:     fromPgText(row.EndedReason),
+			PlayedAt:        row.CreatedAt.Time,
+			PlayerID:        row.PlayerID,
+			Nickname:        row.Nickname,
+			Answered:        row.Answered,
+			JokerCardsDrawn: row.JokerCardsDrawn,
+		}
+	}
+
+	return &PaginatedAccountGame{
+		Games:    games,
+		Metadata: CalculateMetadata(int(totalCount), filters.Page, filters.PageSize),
+	}, nil
+}
+
+func (pg *PostgresAccountStore) GetStats(ctx context.Context, accountID int64) (*AccountStats, error) {
+	row, err := pg.queries.GetAccountStats(ctx, toPgInt8(&accountID))
+	if err != nil {
+		return nil, err
+	}
+
+	return &AccountStats{
+		GamesPlayed:     row.GamesPlayed,
+		Answered:        row.Answered,
+		Asked:           row.Asked,
+		JokerCardsDrawn: row.JokerCardsDrawn,
+		Revealed:        row.Revealed,
+	}, nil
+}
+
+func (pg *PostgresAccountStore) AddFavorite(ctx context.Context, accountID, questionID int64) error {
+	err := pg.queries.AddFavoriteQuestion(ctx, sqlc.AddFavoriteQuestionParams{
+		AccountID:  accountID,
+		QuestionID: questionID,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
+			return errx.ErrQuestionNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (pg *PostgresAccountStore) RemoveFavorite(ctx context.Context, accountID, questionID int64) error {
+	return pg.queries.RemoveFavoriteQuestion(ctx, sqlc.RemoveFavoriteQuestionParams{
+		AccountID:  accountID,
+		QuestionID: questionID,
+	})
+}
+
+func (pg *PostgresAccountStore) ListFavorites(ctx context.Context, accountID int64) ([]FavoriteQuestion, error) {
+	rows, err := pg.queries.ListFavoriteQuestions(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	favorites := make([]FavoriteQuestion, len(rows))
+	for i, row := range rows {
+		favorites[i] = FavoriteQuestion{
+			Question: Question{
+				ID:        row.ID,
+				Level:     row.Level,
+				Content:   row.Content,
+				CreatedAt: row.QuestionCreatedAt.Time,
+			},
+			FavoritedAt: row.CreatedAt.Time,
+		}
+	}
+	return favorites, nil
+}
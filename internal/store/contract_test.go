@@ -0,0 +1,655 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/y3933y3933/joker/internal/utils/errx"
+)
+
+// storeSet 是契約測試用到的所有 store
+type storeSet struct {
+	Stores
+	Questions QuestionStore
+	Feedback  FeedbackStore
+	Users     UserStore
+	Accounts  AccountStore
+}
+
+func TestMemoryStores(t *testing.T) {
+	runStoreContract(t, func(t *testing.T) storeSet {
+		db := NewMemoryDB()
+		return storeSet{
+			Stores:    db.Stores(),
+			Questions: db.Questions(),
+			Feedback:  db.Feedback(),
+			Users:     db.Users(),
+			Accounts:  db.Accounts(),
+		}
+	})
+}
+
+// TestPostgresStores 先以嵌入的 migration 更新資料庫，每個子測試開始前會清空所有資料表
+func TestPostgresStores(t *testing.T) {
+	dbURL := os.Getenv("TEST_DB_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DB_URL is not set")
+	}
+
+	pool, queries, err := Open(dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+
+	migrator, err := NewMigrator(pool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer migrator.Close()
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckSchemaVersion(context.Background(), pool); err != nil {
+		t.Fatal(err)
+	}
+
+	runStoreContract(t, func(t *testing.T) storeSet {
+		_, err := pool.Exec(context.Background(), `TRUNCATE games, players, game_bans, rounds, votes, questions,
+			feedback, users, accounts, account_favorite_questions RESTART IDENTITY CASCADE`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return storeSet{
+			Stores: Stores{
+				Games:   NewPostgresGameStore(queries),
+				Players: NewPostgresPlayerStore(queries),
+				Rounds:  NewPostgresRoundStore(queries),
+				Votes:   NewPostgresVoteStore(queries),
+				Tx:      NewPostgresTxRunner(pool, queries),
+			},
+			Questions: NewPostgresQuestionStore(queries),
+			Feedback:  NewPostgresFeedStore(queries),
+			Users:     NewPostgresUserStore(queries),
+			Accounts:  NewPostgresAccountStore(queries),
+		}
+	})
+}
+
+func runStoreContract(t *testing.T, newStores func(t *testing.T) storeSet) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, s storeSet)
+	}{
+		{"Games", testGames},
+		{"GameList", testGameList},
+		{"Players", testPlayers},
+		{"DeleteGameCascades", testDeleteGameCascades},
+		{"Rounds", testRounds},
+		{"Votes", testVotes},
+		{"Questions", testQuestions},
+		{"Feedback", testFeedback},
+		{"Users", testUsers},
+		{"Accounts", testAccounts},
+		{"Transactions", testTransactions},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newStores(t))
+		})
+	}
+}
+
+func mustNoErr(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func wantErr(t *testing.T, err, want error) {
+	t.Helper()
+	if !errors.Is(err, want) {
+		t.Fatalf("got error %v, want %v", err, want)
+	}
+}
+
+func createGame(t *testing.T, s storeSet, code string) *Game {
+	t.Helper()
+	game, err := s.Games.Create(context.Background(), &Game{
+		Code:     code,
+		Status:   GameStatusWaiting,
+		Mode:     GameModeClassic,
+		Guessing: GuessingOff,
+	})
+	mustNoErr(t, err)
+	return game
+}
+
+func createPlayer(t *testing.T, s storeSet, gameID int64, nickname, role string) *Player {
+	t.Helper()
+	player, err := s.Players.Create(context.Background(), &Player{
+		GameID:      gameID,
+		Nickname:    nickname,
+		NicknameKey: nickname,
+		Role:        role,
+	})
+	mustNoErr(t, err)
+	return player
+}
+
+func createRound(t *testing.T, s storeSet, gameID, questionerID, answererID int64) *Round {
+	t.Helper()
+	round, err := s.Rounds.Create(context.Background(), &Round{
+		GameID:           gameID,
+		QuestionPlayerID: questionerID,
+		AnswerPlayerID:   answererID,
+		Status:           RoundStatusWaitingForQuestion,
+		Deck:             []string{"joker", "safe"},
+	})
+	mustNoErr(t, err)
+	return round
+}
+
+func testGames(t *testing.T, s storeSet) {
+	ctx := context.Background()
+	game := createGame(t, s, "ABC123")
+	if game.Access != GameAccessPublic {
+		t.Errorf("access = %q, want %q", game.Access, GameAccessPublic)
+	}
+
+	got, err := s.Games.GetGameByCode(ctx, "ABC123")
+	mustNoErr(t, err)
+	if got.ID != game.ID || got.Status != GameStatusWaiting || got.Mode != GameModeClassic {
+		t.Errorf("GetGameByCode = %+v, want game %d", got, game.ID)
+	}
+
+	_, err = s.Games.GetGameByCode(ctx, "NOPE00")
+	wantErr(t, err, errx.ErrGameNotFound)
+	_, err = s.Games.GetGameStatusByID(ctx, game.ID+100)
+	wantErr(t, err, errx.ErrGameNotFound)
+	mustNoErr(t, s.Tx.WithTx(ctx, func(tx Stores) error {
+		return tx.Games.LockForUpdate(ctx, game.ID)
+	}))
+	wantErr(t, s.Games.LockForUpdate(ctx, game.ID+100), errx.ErrGameNotFound)
+
+	ok, err := s.Games.SetRematchCode(ctx, game.ID, "DEF456")
+	mustNoErr(t, err)
+	if !ok {
+		t.Error("first SetRematchCode should succeed")
+	}
+	ok, err = s.Games.SetRematchCode(ctx, game.ID, "GHI789")
+	mustNoErr(t, err)
+	if ok {
+		t.Error("second SetRematchCode should not replace the rematch code")
+	}
+
+	// 已結束的遊戲仍然查得到，代碼也不能重複使用
+	mustNoErr(t, s.Games.EndGame(ctx, "ABC123", EndReasonFinished))
+	got, err = s.Games.GetGameByCode(ctx, "ABC123")
+	mustNoErr(t, err)
+	if got.Status != GameStatusEnded || got.EndedReason == nil || *got.EndedReason != EndReasonFinished {
+		t.Errorf("ended game = %+v, want status %q with reason %q", got, GameStatusEnded, EndReasonFinished)
+	}
+	exists, err := s.Games.GameCodeExists(ctx, "ABC123")
+	mustNoErr(t, err)
+	if !exists {
+		t.Error("ended game code should still be taken")
+	}
+
+	// 再次結束不會覆蓋原本的原因
+	mustNoErr(t, s.Games.EndGame(ctx, "ABC123", EndReasonIdle))
+	got, err = s.Games.GetGameByCode(ctx, "ABC123")
+	mustNoErr(t, err)
+	if got.EndedReason == nil || *got.EndedReason != EndReasonFinished {
+		t.Errorf("ended reason = %v, want %q", got.EndedReason, EndReasonFinished)
+	}
+}
+
+func testGameList(t *testing.T, s storeSet) {
+	ctx := context.Background()
+	first := createGame(t, s, "AAA111")
+	createGame(t, s, "BBB222")
+	last := createGame(t, s, "CCC333")
+	createPlayer(t, s, last.ID, "alice", PlayerRolePlayer)
+	createPlayer(t, s, last.ID, "bob", PlayerRolePlayer)
+	createPlayer(t, s, last.ID, "watcher", PlayerRoleSpectator)
+
+	// 觀戰者不算在玩家人數內
+	n, err := s.Players.GetPlayerCountByGameCode(ctx, last.Code)
+	mustNoErr(t, err)
+	live, err := s.Players.GetLivePlayerCount(ctx)
+	mustNoErr(t, err)
+	if n != 2 || live != 2 {
+		t.Errorf("player count = %d, live = %d, want 2 and 2", n, live)
+	}
+
+	page, err := s.Games.List(ctx, "", "", Filters{Page: 1, PageSize: 2})
+	mustNoErr(t, err)
+	if len(page.Games) != 2 || page.Games[0].ID != last.ID {
+		t.Fatalf("first page = %+v, want newest game first", page.Games)
+	}
+	if page.Games[0].PlayerCount != 2 {
+		t.Errorf("player count = %d, want 2", page.Games[0].PlayerCount)
+	}
+	want := Metadata{TotalCount: 3, CurrentPage: 1, PageSize: 2, FirstPage: 1, LastPage: 2}
+	if page.Metadata != want {
+		t.Errorf("metadata = %+v, want %+v", page.Metadata, want)
+	}
+
+	page, err = s.Games.List(ctx, "", "", Filters{Page: 2, PageSize: 2})
+	mustNoErr(t, err)
+	if len(page.Games) != 1 || page.Games[0].ID != first.ID {
+		t.Errorf("second page = %+v, want the oldest game", page.Games)
+	}
+
+	page, err = s.Games.List(ctx, "", "", Filters{Page: 3, PageSize: 2})
+	mustNoErr(t, err)
+	if len(page.Games) != 0 || page.Metadata != (Metadata{}) {
+		t.Errorf("page past the end = %+v, want no games and empty metadata", page)
+	}
+
+	page, err = s.Games.List(ctx, "bbb222", "", Filters{Page: 1, PageSize: 10})
+	mustNoErr(t, err)
+	if len(page.Games) != 1 || page.Games[0].Code != "BBB222" {
+		t.Errorf("code filter = %+v, want BBB222", page.Games)
+	}
+}
+
+func testPlayers(t *testing.T, s storeSet) {
+	ctx := context.Background()
+	game := createGame(t, s, "ABC123")
+	alice := createPlayer(t, s, game.ID, "alice", PlayerRolePlayer)
+	bob := createPlayer(t, s, game.ID, "bob", PlayerRolePlayer)
+	createPlayer(t, s, game.ID, "carol", PlayerRoleSpectator)
+
+	if alice.Seat != 1 || bob.Seat != 2 {
+		t.Errorf("seats = %d, %d, want 1, 2", alice.Seat, bob.Seat)
+	}
+	if alice.Status != PlayerStatusOnline {
+		t.Errorf("status = %q, want %q", alice.Status, PlayerStatusOnline)
+	}
+
+	n, err := s.Players.CountPlayerInGame(ctx, game.ID)
+	mustNoErr(t, err)
+	if n != 2 {
+		t.Errorf("CountPlayerInGame = %d, want 2 (spectators excluded)", n)
+	}
+
+	mustNoErr(t, s.Players.UpdateSeat(ctx, alice.ID, 4))
+	players, err := s.Players.FindPlayersByGameID(ctx, game.ID)
+	mustNoErr(t, err)
+	if len(players) != 3 || players[0].ID != bob.ID || players[2].ID != alice.ID {
+		t.Errorf("FindPlayersByGameID should order by seat, got %+v", players)
+	}
+
+	_, err = s.Players.FindByID(ctx, alice.ID+100)
+	wantErr(t, err, errx.ErrPlayerNotFound)
+
+	found, err := s.Players.FindByNickname(ctx, game.ID, "dave")
+	mustNoErr(t, err)
+	if found != nil {
+		t.Errorf("FindByNickname for a missing nickname = %+v, want nil", found)
+	}
+
+	// 同一場遊戲的比對鍵不能重複，包括同時加入與改名
+	_, err = s.Players.Create(ctx, &Player{GameID: game.ID, Nickname: "Alice", NicknameKey: "alice", Role: PlayerRolePlayer})
+	wantErr(t, err, errx.ErrDuplicateNickname)
+	renamed := *bob
+	renamed.Nickname, renamed.NicknameKey = "Alice", "alice"
+	wantErr(t, s.Players.UpdateProfile(ctx, &renamed), errx.ErrDuplicateNickname)
+	renamed.Nickname = "Bob"
+	renamed.NicknameKey = "bob"
+	mustNoErr(t, s.Players.UpdateProfile(ctx, &renamed))
+	other := createGame(t, s, "XYZ789")
+	createPlayer(t, s, other.ID, "alice", PlayerRolePlayer)
+
+	mustNoErr(t, s.Players.BanNickname(ctx, game.ID, "bob"))
+	banned, err := s.Players.IsNicknameBanned(ctx, game.ID, "bob")
+	mustNoErr(t, err)
+	if !banned {
+		t.Error("bob should be banned")
+	}
+
+	mustNoErr(t, s.Players.CopyBans(ctx, game.ID, other.ID))
+	banned, err = s.Players.IsNicknameBanned(ctx, other.ID, "bob")
+	mustNoErr(t, err)
+	if !banned {
+		t.Error("bob should be banned in the copied game")
+	}
+	mustNoErr(t, s.Players.CopyBans(ctx, game.ID, other.ID))
+}
+
+func testDeleteGameCascades(t *testing.T, s storeSet) {
+	ctx := context.Background()
+	game := createGame(t, s, "ABC123")
+	alice := createPlayer(t, s, game.ID, "alice", PlayerRolePlayer)
+	bob := createPlayer(t, s, game.ID, "bob", PlayerRolePlayer)
+	round := createRound(t, s, game.ID, alice.ID, bob.ID)
+	_, err := s.Votes.Create(ctx, &Vote{RoundID: round.ID, PlayerID: alice.ID, Kind: VoteKindReveal, Choice: VoteChoiceReveal})
+	mustNoErr(t, err)
+
+	mustNoErr(t, s.Games.DeleteByCode(ctx, "ABC123"))
+
+	_, err = s.Players.FindByID(ctx, alice.ID)
+	wantErr(t, err, errx.ErrPlayerNotFound)
+	_, err = s.Rounds.GetRoundByID(ctx, round.ID)
+	wantErr(t, err, errx.ErrRoundNotFound)
+	votes, err := s.Votes.ListByRoundID(ctx, round.ID)
+	mustNoErr(t, err)
+	if len(votes) != 0 {
+		t.Errorf("votes after delete = %+v, want none", votes)
+	}
+}
+
+func testRounds(t *testing.T, s storeSet) {
+	ctx := context.Background()
+	game := createGame(t, s, "ABC123")
+	alice := createPlayer(t, s, game.ID, "alice", PlayerRolePlayer)
+	bob := createPlayer(t, s, game.ID, "bob", PlayerRolePlayer)
+
+	_, err := s.Rounds.FindLastRoundByGameID(ctx, game.ID)
+	wantErr(t, err, errx.ErrRoundNotFound)
+
+	first := createRound(t, s, game.ID, alice.ID, bob.ID)
+	if first.QuestionID != nil {
+		t.Errorf("new round question = %v, want nil", *first.QuestionID)
+	}
+	_, err = s.Rounds.GetRoundWithQuestion(ctx, first.ID)
+	wantErr(t, err, errx.ErrRoundNotFound)
+
+	question, err := s.Questions.Create(ctx, "What is your secret?", QuestionLevelNormal)
+	mustNoErr(t, err)
+	mustNoErr(t, s.Rounds.SetRoundQuestion(ctx, first.ID, question.ID))
+
+	withQuestion, err := s.Rounds.GetRoundWithQuestion(ctx, first.ID)
+	mustNoErr(t, err)
+	if withQuestion.Status != RoundStatusWaitingForAnswer || withQuestion.Question.Content != question.Content {
+		t.Errorf("GetRoundWithQuestion = %+v", withQuestion)
+	}
+
+	mustNoErr(t, s.Rounds.UpdateAnswer(ctx, first.ID, "nothing", RoundStatusWaitingForDraw, AnswerSecret{}))
+	mustNoErr(t, s.Rounds.UpdateDrawResult(ctx, first.ID, true, RoundStatusRevealed))
+	got, err := s.Rounds.GetRoundByID(ctx, first.ID)
+	mustNoErr(t, err)
+	if got.Answer == nil || *got.Answer != "nothing" || !got.IsJoker || got.Status != RoundStatusRevealed {
+		t.Errorf("round after answer and draw = %+v", got)
+	}
+
+	second := createRound(t, s, game.ID, bob.ID, alice.ID)
+	last, err := s.Rounds.FindLastRoundByGameID(ctx, game.ID)
+	mustNoErr(t, err)
+	if last.ID != second.ID {
+		t.Errorf("last round = %d, want %d", last.ID, second.ID)
+	}
+
+	// 投票只會結算一次，不在 waiting_for_votes 的回合不更新
+	ok, err := s.Rounds.ResolveVotes(ctx, second.ID, RoundStatusDone, nil)
+	mustNoErr(t, err)
+	if ok {
+		t.Error("ResolveVotes should not update a round that is not waiting for votes")
+	}
+	mustNoErr(t, s.Rounds.UpdateRoundStatus(ctx, second.ID, RoundStatusWaitingForVotes))
+	revealed := false
+	ok, err = s.Rounds.ResolveVotes(ctx, second.ID, RoundStatusDone, &revealed)
+	mustNoErr(t, err)
+	if !ok {
+		t.Error("first ResolveVotes should succeed")
+	}
+	ok, err = s.Rounds.ResolveVotes(ctx, second.ID, RoundStatusRevealed, nil)
+	mustNoErr(t, err)
+	if ok {
+		t.Error("second ResolveVotes should not succeed")
+	}
+	got, err = s.Rounds.GetRoundByID(ctx, second.ID)
+	mustNoErr(t, err)
+	if got.Status != RoundStatusDone || got.IsJoker {
+		t.Errorf("round after ResolveVotes = %+v, want done and not joker", got)
+	}
+
+	if got.Skipped {
+		t.Error("resolved round should not be marked skipped")
+	}
+	mustNoErr(t, s.Rounds.SkipRound(ctx, first.ID))
+	got, err = s.Rounds.GetRoundByID(ctx, first.ID)
+	mustNoErr(t, err)
+	if got.Status != RoundStatusDone || !got.Skipped {
+		t.Errorf("round after SkipRound = %+v, want done and skipped", got)
+	}
+
+	rounds, err := s.Rounds.ListByGameID(ctx, game.ID)
+	mustNoErr(t, err)
+	if len(rounds) != 2 || rounds[0].Round.ID != first.ID || rounds[1].Question.Content != "" {
+		t.Errorf("ListByGameID = %+v, want both rounds in creation order", rounds)
+	}
+	if !rounds[0].Skipped || rounds[1].Skipped {
+		t.Errorf("ListByGameID skipped = %v, %v, want true, false", rounds[0].Skipped, rounds[1].Skipped)
+	}
+
+	summary, err := s.Games.GetGameSummary(ctx, game.ID)
+	mustNoErr(t, err)
+	if summary.TotalRounds != 2 || summary.JokerCards != 1 {
+		t.Errorf("summary = %+v, want 2 rounds and 1 joker", summary)
+	}
+}
+
+func testVotes(t *testing.T, s storeSet) {
+	ctx := context.Background()
+	game := createGame(t, s, "ABC123")
+	alice := createPlayer(t, s, game.ID, "alice", PlayerRolePlayer)
+	bob := createPlayer(t, s, game.ID, "bob", PlayerRolePlayer)
+	carol := createPlayer(t, s, game.ID, "carol", PlayerRolePlayer)
+	round := createRound(t, s, game.ID, alice.ID, bob.ID)
+
+	for _, p := range []*Player{carol, alice} {
+		_, err := s.Votes.Create(ctx, &Vote{RoundID: round.ID, PlayerID: p.ID, Kind: VoteKindReveal, Choice: VoteChoiceReveal})
+		mustNoErr(t, err)
+	}
+	_, err := s.Votes.Create(ctx, &Vote{RoundID: round.ID, PlayerID: carol.ID, Kind: VoteKindReveal, Choice: VoteChoiceReveal})
+	wantErr(t, err, errx.ErrAlreadyVoted)
+
+	votes, err := s.Votes.ListByGameID(ctx, game.ID)
+	mustNoErr(t, err)
+	if len(votes) != 2 || votes[0].PlayerID != carol.ID || votes[1].PlayerID != alice.ID {
+		t.Errorf("ListByGameID = %+v, want votes in the order they were cast", votes)
+	}
+}
+
+func testQuestions(t *testing.T, s storeSet) {
+	ctx := context.Background()
+	_, err := s.Questions.Get(ctx, 1)
+	wantErr(t, err, errx.ErrQuestionNotFound)
+	_, err = s.Questions.Update(ctx, 1, "content", QuestionLevelNormal)
+	wantErr(t, err, errx.ErrQuestionNotFound)
+
+	secret, err := s.Questions.Create(ctx, "What is your biggest secret?", QuestionLevelSpicy)
+	mustNoErr(t, err)
+	food, err := s.Questions.Create(ctx, "What is your favorite food?", QuestionLevelNormal)
+	mustNoErr(t, err)
+
+	result, err := s.Questions.ListQuestions(ctx, "secret", "", Filters{Page: 1, PageSize: 10})
+	mustNoErr(t, err)
+	if len(result.Questions) != 1 || result.Questions[0].ID != secret.ID {
+		t.Errorf("keyword search = %+v, want only the secret question", result.Questions)
+	}
+
+	result, err = s.Questions.ListQuestions(ctx, "", QuestionLevelNormal, Filters{Page: 1, PageSize: 10})
+	mustNoErr(t, err)
+	if len(result.Questions) != 1 || result.Questions[0].ID != food.ID {
+		t.Errorf("level filter = %+v, want only the normal question", result.Questions)
+	}
+
+	result, err = s.Questions.ListQuestions(ctx, "", "", Filters{Page: 1, PageSize: 10, SortBy: "created_at_asc"})
+	mustNoErr(t, err)
+	if len(result.Questions) != 2 || result.Questions[0].ID != secret.ID || result.TotalCount != 2 {
+		t.Errorf("sorted list = %+v, want oldest question first", result)
+	}
+
+	updated, err := s.Questions.Update(ctx, food.ID, "What is your least favorite food?", QuestionLevelSpicy)
+	mustNoErr(t, err)
+	if updated.Level != QuestionLevelSpicy {
+		t.Errorf("updated level = %q, want %q", updated.Level, QuestionLevelSpicy)
+	}
+
+	mustNoErr(t, s.Questions.Delete(ctx, food.ID))
+	_, err = s.Questions.Get(ctx, food.ID)
+	wantErr(t, err, errx.ErrQuestionNotFound)
+}
+
+func testFeedback(t *testing.T, s storeSet) {
+	ctx := context.Background()
+	_, err := s.Feedback.GetByID(ctx, 1)
+	wantErr(t, err, errx.ErrFeedbackNotFound)
+
+	mustNoErr(t, s.Feedback.Create(ctx, &Feedback{Type: "issue", Content: "the timer froze"}))
+	mustNoErr(t, s.Feedback.Create(ctx, &Feedback{Type: "feature", Content: "more questions"}))
+
+	count, err := s.Feedback.CountRecentFeedbacksOneMonth(ctx)
+	mustNoErr(t, err)
+	if count != 2 {
+		t.Errorf("recent feedback = %d, want 2", count)
+	}
+
+	result, err := s.Feedback.List(ctx, "", "", Filters{Page: 1, PageSize: 10})
+	mustNoErr(t, err)
+	if len(result.Feedbacks) != 2 || result.Feedbacks[0].Type != "feature" || result.Feedbacks[0].ReviewStatus != "new" {
+		t.Fatalf("List = %+v, want newest feedback first with status new", result.Feedbacks)
+	}
+
+	mustNoErr(t, s.Feedback.UpdateReviewStatus(ctx, result.Feedbacks[1].ID, "reviewed"))
+	result, err = s.Feedback.List(ctx, "", "reviewed", Filters{Page: 1, PageSize: 10})
+	mustNoErr(t, err)
+	if len(result.Feedbacks) != 1 || result.Feedbacks[0].Type != "issue" {
+		t.Errorf("status filter = %+v, want the reviewed issue", result.Feedbacks)
+	}
+}
+
+func testUsers(t *testing.T, s storeSet) {
+	ctx := context.Background()
+	user := &User{Username: "admin"}
+	mustNoErr(t, user.Password.Set("password123"))
+	id, err := s.Users.Create(ctx, user)
+	mustNoErr(t, err)
+
+	_, err = s.Users.Create(ctx, user)
+	wantErr(t, err, errx.ErrDuplicateUsername)
+
+	got, err := s.Users.GetUserByUsername(ctx, "admin")
+	mustNoErr(t, err)
+	if got.ID != id {
+		t.Errorf("user id = %d, want %d", got.ID, id)
+	}
+	if ok, err := got.Password.Matches("password123"); err != nil || !ok {
+		t.Errorf("password should match, got %v, %v", ok, err)
+	}
+
+	_, err = s.Users.GetUserByUsername(ctx, "nobody")
+	wantErr(t, err, errx.ErrUserNotFound)
+	_, err = s.Users.GetUserByID(ctx, id+100)
+	wantErr(t, err, errx.ErrUserNotFound)
+}
+
+func testAccounts(t *testing.T, s storeSet) {
+	ctx := context.Background()
+	account := &Account{Username: "alice"}
+	mustNoErr(t, account.Password.Set("password123"))
+	mustNoErr(t, s.Accounts.Create(ctx, account))
+	wantErr(t, s.Accounts.Create(ctx, &Account{Username: "alice"}), errx.ErrDuplicateUsername)
+
+	_, err := s.Accounts.GetByUsername(ctx, "bob")
+	wantErr(t, err, errx.ErrAccountNotFound)
+	_, err = s.Accounts.GetByID(ctx, account.ID+100)
+	wantErr(t, err, errx.ErrAccountNotFound)
+
+	game := createGame(t, s, "ABC123")
+	alice := createPlayer(t, s, game.ID, "alice", PlayerRolePlayer)
+	bob := createPlayer(t, s, game.ID, "bob", PlayerRolePlayer)
+	claimed, err := s.Players.ClaimPlayer(ctx, alice.ID, account.ID)
+	mustNoErr(t, err)
+	if !claimed {
+		t.Fatal("ClaimPlayer should succeed for an unclaimed player")
+	}
+
+	question, err := s.Questions.Create(ctx, "What is your secret?", QuestionLevelNormal)
+	mustNoErr(t, err)
+	asked := createRound(t, s, game.ID, alice.ID, bob.ID)
+	mustNoErr(t, s.Rounds.SetRoundQuestion(ctx, asked.ID, question.ID))
+	answered := createRound(t, s, game.ID, bob.ID, alice.ID)
+	mustNoErr(t, s.Rounds.UpdateAnswer(ctx, answered.ID, "nothing", RoundStatusWaitingForDraw, AnswerSecret{}))
+	mustNoErr(t, s.Rounds.UpdateDrawResult(ctx, answered.ID, true, RoundStatusRevealed))
+
+	stats, err := s.Accounts.GetStats(ctx, account.ID)
+	mustNoErr(t, err)
+	want := AccountStats{GamesPlayed: 1, Answered: 1, Asked: 1, JokerCardsDrawn: 1, Revealed: 1}
+	if *stats != want {
+		t.Errorf("stats = %+v, want %+v", *stats, want)
+	}
+
+	games, err := s.Accounts.ListGames(ctx, account.ID, Filters{Page: 1, PageSize: 10})
+	mustNoErr(t, err)
+	if len(games.Games) != 1 || games.Games[0].PlayerID != alice.ID || games.Games[0].Answered != 1 || games.TotalCount != 1 {
+		t.Errorf("ListGames = %+v", games)
+	}
+
+	wantErr(t, s.Accounts.AddFavorite(ctx, account.ID, question.ID+100), errx.ErrQuestionNotFound)
+	mustNoErr(t, s.Accounts.AddFavorite(ctx, account.ID, question.ID))
+	mustNoErr(t, s.Accounts.AddFavorite(ctx, account.ID, question.ID))
+	favorites, err := s.Accounts.ListFavorites(ctx, account.ID)
+	mustNoErr(t, err)
+	if len(favorites) != 1 || favorites[0].Question.ID != question.ID {
+		t.Errorf("favorites = %+v, want the question once", favorites)
+	}
+
+	mustNoErr(t, s.Accounts.RemoveFavorite(ctx, account.ID, question.ID))
+	favorites, err = s.Accounts.ListFavorites(ctx, account.ID)
+	mustNoErr(t, err)
+	if len(favorites) != 0 {
+		t.Errorf("favorites after remove = %+v, want none", favorites)
+	}
+}
+
+func testTransactions(t *testing.T, s storeSet) {
+	ctx := context.Background()
+	errRollback := errors.New("rollback")
+
+	err := s.Tx.WithTx(ctx, func(tx Stores) error {
+		if _, err := tx.Games.Create(ctx, &Game{Code: "ROLL00", Status: GameStatusWaiting, Mode: GameModeClassic, Guessing: GuessingOff}); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	wantErr(t, err, errRollback)
+	exists, err := s.Games.GameCodeExists(ctx, "ROLL00")
+	mustNoErr(t, err)
+	if exists {
+		t.Error("game created in a failed transaction should be rolled back")
+	}
+
+	// 內層交易失敗只還原自己的寫入
+	err = s.Tx.WithTx(ctx, func(tx Stores) error {
+		if _, err := tx.Games.Create(ctx, &Game{Code: "OUTER0", Status: GameStatusWaiting, Mode: GameModeClassic, Guessing: GuessingOff}); err != nil {
+			return err
+		}
+		err := tx.Tx.WithTx(ctx, func(inner Stores) error {
+			if _, err := inner.Games.Create(ctx, &Game{Code: "INNER0", Status: GameStatusWaiting, Mode: GameModeClassic, Guessing: GuessingOff}); err != nil {
+				return err
+			}
+			return errRollback
+		})
+		if !errors.Is(err, errRollback) {
+			return err
+		}
+		return nil
+	})
+	mustNoErr(t, err)
+
+	for code, want := range map[string]bool{"OUTER0": true, "INNER0": false} {
+		exists, err := s.Games.GameCodeExists(ctx, code)
+		mustNoErr(t, err)
+		if exists != want {
+			t.Errorf("GameCodeExists(%q) = %v, want %v", code, exists, want)
+		}
+	}
+}
@@ -2,15 +2,25 @@ package store
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/y3933y3933/joker/internal/db/sqlc"
 )
 
 func Open(dbUrl string) (*pgxpool.Pool, *sqlc.Queries, error) {
 	ctx := context.Background()
-	dbpool, err := pgxpool.New(context.Background(), dbUrl)
+	config, err := pgxpool.ParseConfig(dbUrl)
+	if err != nil {
+		return nil, nil, fmt.Errorf("db: open %w", err)
+	}
+	// 每個查詢都會成為目前請求 trace 底下的 span
+	config.ConnConfig.Tracer = newQueryTracer()
+
+	dbpool, err := pgxpool.NewWithConfig(ctx, config)
 	if err != nil {
 		return nil, nil, fmt.Errorf("db: open %w", err)
 	}
@@ -20,6 +30,19 @@ func Open(dbUrl string) (*pgxpool.Pool, *sqlc.Queries, error) {
 	}
 	queries := sqlc.New(dbpool)
 
-	fmt.Println("Connected to Database...")
 	return dbpool, queries, nil
 }
+
+// SchemaVersion 讀取 goose 記錄的目前 schema 版本，資料庫從未執行過 migration 時回傳 0
+func SchemaVersion(ctx context.Context, pool *pgxpool.Pool) (int64, error) {
+	var version int64
+	err := pool.QueryRow(ctx, `SELECT COALESCE(MAX(version_id), 0) FROM goose_db_version WHERE is_applied`).Scan(&version)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UndefinedTable {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("db: schema version %w", err)
+	}
+	return version, nil
+}
@@ -2,9 +2,12 @@ package store
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/y3933y3933/joker/internal/db/sqlc"
+	"github.com/y3933y3933/joker/internal/utils/errx"
 )
 
 type Feedback struct {
@@ -50,6 +53,9 @@ func (pg *PostgresFeedbackStore) CountRecentFeedbacksOneMonth(ctx context.Contex
 func (pg *PostgresFeedbackStore) GetByID(ctx context.Context, id int64) (*Feedback, error) {
 	row, err := pg.queries.GetFeedbackByID(ctx, id)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errx.ErrFeedbackNotFound
+		}
 		return nil, err
 	}
 
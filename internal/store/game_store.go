@@ -3,8 +3,8 @@ package store
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
-	"fmt"
 	"time"
 
 	"github.com/y3933y3933/joker/internal/db/sqlc"
@@ -12,27 +12,93 @@ import (
 )
 
 type Game struct {
-	ID     int64  `json:"id"`
-	Code   string `json:"code"`
-	Status string `json:"status"`
+	ID       int64           `json:"id"`
+	Code     string          `json:"code"`
+	Status   string          `json:"status"`
+	Locked   bool            `json:"locked"`
+	Mode     string          `json:"mode"`
+	Guessing string          `json:"guessing"`
+	Scoring  json.RawMessage `json:"scoring"` // 計分規則，由 service 解析並補上預設值
+	// 結束後開的再來一局
+	RematchCode *string `json:"rematchCode,omitempty"`
+	EndedReason *string `json:"endedReason,omitempty"`
+	// 加入方式與密碼，密碼只在 access 為 passcode 時有值
+	Access   string   `json:"access"`
+	Passcode password `json:"-"`
 }
 
+// 房間的加入方式
+const (
+	GameAccessPublic   = "public"   // 知道代碼即可加入
+	GameAccessPasscode = "passcode" // 需要密碼或邀請連結
+	GameAccessInvite   = "invite"   // 只能透過邀請連結加入
+)
+
 const (
 	GameStatusWaiting = "waiting"
 	GameStatusPlaying = "playing"
 	GameStatusEnded   = "ended"
 )
 
+// 遊戲結束的原因
+const (
+	EndReasonFinished         = "finished"           // 玩家按下結束
+	EndReasonNotEnoughPlayers = "not_enough_players" // 在線玩家不足兩人
+	EndReasonIdle             = "idle"               // 閒置過久，由 janitor 結束
+	EndReasonAdmin            = "admin"              // 管理員從後台結束
+)
+
+// GameActivity 未結束遊戲最後一次有動靜的時間，供 janitor 判斷是否閒置
+type GameActivity struct {
+	ID             int64
+	Code           string
+	Status         string
+	PlayerCount    int64
+	CreatedAt      time.Time
+	LastActivityAt time.Time
+}
+
+const (
+	GameModeClassic    = "classic"     // 回答後由回答者抽牌，抽到鬼牌公開題目
+	GameModeHotSeat    = "hot_seat"    // 同一位回答者輪流回答所有人的問題
+	GameModeVoteReveal = "vote_reveal" // 回答後由其他人投票決定是否公開題目
+)
+
+// 回答後的猜測階段，可以和任何玩法搭配
+const (
+	GuessingOff   = "off"
+	GuessingAbout = "about" // 猜回答說的是哪位玩家
+	GuessingTruth = "truth" // 猜回答是否屬實
+)
+
 type GamePlayerSummary struct {
 	ID              int64  `json:"id"`
 	Nickname        string `json:"nickname"`
+	Avatar          Avatar `json:"avatar"`
 	JokerCardsDrawn int32  `json:"jokerCardsDrawn"`
+	CorrectGuesses  int    `json:"correctGuesses"`
+	Score           int    `json:"score"`
+	Rank            int    `json:"rank"`
+	Answered        int    `json:"answered"`      // 回答過幾題
+	Asked           int    `json:"asked"`         // 出過幾題
+	LongestStreak   int    `json:"longestStreak"` // 最多連續幾題回答後沒被公開
+	SpicyAnswered   int    `json:"spicyAnswered"` // 回答過幾題 spicy 題目
+}
+
+// GameAward 從整場的回合紀錄選出的稱號
+type GameAward struct {
+	Award    string `json:"award"`
+	PlayerID int64  `json:"playerID"`
+	Nickname string `json:"nickname"`
+	Count    int    `json:"count"`
 }
 
 type GameSummary struct {
+	Mode        string              `json:"mode"`
 	TotalRounds int64               `json:"totalRounds"`
 	JokerCards  int64               `json:"jokerCards"`
 	Players     []GamePlayerSummary `json:"players"`
+	Awards      []GameAward         `json:"awards"`
 }
 
 type AdminGame struct {
@@ -61,27 +127,42 @@ type GameStore interface {
 	GameCodeExists(ctx context.Context, code string) (bool, error)
 	GetGameByCode(ctx context.Context, code string) (*Game, error)
 	UpdateStatus(ctx context.Context, gameID int64, status string) error
-	EndGame(ctx context.Context, code string) error
+	EndGame(ctx context.Context, code string, reason string) error
 	GetGameSummary(ctx context.Context, gameID int64) (*GameSummary, error)
 	GetGamePlayerStats(ctx context.Context, gameID int64) ([]GamePlayerSummary, error)
 	GetGameStatusByID(ctx context.Context, gameID int64) (string, error)
+	LockForUpdate(ctx context.Context, gameID int64) error
 	DeleteByCode(ctx context.Context, gameCode string) error
 	GetGamesTodayCount(ctx context.Context) (int64, error)
 	GetActiveRoomsCount(ctx context.Context) (int64, error)
 	List(ctx context.Context, code, status string, filters Filters) (*PaginatedGame, error)
+	UpdateLocked(ctx context.Context, gameID int64, locked bool) error
+	UpdateAccess(ctx context.Context, game *Game) error
+	SetRematchCode(ctx context.Context, gameID int64, code string) (bool, error)
+	ListUnfinishedActivity(ctx context.Context) ([]GameActivity, error)
 }
 
 func (pg *PostgresGameStore) Create(ctx context.Context, game *Game) (*Game, error) {
 	args := sqlc.CreateGameParams{
-		Code:   game.Code,
-		Status: game.Status,
+		Code:     game.Code,
+		Status:   game.Status,
+		Mode:     game.Mode,
+		Guessing: game.Guessing,
+		Scoring:  scoringJSON(game.Scoring),
 	}
 	row, err := pg.queries.CreateGame(ctx, args)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Game{ID: row.ID, Code: game.Code, Status: game.Status}, nil
+	return &Game{ID: row.ID, Code: row.Code, Status: row.Status, Mode: row.Mode, Guessing: row.Guessing, Scoring: row.Scoring, Access: GameAccessPublic}, nil
+}
+
+func scoringJSON(raw json.RawMessage) []byte {
+	if len(raw) == 0 {
+		return []byte("{}")
+	}
+	return raw
 }
 
 func (pg *PostgresGameStore) GameCodeExists(ctx context.Context, code string) (bool, error) {
@@ -107,9 +188,17 @@ func (pg *PostgresGameStore) GetGameByCode(ctx context.Context, code string) (*G
 	}
 
 	return &Game{
-		ID:     game.ID,
-		Code:   game.Code,
-		Status: game.Status,
+		ID:          game.ID,
+		Code:        game.Code,
+		Status:      game.Status,
+		Locked:      game.Locked,
+		Mode:        game.Mode,
+		Guessing:    game.Guessing,
+		Scoring:     game.Scoring,
+		RematchCode: fromPgText(game.RematchCode),
+		EndedReason: fromPgText(game.EndedReason),
+		Access:      game.Access,
+		Passcode:    password{hash: game.PasscodeHash},
 	}, nil
 }
 
@@ -120,8 +209,11 @@ func (pg *PostgresGameStore) UpdateStatus(ctx context.Context, gameID int64, sta
 	})
 }
 
-func (pg *PostgresGameStore) EndGame(ctx context.Context, code string) error {
-	return pg.queries.EndGame(ctx, code)
+func (pg *PostgresGameStore) EndGame(ctx context.Context, code string, reason string) error {
+	return pg.queries.EndGame(ctx, sqlc.EndGameParams{
+		Code:        code,
+		EndedReason: toPgText(&reason),
+	})
 }
 
 func (pg *PostgresGameStore) GetGameSummary(ctx context.Context, gameID int64) (*GameSummary, error) {
@@ -145,6 +237,7 @@ func (pg *PostgresGameStore) GetGamePlayerStats(ctx context.Context, gameID int6
 		players[i] = GamePlayerSummary{
 			ID:              p.ID,
 			Nickname:        p.Nickname,
+			Avatar:          Avatar{Preset: p.AvatarPreset, Color: p.AvatarColor, Emoji: p.AvatarEmoji},
 			JokerCardsDrawn: int32(p.JokerCardsDrawn),
 		}
 	}
@@ -163,6 +256,18 @@ func (pg *PostgresGameStore) GetGameStatusByID(ctx context.Context, gameID int64
 	return status, nil
 }
 
+// LockForUpdate 在交易內鎖住遊戲這一列，同一場遊戲的加入會依序分配座位與 host
+func (pg *PostgresGameStore) LockForUpdate(ctx context.Context, gameID int64) error {
+	_, err := pg.queries.LockGame(ctx, gameID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errx.ErrGameNotFound
+		}
+		return err
+	}
+	return nil
+}
+
 func (pg *PostgresGameStore) DeleteByCode(ctx context.Context, gameCode string) error {
 	return pg.queries.DeleteByCode(ctx, gameCode)
 }
@@ -184,7 +289,6 @@ func (pg *PostgresGameStore) List(ctx context.Context, code, status string, filt
 		Offset: int32(filters.offset()),
 	}
 
-	fmt.Printf("Listing games with params: %+v\n", args)
 	rows, err := pg.queries.ListGames(ctx, args)
 
 	if err != nil {
@@ -209,3 +313,54 @@ func (pg *PostgresGameStore) List(ctx context.Context, code, status string, filt
 		Metadata: CalculateMetadata(totalCount, filters.Page, filters.PageSize),
 	}, nil
 }
+
+func (pg *PostgresGameStore) UpdateLocked(ctx context.Context, gameID int64, locked bool) error {
+	return pg.queries.UpdateGameLocked(ctx, sqlc.UpdateGameLockedParams{
+		ID:     gameID,
+		Locked: locked,
+	})
+}
+
+// UpdateAccess 更新加入方式，密碼需先以 game.Passcode.Set 設定，不是 passcode 時清除密碼
+func (pg *PostgresGameStore) UpdateAccess(ctx context.Context, game *Game) error {
+	if game.Access != GameAccessPasscode {
+		game.Passcode = password{}
+	}
+	return pg.queries.UpdateGameAccess(ctx, sqlc.UpdateGameAccessParams{
+		ID:           game.ID,
+		Access:       game.Access,
+		PasscodeHash: game.Passcode.hash,
+	})
+}
+
+// SetRematchCode 記錄再來一局的遊戲代碼，已經有人開過時回傳 false
+func (pg *PostgresGameStore) SetRematchCode(ctx context.Context, gameID int64, code string) (bool, error) {
+	n, err := pg.queries.SetRematchCode(ctx, sqlc.SetRematchCodeParams{
+		ID:          gameID,
+		RematchCode: toPgText(&code),
+	})
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (pg *PostgresGameStore) ListUnfinishedActivity(ctx context.Context) ([]GameActivity, error) {
+	rows, err := pg.queries.ListUnfinishedGameActivity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	games := make([]GameActivity, len(rows))
+	for i, row := range rows {
+		games[i] = GameActivity{
+			ID:             row.ID,
+			Code:           row.Code,
+			Status:         row.Status,
+			PlayerCount:    row.PlayerCount,
+			CreatedAt:      row.CreatedAt.Time,
+			LastActivityAt: row.LastActivityAt.Time,
+		}
+	}
+	return games, nil
+}
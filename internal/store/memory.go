@@ -0,0 +1,214 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"sync"
+	"time"
+)
+
+// 記憶體版本違反資料庫限制（外鍵、唯一）時回傳的錯誤，對應 PostgreSQL 的 constraint violation
+var (
+	errMemoryForeignKey = errors.New("memory store: foreign key violation")
+	errMemoryUnique     = errors.New("memory store: unique violation")
+)
+
+type memoryGame struct {
+	Game
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type memoryPlayer struct {
+	Player
+	JoinedAt time.Time
+}
+
+type memoryRound struct {
+	Round
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type memoryQuestion struct {
+	Question
+	UpdatedAt time.Time
+}
+
+type memoryBan struct {
+	GameID      int64
+	NicknameKey string
+}
+
+type memoryFavorite struct {
+	AccountID  int64
+	QuestionID int64
+}
+
+// memoryTables 是所有資料表，交易開始時整份複製一次，失敗時還原
+type memoryTables struct {
+	nextID    map[string]int64
+	games     map[int64]memoryGame
+	players   map[int64]memoryPlayer
+	bans      map[memoryBan]struct{}
+	rounds    map[int64]memoryRound
+	votes     map[int64]Vote
+	questions map[int64]memoryQuestion
+	feedback  map[int64]Feedback
+	users     map[int64]User
+	accounts  map[int64]Account
+	favorites map[memoryFavorite]time.Time
+}
+
+func newMemoryTables() memoryTables {
+	return memoryTables{
+		nextID:    make(map[string]int64),
+		games:     make(map[int64]memoryGame),
+		players:   make(map[int64]memoryPlayer),
+		bans:      make(map[memoryBan]struct{}),
+		rounds:    make(map[int64]memoryRound),
+		votes:     make(map[int64]Vote),
+		questions: make(map[int64]memoryQuestion),
+		feedback:  make(map[int64]Feedback),
+		users:     make(map[int64]User),
+		accounts:  make(map[int64]Account),
+		favorites: make(map[memoryFavorite]time.Time),
+	}
+}
+
+// clone 複製每張表；資料列是值，寫入時整列取代，所以不需要深拷貝
+func (t memoryTables) clone() memoryTables {
+	return memoryTables{
+		nextID:    maps.Clone(t.nextID),
+		games:     maps.Clone(t.games),
+		players:   maps.Clone(t.players),
+		bans:      maps.Clone(t.bans),
+		rounds:    maps.Clone(t.rounds),
+		votes:     maps.Clone(t.votes),
+		questions: maps.Clone(t.questions),
+		feedback:  maps.Clone(t.feedback),
+		users:     maps.Clone(t.users),
+		accounts:  maps.Clone(t.accounts),
+		favorites: maps.Clone(t.favorites),
+	}
+}
+
+// MemoryDB 以記憶體實作所有 store，行為（錯誤、排序、分頁）與 PostgreSQL 版本相同，供測試使用
+type MemoryDB struct {
+	mu     sync.Mutex
+	tables memoryTables
+	last   time.Time
+
+	txMu sync.Mutex // 交易進行中持有，交易外的操作需等待
+}
+
+func NewMemoryDB() *MemoryDB {
+	return &MemoryDB{tables: newMemoryTables()}
+}
+
+func (db *MemoryDB) Games() *MemoryGameStore         { return &MemoryGameStore{memoryConn{db: db}} }
+func (db *MemoryDB) Players() *MemoryPlayerStore     { return &MemoryPlayerStore{memoryConn{db: db}} }
+func (db *MemoryDB) Rounds() *MemoryRoundStore       { return &MemoryRoundStore{memoryConn{db: db}} }
+func (db *MemoryDB) Votes() *MemoryVoteStore         { return &MemoryVoteStore{memoryConn{db: db}} }
+func (db *MemoryDB) Questions() *MemoryQuestionStore { return &MemoryQuestionStore{memoryConn{db: db}} }
+func (db *MemoryDB) Feedback() *MemoryFeedbackStore  { return &MemoryFeedbackStore{memoryConn{db: db}} }
+func (db *MemoryDB) Users() *MemoryUserStore         { return &MemoryUserStore{memoryConn{db: db}} }
+func (db *MemoryDB) Accounts() *MemoryAccountStore   { return &MemoryAccountStore{memoryConn{db: db}} }
+
+// Stores 回傳與 TxRunner 搭配使用的那組 store
+func (db *MemoryDB) Stores() Stores {
+	return Stores{
+		Games:   db.Games(),
+		Players: db.Players(),
+		Rounds:  db.Rounds(),
+		Votes:   db.Votes(),
+		Tx:      db,
+	}
+}
+
+// WithTx fn 失敗時還原到交易開始前的資料。交易進行中，交易外的操作會等到交易結束，
+// 所以 fn 內只能使用 tx 裡的 store
+func (db *MemoryDB) WithTx(ctx context.Context, fn func(tx Stores) error) error {
+	db.txMu.Lock()
+	defer db.txMu.Unlock()
+	return memoryTx{db: db}.WithTx(ctx, fn)
+}
+
+// memoryTx 在交易中再開的交易，失敗時只還原自己的寫入，效果等同 savepoint
+type memoryTx struct {
+	db *MemoryDB
+}
+
+func (t memoryTx) WithTx(ctx context.Context, fn func(tx Stores) error) error {
+	t.db.mu.Lock()
+	snapshot := t.db.tables.clone()
+	t.db.mu.Unlock()
+
+	conn := memoryConn{db: t.db, inTx: true}
+	stores := Stores{
+		Games:   &MemoryGameStore{conn},
+		Players: &MemoryPlayerStore{conn},
+		Rounds:  &MemoryRoundStore{conn},
+		Votes:   &MemoryVoteStore{conn},
+		Tx:      t,
+	}
+	if err := fn(stores); err != nil {
+		t.db.mu.Lock()
+		t.db.tables = snapshot
+		t.db.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// memoryConn 是 store 對 MemoryDB 的連線，交易外的連線在交易進行中會等待
+type memoryConn struct {
+	db   *MemoryDB
+	inTx bool
+}
+
+// lock 取得資料表，呼叫端負責 unlock
+func (c memoryConn) lock() *memoryTables {
+	if !c.inTx {
+		c.db.txMu.Lock()
+	}
+	c.db.mu.Lock()
+	return &c.db.tables
+}
+
+func (c memoryConn) unlock() {
+	c.db.mu.Unlock()
+	if !c.inTx {
+		c.db.txMu.Unlock()
+	}
+}
+
+// now 回傳遞增的時間，同一個時間點建立的資料也有固定的先後順序。呼叫前需持有 lock
+func (c memoryConn) now() time.Time {
+	db := c.db
+	now := time.Now().Truncate(time.Microsecond)
+	if !now.After(db.last) {
+		now = db.last.Add(time.Microsecond)
+	}
+	db.last = now
+	return now
+}
+
+// id 產生資料表的下一個 id，和 BIGSERIAL 一樣從 1 開始
+func (t *memoryTables) id(table string) int64 {
+	t.nextID[table]++
+	return t.nextID[table]
+}
+
+// paginate 依 filters 取出其中一頁
+func paginate[T any](rows []T, filters Filters) []T {
+	offset := min(max(filters.offset(), 0), len(rows))
+	end := min(offset+filters.limit(), len(rows))
+	return rows[offset:end]
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,187 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/y3933y3933/joker/internal/utils/errx"
+)
+
+type MemoryAccountStore struct {
+	memoryConn
+}
+
+var _ AccountStore = (*MemoryAccountStore)(nil)
+
+func (m *MemoryAccountStore) Create(ctx context.Context, account *Account) error {
+	t := m.lock()
+	defer m.unlock()
+
+	for _, a := range t.accounts {
+		if a.Username == account.Username {
+			return errx.ErrDuplicateUsername
+		}
+	}
+
+	account.ID = t.id("accounts")
+	account.CreatedAt = m.now()
+	t.accounts[account.ID] = Account{
+		ID:        account.ID,
+		Username:  account.Username,
+		Password:  password{hash: account.Password.hash},
+		CreatedAt: account.CreatedAt,
+	}
+	return nil
+}
+
+func (m *MemoryAccountStore) GetByUsername(ctx context.Context, username string) (*Account, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	for _, a := range t.accounts {
+		if a.Username == username {
+			account := a
+			return &account, nil
+		}
+	}
+	return nil, errx.ErrAccountNotFound
+}
+
+func (m *MemoryAccountStore) GetByID(ctx context.Context, id int64) (*Account, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	a, ok := t.accounts[id]
+	if !ok {
+		return nil, errx.ErrAccountNotFound
+	}
+	return &Account{ID: a.ID, Username: a.Username, CreatedAt: a.CreatedAt}, nil
+}
+
+// accountPlayers 列出帳號在每場遊戲中的玩家
+func (t *memoryTables) accountPlayers(accountID int64) []memoryPlayer {
+	var players []memoryPlayer
+	for _, p := range t.players {
+		if p.AccountID != nil && *p.AccountID == accountID {
+			players = append(players, p)
+		}
+	}
+	return players
+}
+
+func (m *MemoryAccountStore) ListGames(ctx context.Context, accountID int64, filters Filters) (*PaginatedAccountGame, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	var rows []AccountGame
+	for _, p := range t.accountPlayers(accountID) {
+		g := t.games[p.GameID]
+		game := AccountGame{
+			Code:        g.Code,
+			Status:      g.Status,
+			Mode:        g.Mode,
+			EndedReason: g.EndedReason,
+			PlayedAt:    g.CreatedAt,
+			PlayerID:    p.ID,
+			Nickname:    p.Nickname,
+		}
+		for _, r := range t.rounds {
+			if r.AnswerPlayerID != p.ID {
+				continue
+			}
+			if r.Answer != nil {
+				game.Answered++
+			}
+			if r.IsJoker {
+				game.JokerCardsDrawn++
+			}
+		}
+		rows = append(rows, game)
+	}
+	slices.SortFunc(rows, func(a, b AccountGame) int {
+		return cmp.Or(b.PlayedAt.Compare(a.PlayedAt), cmp.Compare(b.PlayerID, a.PlayerID))
+	})
+
+	games := append([]AccountGame{}, paginate(rows, filters)...)
+	totalCount := 0
+	if len(games) > 0 {
+		totalCount = len(rows)
+	}
+	return &PaginatedAccountGame{
+		Games:    games,
+		Metadata: CalculateMetadata(totalCount, filters.Page, filters.PageSize),
+	}, nil
+}
+
+func (m *MemoryAccountStore) GetStats(ctx context.Context, accountID int64) (*AccountStats, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	stats := &AccountStats{}
+	games := make(map[int64]struct{})
+	for _, p := range t.accountPlayers(accountID) {
+		games[p.GameID] = struct{}{}
+		for _, r := range t.rounds {
+			if r.QuestionPlayerID == p.ID && r.QuestionID != nil {
+				stats.Asked++
+			}
+			if r.AnswerPlayerID != p.ID {
+				continue
+			}
+			if r.Answer != nil {
+				stats.Answered++
+			}
+			if r.IsJoker {
+				stats.JokerCardsDrawn++
+			}
+			if r.Status == RoundStatusRevealed {
+				stats.Revealed++
+			}
+		}
+	}
+	stats.GamesPlayed = int64(len(games))
+	return stats, nil
+}
+
+func (m *MemoryAccountStore) AddFavorite(ctx context.Context, accountID, questionID int64) error {
+	t := m.lock()
+	defer m.unlock()
+
+	if _, ok := t.questions[questionID]; !ok {
+		return errx.ErrQuestionNotFound
+	}
+	if _, ok := t.accounts[accountID]; !ok {
+		return errx.ErrQuestionNotFound
+	}
+
+	key := memoryFavorite{AccountID: accountID, QuestionID: questionID}
+	if _, ok := t.favorites[key]; !ok {
+		t.favorites[key] = m.now()
+	}
+	return nil
+}
+
+func (m *MemoryAccountStore) RemoveFavorite(ctx context.Context, accountID, questionID int64) error {
+	t := m.lock()
+	defer m.unlock()
+
+	delete(t.favorites, memoryFavorite{AccountID: accountID, QuestionID: questionID})
+	return nil
+}
+
+func (m *MemoryAccountStore) ListFavorites(ctx context.Context, accountID int64) ([]FavoriteQuestion, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	favorites := []FavoriteQuestion{}
+	for f, at := range t.favorites {
+		if f.AccountID != accountID {
+			continue
+		}
+		q := t.questions[f.QuestionID]
+		favorites = append(favorites, FavoriteQuestion{Question: q.Question, FavoritedAt: at})
+	}
+	slices.SortFunc(favorites, func(a, b FavoriteQuestion) int { return b.FavoritedAt.Compare(a.FavoritedAt) })
+	return favorites, nil
+}
@@ -0,0 +1,93 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/y3933y3933/joker/internal/utils/errx"
+)
+
+type MemoryFeedbackStore struct {
+	memoryConn
+}
+
+var _ FeedbackStore = (*MemoryFeedbackStore)(nil)
+
+func (m *MemoryFeedbackStore) Create(ctx context.Context, feedback *Feedback) error {
+	t := m.lock()
+	defer m.unlock()
+
+	row := Feedback{
+		ID:           t.id("feedback"),
+		Type:         feedback.Type,
+		Content:      feedback.Content,
+		CreatedAt:    m.now(),
+		ReviewStatus: "new",
+	}
+	t.feedback[row.ID] = row
+	return nil
+}
+
+func (m *MemoryFeedbackStore) CountRecentFeedbacksOneMonth(ctx context.Context) (int64, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	since := startOfDay(m.now()).AddDate(0, 0, -30)
+	var n int64
+	for _, f := range t.feedback {
+		if !f.CreatedAt.Before(since) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *MemoryFeedbackStore) GetByID(ctx context.Context, id int64) (*Feedback, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	f, ok := t.feedback[id]
+	if !ok {
+		return nil, errx.ErrFeedbackNotFound
+	}
+	return &f, nil
+}
+
+func (m *MemoryFeedbackStore) List(ctx context.Context, feedbackType string, reviewStatus string, filters Filters) (*PaginatedFeedback, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	var rows []Feedback
+	for _, f := range t.feedback {
+		if (feedbackType == "" || f.Type == feedbackType) && (reviewStatus == "" || f.ReviewStatus == reviewStatus) {
+			rows = append(rows, f)
+		}
+	}
+	slices.SortFunc(rows, func(a, b Feedback) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(b.ID, a.ID))
+	})
+
+	feedbacks := append([]Feedback{}, paginate(rows, filters)...)
+	totalCount := 0
+	if len(feedbacks) > 0 {
+		totalCount = len(rows)
+	}
+	return &PaginatedFeedback{
+		Feedbacks: feedbacks,
+		Metadata:  CalculateMetadata(totalCount, filters.Page, filters.PageSize),
+	}, nil
+}
+
+func (m *MemoryFeedbackStore) UpdateReviewStatus(ctx context.Context, id int64, reviewStatus string) error {
+	t := m.lock()
+	defer m.unlock()
+
+	f, ok := t.feedback[id]
+	if !ok {
+		return nil
+	}
+	f.ReviewStatus = reviewStatus
+	t.feedback[id] = f
+	return nil
+}
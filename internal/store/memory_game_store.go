@@ -0,0 +1,337 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/y3933y3933/joker/internal/utils/errx"
+)
+
+type MemoryGameStore struct {
+	memoryConn
+}
+
+var _ GameStore = (*MemoryGameStore)(nil)
+
+func (m *MemoryGameStore) Create(ctx context.Context, game *Game) (*Game, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	for _, g := range t.games {
+		if g.Code == game.Code {
+			return nil, errMemoryUnique
+		}
+	}
+
+	now := m.now()
+	row := memoryGame{
+		Game: Game{
+			ID:       t.id("games"),
+			Code:     game.Code,
+			Status:   game.Status,
+			Mode:     game.Mode,
+			Guessing: game.Guessing,
+			Scoring:  scoringJSON(game.Scoring),
+			Access:   GameAccessPublic,
+		},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	t.games[row.ID] = row
+
+	return &Game{ID: row.ID, Code: row.Code, Status: row.Status, Mode: row.Mode, Guessing: row.Guessing, Scoring: row.Scoring, Access: GameAccessPublic}, nil
+}
+
+// findByCode 對應 GetGameByCode 查詢，遊戲代碼是唯一的，包含已結束的遊戲
+func (t *memoryTables) findByCode(code string) (memoryGame, bool) {
+	for _, g := range t.games {
+		if g.Code == code {
+			return g, true
+		}
+	}
+	return memoryGame{}, false
+}
+
+func (m *MemoryGameStore) GameCodeExists(ctx context.Context, code string) (bool, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	_, ok := t.findByCode(code)
+	return ok, nil
+}
+
+func (m *MemoryGameStore) GetGameByCode(ctx context.Context, code string) (*Game, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	g, ok := t.findByCode(code)
+	if !ok {
+		return nil, errx.ErrGameNotFound
+	}
+	game := g.Game
+	return &game, nil
+}
+
+// updateGame 更新一場遊戲並記錄 updated_at，找不到時不做任何事
+func (m *MemoryGameStore) updateGame(t *memoryTables, gameID int64, fn func(g *memoryGame)) {
+	g, ok := t.games[gameID]
+	if !ok {
+		return
+	}
+	fn(&g)
+	g.UpdatedAt = m.now()
+	t.games[gameID] = g
+}
+
+func (m *MemoryGameStore) UpdateStatus(ctx context.Context, gameID int64, status string) error {
+	t := m.lock()
+	defer m.unlock()
+
+	m.updateGame(t, gameID, func(g *memoryGame) { g.Status = status })
+	return nil
+}
+
+func (m *MemoryGameStore) EndGame(ctx context.Context, code string, reason string) error {
+	t := m.lock()
+	defer m.unlock()
+
+	g, ok := t.findByCode(code)
+	if !ok || g.Status == GameStatusEnded {
+		return nil
+	}
+	m.updateGame(t, g.ID, func(g *memoryGame) {
+		g.Status = GameStatusEnded
+		g.EndedReason = &reason
+	})
+	return nil
+}
+
+func (m *MemoryGameStore) GetGameSummary(ctx context.Context, gameID int64) (*GameSummary, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	summary := &GameSummary{}
+	for _, r := range t.rounds {
+		if r.GameID != gameID {
+			continue
+		}
+		summary.TotalRounds++
+		if r.IsJoker {
+			summary.JokerCards++
+		}
+	}
+	return summary, nil
+}
+
+func (m *MemoryGameStore) GetGamePlayerStats(ctx context.Context, gameID int64) ([]GamePlayerSummary, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	players := []GamePlayerSummary{}
+	for _, p := range t.players {
+		if p.GameID != gameID || p.Role != PlayerRolePlayer {
+			continue
+		}
+		summary := GamePlayerSummary{ID: p.ID, Nickname: p.Nickname, Avatar: p.Avatar}
+		for _, r := range t.rounds {
+			if r.GameID == gameID && r.AnswerPlayerID == p.ID && r.IsJoker {
+				summary.JokerCardsDrawn++
+			}
+		}
+		players = append(players, summary)
+	}
+	slices.SortFunc(players, func(a, b GamePlayerSummary) int { return cmp.Compare(a.ID, b.ID) })
+	return players, nil
+}
+
+func (m *MemoryGameStore) GetGameStatusByID(ctx context.Context, gameID int64) (string, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	g, ok := t.games[gameID]
+	if !ok {
+		return "", errx.ErrGameNotFound
+	}
+	return g.Status, nil
+}
+
+// LockForUpdate 記憶體的交易本來就一次只跑一個，只需確認遊戲存在
+func (m *MemoryGameStore) LockForUpdate(ctx context.Context, gameID int64) error {
+	t := m.lock()
+	defer m.unlock()
+
+	if _, ok := t.games[gameID]; !ok {
+		return errx.ErrGameNotFound
+	}
+	return nil
+}
+
+// DeleteByCode 和資料庫的 ON DELETE CASCADE 一樣，一併刪除玩家、回合、投票與禁止名單
+func (m *MemoryGameStore) DeleteByCode(ctx context.Context, gameCode string) error {
+	t := m.lock()
+	defer m.unlock()
+
+	for id, g := range t.games {
+		if g.Code != gameCode {
+			continue
+		}
+		delete(t.games, id)
+		for rid, r := range t.rounds {
+			if r.GameID == id {
+				delete(t.rounds, rid)
+				t.deleteVotes(func(v Vote) bool { return v.RoundID == rid })
+			}
+		}
+		for pid, p := range t.players {
+			if p.GameID == id {
+				delete(t.players, pid)
+				t.deleteVotes(func(v Vote) bool { return v.PlayerID == pid })
+			}
+		}
+		for ban := range t.bans {
+			if ban.GameID == id {
+				delete(t.bans, ban)
+			}
+		}
+	}
+	return nil
+}
+
+func (m *MemoryGameStore) GetGamesTodayCount(ctx context.Context) (int64, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	today := startOfDay(m.now())
+	var n int64
+	for _, g := range t.games {
+		if !g.CreatedAt.Before(today) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *MemoryGameStore) GetActiveRoomsCount(ctx context.Context) (int64, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	var n int64
+	for _, g := range t.games {
+		if g.Status != GameStatusEnded {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *MemoryGameStore) List(ctx context.Context, code, status string, filters Filters) (*PaginatedGame, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	var rows []memoryGame
+	for _, g := range t.games {
+		if (code == "" || strings.EqualFold(g.Code, code)) && (status == "" || g.Status == status) {
+			rows = append(rows, g)
+		}
+	}
+	slices.SortFunc(rows, func(a, b memoryGame) int { return b.CreatedAt.Compare(a.CreatedAt) })
+
+	page := paginate(rows, filters)
+	games := make([]AdminGame, len(page))
+	for i, g := range page {
+		games[i] = AdminGame{ID: g.ID, Code: g.Code, Status: g.Status, CreatedAt: g.CreatedAt}
+		for _, p := range t.players {
+			if p.GameID == g.ID && p.Role == PlayerRolePlayer {
+				games[i].PlayerCount++
+			}
+		}
+	}
+
+	totalCount := 0
+	if len(page) > 0 {
+		totalCount = len(rows)
+	}
+	return &PaginatedGame{
+		Games:    games,
+		Metadata: CalculateMetadata(totalCount, filters.Page, filters.PageSize),
+	}, nil
+}
+
+func (m *MemoryGameStore) UpdateLocked(ctx context.Context, gameID int64, locked bool) error {
+	t := m.lock()
+	defer m.unlock()
+
+	m.updateGame(t, gameID, func(g *memoryGame) { g.Locked = locked })
+	return nil
+}
+
+func (m *MemoryGameStore) UpdateAccess(ctx context.Context, game *Game) error {
+	if game.Access != GameAccessPasscode {
+		game.Passcode = password{}
+	}
+
+	t := m.lock()
+	defer m.unlock()
+
+	m.updateGame(t, game.ID, func(g *memoryGame) {
+		g.Access = game.Access
+		g.Passcode = password{hash: game.Passcode.hash}
+	})
+	return nil
+}
+
+func (m *MemoryGameStore) SetRematchCode(ctx context.Context, gameID int64, code string) (bool, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	g, ok := t.games[gameID]
+	if !ok || g.RematchCode != nil {
+		return false, nil
+	}
+	m.updateGame(t, gameID, func(g *memoryGame) { g.RematchCode = &code })
+	return true, nil
+}
+
+func (m *MemoryGameStore) ListUnfinishedActivity(ctx context.Context) ([]GameActivity, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	games := []GameActivity{}
+	for _, g := range t.games {
+		if g.Status == GameStatusEnded {
+			continue
+		}
+
+		activity := GameActivity{ID: g.ID, Code: g.Code, Status: g.Status, CreatedAt: g.CreatedAt, LastActivityAt: g.UpdatedAt}
+		seen := func(at time.Time) {
+			if at.After(activity.LastActivityAt) {
+				activity.LastActivityAt = at
+			}
+		}
+		for _, p := range t.players {
+			if p.GameID == g.ID {
+				if p.Role == PlayerRolePlayer {
+					activity.PlayerCount++
+				}
+				seen(p.JoinedAt)
+			}
+		}
+		for _, r := range t.rounds {
+			if r.GameID != g.ID {
+				continue
+			}
+			seen(r.UpdatedAt)
+			for _, v := range t.votes {
+				if v.RoundID == r.ID {
+					seen(v.CreatedAt)
+				}
+			}
+		}
+		games = append(games, activity)
+	}
+	slices.SortFunc(games, func(a, b GameActivity) int { return cmp.Compare(a.ID, b.ID) })
+	return games, nil
+}
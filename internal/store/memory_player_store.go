@@ -0,0 +1,287 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/y3933y3933/joker/internal/utils/errx"
+)
+
+type MemoryPlayerStore struct {
+	memoryConn
+}
+
+var _ PlayerStore = (*MemoryPlayerStore)(nil)
+
+func (m *MemoryPlayerStore) Create(ctx context.Context, player *Player) (*Player, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	if _, ok := t.games[player.GameID]; !ok {
+		return nil, errMemoryForeignKey
+	}
+	if player.AccountID != nil {
+		if _, ok := t.accounts[*player.AccountID]; !ok {
+			return nil, errMemoryForeignKey
+		}
+	}
+
+	if t.nicknameTaken(player.GameID, player.NicknameKey, 0) {
+		return nil, errx.ErrDuplicateNickname
+	}
+
+	// 座位排在同一場遊戲的最後面
+	var seat int32
+	for _, p := range t.players {
+		if p.GameID == player.GameID {
+			seat = max(seat, p.Seat)
+		}
+	}
+
+	row := memoryPlayer{
+		Player: Player{
+			ID:          t.id("players"),
+			Nickname:    player.Nickname,
+			NicknameKey: player.NicknameKey,
+			IsHost:      player.IsHost,
+			GameID:      player.GameID,
+			Status:      PlayerStatusOnline,
+			Role:        player.Role,
+			Seat:        seat + 1,
+			Avatar:      player.Avatar,
+			AccountID:   player.AccountID,
+		},
+		JoinedAt: m.now(),
+	}
+	t.players[row.ID] = row
+
+	created := row.Player
+	return &created, nil
+}
+
+func (m *MemoryPlayerStore) CountPlayerInGame(ctx context.Context, gameID int64) (int64, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	var n int64
+	for _, p := range t.players {
+		if p.GameID == gameID && p.Role == PlayerRolePlayer {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// listPlayers 依座位排序列出符合條件的玩家
+func (t *memoryTables) listPlayers(match func(p Player) bool) []*Player {
+	players := []*Player{}
+	for _, p := range t.players {
+		if match(p.Player) {
+			player := p.Player
+			players = append(players, &player)
+		}
+	}
+	slices.SortFunc(players, func(a, b *Player) int {
+		return cmp.Or(cmp.Compare(a.Seat, b.Seat), cmp.Compare(a.ID, b.ID))
+	})
+	return players
+}
+
+func (m *MemoryPlayerStore) FindPlayersByGameID(ctx context.Context, gameID int64) ([]*Player, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	return t.listPlayers(func(p Player) bool { return p.GameID == gameID }), nil
+}
+
+func (m *MemoryPlayerStore) FindOnlinePlayersByGameID(ctx context.Context, gameID int64) ([]*Player, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	return t.listPlayers(func(p Player) bool {
+		return p.GameID == gameID && p.Status == PlayerStatusOnline && p.Role == PlayerRolePlayer
+	}), nil
+}
+
+// DeleteByID 玩家出過題或回答過時和資料庫一樣無法刪除
+func (m *MemoryPlayerStore) DeleteByID(ctx context.Context, id int64) error {
+	t := m.lock()
+	defer m.unlock()
+
+	for _, r := range t.rounds {
+		if r.QuestionPlayerID == id || r.AnswerPlayerID == id {
+			return errMemoryForeignKey
+		}
+	}
+
+	delete(t.players, id)
+	t.deleteVotes(func(v Vote) bool { return v.PlayerID == id })
+	for rid, r := range t.rounds {
+		if r.AboutPlayerID != nil && *r.AboutPlayerID == id {
+			r.AboutPlayerID = nil
+			t.rounds[rid] = r
+		}
+	}
+	return nil
+}
+
+func (m *MemoryPlayerStore) FindByID(ctx context.Context, id int64) (*Player, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	p, ok := t.players[id]
+	if !ok {
+		return nil, errx.ErrPlayerNotFound
+	}
+	player := p.Player
+	return &player, nil
+}
+
+// updatePlayer 更新一位玩家，找不到時不做任何事
+func (m *MemoryPlayerStore) updatePlayer(id int64, fn func(p *Player)) {
+	t := m.lock()
+	defer m.unlock()
+
+	p, ok := t.players[id]
+	if !ok {
+		return
+	}
+	fn(&p.Player)
+	t.players[id] = p
+}
+
+func (m *MemoryPlayerStore) UpdateHost(ctx context.Context, id int64, isHost bool) error {
+	m.updatePlayer(id, func(p *Player) { p.IsHost = isHost })
+	return nil
+}
+
+func (m *MemoryPlayerStore) FindByNickname(ctx context.Context, gameID int64, nicknameKey string) (*Player, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	players := t.listPlayers(func(p Player) bool { return p.GameID == gameID && p.NicknameKey == nicknameKey })
+	if len(players) == 0 {
+		return nil, nil
+	}
+	return players[0], nil
+}
+
+func (m *MemoryPlayerStore) GetPlayerCountByGameCode(ctx context.Context, gameCode string) (int64, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	var n int64
+	for _, p := range t.players {
+		if t.games[p.GameID].Code == gameCode && p.Role == PlayerRolePlayer {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *MemoryPlayerStore) UpdatePlayerStatus(ctx context.Context, playerID int64, status string) error {
+	m.updatePlayer(playerID, func(p *Player) { p.Status = status })
+	return nil
+}
+
+func (m *MemoryPlayerStore) GetLivePlayerCount(ctx context.Context) (int64, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	var n int64
+	for _, p := range t.players {
+		if p.Status == PlayerStatusOnline && p.Role == PlayerRolePlayer {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *MemoryPlayerStore) UpdatePlayerRole(ctx context.Context, playerID int64, role string) error {
+	m.updatePlayer(playerID, func(p *Player) { p.Role = role })
+	return nil
+}
+
+func (m *MemoryPlayerStore) UpdateSeat(ctx context.Context, playerID int64, seat int32) error {
+	m.updatePlayer(playerID, func(p *Player) { p.Seat = seat })
+	return nil
+}
+
+func (m *MemoryPlayerStore) BanNickname(ctx context.Context, gameID int64, nicknameKey string) error {
+	t := m.lock()
+	defer m.unlock()
+
+	if _, ok := t.games[gameID]; !ok {
+		return errMemoryForeignKey
+	}
+	t.bans[memoryBan{GameID: gameID, NicknameKey: nicknameKey}] = struct{}{}
+	return nil
+}
+
+func (m *MemoryPlayerStore) CopyBans(ctx context.Context, fromGameID, toGameID int64) error {
+	t := m.lock()
+	defer m.unlock()
+
+	if _, ok := t.games[toGameID]; !ok {
+		return errMemoryForeignKey
+	}
+	for ban := range t.bans {
+		if ban.GameID == fromGameID {
+			t.bans[memoryBan{GameID: toGameID, NicknameKey: ban.NicknameKey}] = struct{}{}
+		}
+	}
+	return nil
+}
+
+func (m *MemoryPlayerStore) IsNicknameBanned(ctx context.Context, gameID int64, nicknameKey string) (bool, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	_, ok := t.bans[memoryBan{GameID: gameID, NicknameKey: nicknameKey}]
+	return ok, nil
+}
+
+func (m *MemoryPlayerStore) UpdateProfile(ctx context.Context, player *Player) error {
+	t := m.lock()
+	defer m.unlock()
+
+	p, ok := t.players[player.ID]
+	if !ok {
+		return nil
+	}
+	if t.nicknameTaken(p.GameID, player.NicknameKey, p.ID) {
+		return errx.ErrDuplicateNickname
+	}
+	p.Nickname = player.Nickname
+	p.NicknameKey = player.NicknameKey
+	p.Avatar = player.Avatar
+	t.players[player.ID] = p
+	return nil
+}
+
+// nicknameTaken 對應 Postgres 的唯一索引 (game_id, nickname_key)，except 為正在修改的玩家
+func (t *memoryTables) nicknameTaken(gameID int64, nicknameKey string, except int64) bool {
+	for _, p := range t.players {
+		if p.GameID == gameID && p.NicknameKey == nicknameKey && p.ID != except {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *MemoryPlayerStore) ClaimPlayer(ctx context.Context, playerID, accountID int64) (bool, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	if _, ok := t.accounts[accountID]; !ok {
+		return false, errMemoryForeignKey
+	}
+	p, ok := t.players[playerID]
+	if !ok || (p.AccountID != nil && *p.AccountID != accountID) {
+		return false, nil
+	}
+	p.AccountID = &accountID
+	t.players[playerID] = p
+	return true, nil
+}
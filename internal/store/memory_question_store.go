@@ -0,0 +1,148 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/y3933y3933/joker/internal/utils/errx"
+)
+
+type MemoryQuestionStore struct {
+	memoryConn
+}
+
+var _ QuestionStore = (*MemoryQuestionStore)(nil)
+
+func (m *MemoryQuestionStore) ListRandomQuestions(ctx context.Context, limit int32) ([]*Question, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	var list []*Question
+	for _, q := range t.questions {
+		list = append(list, &Question{ID: q.ID, Level: q.Level, Content: q.Content})
+	}
+	rand.Shuffle(len(list), func(i, j int) { list[i], list[j] = list[j], list[i] })
+	return list[:min(int(limit), len(list))], nil
+}
+
+// searchTokens 近似 PostgreSQL 'simple' 設定的斷詞：以非文字、數字的字元切開並轉小寫
+func searchTokens(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// matchesSearch 對應 to_tsvector @@ plainto_tsquery，查詢的每個詞都要出現在內容裡
+func matchesSearch(content, query string) bool {
+	if query == "" {
+		return true
+	}
+	words := searchTokens(query)
+	if len(words) == 0 {
+		return false
+	}
+	tokens := searchTokens(content)
+	for _, w := range words {
+		if !slices.Contains(tokens, w) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *MemoryQuestionStore) ListQuestions(ctx context.Context, content, level string, filters Filters) (*PaginatedQuestion, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	var rows []Question
+	for _, q := range t.questions {
+		if matchesSearch(q.Content, content) && (level == "" || q.Level == level) {
+			rows = append(rows, q.Question)
+		}
+	}
+	slices.SortFunc(rows, func(a, b Question) int {
+		var byCreated int
+		switch filters.SortBy {
+		case "created_at_asc":
+			byCreated = a.CreatedAt.Compare(b.CreatedAt)
+		case "created_at_desc":
+			byCreated = b.CreatedAt.Compare(a.CreatedAt)
+		}
+		return cmp.Or(byCreated, cmp.Compare(b.ID, a.ID))
+	})
+
+	questions := append([]Question{}, paginate(rows, filters)...)
+	totalCount := 0
+	if len(questions) > 0 {
+		totalCount = len(rows)
+	}
+	return &PaginatedQuestion{
+		Questions: questions,
+		Metadata:  CalculateMetadata(totalCount, filters.Page, filters.PageSize),
+	}, nil
+}
+
+func (m *MemoryQuestionStore) Create(ctx context.Context, content, level string) (*Question, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	now := m.now()
+	row := memoryQuestion{
+		Question:  Question{ID: t.id("questions"), Level: level, Content: content, CreatedAt: now},
+		UpdatedAt: now,
+	}
+	t.questions[row.ID] = row
+
+	created := row.Question
+	return &created, nil
+}
+
+// Delete 已經出過的題目和資料庫一樣無法刪除，收藏則一併刪除
+func (m *MemoryQuestionStore) Delete(ctx context.Context, id int64) error {
+	t := m.lock()
+	defer m.unlock()
+
+	for _, r := range t.rounds {
+		if r.QuestionID != nil && *r.QuestionID == id {
+			return errMemoryForeignKey
+		}
+	}
+	delete(t.questions, id)
+	for f := range t.favorites {
+		if f.QuestionID == id {
+			delete(t.favorites, f)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryQuestionStore) Update(ctx context.Context, id int64, content, level string) (*Question, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	q, ok := t.questions[id]
+	if !ok {
+		return nil, errx.ErrQuestionNotFound
+	}
+	q.Level = level
+	q.Content = content
+	q.UpdatedAt = m.now()
+	t.questions[id] = q
+
+	return &Question{ID: q.ID, Level: q.Level, Content: q.Content}, nil
+}
+
+func (m *MemoryQuestionStore) Get(ctx context.Context, id int64) (*Question, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	q, ok := t.questions[id]
+	if !ok {
+		return nil, errx.ErrQuestionNotFound
+	}
+	return &Question{ID: q.ID, Level: q.Level, Content: q.Content}, nil
+}
@@ -0,0 +1,207 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/y3933y3933/joker/internal/utils/errx"
+)
+
+type MemoryRoundStore struct {
+	memoryConn
+}
+
+var _ RoundStore = (*MemoryRoundStore)(nil)
+
+func (m *MemoryRoundStore) Create(ctx context.Context, round *Round) (*Round, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	_, gameOK := t.games[round.GameID]
+	_, questionerOK := t.players[round.QuestionPlayerID]
+	_, answererOK := t.players[round.AnswerPlayerID]
+	if !gameOK || !questionerOK || !answererOK {
+		return nil, errMemoryForeignKey
+	}
+	if round.QuestionID != nil {
+		if _, ok := t.questions[*round.QuestionID]; !ok {
+			return nil, errMemoryForeignKey
+		}
+	}
+
+	now := m.now()
+	row := memoryRound{
+		Round: Round{
+			ID:               t.id("rounds"),
+			GameID:           round.GameID,
+			QuestionID:       round.QuestionID,
+			Answer:           round.Answer,
+			QuestionPlayerID: round.QuestionPlayerID,
+			AnswerPlayerID:   round.AnswerPlayerID,
+			IsJoker:          round.IsJoker,
+			Status:           round.Status,
+			Deck:             slices.Clone(round.Deck),
+		},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	t.rounds[row.ID] = row
+
+	created := row.Round
+	created.Deck = slices.Clone(row.Deck)
+	return &created, nil
+}
+
+// copyRound 回傳的回合不和資料表共用 deck
+func copyRound(r memoryRound) Round {
+	round := r.Round
+	round.Deck = slices.Clone(r.Deck)
+	return round
+}
+
+// updateRound 更新回合並記錄 updated_at，找不到時不做任何事
+func (m *MemoryRoundStore) updateRound(roundID int64, fn func(r *Round)) {
+	t := m.lock()
+	defer m.unlock()
+
+	r, ok := t.rounds[roundID]
+	if !ok {
+		return
+	}
+	fn(&r.Round)
+	r.UpdatedAt = m.now()
+	t.rounds[roundID] = r
+}
+
+func (m *MemoryRoundStore) SetRoundQuestion(ctx context.Context, roundID int64, questionID int64) error {
+	t := m.lock()
+	_, ok := t.questions[questionID]
+	m.unlock()
+	if !ok {
+		return errMemoryForeignKey
+	}
+
+	m.updateRound(roundID, func(r *Round) {
+		r.QuestionID = &questionID
+		r.Status = RoundStatusWaitingForAnswer
+	})
+	return nil
+}
+
+func (m *MemoryRoundStore) GetRoundByID(ctx context.Context, roundID int64) (*Round, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	r, ok := t.rounds[roundID]
+	if !ok {
+		return nil, errx.ErrRoundNotFound
+	}
+	round := copyRound(r)
+	return &round, nil
+}
+
+// GetRoundWithQuestion 和資料庫的 JOIN 一樣，尚未選題的回合視為找不到
+func (m *MemoryRoundStore) GetRoundWithQuestion(ctx context.Context, id int64) (*RoundWithQuestion, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	r, ok := t.rounds[id]
+	if !ok || r.QuestionID == nil {
+		return nil, errx.ErrRoundNotFound
+	}
+	q := t.questions[*r.QuestionID]
+	return &RoundWithQuestion{
+		Round:    copyRound(r),
+		Question: Question{Level: q.Level, Content: q.Content},
+	}, nil
+}
+
+func (m *MemoryRoundStore) UpdateAnswer(ctx context.Context, roundID int64, answer string, status string, secret AnswerSecret) error {
+	m.updateRound(roundID, func(r *Round) {
+		r.Answer = &answer
+		r.Status = status
+		r.AnswerSecret = secret
+	})
+	return nil
+}
+
+func (m *MemoryRoundStore) UpdateDrawResult(ctx context.Context, roundID int64, isJoker bool, status string) error {
+	m.updateRound(roundID, func(r *Round) {
+		r.IsJoker = isJoker
+		r.Status = status
+	})
+	return nil
+}
+
+// gameRounds 依建立順序列出一場遊戲的回合
+func (t *memoryTables) gameRounds(gameID int64) []memoryRound {
+	var rounds []memoryRound
+	for _, r := range t.rounds {
+		if r.GameID == gameID {
+			rounds = append(rounds, r)
+		}
+	}
+	slices.SortFunc(rounds, func(a, b memoryRound) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), cmp.Compare(a.ID, b.ID))
+	})
+	return rounds
+}
+
+func (m *MemoryRoundStore) FindLastRoundByGameID(ctx context.Context, gameID int64) (*Round, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	rounds := t.gameRounds(gameID)
+	if len(rounds) == 0 {
+		return nil, errx.ErrRoundNotFound
+	}
+	round := copyRound(rounds[len(rounds)-1])
+	return &round, nil
+}
+
+func (m *MemoryRoundStore) UpdateRoundStatus(ctx context.Context, roundID int64, status string) error {
+	m.updateRound(roundID, func(r *Round) { r.Status = status })
+	return nil
+}
+
+func (m *MemoryRoundStore) SkipRound(ctx context.Context, roundID int64) error {
+	m.updateRound(roundID, func(r *Round) {
+		r.Status = RoundStatusDone
+		r.Skipped = true
+	})
+	return nil
+}
+
+func (m *MemoryRoundStore) ResolveVotes(ctx context.Context, roundID int64, status string, isJoker *bool) (bool, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	r, ok := t.rounds[roundID]
+	if !ok || r.Status != RoundStatusWaitingForVotes {
+		return false, nil
+	}
+	r.Status = status
+	if isJoker != nil {
+		r.IsJoker = *isJoker
+	}
+	r.UpdatedAt = m.now()
+	t.rounds[roundID] = r
+	return true, nil
+}
+
+func (m *MemoryRoundStore) ListByGameID(ctx context.Context, gameID int64) ([]*RoundWithQuestion, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	rows := t.gameRounds(gameID)
+	rounds := make([]*RoundWithQuestion, len(rows))
+	for i, r := range rows {
+		rounds[i] = &RoundWithQuestion{Round: copyRound(r)}
+		if r.QuestionID != nil {
+			q := t.questions[*r.QuestionID]
+			rounds[i].Question = Question{Level: q.Level, Content: q.Content}
+		}
+	}
+	return rounds, nil
+}
@@ -0,0 +1,51 @@
+package store
+
+import (
+	"context"
+
+	"github.com/y3933y3933/joker/internal/utils/errx"
+)
+
+type MemoryUserStore struct {
+	memoryConn
+}
+
+var _ UserStore = (*MemoryUserStore)(nil)
+
+func (m *MemoryUserStore) Create(ctx context.Context, user *User) (int64, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	for _, u := range t.users {
+		if u.Username == user.Username {
+			return 0, errx.ErrDuplicateUsername
+		}
+	}
+
+	row := User{ID: t.id("users"), Username: user.Username, Password: password{hash: user.Password.hash}}
+	t.users[row.ID] = row
+	return row.ID, nil
+}
+
+func (m *MemoryUserStore) GetUserByUsername(ctx context.Context, username string) (*User, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	for _, u := range t.users {
+		if u.Username == username {
+			return &User{ID: u.ID, Username: u.Username, Password: password{hash: u.Password.hash}}, nil
+		}
+	}
+	return nil, errx.ErrUserNotFound
+}
+
+func (m *MemoryUserStore) GetUserByID(ctx context.Context, userID int64) (*User, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	u, ok := t.users[userID]
+	if !ok {
+		return nil, errx.ErrUserNotFound
+	}
+	return &User{ID: u.ID, Username: u.Username}, nil
+}
@@ -0,0 +1,77 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/y3933y3933/joker/internal/utils/errx"
+)
+
+type MemoryVoteStore struct {
+	memoryConn
+}
+
+var _ VoteStore = (*MemoryVoteStore)(nil)
+
+func (m *MemoryVoteStore) Create(ctx context.Context, vote *Vote) (*Vote, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	_, roundOK := t.rounds[vote.RoundID]
+	_, playerOK := t.players[vote.PlayerID]
+	if !roundOK || !playerOK {
+		return nil, errMemoryForeignKey
+	}
+	for _, v := range t.votes {
+		if v.RoundID == vote.RoundID && v.PlayerID == vote.PlayerID && v.Kind == vote.Kind {
+			return nil, errx.ErrAlreadyVoted
+		}
+	}
+
+	row := Vote{
+		ID:        t.id("votes"),
+		RoundID:   vote.RoundID,
+		PlayerID:  vote.PlayerID,
+		Kind:      vote.Kind,
+		Choice:    vote.Choice,
+		CreatedAt: m.now(),
+	}
+	t.votes[row.ID] = row
+	return &row, nil
+}
+
+// listVotes 依 id 排序列出符合條件的投票
+func (t *memoryTables) listVotes(match func(v Vote) bool) []*Vote {
+	votes := []*Vote{}
+	for _, v := range t.votes {
+		if match(v) {
+			vote := v
+			votes = append(votes, &vote)
+		}
+	}
+	slices.SortFunc(votes, func(a, b *Vote) int { return cmp.Compare(a.ID, b.ID) })
+	return votes
+}
+
+func (t *memoryTables) deleteVotes(match func(v Vote) bool) {
+	for id, v := range t.votes {
+		if match(v) {
+			delete(t.votes, id)
+		}
+	}
+}
+
+func (m *MemoryVoteStore) ListByRoundID(ctx context.Context, roundID int64) ([]*Vote, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	return t.listVotes(func(v Vote) bool { return v.RoundID == roundID }), nil
+}
+
+func (m *MemoryVoteStore) ListByGameID(ctx context.Context, gameID int64) ([]*Vote, error) {
+	t := m.lock()
+	defer m.unlock()
+
+	return t.listVotes(func(v Vote) bool { return t.rounds[v.RoundID].GameID == gameID }), nil
+}
@@ -0,0 +1,75 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+	"github.com/y3933y3933/joker/migrations"
+)
+
+// ErrSchemaVersion 表示資料庫的 schema 版本與這個執行檔嵌入的 migration 不一致
+var ErrSchemaVersion = errors.New("db: incompatible schema version")
+
+// Migrator 以嵌入在執行檔裡的 migration 更新資料庫，
+// 執行時持有 PostgreSQL 的 advisory lock，多個程序同時啟動也只會有一個在跑 migration
+type Migrator struct {
+	db       *sql.DB
+	provider *goose.Provider
+}
+
+func NewMigrator(pool *pgxpool.Pool) (*Migrator, error) {
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		return nil, fmt.Errorf("db: migrator %w", err)
+	}
+	// goose 需要 database/sql，共用同一個連線池，Close 不會關閉 pool
+	db := stdlib.OpenDBFromPool(pool)
+	provider, err := goose.NewProvider(goose.DialectPostgres, db, migrations.FS, goose.WithSessionLocker(locker))
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("db: migrator %w", err)
+	}
+	return &Migrator{db: db, provider: provider}, nil
+}
+
+// Up 執行所有尚未套用的 migration
+func (m *Migrator) Up(ctx context.Context) ([]*goose.MigrationResult, error) {
+	return m.provider.Up(ctx)
+}
+
+// Down 回退最新的一個 migration
+func (m *Migrator) Down(ctx context.Context) (*goose.MigrationResult, error) {
+	return m.provider.Down(ctx)
+}
+
+// Status 列出每個嵌入的 migration 是否已經套用
+func (m *Migrator) Status(ctx context.Context) ([]*goose.MigrationStatus, error) {
+	return m.provider.Status(ctx)
+}
+
+func (m *Migrator) Close() error {
+	return m.db.Close()
+}
+
+// CheckSchemaVersion 確認資料庫剛好在嵌入的最新版本，落後代表漏跑 migration，
+// 超前代表部署了較舊的執行檔，兩種情況查詢都可能對不上 schema
+func CheckSchemaVersion(ctx context.Context, pool *pgxpool.Pool) error {
+	expected, err := migrations.LatestVersion()
+	if err != nil {
+		return err
+	}
+	current, err := SchemaVersion(ctx, pool)
+	if err != nil {
+		return err
+	}
+	if current != expected {
+		return fmt.Errorf("%w: database is at %d, this build needs %d", ErrSchemaVersion, current, expected)
+	}
+	return nil
+}
@@ -38,6 +38,13 @@ func fromPgBool(p pgtype.Bool) bool {
 	return p.Bool
 }
 
+func fromPgNullBool(p pgtype.Bool) *bool {
+	if !p.Valid {
+		return nil
+	}
+	return &p.Bool
+}
+
 func fromPgInt8(p pgtype.Int8) *int64 {
 	if !p.Valid {
 		return nil
@@ -5,21 +5,41 @@ import (
 	"database/sql"
 	"errors"
 
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/y3933y3933/joker/internal/db/sqlc"
 	"github.com/y3933y3933/joker/internal/utils/errx"
 )
 
 type Player struct {
-	ID       int64  `json:"id"`
-	Nickname string `json:"nickname"`
-	IsHost   bool   `json:"isHost"`
-	GameID   int64  `json:"gameID"`
-	Status   string `json:"status"`
+	ID          int64  `json:"id"`
+	Nickname    string `json:"nickname"`
+	NicknameKey string `json:"-"` // 正規化後的暱稱，用來檢查重複與禁止名單
+	IsHost      bool   `json:"isHost"`
+	GameID      int64  `json:"gameID"`
+	Status      string `json:"status"`
+	Role        string `json:"role"`
+	Seat        int32  `json:"seat"`
+	Avatar      Avatar `json:"avatar"`
+	AccountID   *int64 `json:"accountID,omitempty"` // 登入的玩家帳號，匿名時為 nil
+}
+
+// Avatar 是伺服器提供的預設頭像（Preset），或自選的顏色加 emoji，都沒選時為空值
+type Avatar struct {
+	Preset string `json:"preset,omitempty"`
+	Color  string `json:"color,omitempty"`
+	Emoji  string `json:"emoji,omitempty"`
 }
 
 const (
 	PlayerStatusOnline  = "online"
 	PlayerStatusOffline = "disconnected"
+	PlayerStatusKicked  = "kicked"
+)
+
+const (
+	PlayerRolePlayer    = "player"
+	PlayerRoleSpectator = "spectator"
 )
 
 type PostgresPlayerStore struct {
@@ -38,31 +58,49 @@ type PlayerStore interface {
 	DeleteByID(ctx context.Context, id int64) error
 	FindByID(ctx context.Context, id int64) (*Player, error)
 	UpdateHost(ctx context.Context, id int64, isHost bool) error
-	FindByNickname(ctx context.Context, gameID int64, nickname string) (*Player, error)
+	FindByNickname(ctx context.Context, gameID int64, nicknameKey string) (*Player, error)
 	GetPlayerCountByGameCode(ctx context.Context, gameCode string) (int64, error)
 	UpdatePlayerStatus(ctx context.Context, playerID int64, status string) error
 	GetLivePlayerCount(ctx context.Context) (int64, error)
+	UpdatePlayerRole(ctx context.Context, playerID int64, role string) error
+	UpdateSeat(ctx context.Context, playerID int64, seat int32) error
+	BanNickname(ctx context.Context, gameID int64, nicknameKey string) error
+	IsNicknameBanned(ctx context.Context, gameID int64, nicknameKey string) (bool, error)
+	CopyBans(ctx context.Context, fromGameID, toGameID int64) error
+	UpdateProfile(ctx context.Context, player *Player) error
+	ClaimPlayer(ctx context.Context, playerID, accountID int64) (bool, error)
 }
 
 func (pg *PostgresPlayerStore) Create(ctx context.Context, player *Player) (*Player, error) {
 	args := sqlc.CreatePlayerParams{
-		GameID:   player.GameID,
-		Nickname: player.Nickname,
-		IsHost:   toPgBool(&player.IsHost),
+		GameID:       player.GameID,
+		Nickname:     player.Nickname,
+		IsHost:       toPgBool(&player.IsHost),
+		Role:         player.Role,
+		NicknameKey:  player.NicknameKey,
+		AvatarPreset: player.Avatar.Preset,
+		AvatarColor:  player.Avatar.Color,
+		AvatarEmoji:  player.Avatar.Emoji,
+		AccountID:    toPgInt8(player.AccountID),
 	}
 
 	row, err := pg.queries.CreatePlayer(ctx, args)
 
 	if err != nil {
-		return nil, err
+		return nil, nicknameTaken(err)
 	}
 
 	return &Player{
-		ID:       row.ID,
-		Nickname: row.Nickname,
-		IsHost:   fromPgBool(row.IsHost),
-		GameID:   row.GameID,
-		Status:   row.Status,
+		ID:          row.ID,
+		Nickname:    row.Nickname,
+		IsHost:      fromPgBool(row.IsHost),
+		GameID:      row.GameID,
+		Status:      row.Status,
+		Role:        row.Role,
+		Seat:        row.Seat,
+		NicknameKey: row.NicknameKey,
+		Avatar:      Avatar{Preset: row.AvatarPreset, Color: row.AvatarColor, Emoji: row.AvatarEmoji},
+		AccountID:   fromPgInt8(row.AccountID),
 	}, nil
 
 }
@@ -84,11 +122,16 @@ func (pg *PostgresPlayerStore) FindPlayersByGameID(ctx context.Context, gameID i
 	players := make([]*Player, 0, len(dbPlayers))
 	for _, p := range dbPlayers {
 		players = append(players, &Player{
-			ID:       p.ID,
-			Nickname: p.Nickname,
-			IsHost:   p.IsHost.Bool,
-			GameID:   p.GameID,
-			Status:   p.Status,
+			ID:          p.ID,
+			Nickname:    p.Nickname,
+			IsHost:      p.IsHost.Bool,
+			GameID:      p.GameID,
+			Status:      p.Status,
+			Role:        p.Role,
+			Seat:        p.Seat,
+			NicknameKey: p.NicknameKey,
+			Avatar:      Avatar{Preset: p.AvatarPreset, Color: p.AvatarColor, Emoji: p.AvatarEmoji},
+			AccountID:   fromPgInt8(p.AccountID),
 		})
 	}
 	return players, nil
@@ -108,11 +151,16 @@ func (pg *PostgresPlayerStore) FindByID(ctx context.Context, id int64) (*Player,
 	}
 
 	return &Player{
-		ID:       res.ID,
-		Nickname: res.Nickname,
-		IsHost:   fromPgBool(res.IsHost),
-		GameID:   res.GameID,
-		Status:   res.Status,
+		ID:          res.ID,
+		Nickname:    res.Nickname,
+		IsHost:      fromPgBool(res.IsHost),
+		GameID:      res.GameID,
+		Status:      res.Status,
+		Role:        res.Role,
+		Seat:        res.Seat,
+		NicknameKey: res.NicknameKey,
+		Avatar:      Avatar{Preset: res.AvatarPreset, Color: res.AvatarColor, Emoji: res.AvatarEmoji},
+		AccountID:   fromPgInt8(res.AccountID),
 	}, nil
 }
 
@@ -123,10 +171,11 @@ func (pg *PostgresPlayerStore) UpdateHost(ctx context.Context, id int64, isHost
 	})
 }
 
-func (pg *PostgresPlayerStore) FindByNickname(ctx context.Context, gameID int64, nickname string) (*Player, error) {
+// FindByNickname 依正規化後的暱稱查詢，找不到時回傳 nil
+func (pg *PostgresPlayerStore) FindByNickname(ctx context.Context, gameID int64, nicknameKey string) (*Player, error) {
 	player, err := pg.queries.FindPlayerByNickname(ctx, sqlc.FindPlayerByNicknameParams{
-		GameID:   gameID,
-		Nickname: nickname,
+		GameID:      gameID,
+		NicknameKey: nicknameKey,
 	})
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -135,11 +184,16 @@ func (pg *PostgresPlayerStore) FindByNickname(ctx context.Context, gameID int64,
 		return nil, err
 	}
 	return &Player{
-		ID:       player.ID,
-		Nickname: player.Nickname,
-		IsHost:   fromPgBool(player.IsHost),
-		GameID:   player.GameID,
-		Status:   player.Status,
+		ID:          player.ID,
+		Nickname:    player.Nickname,
+		IsHost:      fromPgBool(player.IsHost),
+		GameID:      player.GameID,
+		Status:      player.Status,
+		Role:        player.Role,
+		Seat:        player.Seat,
+		NicknameKey: player.NicknameKey,
+		Avatar:      Avatar{Preset: player.AvatarPreset, Color: player.AvatarColor, Emoji: player.AvatarEmoji},
+		AccountID:   fromPgInt8(player.AccountID),
 	}, nil
 }
 
@@ -164,11 +218,16 @@ func (pg *PostgresPlayerStore) FindOnlinePlayersByGameID(ctx context.Context, ga
 	players := make([]*Player, 0, len(dbPlayers))
 	for _, p := range dbPlayers {
 		players = append(players, &Player{
-			ID:       p.ID,
-			Nickname: p.Nickname,
-			IsHost:   p.IsHost.Bool,
-			GameID:   p.GameID,
-			Status:   p.Status,
+			ID:          p.ID,
+			Nickname:    p.Nickname,
+			IsHost:      p.IsHost.Bool,
+			GameID:      p.GameID,
+			Status:      p.Status,
+			Role:        p.Role,
+			Seat:        p.Seat,
+			NicknameKey: p.NicknameKey,
+			Avatar:      Avatar{Preset: p.AvatarPreset, Color: p.AvatarColor, Emoji: p.AvatarEmoji},
+			AccountID:   fromPgInt8(p.AccountID),
 		})
 	}
 	return players, nil
@@ -177,3 +236,78 @@ func (pg *PostgresPlayerStore) FindOnlinePlayersByGameID(ctx context.Context, ga
 func (pg *PostgresPlayerStore) GetLivePlayerCount(ctx context.Context) (int64, error) {
 	return pg.queries.GetLivePlayerCount(ctx)
 }
+
+func (pg *PostgresPlayerStore) UpdatePlayerRole(ctx context.Context, playerID int64, role string) error {
+	args := sqlc.UpdatePlayerRoleParams{
+		ID:   playerID,
+		Role: role,
+	}
+	return pg.queries.UpdatePlayerRole(ctx, args)
+}
+
+func (pg *PostgresPlayerStore) UpdateSeat(ctx context.Context, playerID int64, seat int32) error {
+	args := sqlc.UpdatePlayerSeatParams{
+		ID:   playerID,
+		Seat: seat,
+	}
+	return pg.queries.UpdatePlayerSeat(ctx, args)
+}
+
+func (pg *PostgresPlayerStore) BanNickname(ctx context.Context, gameID int64, nicknameKey string) error {
+	args := sqlc.CreateGameBanParams{
+		GameID:   gameID,
+		Nickname: nicknameKey,
+	}
+	return pg.queries.CreateGameBan(ctx, args)
+}
+
+// CopyBans 把 fromGameID 禁止的暱稱也加到 toGameID，例如再來一局時
+func (pg *PostgresPlayerStore) CopyBans(ctx context.Context, fromGameID, toGameID int64) error {
+	args := sqlc.CopyGameBansParams{
+		ToGameID:   toGameID,
+		FromGameID: fromGameID,
+	}
+	return pg.queries.CopyGameBans(ctx, args)
+}
+
+func (pg *PostgresPlayerStore) IsNicknameBanned(ctx context.Context, gameID int64, nicknameKey string) (bool, error) {
+	args := sqlc.IsNicknameBannedParams{
+		GameID:   gameID,
+		Nickname: nicknameKey,
+	}
+	return pg.queries.IsNicknameBanned(ctx, args)
+}
+
+// UpdateProfile 更新暱稱與頭像
+func (pg *PostgresPlayerStore) UpdateProfile(ctx context.Context, player *Player) error {
+	err := pg.queries.UpdatePlayerProfile(ctx, sqlc.UpdatePlayerProfileParams{
+		ID:           player.ID,
+		Nickname:     player.Nickname,
+		NicknameKey:  player.NicknameKey,
+		AvatarPreset: player.Avatar.Preset,
+		AvatarColor:  player.Avatar.Color,
+		AvatarEmoji:  player.Avatar.Emoji,
+	})
+	return nicknameTaken(err)
+}
+
+// nicknameTaken 同時加入或改名時，唯一索引 (game_id, nickname_key) 擋下比對鍵重複的暱稱
+func nicknameTaken(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation && pgErr.ConstraintName == "idx_players_game_id_nickname_key" {
+		return errx.ErrDuplicateNickname
+	}
+	return err
+}
+
+// ClaimPlayer 把玩家歸屬到帳號，已經屬於其他帳號時回傳 false
+func (pg *PostgresPlayerStore) ClaimPlayer(ctx context.Context, playerID, accountID int64) (bool, error) {
+	n, err := pg.queries.ClaimPlayer(ctx, sqlc.ClaimPlayerParams{
+		ID:        playerID,
+		AccountID: toPgInt8(&accountID),
+	})
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
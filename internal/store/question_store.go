@@ -2,9 +2,12 @@ package store
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/y3933y3933/joker/internal/db/sqlc"
+	"github.com/y3933y3933/joker/internal/utils/errx"
 )
 
 type Question struct {
@@ -120,6 +123,9 @@ func (pg *PostgresQuestionStore) Update(ctx context.Context, id int64, content,
 		Content: content,
 	})
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errx.ErrQuestionNotFound
+		}
 		return nil, err
 	}
 
@@ -133,6 +139,9 @@ func (pg *PostgresQuestionStore) Update(ctx context.Context, id int64, content,
 func (pg *PostgresQuestionStore) Get(ctx context.Context, id int64) (*Question, error) {
 	row, err := pg.queries.GetQuestionByID(ctx, id)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errx.ErrQuestionNotFound
+		}
 		return nil, err
 	}
 
@@ -10,26 +10,35 @@ import (
 )
 
 type Round struct {
-	ID               int64    `json:"id"`
-	GameID           int64    `json:"gameID"`
-	QuestionID       *int64   `json:"questionID,omitempty"` // 尚未選題前為 nil
-	Answer           *string  `json:"answer,omitempty"`     // 尚未回答前為 nil
-	QuestionPlayerID int64    `json:"questionerID"`
-	AnswerPlayerID   int64    `json:"answererID"`
-	IsJoker          bool     `json:"isJoker"`
-	Status           string   `json:"status"`
-	Deck             []string `json:"-"`
+	ID               int64      `json:"id"`
+	GameID           int64      `json:"gameID"`
+	QuestionID       *int64     `json:"questionID,omitempty"` // 尚未選題前為 nil
+	Answer           *string    `json:"answer,omitempty"`     // 尚未回答前為 nil
+	QuestionPlayerID int64      `json:"questionerID"`
+	AnswerPlayerID   int64      `json:"answererID"`
+	IsJoker          bool       `json:"isJoker"`
+	Status           string     `json:"status"`
+	Deck             []string   `json:"-"`
+	Skipped          bool       `json:"skipped"` // 因玩家離開而跳過，沒有抽牌或投票結果
+	AnswerSecret     `json:"-"` // 投票結束前不能讓其他玩家看到
+}
+
+// AnswerSecret 是回答者送出回答時提供的正確答案，供其他玩家猜測
+type AnswerSecret struct {
+	AboutPlayerID *int64 // 回答說的是哪位玩家（guessing = about）
+	Truthful      *bool  // 回答是否屬實（guessing = truth）
 }
 
 type RoundWithQuestion struct {
 	Round
-	Question
+	Question `json:"question"`
 }
 
 const (
 	RoundStatusWaitingForQuestion = "waiting_for_question"
 	RoundStatusWaitingForAnswer   = "waiting_for_answer"
 	RoundStatusWaitingForDraw     = "waiting_for_draw"
+	RoundStatusWaitingForVotes    = "waiting_for_votes"
 	RoundStatusRevealed           = "revealed"
 	RoundStatusDone               = "done"
 )
@@ -47,10 +56,16 @@ type RoundStore interface {
 	SetRoundQuestion(ctx context.Context, roundID int64, questionID int64) error
 	GetRoundByID(ctx context.Context, roundID int64) (*Round, error)
 	GetRoundWithQuestion(ctx context.Context, id int64) (*RoundWithQuestion, error)
-	UpdateAnswer(ctx context.Context, roundID int64, answer string, status string) error
+	UpdateAnswer(ctx context.Context, roundID int64, answer string, status string, secret AnswerSecret) error
 	UpdateDrawResult(ctx context.Context, roundID int64, isJoker bool, status string) error
 	FindLastRoundByGameID(ctx context.Context, gameID int64) (*Round, error)
 	UpdateRoundStatus(ctx context.Context, roundID int64, status string) error
+	// SkipRound 結束回合並標記為跳過
+	SkipRound(ctx context.Context, roundID int64) error
+	// ResolveVotes 結束投票階段並寫入新狀態，isJoker 為 nil 時不變。
+	// 回合已經不在 waiting_for_votes（其他請求先結算了）時回傳 false
+	ResolveVotes(ctx context.Context, roundID int64, status string, isJoker *bool) (bool, error)
+	ListByGameID(ctx context.Context, gameID int64) ([]*RoundWithQuestion, error)
 }
 
 func (pg *PostgresRoundStore) Create(ctx context.Context, round *Round) (*Round, error) {
@@ -76,7 +91,7 @@ func (pg *PostgresRoundStore) Create(ctx context.Context, round *Round) (*Round,
 		QuestionPlayerID: res.QuestionPlayerID,
 		AnswerPlayerID:   res.AnswerPlayerID,
 		Status:           res.Status,
-		QuestionID:       &res.QuestionID.Int64,
+		QuestionID:       fromPgInt8(res.QuestionID),
 		Answer:           fromPgText(res.Answer),
 		IsJoker:          fromPgBool(res.IsJoker),
 		Deck:             res.Deck,
@@ -109,6 +124,11 @@ func (s *PostgresRoundStore) GetRoundByID(ctx context.Context, roundID int64) (*
 		IsJoker:          res.IsJoker.Bool,
 		Status:           res.Status,
 		Deck:             res.Deck,
+		Skipped:          res.Skipped,
+		AnswerSecret: AnswerSecret{
+			AboutPlayerID: fromPgInt8(res.AboutPlayerID),
+			Truthful:      fromPgNullBool(res.Truthful),
+		},
 	}, nil
 }
 
@@ -132,6 +152,11 @@ func (pg *PostgresRoundStore) GetRoundWithQuestion(ctx context.Context, id int64
 			IsJoker:          fromPgBool(res.IsJoker), // 如果有這個欄位
 			Status:           res.Status,
 			Deck:             res.Deck,
+			Skipped:          res.Skipped,
+			AnswerSecret: AnswerSecret{
+				AboutPlayerID: fromPgInt8(res.AboutPlayerID),
+				Truthful:      fromPgNullBool(res.Truthful),
+			},
 		},
 		Question: Question{
 			Level:   res.Level,
@@ -140,11 +165,13 @@ func (pg *PostgresRoundStore) GetRoundWithQuestion(ctx context.Context, id int64
 	}, nil
 }
 
-func (pg *PostgresRoundStore) UpdateAnswer(ctx context.Context, roundID int64, answer string, status string) error {
+func (pg *PostgresRoundStore) UpdateAnswer(ctx context.Context, roundID int64, answer string, status string, secret AnswerSecret) error {
 	args := sqlc.UpdateAnswerParams{
-		ID:     roundID,
-		Answer: toPgText(&answer),
-		Status: status,
+		ID:            roundID,
+		Answer:        toPgText(&answer),
+		Status:        status,
+		AboutPlayerID: toPgInt8(secret.AboutPlayerID),
+		Truthful:      toPgBool(secret.Truthful),
 	}
 	err := pg.queries.UpdateAnswer(ctx, args)
 	return err
@@ -178,6 +205,11 @@ func (pg *PostgresRoundStore) FindLastRoundByGameID(ctx context.Context, gameID
 		IsJoker:          fromPgBool(res.IsJoker),
 		Status:           res.Status,
 		Deck:             res.Deck,
+		Skipped:          res.Skipped,
+		AnswerSecret: AnswerSecret{
+			AboutPlayerID: fromPgInt8(res.AboutPlayerID),
+			Truthful:      fromPgNullBool(res.Truthful),
+		},
 	}, nil
 }
 
@@ -188,3 +220,54 @@ func (pg *PostgresRoundStore) UpdateRoundStatus(ctx context.Context, roundID int
 	}
 	return pg.queries.UpdateRoundStatus(ctx, args)
 }
+
+func (pg *PostgresRoundStore) SkipRound(ctx context.Context, roundID int64) error {
+	return pg.queries.SkipRound(ctx, roundID)
+}
+
+func (pg *PostgresRoundStore) ResolveVotes(ctx context.Context, roundID int64, status string, isJoker *bool) (bool, error) {
+	n, err := pg.queries.ResolveVotes(ctx, sqlc.ResolveVotesParams{
+		ID:      roundID,
+		Status:  status,
+		IsJoker: toPgBool(isJoker),
+	})
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// ListByGameID 依建立順序列出整場的回合，尚未選題的回合 Question 為空值
+func (pg *PostgresRoundStore) ListByGameID(ctx context.Context, gameID int64) ([]*RoundWithQuestion, error) {
+	rows, err := pg.queries.ListRoundsByGameID(ctx, gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	rounds := make([]*RoundWithQuestion, len(rows))
+	for i, res := range rows {
+		rounds[i] = &RoundWithQuestion{
+			Round: Round{
+				ID:               res.ID,
+				GameID:           res.GameID,
+				QuestionID:       fromPgInt8(res.QuestionID),
+				Answer:           fromPgText(res.Answer),
+				QuestionPlayerID: res.QuestionPlayerID,
+				AnswerPlayerID:   res.AnswerPlayerID,
+				IsJoker:          fromPgBool(res.IsJoker),
+				Status:           res.Status,
+				Deck:             res.Deck,
+				Skipped:          res.Skipped,
+				AnswerSecret: AnswerSecret{
+					AboutPlayerID: fromPgInt8(res.AboutPlayerID),
+					Truthful:      fromPgNullBool(res.Truthful),
+				},
+			},
+			Question: Question{
+				Level:   res.Level,
+				Content: res.QuestionContent,
+			},
+		}
+	}
+	return rounds, nil
+}
@@ -0,0 +1,62 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/y3933y3933/joker/internal/db/sqlc"
+)
+
+// Stores 同一個交易內使用的 store，透過 Tx 再開的交易會成為目前交易的一部分
+type Stores struct {
+	Games   GameStore
+	Players PlayerStore
+	Rounds  RoundStore
+	Votes   VoteStore
+	Tx      TxRunner
+}
+
+// TxRunner 以交易執行 fn，fn 回傳 error 時整個交易 rollback
+type TxRunner interface {
+	WithTx(ctx context.Context, fn func(tx Stores) error) error
+}
+
+// txBeginner 由 *pgxpool.Pool 與 pgx.Tx 實作，在交易中 Begin 會建立 savepoint
+type txBeginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+type PostgresTxRunner struct {
+	db      txBeginner
+	queries *sqlc.Queries
+}
+
+func NewPostgresTxRunner(pool *pgxpool.Pool, queries *sqlc.Queries) *PostgresTxRunner {
+	return &PostgresTxRunner{db: pool, queries: queries}
+}
+
+// WithTx 已經在交易中時以 savepoint 執行，fn 失敗只會 rollback 自己的寫入
+func (r *PostgresTxRunner) WithTx(ctx context.Context, fn func(tx Stores) error) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("db: begin tx %w", err)
+	}
+	// commit 之後 rollback 不會有作用
+	defer tx.Rollback(ctx)
+
+	queries := r.queries.WithTx(tx)
+	stores := Stores{
+		Games:   NewPostgresGameStore(queries),
+		Players: NewPostgresPlayerStore(queries),
+		Rounds:  NewPostgresRoundStore(queries),
+		Votes:   NewPostgresVoteStore(queries),
+		Tx:      &PostgresTxRunner{db: tx, queries: r.queries},
+	}
+
+	if err := fn(stores); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
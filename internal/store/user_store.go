@@ -84,7 +84,12 @@ func (pg *PostgresUserStore) Create(ctx context.Context, user *User) (int64, err
 func (pg *PostgresUserStore) GetUserByUsername(ctx context.Context, username string) (*User, error) {
 	row, err := pg.queries.GetUserByUsername(ctx, username)
 	if err != nil {
-		return nil, err
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, errx.ErrUserNotFound
+		default:
+			return nil, err
+		}
 	}
 
 	password := password{
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/y3933y3933/joker/internal/db/sqlc"
+	"github.com/y3933y3933/joker/internal/utils/errx"
+)
+
+type Vote struct {
+	ID        int64     `json:"id"`
+	RoundID   int64     `json:"roundID"`
+	PlayerID  int64     `json:"playerID"`
+	Kind      string    `json:"kind"`
+	Choice    string    `json:"choice"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+const (
+	VoteKindReveal = "reveal" // vote_reveal 模式：是否公開題目
+	VoteKindAbout  = "about"  // 猜回答說的是哪位玩家，choice 為玩家 id
+	VoteKindTruth  = "truth"  // 猜回答是否屬實，choice 為 true / false
+)
+
+const (
+	VoteChoiceReveal = "reveal"
+	VoteChoiceKeep   = "keep"
+)
+
+type PostgresVoteStore struct {
+	queries *sqlc.Queries
+}
+
+func NewPostgresVoteStore(queries *sqlc.Queries) *PostgresVoteStore {
+	return &PostgresVoteStore{queries: queries}
+}
+
+type VoteStore interface {
+	Create(ctx context.Context, vote *Vote) (*Vote, error)
+	ListByRoundID(ctx context.Context, roundID int64) ([]*Vote, error)
+	ListByGameID(ctx context.Context, gameID int64) ([]*Vote, error)
+}
+
+func toVote(v sqlc.Vote) *Vote {
+	return &Vote{
+		ID:        v.ID,
+		RoundID:   v.RoundID,
+		PlayerID:  v.PlayerID,
+		Kind:      v.Kind,
+		Choice:    v.Choice,
+		CreatedAt: v.CreatedAt.Time,
+	}
+}
+
+func (pg *PostgresVoteStore) Create(ctx context.Context, vote *Vote) (*Vote, error) {
+	row, err := pg.queries.CreateVote(ctx, sqlc.CreateVoteParams{
+		RoundID:  vote.RoundID,
+		PlayerID: vote.PlayerID,
+		Kind:     vote.Kind,
+		Choice:   vote.Choice,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return nil, errx.ErrAlreadyVoted
+		}
+		return nil, err
+	}
+	return toVote(row), nil
+}
+
+func (pg *PostgresVoteStore) ListByRoundID(ctx context.Context, roundID int64) ([]*Vote, error) {
+	rows, err := pg.queries.ListVotesByRoundID(ctx, roundID)
+	if err != nil {
+		return nil, err
+	}
+
+	votes := make([]*Vote, len(rows))
+	for i, row := range rows {
+		votes[i] = toVote(row)
+	}
+	return votes, nil
+}
+
+func (pg *PostgresVoteStore) ListByGameID(ctx context.Context, gameID int64) ([]*Vote, error) {
+	rows, err := pg.queries.ListVotesByGameID(ctx, gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	votes := make([]*Vote, len(rows))
+	for i, row := range rows {
+		votes[i] = toVote(row)
+	}
+	return votes, nil
+}
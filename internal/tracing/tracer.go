@@ -0,0 +1,23 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracer 每次建立 span 時才向全域的 TracerProvider 取得 tracer。
+// otel.Tracer 在 SetTracerProvider 之前取得時只會綁定第一次設定的 provider，
+// 之後替換 provider（例如每個測試各自設定）就收不到 span
+type Tracer struct {
+	name string
+}
+
+func NewTracer(name string) Tracer {
+	return Tracer{name: name}
+}
+
+func (t Tracer) Start(ctx context.Context, spanName string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.GetTracerProvider().Tracer(t.name).Start(ctx, spanName, opts...)
+}
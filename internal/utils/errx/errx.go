@@ -1,26 +1,91 @@
 package errx
 
-import "errors"
+import "net/http"
+
+// Error 是 API 會回給 client 的錯誤，帶有 HTTP 狀態與固定不變的錯誤碼。
+// 下面的 sentinel 就是錯誤碼的完整清單，service 可以用 %w 附上細節，
+// handler 以 errors.Is 比對，middleware 以 errors.As 取出狀態與錯誤碼
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func newError(status int, code, message string) *Error {
+	e := &Error{Status: status, Code: code, Message: message}
+	registry = append(registry, e)
+	return e
+}
+
+var registry []*Error
+
+// All 列出所有已定義的錯誤，依定義順序
+func All() []*Error {
+	return append([]*Error(nil), registry...)
+}
+
+var (
+	ErrGenerateCode       = newError(http.StatusServiceUnavailable, "GAME_CODE_UNAVAILABLE", "failed to generate unique game code")
+	ErrGameNotFound       = newError(http.StatusNotFound, "GAME_NOT_FOUND", "game not found")
+	ErrGameEnded          = newError(http.StatusGone, "GAME_ENDED", "game has ended")
+	ErrInvalidGameStatus  = newError(http.StatusBadRequest, "INVALID_GAME_STATUS", "invalid game status")
+	ErrNotEnoughPlayers   = newError(http.StatusBadRequest, "NOT_ENOUGH_PLAYERS", "not enough players")
+	ErrRoundNotFound      = newError(http.StatusNotFound, "ROUND_NOT_FOUND", "round not found")
+	ErrInvalidStatus      = newError(http.StatusBadRequest, "INVALID_ROUND_STATUS", "invalid round status")
+	ErrForbidden          = newError(http.StatusForbidden, "FORBIDDEN", "you are not allowed to perform this action")
+	ErrPlayerNotFound     = newError(http.StatusNotFound, "PLAYER_NOT_FOUND", "player not found")
+	ErrDuplicateNickname  = newError(http.StatusBadRequest, "NICKNAME_TAKEN", "nickname already taken")
+	ErrInvalidNickname    = newError(http.StatusBadRequest, "INVALID_NICKNAME", "nickname must be 1-20 printable characters")
+	ErrInvalidAvatar      = newError(http.StatusBadRequest, "INVALID_AVATAR", "avatar must be a preset or a #rrggbb color with an emoji")
+	ErrGameAlreadyStarted = newError(http.StatusBadRequest, "GAME_ALREADY_STARTED", "cannot leave, game has already started")
+	ErrNotSpectator       = newError(http.StatusBadRequest, "NOT_SPECTATOR", "player is not a spectator")
+	ErrGameLocked         = newError(http.StatusForbidden, "GAME_LOCKED", "game is locked")
+	ErrPlayerBanned       = newError(http.StatusForbidden, "PLAYER_BANNED", "you have been removed from this game")
+	ErrInvalidSeatOrder   = newError(http.StatusBadRequest, "INVALID_SEAT_ORDER", "seat order must list every player exactly once")
+	ErrInvalidGameMode    = newError(http.StatusBadRequest, "INVALID_GAME_MODE", "invalid game mode")
+	ErrInvalidGuessing    = newError(http.StatusBadRequest, "INVALID_GUESSING", "invalid guessing option")
+	ErrInvalidScoring     = newError(http.StatusBadRequest, "INVALID_SCORING", "scoring points must be between -10 and 10")
+	ErrAlreadyVoted       = newError(http.StatusBadRequest, "ALREADY_VOTED", "you have already voted in this round")
+	ErrInvalidBallot      = newError(http.StatusBadRequest, "INVALID_BALLOT", "vote is missing a required choice")
+	ErrInvalidSecret      = newError(http.StatusBadRequest, "INVALID_SECRET", "answer is missing the secret for the guessing phase")
+	ErrInvalidCardIndex   = newError(http.StatusBadRequest, "INVALID_CARD_INDEX", "invalid card index")
+)
+
+var (
+	ErrDuplicateUsername          = newError(http.StatusBadRequest, "USERNAME_TAKEN", "username already taken")
+	ErrInvalidCredentials         = newError(http.StatusUnauthorized, "INVALID_CREDENTIALS", "invalid username or password")
+	ErrInvalidAuthorizationHeader = newError(http.StatusUnauthorized, "INVALID_AUTHORIZATION_HEADER", "invalid authorization header")
+	ErrInvalidToken               = newError(http.StatusUnauthorized, "INVALID_TOKEN", "invalid token")
+	ErrTokenExpired               = newError(http.StatusUnauthorized, "TOKEN_EXPIRED", "token expired")
+	ErrUserNotFound               = newError(http.StatusNotFound, "USER_NOT_FOUND", "user not found")
+	ErrLoginRequired              = newError(http.StatusUnauthorized, "LOGIN_REQUIRED", "login required")
+	ErrAccountNotFound            = newError(http.StatusNotFound, "ACCOUNT_NOT_FOUND", "account not found")
+	ErrInvalidAccountInput        = newError(http.StatusBadRequest, "INVALID_ACCOUNT_INPUT", "username must be 3-32 letters, digits or underscores and password at least 8 characters")
+	ErrPlayerClaimed              = newError(http.StatusForbidden, "PLAYER_CLAIMED", "player already belongs to another account")
+	ErrQuestionNotFound           = newError(http.StatusNotFound, "QUESTION_NOT_FOUND", "question not found")
+	ErrFeedbackNotFound           = newError(http.StatusNotFound, "FEEDBACK_NOT_FOUND", "feedback not found")
+)
 
 var (
-	ErrGenerateCode       = errors.New("failed to generate unique game code")
-	ErrGameNotFound       = errors.New("game not found")
-	ErrInvalidGameStatus  = errors.New("invalid game status")
-	ErrNotEnoughPlayers   = errors.New("not enough players")
-	ErrRoundNotFound      = errors.New("round not found")
-	ErrInvalidStatus      = errors.New("invalid round status")
-	ErrForbidden          = errors.New("you are not allowed to perform this action")
-	ErrPlayerNotFound     = errors.New("player not found")
-	ErrDuplicateNickname  = errors.New("nickname already taken")
-	ErrGameAlreadyStarted = errors.New("cannot leave, game has already started")
+	ErrInvalidRoomAccess  = newError(http.StatusBadRequest, "INVALID_ROOM_ACCESS", "access must be public, passcode (with a 4-32 character passcode) or invite")
+	ErrRoomAccessRequired = newError(http.StatusUnauthorized, "ROOM_ACCESS_REQUIRED", "this room requires a passcode or an invite link")
+	ErrRoomAccessDenied   = newError(http.StatusForbidden, "ROOM_ACCESS_DENIED", "wrong passcode or invalid invite link")
+	ErrTooManyAttempts    = newError(http.StatusTooManyRequests, "TOO_MANY_ATTEMPTS", "too many failed attempts, try again later")
+	ErrInvalidSeatToken   = newError(http.StatusForbidden, "INVALID_SEAT_TOKEN", "seat token does not belong to this player")
 )
 
+// 請求本身的錯誤，service 與 handler 用 %w 附上是哪個欄位
 var (
-	ErrDuplicateUsername          = errors.New("username already taken")
-	ErrInvalidCredentials         = errors.New("invalid username or password")
-	ErrInvalidAuthorizationHeader = errors.New("invalid authorization header")
-	ErrInvalidToken               = errors.New("invalid token")
-	ErrTokenExpired               = errors.New("token expired")
-	ErrUserNotFound               = errors.New("user not found")
-	ErrLoginRequired              = errors.New("login required")
+	ErrInvalidInput     = newError(http.StatusBadRequest, "INVALID_INPUT", "invalid input")
+	ErrInvalidParam     = newError(http.StatusBadRequest, "INVALID_PARAM", "invalid param")
+	ErrInvalidPlayerID  = newError(http.StatusBadRequest, "INVALID_PLAYER_ID", "missing or invalid player id")
+	ErrInvalidGameCode  = newError(http.StatusBadRequest, "INVALID_GAME_CODE", "invalid game code")
+	ErrInvalidBody      = newError(http.StatusBadRequest, "INVALID_BODY", "request body is not valid JSON")
+	ErrValidationFailed = newError(http.StatusBadRequest, "VALIDATION_FAILED", "request body failed validation")
+	ErrRouteNotFound    = newError(http.StatusNotFound, "ROUTE_NOT_FOUND", "the requested resource could not be found")
+	ErrInternal         = newError(http.StatusInternalServerError, "INTERNAL_ERROR", "the server encountered a problem and could not process your request")
 )
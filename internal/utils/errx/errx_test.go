@@ -0,0 +1,28 @@
+package errx
+
+import (
+	"regexp"
+	"testing"
+)
+
+var codePattern = regexp.MustCompile(`^[A-Z][A-Z_]*[A-Z]$`)
+
+func TestRegistry(t *testing.T) {
+	codes := make(map[string]bool)
+	for _, e := range All() {
+		if !codePattern.MatchString(e.Code) {
+			t.Errorf("%q: code must be UPPER_SNAKE_CASE", e.Code)
+		}
+		if codes[e.Code] {
+			t.Errorf("%q: duplicate code", e.Code)
+		}
+		codes[e.Code] = true
+
+		if e.Status < 400 || e.Status > 599 {
+			t.Errorf("%s: status %d is not an error status", e.Code, e.Status)
+		}
+		if e.Message == "" {
+			t.Errorf("%s: empty message", e.Code)
+		}
+	}
+}
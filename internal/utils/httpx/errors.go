@@ -0,0 +1,144 @@
+package httpx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/y3933y3933/joker/internal/utils/errx"
+	"github.com/y3933y3933/joker/internal/utils/logx"
+)
+
+// ErrorBody 錯誤回應中 "error" 欄位的內容，code 對應 errx 定義的錯誤碼
+type ErrorBody struct {
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	Details   []FieldError `json:"details,omitempty"`
+	RequestID string       `json:"requestId,omitempty"`
+}
+
+// FieldError 單一欄位沒通過驗證的原因，field 是 JSON 欄位名稱
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// WriteError 將錯誤轉成對應的狀態碼與 ErrorBody。5xx 只記錄原始錯誤，回給 client 的是固定訊息
+func WriteError(c *gin.Context, err error) {
+	status, body := Describe(err)
+	body.RequestID = RequestID(c)
+
+	if status >= 500 {
+		logx.FromContext(c.Request.Context()).ErrorContext(c.Request.Context(), "request failed", "status", status, "error", err)
+	}
+	c.AbortWithStatusJSON(status, gin.H{"error": body})
+}
+
+// Describe 找出錯誤對應的狀態碼與回應內容，不認得的錯誤一律視為 500
+func Describe(err error) (int, ErrorBody) {
+	var validationErrs validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	var apiErr *errx.Error
+
+	switch {
+	case errors.As(err, &validationErrs):
+		return bodyOf(errx.ErrValidationFailed, validationDetails(validationErrs))
+
+	case errors.As(err, &typeErr):
+		return bodyOf(errx.ErrInvalidBody, []FieldError{{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Message: "must be " + jsonType(typeErr.Type),
+		}})
+
+	case errors.As(err, &syntaxErr), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return bodyOf(errx.ErrInvalidBody, nil)
+
+	case errors.As(err, &apiErr) && apiErr.Status < 500:
+		// 4xx 保留 service 用 %w 附上的細節
+		return apiErr.Status, ErrorBody{Code: apiErr.Code, Message: err.Error()}
+
+	case errors.As(err, &apiErr):
+		return bodyOf(apiErr, nil)
+
+	default:
+		return bodyOf(errx.ErrInternal, nil)
+	}
+}
+
+func bodyOf(e *errx.Error, details []FieldError) (int, ErrorBody) {
+	return e.Status, ErrorBody{Code: e.Code, Message: e.Message, Details: details}
+}
+
+func validationDetails(errs validator.ValidationErrors) []FieldError {
+	details := make([]FieldError, 0, len(errs))
+	for _, fe := range errs {
+		details = append(details, FieldError{
+			Field:   fe.Field(),
+			Rule:    fe.Tag(),
+			Message: ruleMessage(fe),
+		})
+	}
+	return details
+}
+
+func ruleMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "min":
+		return "must be at least " + fe.Param()
+	case "max":
+		return "must be at most " + fe.Param()
+	case "len":
+		return "must have length " + fe.Param()
+	default:
+		return fmt.Sprintf("failed on the %q rule", fe.Tag())
+	}
+}
+
+// jsonType 以 JSON 的型別名稱描述欄位，避免把 Go 的型別露給 client
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
+
+// UseJSONFieldNames 讓驗證錯誤回報 JSON 欄位名稱（questionID）而不是 Go 的欄位名稱（QuestionID）
+func UseJSONFieldNames() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return f.Name
+		}
+		return name
+	})
+}
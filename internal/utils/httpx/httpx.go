@@ -1,37 +1,22 @@
 package httpx
 
 import (
-	"log/slog"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
-func ServerErrorResponse(c *gin.Context, logger *slog.Logger, err error) {
-	logger.Error(err.Error(), "method", c.Request.Method, "url", c.Request.URL)
-
-	message := "the server encountered a problem and could not process your request"
-	c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-		"error": message,
-	})
-}
-
-func BadRequestResponse(c *gin.Context, err error) {
-	c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-}
-
-func NotFoundResponse(c *gin.Context, err error) {
-	c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
-}
-
 func SuccessResponse(c *gin.Context, data any) {
 	c.JSON(http.StatusOK, gin.H{"data": data})
 }
 
-func ForbiddenResponse(c *gin.Context, err error) {
-	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+// Error 記錄錯誤並中止後續的 handler，由 middleware.ErrorHandler 統一轉成錯誤回應
+func Error(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
 }
 
-func UnAuthorized(c *gin.Context, err error) {
-	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+// RequestID 取得 middleware.RequestID 設定的請求 ID
+func RequestID(c *gin.Context) string {
+	return c.GetString("request_id")
 }
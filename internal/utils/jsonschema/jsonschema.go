@@ -0,0 +1,198 @@
+// Package jsonschema 依 encoding/json 的規則把 Go 型別轉成 JSON Schema，
+// WebSocket 協定與 OpenAPI 文件都用它產生，確保文件和實際的 payload 一致
+package jsonschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	timeType    = reflect.TypeFor[time.Time]()
+	rawJSONType = reflect.TypeFor[json.RawMessage]()
+)
+
+// Field 是 struct 序列化成 JSON 後的一個欄位
+type Field struct {
+	Name     string
+	Type     reflect.Type
+	Optional bool   // 帶有 omitempty 或 omitzero
+	Binding  string // gin 的 binding tag
+}
+
+// Fields 依 encoding/json 的規則列出欄位，匿名嵌入的 struct 會被攤平
+func Fields(t reflect.Type) []Field {
+	var fields []Field
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			fields = append(fields, Fields(f.Type)...)
+			continue
+		}
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Pointer && f.Type.Elem().Kind() == reflect.Struct {
+			fields = append(fields, Fields(f.Type.Elem())...)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, Field{
+			Name:     name,
+			Type:     f.Type,
+			Optional: strings.Contains(opts, "omitempty") || strings.Contains(opts, "omitzero"),
+			Binding:  f.Tag.Get("binding"),
+		})
+	}
+	return fields
+}
+
+type defKey struct {
+	t       reflect.Type
+	request bool
+}
+
+// Builder 產生 schema，具名的 struct 放進 Defs 並以 RefPrefix 參照，匿名 struct 直接展開
+type Builder struct {
+	RefPrefix string
+	Defs      map[string]any
+
+	names map[defKey]string
+	taken map[string]bool
+}
+
+func New(refPrefix string) *Builder {
+	return &Builder{
+		RefPrefix: refPrefix,
+		Defs:      map[string]any{},
+		names:     map[defKey]string{},
+		taken:     map[string]bool{},
+	}
+}
+
+// Schema 描述伺服器送出的 JSON：沒有 omitempty 的欄位一定會出現
+func (b *Builder) Schema(t reflect.Type) map[string]any {
+	return b.schemaFor(t, false)
+}
+
+// RequestSchema 描述 gin 綁定的 request body：只有 binding:"required" 的欄位必填，
+// binding:"oneof=..." 轉成 enum。同一個型別也出現在回應時，request 版本命名為 <Name>Input
+func (b *Builder) RequestSchema(t reflect.Type) map[string]any {
+	return b.schemaFor(t, true)
+}
+
+func (b *Builder) schemaFor(t reflect.Type, request bool) map[string]any {
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t == rawJSONType:
+		return map[string]any{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return map[string]any{"anyOf": []any{b.schemaFor(t.Elem(), request), map[string]any{"type": "null"}}}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t, request)
+		}
+		return map[string]any{"$ref": b.RefPrefix + b.define(t, request)}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": b.schemaFor(t.Elem(), request)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": b.schemaFor(t.Elem(), request)}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	default:
+		return map[string]any{}
+	}
+}
+
+// define 登記具名 struct 並回傳在 Defs 中的名稱
+func (b *Builder) define(t reflect.Type, request bool) string {
+	key := defKey{t: t, request: request}
+	if name, ok := b.names[key]; ok {
+		return name
+	}
+
+	name := t.Name()
+	if b.taken[name] && request {
+		name += "Input"
+	}
+	b.names[key] = name
+	b.taken[name] = true
+
+	b.Defs[name] = nil // 先佔位，避免遞迴型別無限展開
+	b.Defs[name] = b.structSchema(t, request)
+	return name
+}
+
+func (b *Builder) structSchema(t reflect.Type, request bool) map[string]any {
+	properties := map[string]any{}
+	required := []string{}
+	for _, f := range Fields(t) {
+		schema := b.schemaFor(f.Type, request)
+		rules := bindingRules(f.Binding)
+
+		isRequired := !f.Optional
+		if request {
+			_, isRequired = rules["required"]
+			if oneOf, ok := rules["oneof"]; ok {
+				schema = withEnum(schema, strings.Fields(oneOf))
+			}
+		}
+
+		properties[f.Name] = schema
+		if isRequired {
+			required = append(required, f.Name)
+		}
+	}
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// bindingRules 解析 binding:"required,oneof=a b"
+func bindingRules(tag string) map[string]string {
+	rules := map[string]string{}
+	for _, rule := range strings.Split(tag, ",") {
+		if rule == "" {
+			continue
+		}
+		name, param, _ := strings.Cut(rule, "=")
+		rules[name] = param
+	}
+	return rules
+}
+
+// withEnum 把 enum 加到字串欄位上，可為 null 的欄位加在非 null 的分支
+func withEnum(schema map[string]any, values []string) map[string]any {
+	if anyOf, ok := schema["anyOf"].([]any); ok {
+		return map[string]any{"anyOf": []any{withEnum(anyOf[0].(map[string]any), values), anyOf[1]}}
+	}
+	out := map[string]any{"enum": values}
+	for k, v := range schema {
+		out[k] = v
+	}
+	return out
+}
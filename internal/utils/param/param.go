@@ -1,20 +1,18 @@
 package param
 
 import (
-	"errors"
+	"fmt"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/y3933y3933/joker/internal/utils/errx"
 )
 
 func ParseIntParam(c *gin.Context, key string) (int64, error) {
 	value := c.Param(key)
-	if value == "" {
-		return 0, errors.New("missing param: " + key)
-	}
 	id, err := strconv.ParseInt(value, 10, 64)
 	if err != nil {
-		return 0, errors.New("invalid param: " + key)
+		return 0, fmt.Errorf("%w: %s", errx.ErrInvalidParam, key)
 	}
 	return id, nil
 }
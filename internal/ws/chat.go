@@ -0,0 +1,279 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/y3933y3933/joker/internal/utils/errx"
+)
+
+const (
+	maxChatLength      = 200 // 聊天訊息長度上限（字元數）
+	chatHistorySize    = 50  // 房間保留、並放進 snapshot 的聊天訊息數
+	chatRateLimit      = 5   // 每個 chatRateWindow 內最多幾則聊天訊息
+	chatRateWindow     = 10 * time.Second
+	reactionRateLimit  = 10
+	reactionRateWindow = 10 * time.Second
+)
+
+// 可以使用的快速表情
+var allowedReactions = []string{"😂", "😱", "🔥", "👏", "😳", "🤔", "❤️", "🃏"}
+
+var (
+	errChatEmpty       = errors.New("message is empty")
+	errChatTooLong     = errors.New("message is too long")
+	errChatRateLimited = errors.New("you are sending messages too fast")
+	errChatMuted       = errors.New("you have been muted by the host")
+	errInvalidReaction = errors.New("unsupported reaction")
+)
+
+// ChatFilter 在聊天訊息廣播前檢查或改寫內容（例如過濾不雅字詞），
+// 回傳 error 時訊息會被拒絕並把錯誤回給送出者
+type ChatFilter func(text string) (string, error)
+
+// NewBlocklistFilter 建立把清單中的字詞替換成 * 的 ChatFilter，比對不分大小寫，空白的項目會被忽略
+func NewBlocklistFilter(words []string) ChatFilter {
+	var blocked [][]rune
+	for _, w := range words {
+		if w = strings.TrimSpace(w); w != "" {
+			blocked = append(blocked, lowerRunes(w))
+		}
+	}
+
+	return func(text string) (string, error) {
+		runes := []rune(text)
+		lower := lowerRunes(text)
+		for i := range lower {
+			for _, w := range blocked {
+				if i+len(w) <= len(lower) && slices.Equal(lower[i:i+len(w)], w) {
+					for j := i; j < i+len(w); j++ {
+						runes[j] = '*'
+					}
+				}
+			}
+		}
+		return string(runes), nil
+	}
+}
+
+// lowerRunes 逐字轉小寫，字元數和原字串相同，位置才能對應回去
+func lowerRunes(s string) []rune {
+	runes := []rune(s)
+	for i, r := range runes {
+		runes[i] = unicode.ToLower(r)
+	}
+	return runes
+}
+
+// rateLimiter 是簡單的滑動視窗限流，只會在單一 client 的 readPump 中使用
+type rateLimiter struct {
+	limit  int
+	window time.Duration
+	hits   []time.Time
+}
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{limit: limit, window: window}
+}
+
+func (l *rateLimiter) Allow(now time.Time) bool {
+	cutoff := now.Add(-l.window)
+	i := 0
+	for i < len(l.hits) && !l.hits[i].After(cutoff) {
+		i++
+	}
+	l.hits = l.hits[i:]
+
+	if len(l.hits) >= l.limit {
+		return false
+	}
+	l.hits = append(l.hits, now)
+	return true
+}
+
+// RecordChat 保留最近的聊天訊息
+func (r *Room) RecordChat(msg ChatMessagePayload) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.chat) == chatHistorySize {
+		r.chat = append(r.chat[:0], r.chat[1:]...)
+	}
+	r.chat = append(r.chat, msg)
+}
+
+func (r *Room) ChatHistory() []ChatMessagePayload {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.chat)
+}
+
+func (r *Room) SetMuted(playerID int64, muted bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if muted {
+		r.muted[playerID] = true
+	} else {
+		delete(r.muted, playerID)
+	}
+}
+
+func (r *Room) IsMuted(playerID int64) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.muted[playerID]
+}
+
+// handleCommand 處理 client 透過 WebSocket 送來的指令
+func (h *Handler) handleCommand(ctx context.Context, c *Client, msg WSMessage) {
+	if hostCommands[msg.Type] && !c.seated {
+		c.sendError(msg.Type, errSeatRequired)
+		return
+	}
+
+	var err error
+	switch msg.Type {
+	case CmdSendChat:
+		err = h.handleSendChat(ctx, c, msg.Data)
+	case CmdSendReaction:
+		err = h.handleSendReaction(ctx, c, msg.Data)
+	case CmdMutePlayer:
+		err = h.handleMutePlayer(ctx, c, msg.Data)
+	case CmdKickPlayer:
+		err = h.handleKickPlayer(ctx, c, msg.Data)
+	case CmdTransferHost:
+		err = h.handleTransferHost(ctx, c, msg.Data)
+	case CmdReorderSeats:
+		err = h.handleReorderSeats(ctx, c, msg.Data)
+	case CmdLockRoom:
+		err = h.handleLockRoom(ctx, c, msg.Data)
+	default:
+		err = errors.New("unknown command")
+	}
+
+	if err != nil {
+		c.logger.DebugContext(ctx, "websocket command rejected", "command", msg.Type, "error", err)
+		c.sendError(msg.Type, err)
+	}
+}
+
+func (h *Handler) handleSendChat(ctx context.Context, c *Client, data json.RawMessage) error {
+	var cmd SendChatCommand
+	if err := json.Unmarshal(data, &cmd); err != nil {
+		return errors.New("invalid payload")
+	}
+
+	text := strings.TrimSpace(cmd.Text)
+	if text == "" {
+		return errChatEmpty
+	}
+	if utf8.RuneCountInString(text) > maxChatLength {
+		return errChatTooLong
+	}
+	if c.room.IsMuted(c.ID) {
+		return errChatMuted
+	}
+	if !c.chatLimiter.Allow(time.Now()) {
+		return errChatRateLimited
+	}
+
+	if h.ChatFilter != nil {
+		filtered, err := h.ChatFilter(text)
+		if err != nil {
+			return err
+		}
+		text = filtered
+	}
+
+	payload := ChatMessagePayload{
+		PlayerID: c.ID,
+		Nickname: c.displayName(),
+		Text:     text,
+		SentAt:   time.Now().UTC(),
+	}
+	msg, err := NewWSMessage(MsgTypeChatMessage, payload)
+	if err != nil {
+		return err
+	}
+
+	c.room.RecordChat(payload)
+	c.room.Broadcast(ctx, msg)
+	return nil
+}
+
+func (h *Handler) handleSendReaction(ctx context.Context, c *Client, data json.RawMessage) error {
+	var cmd SendReactionCommand
+	if err := json.Unmarshal(data, &cmd); err != nil {
+		return errors.New("invalid payload")
+	}
+
+	if !slices.Contains(allowedReactions, cmd.Emoji) {
+		return errInvalidReaction
+	}
+	if c.room.IsMuted(c.ID) {
+		return errChatMuted
+	}
+	if !c.reactionLimiter.Allow(time.Now()) {
+		return errChatRateLimited
+	}
+
+	msg, err := NewWSMessage(MsgTypeReaction, ReactionPayload{
+		PlayerID: c.ID,
+		Nickname: c.displayName(),
+		Emoji:    cmd.Emoji,
+	})
+	if err != nil {
+		return err
+	}
+
+	c.room.Broadcast(ctx, msg)
+	return nil
+}
+
+func (h *Handler) handleMutePlayer(ctx context.Context, c *Client, data json.RawMessage) error {
+	var cmd MutePlayerCommand
+	if err := json.Unmarshal(data, &cmd); err != nil {
+		return errors.New("invalid payload")
+	}
+
+	// host 可能已經轉移，每次都重新查詢
+	host, err := h.PlayerService.FindPlayerByID(ctx, c.ID)
+	if err != nil {
+		c.logger.ErrorContext(ctx, "FindPlayerByID failed", "error", err)
+		return errors.New("failed to mute player")
+	}
+	if !host.IsHost {
+		return errors.New("only the host can mute players")
+	}
+
+	target, err := h.PlayerService.FindPlayerByID(ctx, cmd.PlayerID)
+	if err != nil {
+		if errors.Is(err, errx.ErrPlayerNotFound) {
+			return err
+		}
+		c.logger.ErrorContext(ctx, "FindPlayerByID failed", "error", err)
+		return errors.New("failed to mute player")
+	}
+	if target.GameID != host.GameID || target.ID == host.ID {
+		return errors.New("invalid player")
+	}
+
+	c.room.SetMuted(target.ID, cmd.Muted)
+
+	msg, err := NewWSMessage(MsgTypePlayerMuted, PlayerMutedPayload{
+		ID:       target.ID,
+		Nickname: target.Nickname,
+		Muted:    cmd.Muted,
+	})
+	if err != nil {
+		return err
+	}
+
+	c.room.Broadcast(ctx, msg)
+	return nil
+}
@@ -0,0 +1,31 @@
+package ws
+
+import "testing"
+
+func TestBlocklistFilter(t *testing.T) {
+	filter := NewBlocklistFilter([]string{"darn", " Heck ", "", "ÉCLAIR"})
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"nice answer", "nice answer"},
+		{"darn it", "**** it"},
+		{"DARN, what the HeCk", "****, what the ****"},
+		{"darndarn", "********"},
+		{"小丑 éclair 🃏", "小丑 ****** 🃏"},
+	}
+	for _, tt := range tests {
+		got, err := filter(tt.text)
+		if err != nil {
+			t.Errorf("filter(%q) error = %v", tt.text, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("filter(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+
+	if got, _ := NewBlocklistFilter([]string{""})("darn"); got != "darn" {
+		t.Errorf("empty blocklist changed %q to %q", "darn", got)
+	}
+}
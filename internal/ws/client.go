@@ -1,46 +1,176 @@
 package ws
 
 import (
-	"log"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/y3933y3933/joker/internal/utils/logx"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// client 送來的單一訊息大小上限
+const maxMessageSize = 4096
+
+// 直接送出 close frame 的等待上限，連線卡住時不能拖住呼叫端
+const closeWait = time.Second
+
+// closeFrame 是伺服器主動關閉連線時送出的 close frame
+type closeFrame struct {
+	code int
+	text string
+}
+
 type Client struct {
-	ID           int64           // 玩家 ID，供單播使用
-	conn         *websocket.Conn // WebSocket 實際連線
-	send         chan []byte     // 發送訊息用的 channel
-	room         *Room           // 所屬房間
-	OnDisconnect func(playerID int64)
+	ID              int64                           // 玩家 ID，供單播使用
+	conn            *websocket.Conn                 // WebSocket 實際連線
+	send            chan *websocket.PreparedMessage // 發送訊息用的 channel
+	room            *Room                           // 所屬房間
+	logger          *slog.Logger                    // 帶有升級請求的 request_id、game_code 與 player_id
+	connSpan        trace.SpanContext               // 升級請求的 span，指令與斷線的 trace 以 link 連回它
+	nickname        string                          // 聊天與表情顯示用
+	spectator       bool                            // 觀戰者只收公開事件，不收私訊
+	seated          bool                            // 以座位憑證連線，才能送出 host 指令
+	protocol        int                             // 協商後的協定版本
+	batch           bool                            // 同時發生的事件合併成一個 batch frame
+	closing         atomic.Pointer[closeFrame]      // 伺服器主動關閉（被踢出、遊戲過期），斷線時不走一般的離線流程
+	abortOnce       sync.Once                       // 連線只關閉一次
+	resume          bool                            // 是否為帶 since 的重連
+	lastSeq         uint64                          // 重連時已收到的最後一個事件序號
+	chatLimiter     *rateLimiter
+	reactionLimiter *rateLimiter
+	OnMessage       func(ctx context.Context, c *Client, msg WSMessage)
+	OnDisconnect    func(ctx context.Context, playerID int64)
 }
 
 func (c *Client) readPump() {
 	defer c.disconnect()
+	c.conn.SetReadLimit(maxMessageSize)
 	for {
-		_, _, err := c.conn.ReadMessage()
+		_, data, err := c.conn.ReadMessage()
 		if err != nil {
-			log.Printf("read error: %v", err)
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				c.logger.Warn("websocket read failed", "error", err)
+			} else {
+				c.logger.Debug("websocket closed", "error", err)
+			}
 			break
 		}
+
+		if c.OnMessage == nil {
+			continue
+		}
+
+		var msg WSMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			c.sendError("", errors.New("invalid message"))
+			continue
+		}
+		ctx, span := c.startSpan("ws " + msg.Type)
+		c.OnMessage(ctx, c, msg)
+		span.End()
 	}
 }
 
 func (c *Client) writePump() {
 	for msg := range c.send {
-		err := c.conn.WriteMessage(websocket.TextMessage, msg)
+		if msg == nil {
+			f := c.closing.Load()
+			_ = c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(f.code, f.text))
+			_ = c.conn.Close()
+			return
+		}
+		err := c.conn.WritePreparedMessage(msg)
 		if err != nil {
-			log.Printf("write error: %v", err)
+			c.logger.Warn("websocket write failed", "error", err)
 			break
 		}
 	}
 }
 
+// enqueue 不等待地把 frame 放進 send，回傳是否全部放入。
+// send 滿了表示 client 跟不上（或 writePump 已經結束），直接關閉連線，
+// 房間不會因為一個慢的 client 卡住；client 之後可以帶 since 重連補發
+func (c *Client) enqueue(frames ...*websocket.PreparedMessage) bool {
+	for _, f := range frames {
+		select {
+		case c.send <- f:
+		default:
+			c.logger.Warn("websocket client too slow, closing connection", "queued", len(c.send))
+			c.abort(closeFrame{code: websocket.CloseTryAgainLater, text: "too slow"})
+			return false
+		}
+	}
+	return true
+}
+
+// close 讓 writePump 送完已排隊的訊息後送出 f 並關閉連線，send 已滿時直接關閉。
+// 不會觸發一般的斷線流程
+func (c *Client) close(f closeFrame) {
+	c.closing.Store(&f)
+	select {
+	case c.send <- nil:
+	default:
+		c.abort(f)
+	}
+}
+
+// abort 不經過 send 直接送出 close frame 並關閉連線，readPump 隨後走斷線流程
+func (c *Client) abort(f closeFrame) {
+	c.abortOnce.Do(func() {
+		_ = c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(f.code, f.text), time.Now().Add(closeWait))
+		_ = c.conn.Close()
+	})
+}
+
 func (c *Client) disconnect() {
-	c.room.leave <- c
+	select {
+	case c.room.leave <- c:
+	case <-c.room.done:
+	}
 
 	_ = c.conn.Close()
-	if c.OnDisconnect != nil {
-		c.OnDisconnect(c.ID)
+	if c.OnDisconnect != nil && c.closing.Load() == nil {
+		ctx, span := c.startSpan("ws disconnect")
+		defer span.End()
+		c.OnDisconnect(ctx, c.ID)
 	}
 
 }
+
+// context 回傳帶有這條連線 logger 的 context，WebSocket 指令以它呼叫 service
+func (c *Client) context() context.Context {
+	return logx.WithLogger(context.Background(), c.logger)
+}
+
+// startSpan 為 WebSocket 指令或斷線開始新的 trace，一條連線的所有事件不會擠在同一個 trace 裡
+func (c *Client) startSpan(name string) (context.Context, trace.Span) {
+	return tracer.Start(c.context(), name,
+		trace.WithNewRoot(),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithLinks(trace.Link{SpanContext: c.connSpan}),
+		trace.WithAttributes(attribute.String("game_code", c.room.Code), attribute.Int64("player_id", c.ID)),
+	)
+}
+
+// sendError 私訊告知 client 指令被拒絕的原因
+func (c *Client) sendError(command string, err error) {
+	msg := MustWSMessage(MsgTypeError, ErrorPayload{
+		Command: command,
+		Message: err.Error(),
+	})
+	c.enqueue(prepare(msg.Encode()))
+}
+
+// displayName 目前的暱稱，玩家改名時由 Room.SetNickname 更新
+func (c *Client) displayName() string {
+	c.room.mu.RLock()
+	defer c.room.mu.RUnlock()
+	return c.nickname
+}
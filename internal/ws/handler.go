@@ -1,10 +1,10 @@
 package ws
 
 import (
+	"compress/flate"
 	"context"
 	"errors"
 	"fmt"
-	"log/slog"
 	"net/http"
 	"strconv"
 
@@ -14,9 +14,13 @@ import (
 	"github.com/y3933y3933/joker/internal/store"
 	"github.com/y3933y3933/joker/internal/utils/errx"
 	"github.com/y3933y3933/joker/internal/utils/httpx"
+	"github.com/y3933y3933/joker/internal/utils/logx"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var upgrader = websocket.Upgrader{
+	// 支援 permessage-deflate，由 client 決定是否啟用
+	EnableCompression: true,
 	CheckOrigin: func(r *http.Request) bool {
 		allowedOrigins := []string{
 			"https://joker.jienian.tw", // prod
@@ -37,57 +41,152 @@ var upgrader = websocket.Upgrader{
 // Handler struct 用來包裝 Hub 實例
 type Handler struct {
 	Hub           *Hub
-	Logger        *slog.Logger
 	PlayerService *service.PlayerService
 	GameService   *service.GameService
 	RoundService  *service.RoundService
+	HostService   *service.HostService
+	RoomAccess    *service.RoomAccess
+	ChatFilter    ChatFilter // 可選：聊天訊息過濾，nil 時不過濾
 }
 
 // NewHandler 用來建立新的 WebSocket handler
-func NewHandler(hub *Hub, logger *slog.Logger, playerService *service.PlayerService, gameService *service.GameService, roundService *service.RoundService) *Handler {
-	return &Handler{Hub: hub, Logger: logger, PlayerService: playerService, GameService: gameService, RoundService: roundService}
+func NewHandler(hub *Hub, playerService *service.PlayerService, gameService *service.GameService, roundService *service.RoundService, hostService *service.HostService, roomAccess *service.RoomAccess) *Handler {
+	return &Handler{Hub: hub, PlayerService: playerService, GameService: gameService, RoundService: roundService, HostService: hostService, RoomAccess: roomAccess}
 
 }
 
+// checkRoomAccess 遊戲必須尚未結束，玩家必須屬於這場遊戲；受保護的房間另外需要座位憑證（?seat）、
+// 密碼（?passcode）或邀請（?invite）其中之一
+func (h *Handler) checkRoomAccess(c *gin.Context, gameCode string, player *store.Player) bool {
+	game, err := h.GameService.GetUnfinishedGameByCode(c.Request.Context(), gameCode)
+	if err != nil {
+		httpx.Error(c, err)
+		return false
+	}
+	if player.GameID != game.ID {
+		httpx.Error(c, errx.ErrForbidden)
+		return false
+	}
+
+	err = h.RoomAccess.Check(game, player.ID, service.AccessCredentials{
+		Passcode: c.Query("passcode"),
+		Invite:   c.Query("invite"),
+		Seat:     c.Query("seat"),
+	})
+	if err != nil {
+		httpx.Error(c, err)
+		return false
+	}
+	return true
+}
+
 func (h *Handler) ServeWS(c *gin.Context) {
 	gameCode := c.Param("code")
 	playerIDStr := c.Query("player_id")
 	playerID, err := strconv.ParseInt(playerIDStr, 10, 64)
 	if err != nil {
-		httpx.ServerErrorResponse(c, h.Logger, errors.New("invalid player id"))
+		httpx.Error(c, errx.ErrInvalidPlayerID)
+		return
+	}
+
+	player, err := h.PlayerService.FindPlayerByID(c.Request.Context(), playerID)
+	if err != nil {
+		httpx.Error(c, err)
 		return
 	}
 
+	// 被 host 踢出的玩家不能重新連線
+	if player.Status == store.PlayerStatusKicked {
+		httpx.Error(c, errx.ErrPlayerBanned)
+		return
+	}
+
+	if !h.checkRoomAccess(c, gameCode, player) {
+		return
+	}
+	seatCode, seatPlayerID, seated := h.RoomAccess.VerifySeat(c.Query("seat"))
+	seated = seated && seatCode == gameCode && seatPlayerID == playerID
+
+	// 協商協定版本，未帶 ?protocol 視為目前版本
+	protocol := ProtocolVersion
+	if v, ok := c.GetQuery("protocol"); ok {
+		protocol, err = strconv.Atoi(v)
+		if err != nil || !SupportsProtocol(protocol) {
+			httpx.Error(c, fmt.Errorf("%w: unsupported protocol version, server supports %d-%d", errx.ErrInvalidInput, MinProtocolVersion, ProtocolVersion))
+			return
+		}
+	}
+
+	// 重連時帶 ?since=<seq>，補發錯過的事件
+	var since uint64
+	sinceStr, resume := c.GetQuery("since")
+	if resume {
+		since, err = strconv.ParseUint(sinceStr, 10, 64)
+		if err != nil {
+			httpx.Error(c, fmt.Errorf("%w: since", errx.ErrInvalidParam))
+			return
+		}
+	}
+
+	// 連線期間沿用升級請求的 logger，帶有 request_id、game_code 與 player_id
+	logger := logx.FromContext(c.Request.Context())
+
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
-		h.Logger.Error("ws upgrade error: ", err)
+		logger.WarnContext(c.Request.Context(), "websocket upgrade failed", "error", err)
 		return
 	}
-	fmt.Printf("ServeWS: playerID=%d (query=%s)\n", playerID, playerIDStr)
+	_ = conn.SetCompressionLevel(flate.BestSpeed)
+	logger.InfoContext(c.Request.Context(), "websocket connected", "protocol", protocol, "batch", c.Query("batch") == "1", "resume", resume, "since", since)
 
 	room := h.Hub.GetRoom(gameCode)
 	if room == nil {
 		room = h.Hub.CreateRoom(gameCode)
 	}
-	fmt.Printf("ServeWS: room=%p\n", room)
 
 	client := &Client{
-		ID:   playerID,
-		conn: conn,
-		send: make(chan []byte, 256),
-		room: room,
-		OnDisconnect: func(playerID int64) {
-			ctx := context.Background()
+		ID:              playerID,
+		conn:            conn,
+		send:            make(chan *websocket.PreparedMessage, 256),
+		room:            room,
+		logger:          logger,
+		connSpan:        trace.SpanContextFromContext(c.Request.Context()),
+		nickname:        player.Nickname,
+		spectator:       player.Role == store.PlayerRoleSpectator,
+		seated:          seated,
+		protocol:        protocol,
+		batch:           c.Query("batch") == "1",
+		resume:          resume,
+		lastSeq:         since,
+		chatLimiter:     newRateLimiter(chatRateLimit, chatRateWindow),
+		reactionLimiter: newRateLimiter(reactionRateLimit, reactionRateWindow),
+		OnMessage:       h.handleCommand,
+		OnDisconnect: func(ctx context.Context, playerID int64) {
 
 			player, err := h.PlayerService.FindPlayerByID(ctx, playerID)
 			if err != nil {
-				h.Logger.Error("FindByID failed", "error", err)
+				logger.ErrorContext(ctx, "FindByID failed", "error", err)
+				return
+			}
+
+			// 觀戰者離開不影響遊戲，直接移除
+			if player.Role == store.PlayerRoleSpectator {
+				left, err := h.PlayerService.RemoveSpectator(ctx, playerID)
+				if err != nil {
+					logger.ErrorContext(ctx, "RemoveSpectator failed", "error", err)
+					return
+				}
+				msg := MustWSMessage(MsgSpectatorLeft, SpectatorPayload{
+					ID:       left.ID,
+					Nickname: left.Nickname,
+				})
+				room.Broadcast(ctx, msg)
 				return
 			}
 
 			game, err := h.GameService.GetGameByCode(ctx, gameCode)
 			if err != nil {
-				h.Logger.Error("GetGameByCode failed", "error", err)
+				logger.ErrorContext(ctx, "GetGameByCode failed", "error", err)
 				return
 			}
 
@@ -95,80 +194,86 @@ func (h *Handler) ServeWS(c *gin.Context) {
 			case store.GameStatusWaiting:
 				left, newHost, err := h.PlayerService.LeaveGame(ctx, playerID)
 				if err != nil {
-					h.Logger.Error("LeaveGame failed", "error", err)
+					logger.ErrorContext(ctx, "LeaveGame failed", "error", err)
 					return
 				}
-				msg1, _ := NewWSMessage(MsgPlayerLeft, PlayerLeftPayload{
+				msg1 := MustWSMessage(MsgPlayerLeft, PlayerLeftPayload{
 					ID:       left.ID,
 					Nickname: left.Nickname,
 				})
-				room.Broadcast(msg1)
+				burst := []WSMessage{msg1}
 
 				// ✅ 如果有 host 轉移，廣播
 				if newHost != nil {
-					msg2, _ := NewWSMessage(MsgHostTransferred, HostTransferredPayload{
+					msg2 := MustWSMessage(MsgHostTransferred, HostTransferredPayload{
 						ID:       newHost.ID,
 						Nickname: newHost.Nickname,
 					})
-					room.Broadcast(msg2)
+					burst = append(burst, msg2)
 				}
+				room.Broadcast(ctx, burst...)
 			case store.GameStatusPlaying:
 				err := h.PlayerService.MarkPlayerDisconnected(ctx, playerID)
 				if err != nil {
-					h.Logger.Error("MarkPlayerDisconnected failed", "error", err)
+					logger.ErrorContext(ctx, "MarkPlayerDisconnected failed", "error", err)
 					return
 				}
 
-				msgOffline, _ := NewWSMessage(MsgTypePlayerOffline, PlayerOfflinePayload{
+				// 斷線、host 轉移、跳過回合通常一起發生，最後一次推播
+				var burst []WSMessage
+				defer func() { room.Broadcast(ctx, burst...) }()
+
+				msgOffline := MustWSMessage(MsgTypePlayerOffline, PlayerOfflinePayload{
 					ID:       playerID,
 					Nickname: player.Nickname,
 				})
-				room.Broadcast(msgOffline)
+				burst = append(burst, msgOffline)
 
 				if player.IsHost {
 					newHost, err := h.PlayerService.TransferHost(ctx, player)
 					if err != nil {
-						h.Logger.Error("FindOnlinePlayers failed", "error", err)
+						logger.ErrorContext(ctx, "FindOnlinePlayers failed", "error", err)
 						return
 					}
-					msg, _ := NewWSMessage(MsgHostTransferred, HostTransferredPayload{
+					msg := MustWSMessage(MsgHostTransferred, HostTransferredPayload{
 						ID:       newHost.ID,
 						Nickname: newHost.Nickname,
 					})
-					room.Broadcast(msg)
+					burst = append(burst, msg)
+				}
+
+				newRound, err := h.RoundService.SkipRoundIfInvolved(ctx, game, playerID)
+				if err != nil {
+					if errors.Is(err, errx.ErrNotEnoughPlayers) {
+						// 遊戲結束
+						_ = h.GameService.EndGame(ctx, game.Code, store.EndReasonNotEnoughPlayers)
+						msg := MustWSMessage(MsgTypeGameEnded, GameEndedPayload{GameCode: game.Code, Reason: store.EndReasonNotEnoughPlayers})
+						burst = append(burst, msg)
+						return
+					}
+					logger.ErrorContext(ctx, "SkipRoundIfInvolved failed", "error", err)
+					return
+				}
+
+				if newRound != nil {
+					burst = append(burst, RoundSkippedMessage(newRound, fmt.Sprintf("%s disconnect", player.Nickname)))
 				}
 
-				round, err := h.RoundService.FindLastRoundByGameID(ctx, game.ID)
+				// 離線的人不再計入投票人數，剩下的人可能已經投完
+				votes, err := h.RoundService.RecheckVotes(ctx, game)
 				if err != nil {
-					h.Logger.Error("FindLastRoundByGameID failed", "error", err)
+					logger.ErrorContext(ctx, "RecheckVotes failed", "error", err)
 					return
 				}
+				if votes != nil && votes.Resolved {
+					burst = append(burst, VoteMessages(0, votes)...)
 
-				isQuestion := round.Status == store.RoundStatusWaitingForQuestion && round.QuestionPlayerID == playerID
-				isAnswer := (round.Status == store.RoundStatusWaitingForAnswer || round.Status == store.RoundStatusWaitingForDraw) && round.AnswerPlayerID == playerID
-				if isQuestion || isAnswer {
-					newRound, err := h.RoundService.SkipRound(ctx, game, round.ID)
+					entries, err := h.RoundService.Leaderboard(ctx, game)
 					if err != nil {
-						if errors.Is(err, errx.ErrNotEnoughPlayers) {
-							// 遊戲結束
-							_ = h.GameService.EndGame(ctx, game.Code)
-							msg, _ := NewWSMessage(MsgTypeGameEnded, gin.H{"gameCode": game.Code})
-							room.Broadcast(msg)
-							return
-						}
-						h.Logger.Error("SkipRound failed", "error", err)
+						logger.ErrorContext(ctx, "Leaderboard failed", "error", err)
 						return
 					}
-
-					msg, _ := NewWSMessage(MsgTypeRoundSkipped, RoundSkippedPayload{
-						Reason: fmt.Sprintf("%s disconnect", player.Nickname),
-						RoundStartedPayload: RoundStartedPayload{
-							RoundID:          newRound.ID,
-							QuestionPlayerID: newRound.QuestionPlayerID,
-							AnswererID:       newRound.AnswerPlayerID,
-						},
-					})
-					room.Broadcast(msg)
+					burst = append(burst, LeaderboardMessage(entries))
 				}
 
 			}
@@ -176,8 +281,56 @@ func (h *Handler) ServeWS(c *gin.Context) {
 		},
 	}
 
-	room.join <- client
+	welcome := WelcomePayload{
+		ProtocolVersion: protocol,
+		PlayerID:        playerID,
+		Seq:             room.LastSeq(),
+		Batch:           client.batch,
+	}
+	if !resume {
+		welcome.Chat = room.ChatHistory()
+	}
+	client.send <- prepare(MustWSMessage(MsgTypeWelcome, welcome).Encode())
+
+	// 落差超過 replay buffer：先送完整狀態，再補發 snapshot 之後的事件
+	if resume && !room.CanReplay(since) {
+		snapshot, err := h.buildSnapshot(c.Request.Context(), gameCode, room.LastSeq())
+		if err != nil {
+			// 沒有完整狀態又無法補發漏掉的事件，繼續下去 client 的狀態會不一致
+			logger.ErrorContext(c.Request.Context(), "buildSnapshot failed", "error", err)
+			closeTryAgainLater(conn, "resync failed")
+			return
+		}
+		client.send <- prepare(snapshot.Encode())
+		client.lastSeq = snapshot.Seq
+	}
+
+	if !room.Join(client) {
+		// 房間剛好被關閉（遊戲過期），請 client 重新連線
+		closeTryAgainLater(conn, "room closed")
+		return
+	}
+
+	// 斷線後重新連線的玩家回到輪替中
+	if player.Status == store.PlayerStatusOffline {
+		err := h.PlayerService.MarkPlayerReconnected(c.Request.Context(), playerID)
+		if err != nil {
+			logger.ErrorContext(c.Request.Context(), "MarkPlayerReconnected failed", "error", err)
+		} else {
+			msg := MustWSMessage(MsgTypePlayerOnline, PlayerOfflinePayload{
+				ID:       playerID,
+				Nickname: player.Nickname,
+			})
+			room.Broadcast(c.Request.Context(), msg)
+		}
+	}
 
 	go client.writePump()
 	go client.readPump()
 }
+
+// closeTryAgainLater 在加入房間之前關閉連線，client 收到後稍後重新連線
+func closeTryAgainLater(conn *websocket.Conn, reason string) {
+	_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, reason))
+	_ = conn.Close()
+}
@@ -0,0 +1,186 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/y3933y3933/joker/internal/service"
+	"github.com/y3933y3933/joker/internal/store"
+	"github.com/y3933y3933/joker/internal/utils/errx"
+)
+
+// RoundSkippedMessage 建立跳過回合後的新回合訊息
+func RoundSkippedMessage(round *store.Round, reason string) WSMessage {
+	msg := MustWSMessage(MsgTypeRoundSkipped, RoundSkippedPayload{
+		Reason: reason,
+		RoundStartedPayload: RoundStartedPayload{
+			RoundID:          round.ID,
+			QuestionPlayerID: round.QuestionPlayerID,
+			AnswererID:       round.AnswerPlayerID,
+		},
+	})
+	return msg
+}
+
+// KickMessages 依踢人的結果產生要一起廣播的訊息
+func KickMessages(res *service.KickResult, gameCode string) []WSMessage {
+	kicked := MustWSMessage(MsgPlayerKicked, PlayerKickedPayload{
+		ID:       res.Player.ID,
+		Nickname: res.Player.Nickname,
+	})
+	msgs := []WSMessage{kicked}
+
+	switch {
+	case res.GameEnded:
+		msg := MustWSMessage(MsgTypeGameEnded, GameEndedPayload{GameCode: gameCode, Reason: store.EndReasonNotEnoughPlayers})
+		msgs = append(msgs, msg)
+	case res.SkippedRound != nil:
+		msgs = append(msgs, RoundSkippedMessage(res.SkippedRound, res.Player.Nickname+" kicked"))
+	}
+	if res.Votes != nil {
+		msgs = append(msgs, VoteMessages(0, res.Votes)...)
+		msgs = append(msgs, LeaderboardMessage(res.Leaderboard))
+	}
+	return msgs
+}
+
+// hostCommands 只能由以座位憑證（?seat）連線的 client 送出，只知道玩家 ID 不能冒充 host
+var hostCommands = map[string]bool{
+	CmdMutePlayer:   true,
+	CmdKickPlayer:   true,
+	CmdTransferHost: true,
+	CmdReorderSeats: true,
+	CmdLockRoom:     true,
+}
+
+var errSeatRequired = errors.New("reconnect with your seat token to use host commands")
+
+// hostCommandError 把 service 的錯誤轉成可以回給 client 的訊息
+func (h *Handler) hostCommandError(ctx context.Context, c *Client, err error) error {
+	switch {
+	case errors.Is(err, errx.ErrForbidden):
+		return errors.New("only the host can do this")
+	case errors.Is(err, errx.ErrPlayerNotFound),
+		errors.Is(err, errx.ErrInvalidGameStatus),
+		errors.Is(err, errx.ErrInvalidSeatOrder):
+		return err
+	default:
+		c.logger.ErrorContext(ctx, "host command failed", "error", err)
+		return errors.New("failed to run command")
+	}
+}
+
+func (h *Handler) handleKickPlayer(ctx context.Context, c *Client, data json.RawMessage) error {
+	var cmd KickPlayerCommand
+	if err := json.Unmarshal(data, &cmd); err != nil {
+		return errors.New("invalid payload")
+	}
+
+	game, err := h.GameService.GetGameByCode(ctx, c.room.Code)
+	if err != nil {
+		return h.hostCommandError(ctx, c, err)
+	}
+
+	res, err := h.HostService.KickPlayer(ctx, game, c.ID, cmd.PlayerID)
+	if err != nil {
+		return h.hostCommandError(ctx, c, err)
+	}
+
+	c.room.Broadcast(ctx, KickMessages(res, game.Code)...)
+	c.room.Kick(res.Player.ID)
+	return nil
+}
+
+func (h *Handler) handleTransferHost(ctx context.Context, c *Client, data json.RawMessage) error {
+	var cmd TransferHostCommand
+	if err := json.Unmarshal(data, &cmd); err != nil {
+		return errors.New("invalid payload")
+	}
+
+	game, err := h.GameService.GetGameByCode(ctx, c.room.Code)
+	if err != nil {
+		return h.hostCommandError(ctx, c, err)
+	}
+
+	newHost, err := h.HostService.TransferHost(ctx, game, c.ID, cmd.PlayerID)
+	if err != nil {
+		return h.hostCommandError(ctx, c, err)
+	}
+
+	msg := MustWSMessage(MsgHostTransferred, HostTransferredPayload{
+		ID:       newHost.ID,
+		Nickname: newHost.Nickname,
+	})
+	c.room.Broadcast(ctx, msg)
+	return nil
+}
+
+func (h *Handler) handleReorderSeats(ctx context.Context, c *Client, data json.RawMessage) error {
+	var cmd ReorderSeatsCommand
+	if err := json.Unmarshal(data, &cmd); err != nil {
+		return errors.New("invalid payload")
+	}
+
+	game, err := h.GameService.GetGameByCode(ctx, c.room.Code)
+	if err != nil {
+		return h.hostCommandError(ctx, c, err)
+	}
+
+	err = h.HostService.ReorderSeats(ctx, game, c.ID, cmd.PlayerIDs)
+	if err != nil {
+		return h.hostCommandError(ctx, c, err)
+	}
+
+	msg := MustWSMessage(MsgSeatsReordered, SeatsReorderedPayload{PlayerIDs: cmd.PlayerIDs})
+	c.room.Broadcast(ctx, msg)
+	return nil
+}
+
+func (h *Handler) handleLockRoom(ctx context.Context, c *Client, data json.RawMessage) error {
+	var cmd LockRoomCommand
+	if err := json.Unmarshal(data, &cmd); err != nil {
+		return errors.New("invalid payload")
+	}
+
+	game, err := h.GameService.GetGameByCode(ctx, c.room.Code)
+	if err != nil {
+		return h.hostCommandError(ctx, c, err)
+	}
+
+	err = h.HostService.SetLocked(ctx, game, c.ID, cmd.Locked)
+	if err != nil {
+		return h.hostCommandError(ctx, c, err)
+	}
+
+	msg := MustWSMessage(MsgRoomLocked, RoomLockedPayload{Locked: cmd.Locked})
+	c.room.Broadcast(ctx, msg)
+	return nil
+}
+
+// RematchSeatMessage 私訊玩家在再來一局中的座位憑證
+func RematchSeatMessage(gameCode string, playerID int64, seat string) WSMessage {
+	return MustWSMessage(MsgTypeRematchSeat, RematchSeatPayload{
+		GameCode:  gameCode,
+		PlayerID:  playerID,
+		SeatToken: seat,
+	})
+}
+
+// RematchMessage 通知舊房間的玩家移到再來一局的新遊戲
+func RematchMessage(res *service.RematchResult) WSMessage {
+	players := make([]RematchPlayerPayload, len(res.Players))
+	for i, p := range res.Players {
+		players[i] = RematchPlayerPayload{
+			PreviousID: p.PreviousID,
+			PlayerID:   p.Player.ID,
+			Nickname:   p.Player.Nickname,
+			IsHost:     p.Player.IsHost,
+		}
+	}
+	msg := MustWSMessage(MsgTypeRematchCreated, RematchCreatedPayload{
+		GameCode: res.Game.Code,
+		Players:  players,
+	})
+	return msg
+}
@@ -1,15 +1,23 @@
 package ws
 
-import "sync"
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
 
 type Hub struct {
-	mu    sync.RWMutex
-	rooms map[string]*Room
+	mu     sync.RWMutex
+	rooms  map[string]*Room
+	logger *slog.Logger
 }
 
-func NewHub() *Hub {
+func NewHub(logger *slog.Logger) *Hub {
 	return &Hub{
-		rooms: make(map[string]*Room),
+		rooms:  make(map[string]*Room),
+		logger: logger,
 	}
 }
 
@@ -23,7 +31,7 @@ func (h *Hub) CreateRoom(code string) *Room {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	room := NewRoom(code)
+	room := NewRoom(code, h.logger.With("game_code", code))
 	h.rooms[code] = room
 
 	go room.Run()
@@ -36,3 +44,70 @@ func (h *Hub) DeleteRoom(code string) {
 	defer h.mu.Unlock()
 	delete(h.rooms, code)
 }
+
+// CloseRoom 通知房間內的人遊戲已結束，關閉所有連線並移除房間
+func (h *Hub) CloseRoom(ctx context.Context, code string, reason string) {
+	h.mu.Lock()
+	room := h.rooms[code]
+	delete(h.rooms, code)
+	h.mu.Unlock()
+
+	if room == nil {
+		return
+	}
+	msg := MustWSMessage(MsgTypeGameEnded, GameEndedPayload{GameCode: code, Reason: reason})
+	room.Broadcast(ctx, msg)
+	room.Close("game ended: " + reason)
+}
+
+// Shutdown 在伺服器關機時關閉所有房間，以 1001 (going away) 關閉連線讓前端自動重連到新的程序，
+// 遊戲狀態都在資料庫裡，不會通知遊戲結束。等到所有房間結束或 ctx 逾時
+func (h *Hub) Shutdown(ctx context.Context, reason string) error {
+	h.mu.Lock()
+	rooms := h.rooms
+	h.rooms = make(map[string]*Room)
+	h.mu.Unlock()
+
+	for _, room := range rooms {
+		go room.closeWith(closeFrame{code: websocket.CloseGoingAway, text: reason})
+	}
+	for _, room := range rooms {
+		select {
+		case <-room.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// HubStats 是某一刻的連線統計，給 metrics 在 scrape 時讀取
+type HubStats struct {
+	Rooms        int // 目前的房間數
+	Connections  int // 目前的 WebSocket 連線數
+	QueuedFrames int // 所有連線尚未送出的訊息總數
+	MaxQueue     int // 單一連線最多排隊的訊息數
+}
+
+// Stats 走訪所有房間計算連線統計，只持有讀鎖
+func (h *Hub) Stats() HubStats {
+	h.mu.RLock()
+	rooms := make([]*Room, 0, len(h.rooms))
+	for _, room := range h.rooms {
+		rooms = append(rooms, room)
+	}
+	h.mu.RUnlock()
+
+	stats := HubStats{Rooms: len(rooms)}
+	for _, room := range rooms {
+		room.mu.RLock()
+		for client := range room.clients {
+			stats.Connections++
+			queued := len(client.send)
+			stats.QueuedFrames += queued
+			stats.MaxQueue = max(stats.MaxQueue, queued)
+		}
+		room.mu.RUnlock()
+	}
+	return stats
+}
@@ -1,32 +1,84 @@
 package ws
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/y3933y3933/joker/internal/service"
+	"github.com/y3933y3933/joker/internal/store"
+)
 
 type WSMessage struct {
 	Type string          `json:"type"`
+	Seq  uint64          `json:"seq,omitempty"` // 房間廣播事件的遞增序號，私訊不帶序號
 	Data json.RawMessage `json:"data"`
 }
 
 const (
-	MsgTypePlayerJoined    = "player_joined"
-	MsgTypeGameStarted     = "game_started"
-	MsgTypeRoundQuestion   = "round_question"
-	MsgTypeAnswerTime      = "answer_time"
-	MsgTypeAnswerSubmitted = "answer_submitted"
-	MsgTypeJokerRevealed   = "joker_revealed"
-	MsgTypePlayerSafe      = "player_safe"
-	MsgTypeGameEnded       = "game_ended"
-	MsgNextRoundStarted    = "next_round_started"
-	MsgPlayerLeft          = "player_left"
-	MsgHostTransferred     = "host_transferred"
-	MsgTypeRoundSkipped    = "round_skipped"
-	MsgTypePlayerOffline   = "player_disconnected"
+	MsgTypePlayerJoined     = "player_joined"
+	MsgTypeGameStarted      = "game_started"
+	MsgTypeRoundQuestion    = "round_question"
+	MsgTypeAnswerTime       = "answer_time"
+	MsgTypeAnswerSubmitted  = "answer_submitted"
+	MsgTypeJokerRevealed    = "joker_revealed"
+	MsgTypePlayerSafe       = "player_safe"
+	MsgTypeGameEnded        = "game_ended"
+	MsgNextRoundStarted     = "next_round_started"
+	MsgPlayerLeft           = "player_left"
+	MsgHostTransferred      = "host_transferred"
+	MsgTypeRoundSkipped     = "round_skipped"
+	MsgTypePlayerOffline    = "player_disconnected"
+	MsgTypePlayerOnline     = "player_reconnected"
+	MsgTypeStateSnapshot    = "state_snapshot"
+	MsgSpectatorJoined      = "spectator_joined"
+	MsgSpectatorLeft        = "spectator_left"
+	MsgSpectatorPromoted    = "spectator_promoted"
+	MsgTypeWelcome          = "welcome"
+	MsgTypeChatMessage      = "chat_message"
+	MsgTypeReaction         = "reaction"
+	MsgTypePlayerMuted      = "player_muted"
+	MsgTypeError            = "error"
+	MsgTypeBatch            = "batch"
+	MsgPlayerKicked         = "player_kicked"
+	MsgSeatsReordered       = "seats_reordered"
+	MsgRoomLocked           = "room_locked"
+	MsgRoomAccessChanged    = "room_access_changed"
+	MsgTypeVoteCast         = "vote_cast"
+	MsgTypeGuessResults     = "guess_results"
+	MsgTypeRevealVoteResult = "reveal_vote_result"
+	MsgTypeLeaderboard      = "leaderboard"
+	MsgTypeRematchCreated   = "rematch_created"
+	MsgTypeRematchSeat      = "rematch_seat"
+	MsgTypePlayerUpdated    = "player_updated"
+)
+
+// client 送給伺服器的指令
+const (
+	CmdSendChat     = "send_chat"
+	CmdSendReaction = "send_reaction"
+	CmdMutePlayer   = "mute_player"
+	CmdKickPlayer   = "kick_player"
+	CmdTransferHost = "transfer_host"
+	CmdReorderSeats = "reorder_seats"
+	CmdLockRoom     = "lock_room"
 )
 
 type PlayerJoinedPayload struct {
-	ID       int64  `json:"id"`
-	Nickname string `json:"nickname"`
-	IsHost   bool   `json:"isHost"`
+	ID       int64        `json:"id"`
+	Nickname string       `json:"nickname"`
+	IsHost   bool         `json:"isHost"`
+	Avatar   store.Avatar `json:"avatar"`
+}
+
+type PlayerUpdatedPayload struct {
+	ID       int64        `json:"id"`
+	Nickname string       `json:"nickname"`
+	Avatar   store.Avatar `json:"avatar"`
 }
 
 type RoundStartedPayload struct {
@@ -40,6 +92,11 @@ type JokerRevealedPayload struct {
 	Content string `json:"content"`
 }
 
+type SpectatorPayload struct {
+	ID       int64  `json:"id"`
+	Nickname string `json:"nickname"`
+}
+
 type PlayerLeftPayload struct {
 	ID       int64  `json:"id"`
 	Nickname string `json:"nickname"`
@@ -59,8 +116,154 @@ type RoundSkippedPayload struct {
 	RoundStartedPayload
 }
 
-// NewWSMessage creates a new WSMessage from any data struct or map.
+type RoundQuestionPayload struct {
+	Level   string `json:"level"`
+	Content string `json:"content"`
+}
+
+type AnswerTimePayload struct{}
+
+type PlayerSafePayload struct{}
+
+type GameEndedPayload struct {
+	GameCode string `json:"gameCode"`
+	Reason   string `json:"reason,omitempty"` // finished、not_enough_players、idle、admin
+}
+
+// WelcomePayload 是連線建立後第一個送出的訊息，告知協商後的協定版本
+type WelcomePayload struct {
+	ProtocolVersion int                  `json:"protocolVersion"`
+	PlayerID        int64                `json:"playerID"`
+	Seq             uint64               `json:"seq"`
+	Batch           bool                 `json:"batch"`
+	Chat            []ChatMessagePayload `json:"chat,omitempty"` // 最近的聊天訊息，只在首次連線時附上
+}
+
+type ChatMessagePayload struct {
+	PlayerID int64     `json:"playerID"`
+	Nickname string    `json:"nickname"`
+	Text     string    `json:"text"`
+	SentAt   time.Time `json:"sentAt"`
+}
+
+type ReactionPayload struct {
+	PlayerID int64  `json:"playerID"`
+	Nickname string `json:"nickname"`
+	Emoji    string `json:"emoji"`
+}
+
+type PlayerMutedPayload struct {
+	ID       int64  `json:"id"`
+	Nickname string `json:"nickname"`
+	Muted    bool   `json:"muted"`
+}
+
+// ErrorPayload 私訊給送出無效指令的 client
+type ErrorPayload struct {
+	Command string `json:"command"`
+	Message string `json:"message"`
+}
+
+type SendChatCommand struct {
+	Text string `json:"text"`
+}
+
+type SendReactionCommand struct {
+	Emoji string `json:"emoji"`
+}
+
+type MutePlayerCommand struct {
+	PlayerID int64 `json:"playerID"`
+	Muted    bool  `json:"muted"`
+}
+
+type PlayerKickedPayload struct {
+	ID       int64  `json:"id"`
+	Nickname string `json:"nickname"`
+}
+
+type SeatsReorderedPayload struct {
+	PlayerIDs []int64 `json:"playerIDs"`
+}
+
+type RoomLockedPayload struct {
+	Locked bool `json:"locked"`
+}
+
+type RoomAccessPayload struct {
+	Access string `json:"access"`
+}
+
+type VoteCastPayload struct {
+	PlayerID int64 `json:"playerID"`
+	Votes    int   `json:"votes"`
+	Needed   int   `json:"needed"`
+}
+
+type RevealVoteResultPayload struct {
+	Revealed    bool `json:"revealed"`
+	RevealVotes int  `json:"revealVotes"`
+	KeepVotes   int  `json:"keepVotes"`
+}
+
+type GuessResultsPayload struct {
+	Kind          string  `json:"kind"`
+	AboutPlayerID *int64  `json:"aboutPlayerID,omitempty"`
+	Truthful      *bool   `json:"truthful,omitempty"`
+	Correct       []int64 `json:"correct"`
+	RoundStatus   string  `json:"roundStatus"`
+}
+
+type LeaderboardPayload struct {
+	Entries []service.LeaderboardEntry `json:"entries"` // 依名次排序
+}
+
+type RematchCreatedPayload struct {
+	GameCode string                 `json:"gameCode"`
+	Players  []RematchPlayerPayload `json:"players"` // 每位玩家用自己的新 ID 連到新遊戲
+}
+
+type RematchPlayerPayload struct {
+	PreviousID int64  `json:"previousID"`
+	PlayerID   int64  `json:"playerID"`
+	Nickname   string `json:"nickname"`
+	IsHost     bool   `json:"isHost"`
+}
+
+// RematchSeatPayload 只送給單一玩家，座位憑證不能讓其他人看到
+type RematchSeatPayload struct {
+	GameCode  string `json:"gameCode"`
+	PlayerID  int64  `json:"playerID"`
+	SeatToken string `json:"seatToken"`
+}
+
+type KickPlayerCommand struct {
+	PlayerID int64 `json:"playerID"`
+}
+
+type TransferHostCommand struct {
+	PlayerID int64 `json:"playerID"`
+}
+
+type ReorderSeatsCommand struct {
+	PlayerIDs []int64 `json:"playerIDs"`
+}
+
+type LockRoomCommand struct {
+	Locked bool `json:"locked"`
+}
+
+// NewWSMessage creates a new WSMessage, checking data against the payload
+// type registered for msgType in the event catalog.
 func NewWSMessage(msgType string, data any) (WSMessage, error) {
+	spec, ok := LookupEvent(msgType)
+	if !ok {
+		return WSMessage{}, fmt.Errorf("ws: unknown message type %q", msgType)
+	}
+	if reflect.TypeOf(data) != spec.Payload {
+		return WSMessage{}, fmt.Errorf("ws: %s expects %s payload, got %T", msgType, spec.Payload, data)
+	}
+
 	b, err := json.Marshal(data)
 	if err != nil {
 		return WSMessage{}, err
@@ -72,7 +275,72 @@ func NewWSMessage(msgType string, data any) (WSMessage, error) {
 	}, nil
 }
 
+// MustWSMessage 和 NewWSMessage 相同，但 payload 與 catalog 不符時直接 panic。
+// 供型別在編譯時就已固定的呼叫端使用，這類錯誤只可能是程式寫錯
+func MustWSMessage(msgType string, data any) WSMessage {
+	msg, err := NewWSMessage(msgType, data)
+	if err != nil {
+		panic(err)
+	}
+	return msg
+}
+
+// Encode 組出訊息的 JSON。Data 在 NewWSMessage 時已經編碼過，
+// 這裡直接拼接，避免對整個 envelope 再做一次 json.Marshal
+func (m WSMessage) Encode() []byte {
+	data := m.Data
+	if data == nil {
+		data = json.RawMessage("null")
+	}
+
+	buf := make([]byte, 0, len(m.Type)+len(data)+40)
+	buf = append(buf, `{"type":`...)
+	buf = strconv.AppendQuote(buf, m.Type)
+	if m.Seq > 0 {
+		buf = append(buf, `,"seq":`...)
+		buf = strconv.AppendUint(buf, m.Seq, 10)
+	}
+	buf = append(buf, `,"data":`...)
+	buf = append(buf, data...)
+	buf = append(buf, '}')
+	return buf
+}
+
+// encodeBatch 把多個已編碼的訊息包成一個 batch 訊息
+func encodeBatch(encoded [][]byte) []byte {
+	size := 32
+	for _, e := range encoded {
+		size += len(e) + 1
+	}
+
+	buf := make([]byte, 0, size)
+	buf = append(buf, `{"type":"`+MsgTypeBatch+`","data":[`...)
+	for i, e := range encoded {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, e...)
+	}
+	buf = append(buf, "]}"...)
+	return buf
+}
+
+func prepare(data []byte) *websocket.PreparedMessage {
+	// 只有在 message type 無效時才會出錯，TextMessage 不會發生
+	pm, _ := websocket.NewPreparedMessage(websocket.TextMessage, data)
+	return pm
+}
+
 type PlayerOfflinePayload struct {
 	ID       int64  `json:"id"`
 	Nickname string `json:"nickname"`
 }
+
+// StateSnapshotPayload 是重連時落差過大、無法補發事件時送出的完整狀態
+type StateSnapshotPayload struct {
+	Game       *store.Game          `json:"game"`
+	Players    []*store.Player      `json:"players"`
+	Spectators []*store.Player      `json:"spectators"`
+	Round      *store.Round         `json:"round"`
+	Chat       []ChatMessagePayload `json:"chat"`
+}
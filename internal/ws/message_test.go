@@ -0,0 +1,59 @@
+package ws
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// catalog 裡的每個事件都要能用登記的 payload 建立訊息，編碼後再解回相同的值
+func TestEventCatalogRoundTrip(t *testing.T) {
+	for _, spec := range Events() {
+		payload := reflect.New(spec.Payload).Elem().Interface()
+		msg := MustWSMessage(spec.Type, payload)
+		msg.Seq = 7
+
+		var decoded WSMessage
+		if err := json.Unmarshal(msg.Encode(), &decoded); err != nil {
+			t.Errorf("%s: decode envelope: %v", spec.Type, err)
+			continue
+		}
+		if decoded.Type != spec.Type || decoded.Seq != msg.Seq {
+			t.Errorf("%s: envelope = %s seq %d, want %s seq %d", spec.Type, decoded.Type, decoded.Seq, spec.Type, msg.Seq)
+		}
+
+		data := reflect.New(spec.Payload)
+		if err := json.Unmarshal(decoded.Data, data.Interface()); err != nil {
+			t.Errorf("%s: decode %s: %v", spec.Type, spec.Payload, err)
+			continue
+		}
+		if got := data.Elem().Interface(); !reflect.DeepEqual(got, payload) {
+			t.Errorf("%s: round trip = %+v, want %+v", spec.Type, got, payload)
+		}
+	}
+}
+
+func TestMustWSMessagePanicsOnCatalogMismatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		msgType string
+		data    any
+	}{
+		{"wrong payload", MsgTypeGameEnded, PlayerSafePayload{}},
+		{"pointer payload", MsgTypeGameEnded, &GameEndedPayload{}},
+		{"unknown type", "no_such_event", PlayerSafePayload{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewWSMessage(tt.msgType, tt.data); err == nil {
+				t.Fatalf("NewWSMessage(%s, %T) error = nil", tt.msgType, tt.data)
+			}
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("MustWSMessage(%s, %T) did not panic", tt.msgType, tt.data)
+				}
+			}()
+			MustWSMessage(tt.msgType, tt.data)
+		})
+	}
+}
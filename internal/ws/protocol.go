@@ -0,0 +1,95 @@
+package ws
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+)
+
+// 協定版本：事件被移除或 payload 有不相容的變更時遞增 ProtocolVersion，
+// 並視情況調整仍支援的最低版本
+const (
+	ProtocolVersion    = 1
+	MinProtocolVersion = 1
+)
+
+// EventSpec 描述一種伺服器推送的事件與其 payload 型別
+type EventSpec struct {
+	Type        string
+	Payload     reflect.Type
+	Private     bool // 只送給單一 client，不帶序號也不進 replay buffer
+	Description string
+}
+
+var catalog = []EventSpec{
+	{Type: MsgTypeWelcome, Payload: reflect.TypeFor[WelcomePayload](), Private: true, Description: "Sent once after the connection is established. A fresh connection also gets the recent chat history; a resumed one gets chat through replayed events or the snapshot."},
+	{Type: MsgTypeStateSnapshot, Payload: reflect.TypeFor[StateSnapshotPayload](), Private: true, Description: "Full game state, sent on reconnect when missed events can no longer be replayed."},
+	{Type: MsgTypePlayerJoined, Payload: reflect.TypeFor[PlayerJoinedPayload](), Description: "A player joined the game."},
+	{Type: MsgPlayerLeft, Payload: reflect.TypeFor[PlayerLeftPayload](), Description: "A player left a game that has not started."},
+	{Type: MsgHostTransferred, Payload: reflect.TypeFor[HostTransferredPayload](), Description: "Another player became the host."},
+	{Type: MsgTypePlayerOffline, Payload: reflect.TypeFor[PlayerOfflinePayload](), Description: "A player disconnected during the game."},
+	{Type: MsgTypePlayerOnline, Payload: reflect.TypeFor[PlayerOfflinePayload](), Description: "A disconnected player came back and rejoined the turn rotation at their seat."},
+	{Type: MsgSpectatorJoined, Payload: reflect.TypeFor[SpectatorPayload](), Description: "A spectator started watching."},
+	{Type: MsgSpectatorLeft, Payload: reflect.TypeFor[SpectatorPayload](), Description: "A spectator stopped watching."},
+	{Type: MsgSpectatorPromoted, Payload: reflect.TypeFor[PlayerJoinedPayload](), Description: "The host moved a spectator into the game."},
+	{Type: MsgTypeGameStarted, Payload: reflect.TypeFor[RoundStartedPayload](), Description: "The game started with its first round."},
+	{Type: MsgNextRoundStarted, Payload: reflect.TypeFor[RoundStartedPayload](), Description: "A new round started."},
+	{Type: MsgTypeRoundSkipped, Payload: reflect.TypeFor[RoundSkippedPayload](), Description: "The current round was skipped and a new one started."},
+	{Type: MsgTypeRoundQuestion, Payload: reflect.TypeFor[RoundQuestionPayload](), Private: true, Description: "The question, sent only to the answerer."},
+	{Type: MsgTypeAnswerTime, Payload: reflect.TypeFor[AnswerTimePayload](), Description: "The question was picked and the answerer is answering."},
+	{Type: MsgTypeAnswerSubmitted, Payload: reflect.TypeFor[AnswerSubmittedPayload](), Description: "The answerer submitted an answer."},
+	{Type: MsgTypeJokerRevealed, Payload: reflect.TypeFor[JokerRevealedPayload](), Description: "The answerer drew the joker and the question is revealed."},
+	{Type: MsgTypePlayerSafe, Payload: reflect.TypeFor[PlayerSafePayload](), Description: "The answerer drew a safe card."},
+	{Type: MsgTypeGameEnded, Payload: reflect.TypeFor[GameEndedPayload](), Description: "The game ended."},
+	{Type: MsgTypeChatMessage, Payload: reflect.TypeFor[ChatMessagePayload](), Description: "A chat message from a player or spectator."},
+	{Type: MsgTypeReaction, Payload: reflect.TypeFor[ReactionPayload](), Description: "A quick emoji reaction."},
+	{Type: MsgTypePlayerMuted, Payload: reflect.TypeFor[PlayerMutedPayload](), Description: "The host muted or unmuted a player in chat."},
+	{Type: MsgTypeError, Payload: reflect.TypeFor[ErrorPayload](), Private: true, Description: "A command sent by this client was rejected."},
+	{Type: MsgPlayerKicked, Payload: reflect.TypeFor[PlayerKickedPayload](), Description: "The host removed a player from the game."},
+	{Type: MsgSeatsReordered, Payload: reflect.TypeFor[SeatsReorderedPayload](), Description: "The host changed the seating order used for turn rotation."},
+	{Type: MsgRoomLocked, Payload: reflect.TypeFor[RoomLockedPayload](), Description: "The host locked or unlocked the room to new joins."},
+	{Type: MsgRoomAccessChanged, Payload: reflect.TypeFor[RoomAccessPayload](), Description: "The host made the room public, passcode-protected or invite-only. Players already in the room are not affected."},
+	{Type: MsgTypeVoteCast, Payload: reflect.TypeFor[VoteCastPayload](), Description: "A player submitted their vote during waiting_for_votes; the choices stay secret until everyone has voted."},
+	{Type: MsgTypeGuessResults, Payload: reflect.TypeFor[GuessResultsPayload](), Description: "Everyone guessed: the answerer's secret, who guessed right, and the round's next status."},
+	{Type: MsgTypeRevealVoteResult, Payload: reflect.TypeFor[RevealVoteResultPayload](), Description: "vote_reveal mode: everyone voted. Followed by joker_revealed or player_safe."},
+	{Type: MsgTypeLeaderboard, Payload: reflect.TypeFor[LeaderboardPayload](), Description: "Current scores after a round ends, sorted by rank. Tied players share a rank."},
+	{Type: MsgTypeRematchCreated, Payload: reflect.TypeFor[RematchCreatedPayload](), Description: "Someone started a rematch of this ended game. Each client reconnects to gameCode using the playerID mapped from its previous ID."},
+	{Type: MsgTypeRematchSeat, Payload: reflect.TypeFor[RematchSeatPayload](), Private: true, Description: "Sent with rematch_created: this client's seat token for the new game, needed to reconnect when the room is passcode-protected or invite-only."},
+	{Type: MsgTypePlayerUpdated, Payload: reflect.TypeFor[PlayerUpdatedPayload](), Description: "A player changed their nickname or avatar before the game started."},
+	{Type: MsgTypeBatch, Payload: reflect.TypeFor[[]json.RawMessage](), Description: "Several events sent in one frame, in order. Only sent to clients that connected with ?batch=1."},
+}
+
+// commandCatalog 列出 client 可以送給伺服器的指令
+var commandCatalog = []EventSpec{
+	{Type: CmdSendChat, Payload: reflect.TypeFor[SendChatCommand](), Description: "Send a chat message to the room."},
+	{Type: CmdSendReaction, Payload: reflect.TypeFor[SendReactionCommand](), Description: "Send an emoji reaction to the room."},
+	{Type: CmdMutePlayer, Payload: reflect.TypeFor[MutePlayerCommand](), Description: "Host only: mute or unmute a player in chat."},
+	{Type: CmdKickPlayer, Payload: reflect.TypeFor[KickPlayerCommand](), Description: "Host only: remove a player and ban the nickname for this game."},
+	{Type: CmdTransferHost, Payload: reflect.TypeFor[TransferHostCommand](), Description: "Host only: hand the host role to another online player."},
+	{Type: CmdReorderSeats, Payload: reflect.TypeFor[ReorderSeatsCommand](), Description: "Host only: set the seating order, listing every player once."},
+	{Type: CmdLockRoom, Payload: reflect.TypeFor[LockRoomCommand](), Description: "Host only: lock or unlock the room to new joins."},
+}
+
+// Events 回傳協定中所有事件的定義
+func Events() []EventSpec {
+	return slices.Clone(catalog)
+}
+
+// Commands 回傳 client 可送出的所有指令定義
+func Commands() []EventSpec {
+	return slices.Clone(commandCatalog)
+}
+
+func LookupEvent(msgType string) (EventSpec, bool) {
+	for _, spec := range catalog {
+		if spec.Type == msgType {
+			return spec, true
+		}
+	}
+	return EventSpec{}, false
+}
+
+// SupportsProtocol 回報伺服器是否能以 version 與 client 溝通
+func SupportsProtocol(version int) bool {
+	return version >= MinProtocolVersion && version <= ProtocolVersion
+}
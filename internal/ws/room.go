@@ -1,81 +1,285 @@
 package ws
 
 import (
-	"encoding/json"
-	"fmt"
+	"context"
+	"log/slog"
 	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/y3933y3933/joker/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// 每個房間保留的最近廣播事件數量，超過後需改用 snapshot 重新同步
+const replayBufferSize = 128
+
+var tracer = tracing.NewTracer("github.com/y3933y3933/joker/internal/ws")
+
+// broadcastRequest 帶著呼叫端的 context，讓 Run 中的推播成為同一個 trace 的 span
+type broadcastRequest struct {
+	ctx  context.Context
+	msgs []WSMessage
+}
+
+type sequencedMessage struct {
+	seq   uint64
+	frame *websocket.PreparedMessage
+}
+
 type Room struct {
 	Code        string
 	clients     map[*Client]bool
 	clientsByID map[int64]*Client
 	join        chan *Client
 	leave       chan *Client
-	broadcast   chan []byte
+	kick        chan int64
+	broadcast   chan broadcastRequest
+	close       chan closeFrame
+	done        chan struct{}      // 房間關閉後 close
+	seq         uint64             // 最後一個廣播事件的序號
+	history     []sequencedMessage // 最近的廣播事件，供斷線重連補發
+	chat        []ChatMessagePayload
+	muted       map[int64]bool // 被 host 禁言的玩家
+	logger      *slog.Logger
 	mu          sync.RWMutex
 }
 
-func NewRoom(code string) *Room {
+func NewRoom(code string, logger *slog.Logger) *Room {
 	return &Room{
 		Code:        code,
 		clients:     make(map[*Client]bool),
 		clientsByID: make(map[int64]*Client),
 		join:        make(chan *Client),
 		leave:       make(chan *Client),
-		broadcast:   make(chan []byte),
+		kick:        make(chan int64),
+		broadcast:   make(chan broadcastRequest),
+		close:       make(chan closeFrame),
+		done:        make(chan struct{}),
+		history:     make([]sequencedMessage, 0, replayBufferSize),
+		muted:       make(map[int64]bool),
+		logger:      logger,
 	}
 }
 
 func (r *Room) Run() {
-	fmt.Printf("Run(): room=%p\n", r)
+	r.logger.Debug("room opened")
 	for {
 		select {
 		case client := <-r.join:
-			fmt.Printf("Run: received join from client ID=%d\n", client.ID)
 			r.mu.Lock()
 			r.clients[client] = true
 			r.clientsByID[client.ID] = client
+			// 重連的 client：補發 lastSeq 之後錯過的事件。先在鎖內複製，解鎖後才送出，
+			// 下一個廣播也在這個迴圈處理，順序不會亂
+			var replay []*websocket.PreparedMessage
+			if client.resume {
+				for _, m := range r.history {
+					if m.seq > client.lastSeq {
+						replay = append(replay, m.frame)
+					}
+				}
+			}
+			connections := len(r.clients)
 			r.mu.Unlock()
+			client.enqueue(replay...)
+			r.logger.Debug("client joined room", "player_id", client.ID, "resume", client.resume, "replayed", len(replay), "connections", connections)
 
 		case client := <-r.leave:
-			fmt.Printf("Client ID=%d left room %s. (before delete) current clientsByID: %v\n", client.ID, r.Code, r.clientsByID)
 			r.mu.Lock()
 			delete(r.clients, client)
 			delete(r.clientsByID, client.ID)
+			connections := len(r.clients)
 			r.mu.Unlock()
+			r.logger.Debug("client left room", "player_id", client.ID, "connections", connections)
 
-		case msg := <-r.broadcast:
+		case playerID := <-r.kick:
+			// 送完已排隊的訊息後由 writePump 關閉連線，且不觸發斷線流程
 			r.mu.RLock()
-			for c := range r.clients {
-				c.send <- msg
-			}
+			c, ok := r.clientsByID[playerID]
 			r.mu.RUnlock()
+			if ok {
+				c.close(closeFrame{code: websocket.ClosePolicyViolation, text: "kicked by host"})
+			}
+
+		case f := <-r.close:
+			// 關閉所有連線後結束，之後對房間的操作都直接略過
+			for _, c := range r.snapshotClients() {
+				c.close(f)
+			}
+			close(r.done)
+			r.logger.Debug("room closed", "reason", f.text)
+			return
+
+		case req := <-r.broadcast:
+			// span 的時間就是推播給所有人花的時間
+			_, span := tracer.Start(req.ctx, "Room.fanout")
+			msgs := req.msgs
+			r.mu.Lock()
+			// 每個事件只編碼一次，壓縮後的 frame 由所有 client 共用
+			frames := make([]*websocket.PreparedMessage, len(msgs))
+			encoded := make([][]byte, len(msgs))
+			for i := range msgs {
+				r.seq++
+				msgs[i].Seq = r.seq
+				encoded[i] = msgs[i].Encode()
+				frames[i] = prepare(encoded[i])
+
+				if len(r.history) == replayBufferSize {
+					r.history = append(r.history[:0], r.history[1:]...)
+				}
+				r.history = append(r.history, sequencedMessage{seq: msgs[i].Seq, frame: frames[i]})
+			}
+
+			seq := r.seq
+			r.mu.Unlock()
+
+			var batch *websocket.PreparedMessage
+			if len(msgs) > 1 {
+				batch = prepare(encodeBatch(encoded))
+			}
+
+			// 序號與 history 已經記好，送出時不持有鎖；下一個廣播也在這個迴圈處理，順序不會亂。
+			// 跟不上的 client 直接關閉，不會拖住整個房間
+			clients := r.snapshotClients()
+			dropped := 0
+			for _, c := range clients {
+				out := frames
+				if c.batch && batch != nil {
+					out = []*websocket.PreparedMessage{batch}
+				}
+				if !c.enqueue(out...) {
+					dropped++
+				}
+			}
+			span.SetAttributes(attribute.Int("ws.connections", len(clients)), attribute.Int("ws.dropped", dropped), attribute.Int64("ws.seq", int64(seq)))
+			span.End()
 		}
 	}
 }
 
-func (r *Room) Broadcast(msg any) {
-	data, _ := json.Marshal(msg)
-	r.broadcast <- data
+// snapshotClients 複製目前的連線，送出訊息時不需要持有鎖
+func (r *Room) snapshotClients() []*Client {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	clients := make([]*Client, 0, len(r.clients))
+	for c := range r.clients {
+		clients = append(clients, c)
+	}
+	return clients
 }
 
-func (r *Room) SendTo(playerID int64, msg any) {
+// Broadcast 推播給房間內所有人，並在 Run 中依序配發序號。
+// 一次傳入多個事件時，有開啟 batch 的 client 會在同一個 frame 收到。
+// 等待 Run 接手的時間記錄在 Room.Broadcast span，實際推播記錄在子 span Room.fanout
+func (r *Room) Broadcast(ctx context.Context, msgs ...WSMessage) {
+	if len(msgs) == 0 {
+		return
+	}
+	types := make([]string, len(msgs))
+	for i, m := range msgs {
+		types[i] = m.Type
+	}
+	ctx, span := tracer.Start(ctx, "Room.Broadcast", trace.WithAttributes(
+		attribute.String("game_code", r.Code),
+		attribute.StringSlice("ws.message_types", types),
+	))
+	defer span.End()
 
-	data, _ := json.Marshal(msg)
+	select {
+	case r.broadcast <- broadcastRequest{ctx: ctx, msgs: msgs}:
+	case <-r.done:
+	}
+}
+
+// Kick 關閉被 host 踢出的玩家連線，在此之前廣播的事件仍會送達
+func (r *Room) Kick(playerID int64) {
+	select {
+	case r.kick <- playerID:
+	case <-r.done:
+	}
+}
+
+// Join 把 client 加入房間，房間已關閉時回傳 false
+func (r *Room) Join(c *Client) bool {
+	select {
+	case r.join <- c:
+		return true
+	case <-r.done:
+		return false
+	}
+}
+
+// Close 送出 close frame 關閉所有連線並停止房間，在此之前廣播的事件仍會送達
+func (r *Room) Close(reason string) {
+	r.closeWith(closeFrame{code: websocket.CloseNormalClosure, text: reason})
+}
+
+func (r *Room) closeWith(f closeFrame) {
+	select {
+	case r.close <- f:
+	case <-r.done:
+	}
+}
+
+func (r *Room) SendTo(playerID int64, msg WSMessage) {
+	frame := prepare(msg.Encode())
+	r.mu.RLock()
+	c, ok := r.clientsByID[playerID]
+	spectator := ok && c.spectator
+	r.mu.RUnlock()
+
+	if !ok {
+		r.logger.Debug("direct message to player without connection", "player_id", playerID, "type", msg.Type)
+		return
+	}
+	if !spectator {
+		c.enqueue(frame)
+	}
+}
+
+func (r *Room) PlayerCount() int {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
+	return len(r.clientsByID)
+}
 
-	fmt.Printf("SendTo: playerID=%d, current clientsByID=%v\n", playerID, r.clientsByID)
+// SetNickname 在玩家改暱稱後更新聊天顯示的名稱
+func (r *Room) SetNickname(playerID int64, nickname string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, ok := r.clientsByID[playerID]; ok {
+		c.nickname = nickname
+	}
+}
 
+// SetSpectator 在觀戰者被轉為玩家時更新連線的身分
+func (r *Room) SetSpectator(playerID int64, spectator bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	if c, ok := r.clientsByID[playerID]; ok {
-		c.send <- data
+		c.spectator = spectator
 	}
+}
 
+// LastSeq 回傳最後一個廣播事件的序號
+func (r *Room) LastSeq() uint64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.seq
 }
 
-func (r *Room) PlayerCount() int {
+// CanReplay 回報 since 之後的事件是否都還留在 replay buffer 中
+func (r *Room) CanReplay(since uint64) bool {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	return len(r.clientsByID)
+
+	// since 比目前序號還新（例如伺服器重啟後房間被重建），只能重新同步
+	if since > r.seq {
+		return false
+	}
+	if since == r.seq {
+		return true
+	}
+	return len(r.history) > 0 && r.history[0].seq <= since+1
 }
@@ -0,0 +1,136 @@
+package ws
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// newTestConn 建立一條真實的 WebSocket 連線，回傳伺服器端與 client 端
+func newTestConn(t *testing.T) (server, client *websocket.Conn) {
+	t.Helper()
+	conns := make(chan *websocket.Conn, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		conns <- conn
+	}))
+	t.Cleanup(srv.Close)
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	server = <-conns
+	t.Cleanup(func() { _ = server.Close() })
+	return server, client
+}
+
+func newTestRoom(t *testing.T) *Room {
+	t.Helper()
+	room := NewRoom("ROOM00", discardLogger)
+	go room.Run()
+	t.Cleanup(func() { room.Close("test done") })
+	return room
+}
+
+// newStalledClient 建立沒有 writePump 的 client，send 滿了之後不會再被取出
+func newStalledClient(t *testing.T, room *Room, id int64, buffer int) (*Client, *websocket.Conn) {
+	t.Helper()
+	server, peer := newTestConn(t)
+	return &Client{ID: id, conn: server, send: make(chan *websocket.PreparedMessage, buffer), room: room, logger: discardLogger}, peer
+}
+
+// within 要求 fn 在時限內完成，房間卡住時測試會失敗而不是一直等下去
+func within(t *testing.T, name string, fn func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("%s blocked", name)
+	}
+}
+
+// expectClosed 要求對方收到 try-again-later 的 close frame
+func expectClosed(t *testing.T, peer *websocket.Conn) {
+	t.Helper()
+	_ = peer.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		_, _, err := peer.ReadMessage()
+		if err == nil {
+			continue
+		}
+		if !websocket.IsCloseError(err, websocket.CloseTryAgainLater) {
+			t.Fatalf("read error = %v, want close %d", err, websocket.CloseTryAgainLater)
+		}
+		return
+	}
+}
+
+// 補發的事件比 send 的容量多時，房間不能卡住，跟不上的 client 被關閉後重連
+func TestRoomReplayDoesNotBlockOnFullClient(t *testing.T) {
+	room := newTestRoom(t)
+	ctx := context.Background()
+	for range 10 {
+		room.Broadcast(ctx, MustWSMessage(MsgTypePlayerSafe, PlayerSafePayload{}))
+	}
+
+	client, peer := newStalledClient(t, room, 1, 2)
+	client.resume = true
+	within(t, "Join", func() { room.Join(client) })
+
+	// 房間仍然可以讀取狀態與加入新的 client
+	within(t, "LastSeq", func() { room.LastSeq() })
+	other, _ := newStalledClient(t, room, 2, 16)
+	within(t, "Join", func() { room.Join(other) })
+	within(t, "PlayerCount", func() { room.PlayerCount() })
+
+	expectClosed(t, peer)
+}
+
+// 一個跟不上的 client 不能拖住房間：其他人照常收到廣播，慢的 client 被關閉
+func TestRoomBroadcastSkipsSlowClient(t *testing.T) {
+	room := newTestRoom(t)
+	ctx := context.Background()
+
+	slow, peer := newStalledClient(t, room, 1, 1)
+	fast, _ := newStalledClient(t, room, 2, 64)
+	within(t, "Join", func() { room.Join(slow) })
+	within(t, "Join", func() { room.Join(fast) })
+
+	for range 5 {
+		within(t, "Broadcast", func() { room.Broadcast(ctx, MustWSMessage(MsgTypePlayerSafe, PlayerSafePayload{})) })
+	}
+	within(t, "SendTo", func() { room.SendTo(slow.ID, MustWSMessage(MsgTypePlayerSafe, PlayerSafePayload{})) })
+	// Run 處理完最後一個廣播後才會接手下一個 Join
+	other, _ := newStalledClient(t, room, 3, 1)
+	within(t, "Join", func() { room.Join(other) })
+	within(t, "LastSeq", func() {
+		if seq := room.LastSeq(); seq != 5 {
+			t.Errorf("last seq = %d, want 5", seq)
+		}
+	})
+
+	if n := len(fast.send); n != 5 {
+		t.Errorf("fast client queued %d frames, want 5", n)
+	}
+	expectClosed(t, peer)
+}
@@ -0,0 +1,177 @@
+package ws
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/y3933y3933/joker/internal/utils/jsonschema"
+)
+
+// 從事件目錄與 Go 型別產生 JSON Schema 與 TypeScript 定義，
+// 前端直接使用產生的檔案，避免和後端的 payload 脫節
+
+var (
+	timeType    = reflect.TypeFor[time.Time]()
+	rawJSONType = reflect.TypeFor[json.RawMessage]()
+)
+
+type jsonSchemaBuilder struct {
+	*jsonschema.Builder
+}
+
+func (b *jsonSchemaBuilder) messageSchemas(specs []EventSpec, withSeq bool) []any {
+	messages := make([]any, 0, len(specs))
+	for _, spec := range specs {
+		properties := map[string]any{
+			"type": map[string]any{"const": spec.Type},
+			"data": b.Schema(spec.Payload),
+		}
+		if withSeq {
+			properties["seq"] = map[string]any{"type": "integer", "minimum": 1}
+		}
+		messages = append(messages, map[string]any{
+			"title":                spec.Type,
+			"description":          spec.Description,
+			"type":                 "object",
+			"properties":           properties,
+			"required":             []string{"type", "data"},
+			"additionalProperties": false,
+		})
+	}
+	return messages
+}
+
+// JSONSchema 產生所有 WebSocket 訊息的 JSON Schema（draft 2020-12），
+// 根節點描述伺服器送出的訊息，client 送出的指令放在 $defs.ClientMessage
+func JSONSchema() ([]byte, error) {
+	b := &jsonSchemaBuilder{jsonschema.New("#/$defs/")}
+
+	messages := b.messageSchemas(catalog, true)
+	b.Defs["ClientMessage"] = map[string]any{
+		"oneOf": b.messageSchemas(commandCatalog, false),
+	}
+
+	schema := map[string]any{
+		"$schema":                "https://json-schema.org/draft/2020-12/schema",
+		"title":                  "Joker WebSocket message",
+		"x-protocol-version":     ProtocolVersion,
+		"x-min-protocol-version": MinProtocolVersion,
+		"oneOf":                  messages,
+		"$defs":                  b.Defs,
+	}
+
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+type tsBuilder struct {
+	interfaces map[string]string
+}
+
+func (b *tsBuilder) typeFor(t reflect.Type) string {
+	switch {
+	case t == timeType:
+		return "string"
+	case t == rawJSONType:
+		return "unknown"
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return b.typeFor(t.Elem()) + " | null"
+	case reflect.Struct:
+		if _, ok := b.interfaces[t.Name()]; !ok {
+			b.interfaces[t.Name()] = ""
+			b.interfaces[t.Name()] = b.interfaceFor(t)
+		}
+		return t.Name()
+	case reflect.Slice, reflect.Array:
+		elem := b.typeFor(t.Elem())
+		if strings.Contains(elem, "|") {
+			elem = "(" + elem + ")"
+		}
+		return elem + "[]"
+	case reflect.Map:
+		return "Record<string, " + b.typeFor(t.Elem()) + ">"
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	default:
+		return "unknown"
+	}
+}
+
+func (b *tsBuilder) interfaceFor(t reflect.Type) string {
+	fields := jsonschema.Fields(t)
+	if len(fields) == 0 {
+		return fmt.Sprintf("export type %s = Record<string, never>;\n", t.Name())
+	}
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "export interface %s {\n", t.Name())
+	for _, f := range fields {
+		optional := ""
+		if f.Optional {
+			optional = "?"
+		}
+		fmt.Fprintf(&buf, "  %s%s: %s;\n", f.Name, optional, b.typeFor(f.Type))
+	}
+	buf.WriteString("}\n")
+	return buf.String()
+}
+
+func (b *tsBuilder) union(name string, specs []EventSpec, withSeq bool) []byte {
+	seq := ""
+	if withSeq {
+		seq = " seq?: number;"
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "export type %s =\n", name)
+	for i, spec := range specs {
+		end := "\n"
+		if i == len(specs)-1 {
+			end = ";\n"
+		}
+		fmt.Fprintf(&buf, "  | { type: %q;%s data: %s }%s", spec.Type, seq, b.typeFor(spec.Payload), end)
+	}
+	return buf.Bytes()
+}
+
+// TypeScript 產生所有 WebSocket 訊息的 TypeScript 定義
+func TypeScript() []byte {
+	b := &tsBuilder{interfaces: map[string]string{}}
+
+	serverUnion := b.union("WSMessage", catalog, true)
+	clientUnion := b.union("ClientMessage", commandCatalog, false)
+
+	names := make([]string, 0, len(b.interfaces))
+	for name := range b.interfaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var out bytes.Buffer
+	out.WriteString("// Code generated by cmd/wsschema. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "export const PROTOCOL_VERSION = %d;\n", ProtocolVersion)
+	fmt.Fprintf(&out, "export const MIN_PROTOCOL_VERSION = %d;\n\n", MinProtocolVersion)
+	for _, name := range names {
+		out.WriteString(b.interfaces[name])
+		out.WriteString("\n")
+	}
+	out.Write(serverUnion)
+	out.WriteString("\nexport type WSMessageType = WSMessage[\"type\"];\n\n")
+	out.Write(clientUnion)
+	out.WriteString("\nexport type ClientMessageType = ClientMessage[\"type\"];\n")
+
+	return out.Bytes()
+}
@@ -0,0 +1,46 @@
+package ws
+
+import (
+	"context"
+	"errors"
+
+	"github.com/y3933y3933/joker/internal/utils/errx"
+)
+
+// buildSnapshot 組出目前遊戲的完整狀態，seq 為取狀態前房間的最後序號，
+// client 以此為新的起點，之後的事件會再由 replay buffer 補發
+func (h *Handler) buildSnapshot(ctx context.Context, gameCode string, seq uint64) (WSMessage, error) {
+	game, err := h.GameService.GetGameByCode(ctx, gameCode)
+	if err != nil {
+		return WSMessage{}, err
+	}
+
+	players, err := h.PlayerService.ListPlayersInGame(ctx, game.ID)
+	if err != nil {
+		return WSMessage{}, err
+	}
+
+	payload := StateSnapshotPayload{
+		Game:       game,
+		Players:    players.Players,
+		Spectators: players.Spectators,
+		Chat:       make([]ChatMessagePayload, 0),
+	}
+	if room := h.Hub.GetRoom(gameCode); room != nil {
+		payload.Chat = room.ChatHistory()
+	}
+
+	round, err := h.RoundService.FindLastRoundByGameID(ctx, game.ID)
+	if err != nil && !errors.Is(err, errx.ErrRoundNotFound) {
+		return WSMessage{}, err
+	}
+	payload.Round = round
+
+	msg, err := NewWSMessage(MsgTypeStateSnapshot, payload)
+	if err != nil {
+		return WSMessage{}, err
+	}
+	msg.Seq = seq
+
+	return msg, nil
+}
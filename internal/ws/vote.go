@@ -0,0 +1,57 @@
+package ws
+
+import "github.com/y3933y3933/joker/internal/service"
+
+// VoteMessages 依投票結果產生要一起廣播的訊息，playerID 為 0 表示不是由投票觸發（例如有人離線）
+func VoteMessages(playerID int64, res *service.VoteResult) []WSMessage {
+	var msgs []WSMessage
+	if playerID != 0 {
+		msg := MustWSMessage(MsgTypeVoteCast, VoteCastPayload{
+			PlayerID: playerID,
+			Votes:    res.Votes,
+			Needed:   res.Needed,
+		})
+		msgs = append(msgs, msg)
+	}
+	if !res.Resolved {
+		return msgs
+	}
+
+	if res.Guess != nil {
+		msg := MustWSMessage(MsgTypeGuessResults, GuessResultsPayload{
+			Kind:          res.Guess.Kind,
+			AboutPlayerID: res.Guess.AboutPlayerID,
+			Truthful:      res.Guess.Truthful,
+			Correct:       res.Guess.Correct,
+			RoundStatus:   res.Status,
+		})
+		msgs = append(msgs, msg)
+	}
+
+	// vote_reveal：公布結果，和抽牌一樣推播公開或安全
+	if res.Reveal != nil {
+		msg := MustWSMessage(MsgTypeRevealVoteResult, RevealVoteResultPayload{
+			Revealed:    res.Reveal.Revealed,
+			RevealVotes: res.Reveal.RevealVotes,
+			KeepVotes:   res.Reveal.KeepVotes,
+		})
+		msgs = append(msgs, msg)
+
+		if res.Reveal.Revealed {
+			msg = MustWSMessage(MsgTypeJokerRevealed, JokerRevealedPayload{
+				Level:   res.Round.Level,
+				Content: res.Round.Content,
+			})
+		} else {
+			msg = MustWSMessage(MsgTypePlayerSafe, PlayerSafePayload{})
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
+
+// LeaderboardMessage 回合結束後推播目前的排行榜
+func LeaderboardMessage(entries []service.LeaderboardEntry) WSMessage {
+	msg := MustWSMessage(MsgTypeLeaderboard, LeaderboardPayload{Entries: entries})
+	return msg
+}
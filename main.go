@@ -1,21 +1,47 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"log/slog"
+	"os"
 
 	a "github.com/y3933y3933/joker/internal/app"
 	"github.com/y3933y3933/joker/internal/routes"
+	"github.com/y3933y3933/joker/internal/tracing"
 )
 
 func main() {
+	// api migrate -db=... up|down|status 只處理 migration，不啟動伺服器
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := a.RunMigrate(os.Args[2:], os.Stdout); err != nil {
+			slog.Error("migrate failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	app, err := a.NewApplication()
+	if err != nil {
+		// 包含 schema 版本不符，讓 systemd 記錄錯誤後重試
+		slog.Error("startup failed", "error", err)
+		os.Exit(1)
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), app.Config.Tracing, app.Config.Env)
 	if err != nil {
 		panic(err)
 	}
-	defer app.DB.ConnPool.Close()
 
 	router := routes.SetupRoutes(app)
-	port := fmt.Sprintf(":%d", app.Config.Port)
-	router.Run(port)
+	err = app.Serve(router)
 
+	// 關機時送出尚未匯出的 span 並關閉連線池
+	if err := shutdownTracing(context.Background()); err != nil {
+		slog.Error("tracing shutdown failed", "error", err)
+	}
+	app.DB.ConnPool.Close()
+	if err != nil {
+		slog.Error("server stopped", "error", err)
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE players
+ADD COLUMN role TEXT NOT NULL DEFAULT 'player'
+CHECK (role IN ('player', 'spectator'));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE players DROP COLUMN role;
+-- +goose StatementEnd
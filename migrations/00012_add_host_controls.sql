@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE players ADD COLUMN seat INT NOT NULL DEFAULT 0;
+
+UPDATE players p
+SET seat = s.seat
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY game_id ORDER BY id) AS seat
+    FROM players
+) s
+WHERE p.id = s.id;
+
+ALTER TABLE games ADD COLUMN locked BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS game_bans (
+    id BIGSERIAL PRIMARY KEY,
+    game_id BIGINT NOT NULL REFERENCES games(id) ON DELETE CASCADE,
+    nickname VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (game_id, nickname)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS game_bans;
+ALTER TABLE games DROP COLUMN locked;
+ALTER TABLE players DROP COLUMN seat;
+-- +goose StatementEnd
@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE games
+ADD COLUMN mode TEXT NOT NULL DEFAULT 'classic'
+CHECK (mode IN ('classic', 'hot_seat', 'vote_reveal'));
+
+ALTER TABLE rounds DROP CONSTRAINT IF EXISTS rounds_status_check;
+ALTER TABLE rounds ADD CONSTRAINT rounds_status_check CHECK (status IN (
+    'waiting_for_question',
+    'waiting_for_answer',
+    'waiting_for_draw',
+    'waiting_for_votes',
+    'revealed',
+    'done'
+));
+
+CREATE TABLE IF NOT EXISTS votes (
+    id BIGSERIAL PRIMARY KEY,
+    round_id BIGINT NOT NULL REFERENCES rounds(id) ON DELETE CASCADE,
+    player_id BIGINT NOT NULL REFERENCES players(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('reveal')),
+    choice TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (round_id, player_id, kind)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS votes;
+
+UPDATE rounds SET status = 'done' WHERE status = 'waiting_for_votes';
+ALTER TABLE rounds DROP CONSTRAINT IF EXISTS rounds_status_check;
+ALTER TABLE rounds ADD CONSTRAINT rounds_status_check CHECK (status IN (
+    'waiting_for_question',
+    'waiting_for_answer',
+    'waiting_for_draw',
+    'revealed',
+    'done'
+));
+
+ALTER TABLE games DROP COLUMN mode;
+-- +goose StatementEnd
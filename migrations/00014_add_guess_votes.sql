@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE games
+ADD COLUMN guessing TEXT NOT NULL DEFAULT 'off'
+CHECK (guessing IN ('off', 'about', 'truth'));
+
+-- 回答者送出回答時一併提供的正確答案，投票結束前不公開
+ALTER TABLE rounds ADD COLUMN about_player_id BIGINT REFERENCES players(id) ON DELETE SET NULL;
+ALTER TABLE rounds ADD COLUMN truthful BOOLEAN;
+
+ALTER TABLE votes DROP CONSTRAINT IF EXISTS votes_kind_check;
+ALTER TABLE votes ADD CONSTRAINT votes_kind_check CHECK (kind IN ('reveal', 'about', 'truth'));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM votes WHERE kind IN ('about', 'truth');
+ALTER TABLE votes DROP CONSTRAINT IF EXISTS votes_kind_check;
+ALTER TABLE votes ADD CONSTRAINT votes_kind_check CHECK (kind IN ('reveal'));
+
+ALTER TABLE rounds DROP COLUMN truthful;
+ALTER TABLE rounds DROP COLUMN about_player_id;
+
+ALTER TABLE games DROP COLUMN guessing;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 每場遊戲的計分規則，空物件表示全部使用預設值
+ALTER TABLE games ADD COLUMN scoring JSONB NOT NULL DEFAULT '{}'::jsonb;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE games DROP COLUMN scoring;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 遊戲結束後開的再來一局，只會有一場
+ALTER TABLE games ADD COLUMN rematch_code TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE games DROP COLUMN rematch_code;
+-- +goose StatementEnd
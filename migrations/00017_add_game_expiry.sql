@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 遊戲結束的原因：finished（玩家結束）、not_enough_players、idle（閒置過久）、admin
+ALTER TABLE games ADD COLUMN ended_reason TEXT;
+
+-- 回合最後一次變動的時間，用來判斷遊戲是否閒置
+ALTER TABLE rounds ADD COLUMN updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW();
+UPDATE rounds SET updated_at = created_at;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE rounds DROP COLUMN updated_at;
+ALTER TABLE games DROP COLUMN ended_reason;
+-- +goose StatementEnd
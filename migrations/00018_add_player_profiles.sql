@@ -0,0 +1,46 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 暱稱正規化後的比對鍵，外觀相同的暱稱視為重複。
+-- TRANSLATE 的對照表和 service.confusables 相同（UTS #39 skeleton 的子集）
+ALTER TABLE players ADD COLUMN nickname_key VARCHAR(100) NOT NULL DEFAULT '';
+UPDATE players SET nickname_key = LOWER(TRANSLATE(NORMALIZE(nickname, NFKC),
+    '01|АаВвЕеКкМмНнОоРрСсТтХхУуҮүЅѕІіӀӏЈјԚԛԜԝҺһԀԁΑαΒβΕεΖζΗηΙιΚκΜμΝνΟοΡρΤτΥυΧχϹϲͿϳՕօՍսıⱭɑꞬɡ',
+    'OIIAABBEEKKMMHHOOPPCCTTXXYYYYSSIIIIJJQQWWHHDDAABBEEZZHHIIKKMMNNOOPPTTYYXXCCJJOOUUIAAGG'));
+
+-- 既有資料可能已有比對鍵相同的玩家，保留最早加入的，其他人的比對鍵加上 id 區分
+UPDATE players SET nickname_key = nickname_key || '#' || id
+WHERE EXISTS (
+    SELECT 1 FROM players p
+    WHERE p.game_id = players.game_id
+      AND p.nickname_key = players.nickname_key
+      AND p.id < players.id
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_players_game_id_nickname_key ON players(game_id, nickname_key);
+
+-- 頭像：伺服器提供的預設頭像，或自選顏色加 emoji
+ALTER TABLE players ADD COLUMN avatar_preset TEXT NOT NULL DEFAULT '';
+ALTER TABLE players ADD COLUMN avatar_color TEXT NOT NULL DEFAULT '';
+ALTER TABLE players ADD COLUMN avatar_emoji TEXT NOT NULL DEFAULT '';
+
+-- 禁止名單也改用比對鍵
+UPDATE game_bans SET nickname = LOWER(TRANSLATE(NORMALIZE(nickname, NFKC),
+    '01|АаВвЕеКкМмНнОоРрСсТтХхУуҮүЅѕІіӀӏЈјԚԛԜԝҺһԀԁΑαΒβΕεΖζΗηΙιΚκΜμΝνΟοΡρΤτΥυΧχϹϲͿϳՕօՍսıⱭɑꞬɡ',
+    'OIIAABBEEKKMMHHOOPPCCTTXXYYYYSSIIIIJJQQWWHHDDAABBEEZZHHIIKKMMNNOOPPTTYYXXCCJJOOUUIAAGG'))
+WHERE NOT EXISTS (
+    SELECT 1 FROM game_bans b
+    WHERE b.game_id = game_bans.game_id
+      AND b.id <> game_bans.id
+      AND b.nickname = LOWER(TRANSLATE(NORMALIZE(game_bans.nickname, NFKC),
+          '01|АаВвЕеКкМмНнОоРрСсТтХхУуҮүЅѕІіӀӏЈјԚԛԜԝҺһԀԁΑαΒβΕεΖζΗηΙιΚκΜμΝνΟοΡρΤτΥυΧχϹϲͿϳՕօՍսıⱭɑꞬɡ',
+          'OIIAABBEEKKMMHHOOPPCCTTXXYYYYSSIIIIJJQQWWHHDDAABBEEZZHHIIKKMMNNOOPPTTYYXXCCJJOOUUIAAGG'))
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_players_game_id_nickname_key;
+ALTER TABLE players DROP COLUMN avatar_emoji;
+ALTER TABLE players DROP COLUMN avatar_color;
+ALTER TABLE players DROP COLUMN avatar_preset;
+ALTER TABLE players DROP COLUMN nickname_key;
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 玩家帳號，和後台的 users 分開；不登入也可以照常玩
+CREATE TABLE IF NOT EXISTS accounts (
+    id BIGSERIAL PRIMARY KEY,
+    username TEXT NOT NULL UNIQUE,
+    password_hash BYTEA NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- 每場遊戲的玩家可以歸屬到一個帳號
+ALTER TABLE players ADD COLUMN account_id BIGINT REFERENCES accounts(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_players_account_id ON players(account_id);
+
+CREATE TABLE IF NOT EXISTS account_favorite_questions (
+    account_id BIGINT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    question_id BIGINT NOT NULL REFERENCES questions(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (account_id, question_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS account_favorite_questions;
+DROP INDEX IF EXISTS idx_players_account_id;
+ALTER TABLE players DROP COLUMN account_id;
+DROP TABLE IF EXISTS accounts;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 房間的加入方式：public（知道代碼即可）、passcode（需要密碼或邀請連結）、invite（只能透過邀請連結）
+ALTER TABLE games ADD COLUMN access TEXT NOT NULL DEFAULT 'public'
+    CHECK (access IN ('public', 'passcode', 'invite'));
+ALTER TABLE games ADD COLUMN passcode_hash BYTEA;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE games DROP COLUMN passcode_hash;
+ALTER TABLE games DROP COLUMN access;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 因出題者或回答者離開而跳過的回合，狀態同樣是 done，但沒有抽牌或投票結果，不計分
+ALTER TABLE rounds ADD COLUMN skipped BOOLEAN NOT NULL DEFAULT FALSE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE rounds DROP COLUMN skipped;
+-- +goose StatementEnd
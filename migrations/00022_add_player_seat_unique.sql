@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 先前同時加入可能拿到相同座位，依原本順序重新編號
+UPDATE players p
+SET seat = s.seat
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY game_id ORDER BY seat, id) AS seat
+    FROM players
+) s
+WHERE p.id = s.id AND p.seat != s.seat;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- 調整座位時會在同一個交易內互換，檢查延到 commit
+ALTER TABLE players
+    ADD CONSTRAINT players_game_id_seat_key UNIQUE (game_id, seat) DEFERRABLE INITIALLY DEFERRED;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE players DROP CONSTRAINT players_game_id_seat_key;
+-- +goose StatementEnd
@@ -0,0 +1,36 @@
+// Package migrations 將 goose 的 SQL migration 嵌入執行檔，讓程式知道自己需要的 schema 版本
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//go:embed *.sql
+var FS embed.FS
+
+// LatestVersion 回傳嵌入的 migration 中最大的版本，也就是這個版本的程式需要的 schema 版本
+var LatestVersion = sync.OnceValues(func() (int64, error) {
+	files, err := fs.Glob(FS, "*.sql")
+	if err != nil {
+		return 0, err
+	}
+
+	var latest int64
+	for _, name := range files {
+		prefix, _, ok := strings.Cut(name, "_")
+		if !ok {
+			return 0, fmt.Errorf("migrations: %s has no version prefix", name)
+		}
+		v, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("migrations: %s has no version prefix", name)
+		}
+		latest = max(latest, v)
+	}
+	return latest, nil
+})
@@ -42,12 +42,6 @@ ufw --force enable
 # Install fail2ban
 apt --yes install fail2ban
 
-# Install Goose (https://github.com/pressly/goose)
-curl -fsSL \
-    https://raw.githubusercontent.com/pressly/goose/master/install.sh |\
-    sh
-
-
 # Install PostgreSQL
 apt --yes install postgresql
 